@@ -1,12 +1,606 @@
 package main
 
-import "toJson/utils"
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"toJson/utils"
+)
 
 func main() {
+	args := os.Args[1:]
+
+	// convert is the default command, so `toJson [flags]` keeps working
+	command := "convert"
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		command, args = args[0], args[1:]
+	}
+
+	// Spans are only exported when the OpenTelemetry environment variables ask for it
+	flushTraces, err := utils.SetupTracing(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	defer flushTraces()
+
+	switch command {
+	case "convert":
+		runConvert(args)
+	case "export":
+		runExport(args)
+	case "merge":
+		runMerge(args)
+	case "import":
+		runImport(args)
+	case "diff":
+		runDiff(args)
+	case "schema":
+		runSchema(args)
+	case "validate":
+		runValidate(args)
+	case "serve":
+		runServe(args)
+	case "search":
+		runSearch(args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", command)
+		os.Exit(2)
+	}
+}
+
+// runConvert reads quotes from an Excel file and converts them in to json format
+func runConvert(args []string) {
 	var fileName string = "quotes.xlsx"
 
-	// reads quotes from excel and converts in to json format
-	if err := utils.ReadQuotesFromExcel(fileName); err != nil {
+	opts := utils.DefaultOptions()
+	flags := flag.NewFlagSet("convert", flag.ExitOnError)
+	flags.IntVar(&opts.BatchSize, "batch-size", opts.BatchSize, "number of rows processed and flushed to disk at a time")
+	flags.BoolVar(&opts.Resume, "resume", opts.Resume, "continue an interrupted conversion from its checkpoint")
+	flags.Var(&opts.Format, "format", "output format: "+strings.Join(utils.StreamFormatNames(), ", "))
+	flags.Var(&opts.Sinks, "to", "additional destination as scheme:target, e.g. sqlite:quotes.db, s3://bucket/path/, gs://bucket/path/, azblob://container/path/ or sftp://user@host/path/ (repeatable)")
+	flags.StringVar(&opts.UploadContentType, "content-type", opts.UploadContentType, "Content-Type of the quotes file uploaded to a bucket by --to (default from its extension)")
+	flags.StringVar(&opts.UploadCacheControl, "cache-control", opts.UploadCacheControl, `Cache-Control of the files uploaded to a bucket by --to, e.g. "public, max-age=300"`)
+	flags.StringVar(&opts.SFTPKey, "sftp-key", opts.SFTPKey, "private key authenticating --to sftp://... (default ~/.ssh/id_ed25519, id_ecdsa or id_rsa)")
+	flags.StringVar(&opts.SFTPKnownHosts, "sftp-known-hosts", opts.SFTPKnownHosts, "known_hosts file the server of --to sftp://... is checked against (default ~/.ssh/known_hosts)")
+	flags.BoolVar(&opts.Strfile, "strfile", opts.Strfile, "with --format fortune, also write the strfile index quotes.fortune.dat")
+	flags.StringVar(&opts.ESIndex, "es-index", opts.ESIndex, "index name used by --format es-bulk and alias of the elasticsearch sink")
+	flags.StringVar(&opts.MongoTagsField, "mongo-tags-field", opts.MongoTagsField, "name of the tags array in --format mongo documents")
+	flags.Var(&opts.SQLDialect, "dialect", "SQL dialect for --format sql: postgres, mysql or sqlite")
+	flags.StringVar(&opts.RedisPrefix, "redis-prefix", opts.RedisPrefix, "prefix of every key written by --format redis and the redis sink")
+	flags.StringVar(&opts.TemplateFile, "template", opts.TemplateFile, "render the output through this text/template file instead of a built-in format")
+	flags.Func("indent", `indentation of --format json, e.g. "\t" (default two spaces)`, func(value string) (err error) {
+		opts.Indent, err = utils.ParseIndent(value)
+		return err
+	})
+	flags.BoolVar(&opts.Compact, "compact", opts.Compact, "write --format json without any whitespace")
+	flags.StringVar(&opts.Output, "out", opts.Output, `quotes output file, "-" for stdout (default quotes.<format extension>)`)
+	flags.Var(&opts.Compress, "compress", "compress the quotes and metadata files: gzip or zstd")
+	flags.IntVar(&opts.Backups, "backups", opts.Backups, "keep this many previous outputs as quotes.json.1, quotes.json.2, ... before overwriting")
+	flags.BoolVar(&opts.BackupTime, "backup-timestamp", opts.BackupTime, "name --backups after the time they were taken instead of numbering them")
+	flags.BoolVar(&opts.Checksums, "checksums", opts.Checksums, "write checksums.txt with the SHA-256 of the outputs and record the quotes digest in the metadata")
+	flags.StringVar(&opts.SignKey, "sign-key", opts.SignKey, "ed25519 private key (PEM) used to write a detached signature of the quotes file to <file>.sig")
+	flags.Var(&opts.Encrypt, "encrypt-recipient", "encrypt the quotes file to this age public key, age1... (repeatable)")
+	flags.Var(&opts.SplitBy, "split-by", "also write one quotes file per group: tag, lang or author")
+	flags.IntVar(&opts.ChunkSize, "chunk-size", opts.ChunkSize, "also page the quotes into quotes-0001.json, quotes-0002.json, ... of this many quotes")
+	flags.StringVar(&opts.SplitDir, "out-dir", opts.SplitDir, "directory of the --split-by or --chunk-size files (default by-<key> or chunks)")
+	flags.IntVar(&opts.Related, "related", opts.Related, "link every quote to this many of the most similar quotes in relatedIds, 0 for none")
+	flags.Func("translate-to", "comma separated languages the quotes are translated into, e.g. ta,hi,fr", func(value string) error {
+		for _, lang := range strings.Split(value, ",") {
+			if lang = strings.TrimSpace(lang); lang != "" {
+				opts.TranslateTo = append(opts.TranslateTo, lang)
+			}
+		}
+		return nil
+	})
+	flags.StringVar(&opts.Translator, "translator", "libretranslate", "translation provider of --translate-to: "+strings.Join(utils.TranslatorNames(), ", "))
+	flags.StringVar(&opts.TranslatorURL, "translator-url", opts.TranslatorURL, "API of the translation provider, e.g. a self-hosted LibreTranslate (default its public API)")
+	flags.BoolVar(&opts.TagIndex, "tag-index", opts.TagIndex, "also write tags.json mapping every tag to its quote IDs and count")
+	flags.Var(&opts.IDStrategy, "id-strategy", "how quote IDs are assigned: row or uuid")
+	flags.StringVar(&opts.PreserveIDs, "preserve-ids", opts.PreserveIDs, "previous quotes.json whose IDs are reused for quotes with the same text")
+	flags.BoolVar(&opts.Incremental, "incremental", opts.Incremental, "only process rows that changed since the previous incremental run, tracked in <out>.rows.json")
+	flags.Var(&opts.VersionBump, "bump", "how the metadata version changes from the previous run: auto, none, patch, minor or major")
+	flags.StringVar(&opts.MetadataURL, "metadata-url", opts.MetadataURL, "URL recorded in the metadata, e.g. where the dataset is published")
+	flags.StringVar(&opts.MetadataVersion, "metadata-version", opts.MetadataVersion, "fixed metadata version, replacing the bumped one")
+	flags.Var(&opts.MetadataExtra, "meta", "extra key=value recorded in the metadata, e.g. license=CC-BY-4.0 (repeatable)")
+	flags.StringVar(&opts.MetadataConfig, "metadata-config", opts.MetadataConfig, "YAML file setting the url, version and extra fields of the metadata")
+	flags.BoolVar(&opts.EmbedMetadata, "embed-metadata", opts.EmbedMetadata, `write one {"metadata": ..., "quotes": [...]} file instead of a separate metadata file`)
+	flags.Func("webhook", "URL POSTed a JSON summary when the conversion finishes, signed with $TOJSON_WEBHOOK_SECRET if set (repeatable)", func(url string) error {
+		opts.Webhooks = append(opts.Webhooks, url)
+		return nil
+	})
+	opts.WebhookSecret = os.Getenv("TOJSON_WEBHOOK_SECRET")
+	metricsFile := flags.String("metrics-file", "", "write the Prometheus metrics of the conversion to this file, for the textfile collector of node_exporter")
+	metricsPush := flags.String("metrics-push", "", "push the Prometheus metrics of the conversion to the Pushgateway at this URL")
+	addProcessingFlags(flags, &opts)
+	profile := addProfilingFlags(flags)
+	// Flags may follow the input file, as in `convert quotes.json --normalize trim`
+	files := parseInterspersed(flags, args)
+	if len(files) > 1 {
+		fmt.Fprintln(os.Stderr, "usage: toJson [convert] [flags] [quotes.xlsx|quotes.json]")
+		os.Exit(2)
+	}
+	if len(files) == 1 {
+		fileName = files[0]
+	}
+	defer startProfiling(profile)()
+	if opts.TemplateFile != "" {
+		opts.Format = utils.StreamTemplate
+	}
+
+	registry := prometheus.NewRegistry()
+	done := utils.NewConversionMetrics(registry).Track(&opts)
+
+	// reads quotes from the spreadsheet, or another supported input, and converts them
+	err := utils.ConvertFile(fileName, opts)
+	done(err)
+	if metricsErr := utils.WriteMetrics(registry, *metricsFile, *metricsPush); metricsErr != nil {
+		fmt.Fprintln(os.Stderr, metricsErr)
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+// addProcessingFlags registers the flags controlling how inputs are read and their rows turned
+// into quotes, shared by the commands reading spreadsheets
+func addProcessingFlags(flags *flag.FlagSet, opts *utils.Options) {
+	flags.Var(&opts.InputFormat, "from", "input format: "+strings.Join(utils.InputFormatNames(), ", ")+" (default detected from the file)")
+	flags.StringVar(&opts.TextDelimiter, "text-delimiter", opts.TextDelimiter, `line separating the quotes of .txt input, e.g. "%", or "blank" for blank lines (default "%" if the file has such lines, else blank)`)
+	flags.IntVar(&opts.Workers, "workers", opts.Workers, "number of workers processing rows concurrently")
+	flags.Var(&opts.Normalize, "normalize", "comma separated normalizations of the quote text: whitespace, ascii, smart, strip-period, trim")
+	flags.BoolVar(&opts.KeepInvisible, "keep-invisible", opts.KeepInvisible, "keep zero-width and control characters and skip NFC normalization")
+	flags.IntVar(&opts.Validation.MinLength, "min-length", opts.Validation.MinLength, "skip quotes with fewer characters than this")
+	flags.IntVar(&opts.Validation.MaxLength, "max-length", opts.Validation.MaxLength, "skip quotes with more characters than this")
+	flags.BoolVar(&opts.Validation.RequireAuthor, "require-author", opts.Validation.RequireAuthor, "skip quotes without an author")
+	flags.BoolVar(&opts.Validation.RequireTags, "require-tags", opts.Validation.RequireTags, "skip quotes without any tag")
+	flags.StringVar(&opts.Validation.DisallowedChars, "disallow-chars", opts.Validation.DisallowedChars, "skip quotes whose text contains any of these characters")
+	flags.BoolVar(&opts.Validation.Strict, "strict", opts.Validation.Strict, "fail the conversion if any row fails validation")
+	flags.Func("lang", "BCP 47 language tag of the quotes (default en-US)", func(value string) (err error) {
+		opts.Language, err = utils.NormalizeLanguageTag(value)
+		return err
+	})
+	flags.Var(&opts.Columns, "column", "read a field from a column as field=column, e.g. lang=C or lang=Language (repeatable)")
+	flags.BoolVar(&opts.KeepAttribution, "keep-attribution", opts.KeepAttribution, `leave trailing "— Author" attributions in the quote text`)
+	flags.StringVar(&opts.AuthorAliases, "author-aliases", opts.AuthorAliases, "YAML file mapping canonical author names to their aliases, e.g. authors.yaml")
+	flags.Var(&opts.TagPolicy, "tag-policy", "how tags are split: legacy, clean, or a list of trim, lowercase, drop-empty, dedupe")
+	flags.StringVar(&opts.TagPolicy.Separators, "tag-separators", opts.TagPolicy.Separators, `characters separating tags (default ",", ",;|" with --tag-policy clean)`)
+	flags.StringVar(&opts.TagAliases, "tag-aliases", opts.TagAliases, "YAML file folding tag variants into canonical tags, e.g. tags.yaml")
+	flags.StringVar(&opts.TagRules, "tag-rules", opts.TagRules, "YAML file mapping tags to keywords; quotes containing a keyword gain its tag")
+	flags.StringVar(&opts.AllowedTags, "allowed-tags", opts.AllowedTags, "file listing the only tags that are kept, one per line")
+	flags.StringVar(&opts.BannedTags, "banned-tags", opts.BannedTags, "file listing tags that are removed, one per line")
+	flags.BoolVar(&opts.DropFilteredQuotes, "drop-filtered-quotes", opts.DropFilteredQuotes, "drop quotes whose tags were all removed by --allowed-tags or --banned-tags")
+	flags.StringVar(&opts.Blocklist, "blocklist", opts.Blocklist, "file listing prohibited terms, one per line")
+	flags.Var(&opts.BlocklistAction, "blocklist-action", "what to do with quotes containing a prohibited term: exclude or flag")
+	flags.StringVar(&opts.BlocklistReview, "blocklist-review", opts.BlocklistReview, "JSON file listing the quotes caught by --blocklist")
+	flags.StringVar(&opts.Spellcheck, "spellcheck", opts.Spellcheck, "dictionary file, one word per line, the quote text and authors are spellchecked against, e.g. /usr/share/dict/words")
+	flags.StringVar(&opts.SpellcheckIgnore, "spellcheck-ignore", opts.SpellcheckIgnore, "file listing the names and terms --spellcheck accepts, one per line")
+	flags.StringVar(&opts.SpellcheckReport, "spellcheck-report", opts.SpellcheckReport, "JSON file listing the likely typos found by --spellcheck")
+	flags.Var(&opts.PII, "pii", "detect emails, phone numbers and URLs in quotes and redact, drop or report them")
+	flags.BoolVar(&opts.Sentiment, "sentiment", opts.Sentiment, "add a sentiment label and score to every quote")
+	flags.StringVar(&opts.Taxonomy, "taxonomy", opts.Taxonomy, "YAML file nesting tags under their parents; quotes also inherit the parents of their tags")
+}
+
+// addProfilingFlags registers the flags profiling a run, so performance issues can be looked
+// into without recompiling
+func addProfilingFlags(flags *flag.FlagSet) *utils.ProfileOptions {
+	var profile utils.ProfileOptions
+	flags.StringVar(&profile.Addr, "pprof", "", "serve net/http/pprof on this address while running, e.g. localhost:6060")
+	flags.StringVar(&profile.CPUProfile, "cpu-profile", "", "write a CPU profile of the run to this file")
+	flags.StringVar(&profile.HeapProfile, "heap-profile", "", "write a heap profile to this file when the run ends")
+	return &profile
+}
+
+// startProfiling starts the profiling asked for on the command line, returning how to stop it
+func startProfiling(profile *utils.ProfileOptions) func() {
+	stop, err := utils.StartProfiling(*profile)
+	if err != nil {
+		panic(err)
+	}
+	return func() {
+		if err := stop(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+}
+
+// runMerge upserts the quotes of a spreadsheet, or another input, into an existing quotes.json
+func runMerge(args []string) {
+	opts := utils.DefaultOptions()
+	flags := flag.NewFlagSet("merge", flag.ExitOnError)
+	into, metadataFile, match := addMergeFlags(flags, &opts)
+	profile := addProfilingFlags(flags)
+	files := parseInterspersed(flags, args)
+	if len(files) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: toJson merge new.xlsx|new.csv|... --into quotes.json [flags]")
+		os.Exit(2)
+	}
+	defer startProfiling(profile)()
+
+	result, err := utils.MergeFile(files[0], *into, *metadataFile, *match, opts)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Fprintf(os.Stderr, "Merged %s into %s: %d added, %d updated, %d unchanged, %d removed\n", files[0], *into, result.Added, result.Updated, result.Unchanged, result.Removed)
+}
+
+// runImport fetches quotes from a remote source and merges them into an existing quotes.json
+func runImport(args []string) {
+	opts := utils.DefaultOptions()
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	into, metadataFile, match := addMergeFlags(flags, &opts)
+	var importOpts utils.ImportOptions
+	flags.StringVar(&importOpts.API, "api", importOpts.API, "base URL of the source's API (default its public API)")
+	flags.DurationVar(&importOpts.Interval, "rate", time.Second, "time waited between two requests to the API")
+	flags.IntVar(&importOpts.Limit, "limit", importOpts.Limit, "most quotes fetched (default all)")
+	flags.StringVar(&importOpts.Token, "token", importOpts.Token, "API token of the source (default from its environment variable, such as NOTION_TOKEN)")
+	profile := addProfilingFlags(flags)
+	args = parseInterspersed(flags, args)
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "usage: toJson import %s ... --into quotes.json [flags]\n", strings.Join(utils.ImportSourceNames(), "|"))
+		os.Exit(2)
+	}
+	defer startProfiling(profile)()
+
+	result, err := utils.ImportQuotes(args[0], args[1:], *into, *metadataFile, *match, opts, importOpts)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Fprintf(os.Stderr, "Imported %s into %s: %d added, %d updated, %d unchanged, %d removed\n", args[0], *into, result.Added, result.Updated, result.Unchanged, result.Removed)
+}
+
+// addMergeFlags registers the flags of the commands merging quotes into an existing dataset,
+// returning the file merged into, its metadata file and how quotes are matched
+func addMergeFlags(flags *flag.FlagSet, opts *utils.Options) (*string, *string, *utils.MergeMatch) {
+	into := flags.String("into", "quotes.json", "quotes file the new quotes are merged into")
+	metadataFile := flags.String("metadata", "quotesMetadata.json", "metadata file whose counts are updated")
+	match := utils.MergeByText
+	flags.Var(&match, "match", "how quotes are matched: text or id")
+	flags.BoolVar(&opts.KeepExisting, "keep-existing", opts.KeepExisting, "only add new quotes, leaving those matching an existing quote untouched")
+	flags.BoolVar(&opts.Replace, "replace", opts.Replace, "drop the existing quotes missing from the input, so the dataset mirrors it")
+	flags.Var(&opts.IDStrategy, "id-strategy", "how new quote IDs are assigned: row or uuid")
+	flags.IntVar(&opts.Backups, "backups", opts.Backups, "keep this many previous outputs as quotes.json.1, quotes.json.2, ... before overwriting")
+	flags.Var(&opts.VersionBump, "bump", "how the metadata version changes: auto, none, patch, minor or major")
+	flags.StringVar(&opts.MetadataURL, "metadata-url", opts.MetadataURL, "URL recorded in the metadata, e.g. where the dataset is published")
+	flags.StringVar(&opts.MetadataVersion, "metadata-version", opts.MetadataVersion, "fixed metadata version, replacing the bumped one")
+	flags.Var(&opts.MetadataExtra, "meta", "extra key=value recorded in the metadata, e.g. license=CC-BY-4.0 (repeatable)")
+	flags.StringVar(&opts.MetadataConfig, "metadata-config", opts.MetadataConfig, "YAML file setting the url, version and extra fields of the metadata")
+	flags.BoolVar(&opts.EmbedMetadata, "embed-metadata", opts.EmbedMetadata, `write one {"metadata": ..., "quotes": [...]} file instead of a separate metadata file`)
+	addProcessingFlags(flags, opts)
+	return into, metadataFile, &match
+}
+
+// runDiff reports the quotes added, removed and modified between two datasets, exiting with
+// status 1 when they differ like diff(1)
+func runDiff(args []string) {
+	opts := utils.DefaultOptions()
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := flags.String("format", "text", "output format: text or json")
+	match := utils.MergeByText
+	flags.Var(&match, "match", "how quotes are matched: text or id")
+	addProcessingFlags(flags, &opts)
+	files := parseInterspersed(flags, args)
+	if len(files) != 2 || (*format != "text" && *format != "json") {
+		fmt.Fprintln(os.Stderr, "usage: toJson diff old.json|old.xlsx new.json|new.xlsx [--format text|json] [flags]")
+		os.Exit(2)
+	}
+
+	oldQuotes, err := utils.LoadQuotes(files[0], opts)
+	if err != nil {
+		panic(err)
+	}
+	newQuotes, err := utils.LoadQuotes(files[1], opts)
+	if err != nil {
+		panic(err)
+	}
+	diff, err := utils.DiffQuotes(oldQuotes, newQuotes, match)
+	if err != nil {
+		panic(err)
+	}
+	if *format == "json" {
+		err = diff.WriteJSON(os.Stdout)
+	} else {
+		err = diff.WriteText(os.Stdout)
+	}
+	if err != nil {
+		panic(err)
+	}
+	if !diff.Empty() {
+		os.Exit(1)
+	}
+}
+
+// runSchema prints the JSON Schema of the output files
+func runSchema(args []string) {
+	flags := flag.NewFlagSet("schema", flag.ExitOnError)
+	root := flags.String("type", "quotes", "document described: "+strings.Join(utils.SchemaRoots, ", "))
+	output := flags.String("out", "-", `file the schema is written to, "-" for stdout`)
+	flags.Parse(args)
+
+	schema, err := utils.JSONSchema(*root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	schema = append(schema, '\n')
+	if *output == "-" {
+		_, err = os.Stdout.Write(schema)
+	} else {
+		err = os.WriteFile(*output, schema, 0644)
+	}
+	if err != nil {
 		panic(err)
 	}
-}
\ No newline at end of file
+}
+
+// runValidate checks existing JSON outputs, exiting with status 1 if anything is wrong
+func runValidate(args []string) {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	metadataFile := flags.String("metadata", "quotesMetadata.json", `metadata file checked against the quotes, "" to skip it`)
+	files := parseInterspersed(flags, args)
+	if len(files) > 1 {
+		fmt.Fprintln(os.Stderr, "usage: toJson validate [quotes.json] [--metadata quotesMetadata.json]")
+		os.Exit(2)
+	}
+	quotesFile := "quotes.json"
+	if len(files) == 1 {
+		quotesFile = files[0]
+	}
+	// The default metadata file is optional, one named on the command line isn't
+	if _, err := os.Stat(*metadataFile); os.IsNotExist(err) && !isFlagSet(flags, "metadata") {
+		*metadataFile = ""
+	}
+
+	problems, err := utils.ValidateOutput(quotesFile, *metadataFile)
+	if err != nil {
+		panic(err)
+	}
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%d problems found\n", len(problems))
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "%s is valid\n", quotesFile)
+}
+
+// isFlagSet reports whether the flag name was given on the command line
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+// parseInterspersed parses flags appearing before and after the positional arguments, so
+// `toJson merge new.xlsx --into quotes.json` works, and returns the positional arguments
+func parseInterspersed(flags *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		flags.Parse(args)
+		if flags.NArg() == 0 {
+			return positional
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// runServe serves an existing quotes.json over HTTP until the process is stopped
+func runServe(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	dataFile := flags.String("data", "quotes.json", "quotes file to serve")
+	addr := flags.String("addr", ":8080", "address the server listens on")
+	grpcAddr := flags.String("grpc-addr", "", "also serve the QuoteService gRPC API on this address, e.g. :9090")
+	var serverOpts utils.ServerOptions
+	flags.StringVar(&serverOpts.CacheControl, "cache-control", "no-cache", `Cache-Control header of the dataset and its listings, e.g. "public, max-age=300"`)
+	flags.Func("api-key", "API key a request needs, one of them when repeated (default open to all)", func(key string) error {
+		serverOpts.APIKeys = append(serverOpts.APIKeys, key)
+		return nil
+	})
+	keysFile := flags.String("api-keys-file", "", "file of the API keys a request needs one of, one per line")
+	flags.Float64Var(&serverOpts.RateLimit, "rate-limit", serverOpts.RateLimit, "requests per second allowed per client IP, 0 for unlimited")
+	flags.IntVar(&serverOpts.RateBurst, "rate-burst", serverOpts.RateBurst, "requests a client may send at once (default one second of --rate-limit)")
+	flags.BoolVar(&serverOpts.TrustProxy, "trust-proxy", serverOpts.TrustProxy, "identify clients by X-Forwarded-For, when behind a reverse proxy")
+	flags.IntVar(&serverOpts.Conversions, "conversions", serverOpts.Conversions, "convert uploads through POST /conversions and gRPC, this many at once (default disabled)")
+	flags.DurationVar(&serverOpts.ConversionTTL, "conversion-ttl", 15*time.Minute, "how long the result of a finished conversion is kept")
+	profile := addProfilingFlags(flags)
+	flags.Parse(args)
+	defer startProfiling(profile)()
+	if *keysFile != "" {
+		keys, err := utils.LoadAPIKeys(*keysFile)
+		if err != nil {
+			panic(err)
+		}
+		serverOpts.APIKeys = append(serverOpts.APIKeys, keys...)
+	}
+
+	data, err := utils.ReadQuotesFromJSON(*dataFile)
+	if err != nil {
+		panic(err)
+	}
+	if info, err := os.Stat(*dataFile); err == nil {
+		serverOpts.ModTime = info.ModTime()
+	}
+	handler := utils.NewServer(data, serverOpts)
+	if *grpcAddr != "" {
+		listener, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Fprintf(os.Stderr, "Serving gRPC on %s\n", *grpcAddr)
+		go func() {
+			if err := utils.NewGRPCServer(handler).Serve(listener); err != nil {
+				panic(err)
+			}
+		}()
+	}
+	server := &http.Server{Addr: *addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	fmt.Fprintf(os.Stderr, "Serving %d quotes of %s on %s\n", len(data.Quotes), *dataFile, *addr)
+	if err := server.ListenAndServe(); err != nil {
+		panic(err)
+	}
+}
+
+// runSearch prints the quotes of a search index built by --to search:... matching a query
+func runSearch(args []string) {
+	flags := flag.NewFlagSet("search", flag.ExitOnError)
+	index := flags.String("index", "quotes.search.db", "search index built by convert --to search:<file>")
+	format := flags.String("format", "text", "output format: text or json")
+	var opts utils.SearchOptions
+	flags.Func("tag", "only quotes with this tag (repeatable, all of them must match)", func(tag string) error {
+		opts.Tags = append(opts.Tags, tag)
+		return nil
+	})
+	flags.IntVar(&opts.Limit, "limit", 20, "most quotes printed, 0 for all")
+	words := parseInterspersed(flags, args)
+	if (len(words) == 0 && len(opts.Tags) == 0) || (*format != "text" && *format != "json") {
+		fmt.Fprintln(os.Stderr, `usage: toJson search "words" [--tag tag] [--index quotes.search.db] [--limit 20] [--format text|json]`)
+		os.Exit(2)
+	}
+
+	quotes, err := utils.SearchQuotes(*index, strings.Join(words, " "), opts)
+	if err != nil {
+		panic(err)
+	}
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(quotes); err != nil {
+			panic(err)
+		}
+		return
+	}
+	for _, quote := range quotes {
+		line := `"` + quote.Text + `"`
+		if quote.Author != "" {
+			line += " — " + quote.Author
+		}
+		if tags := strings.Trim(strings.Join(quote.Tags, ", "), ", "); tags != "" {
+			line += " [" + tags + "]"
+		}
+		fmt.Println(line)
+	}
+	if len(quotes) == 0 {
+		fmt.Fprintln(os.Stderr, "no quotes found")
+		os.Exit(1)
+	}
+}
+
+// runExport renders an existing quotes.json into another publishable form
+func runExport(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: toJson export html|content|obsidian|feed|xlsx [flags]")
+		os.Exit(2)
+	}
+
+	switch target := args[0]; target {
+	case "html":
+		flags := flag.NewFlagSet("export html", flag.ExitOnError)
+		dataFile := flags.String("data", "quotes.json", "quotes file to export")
+		outDir := flags.String("out-dir", "site", "directory the static site is written to")
+		title := flags.String("title", "Quotes", "site title")
+		flags.Parse(args[1:])
+
+		data, err := utils.ReadQuotesFromJSON(*dataFile)
+		if err != nil {
+			panic(err)
+		}
+		if err := utils.ExportHTMLSite(data, *outDir, *title); err != nil {
+			panic(err)
+		}
+		fmt.Fprintf(os.Stderr, "Static site successfully written to %s\n", *outDir)
+	case "content":
+		flags := flag.NewFlagSet("export content", flag.ExitOnError)
+		dataFile := flags.String("data", "quotes.json", "quotes file to export")
+		outDir := flags.String("out-dir", "content/quotes", "directory the Markdown files are written to")
+		flags.Parse(args[1:])
+
+		data, err := utils.ReadQuotesFromJSON(*dataFile)
+		if err != nil {
+			panic(err)
+		}
+		if err := utils.ExportContentFiles(data, *outDir); err != nil {
+			panic(err)
+		}
+		fmt.Fprintf(os.Stderr, "%d content files successfully written to %s\n", len(data.Quotes), *outDir)
+	case "obsidian":
+		flags := flag.NewFlagSet("export obsidian", flag.ExitOnError)
+		dataFile := flags.String("data", "quotes.json", "quotes file to export")
+		outDir := flags.String("out-dir", "vault/Quotes", "vault folder the notes are written to")
+		flags.Parse(args[1:])
+
+		data, err := utils.ReadQuotesFromJSON(*dataFile)
+		if err != nil {
+			panic(err)
+		}
+		if err := utils.ExportObsidianVault(data, *outDir); err != nil {
+			panic(err)
+		}
+		fmt.Fprintf(os.Stderr, "%d notes successfully written to %s\n", len(data.Quotes), *outDir)
+	case "feed":
+		flags := flag.NewFlagSet("export feed", flag.ExitOnError)
+		dataFile := flags.String("data", "quotes.json", "quotes file to export")
+		metadataFile := flags.String("metadata", "quotesMetadata.json", "metadata file providing the feed date")
+		feedOpts := utils.FeedOptions{}
+		flags.StringVar(&feedOpts.Format, "format", "rss", "feed format: rss or atom")
+		flags.StringVar(&feedOpts.BaseURL, "base-url", "", "site URL the feed entries link to (required)")
+		flags.StringVar(&feedOpts.Title, "title", "Quotes", "feed title")
+		flags.IntVar(&feedOpts.Limit, "limit", 20, "number of newest quotes to include, 0 for all")
+		outFile := flags.String("out", "feed.xml", "feed file to write")
+		flags.Parse(args[1:])
+
+		data, err := utils.ReadQuotesFromJSON(*dataFile)
+		if err != nil {
+			panic(err)
+		}
+		metadata, err := utils.ReadMetadataFromJSON(*metadataFile)
+		if err != nil {
+			panic(err)
+		}
+		file, err := os.Create(*outFile)
+		if err != nil {
+			panic(err)
+		}
+		defer file.Close()
+		if err := utils.ExportFeed(data, metadata, feedOpts, file); err != nil {
+			panic(err)
+		}
+		fmt.Fprintf(os.Stderr, "Feed successfully written to %s\n", *outFile)
+	case "xlsx":
+		flags := flag.NewFlagSet("export xlsx", flag.ExitOnError)
+		dataFile := flags.String("data", "quotes.json", "quotes file to export, also accepted as an argument")
+		outFile := flags.String("out", "quotes.xlsx", "spreadsheet to write")
+		flags.StringVar(outFile, "o", *outFile, "shorthand for --out")
+		files := parseInterspersed(flags, args[1:])
+		if len(files) > 1 {
+			fmt.Fprintln(os.Stderr, "usage: toJson export xlsx [quotes.json] [-o quotes.xlsx]")
+			os.Exit(2)
+		}
+		if len(files) == 1 {
+			*dataFile = files[0]
+		}
+
+		data, err := utils.ReadQuotesFromJSON(*dataFile)
+		if err != nil {
+			panic(err)
+		}
+		if err := utils.ExportExcelFile(data, *outFile); err != nil {
+			panic(err)
+		}
+		fmt.Fprintf(os.Stderr, "%d quotes successfully written to %s\n", len(data.Quotes), *outFile)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown export target %q\n", target)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"toJson/utils"
+)
+
+// TestConvertSearchIndex runs the documented `convert quotes.csv --to search:quotes.search.db`,
+// with the flags after the input file
+func TestConvertSearchIndex(t *testing.T) {
+	// The metadata is written to the working directory, which must not be the repository's
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+	input := filepath.Join(dir, "quotes.csv")
+	require.NoError(t, os.WriteFile(input, []byte("Tags,Quote\ncourage,Courage is grace under pressure.\nlove,Love is patient.\n"), 0644))
+	index := filepath.Join(dir, "quotes.search.db")
+
+	runConvert([]string{input, "--to", "search:" + index, "--out", filepath.Join(dir, "quotes.json")})
+
+	quotes, err := utils.SearchQuotes(index, "courage", utils.SearchOptions{})
+	require.NoError(t, err)
+	require.Len(t, quotes, 1)
+	assert.Equal(t, "Courage is grace under pressure.", quotes[0].Text)
+}
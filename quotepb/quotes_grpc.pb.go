@@ -0,0 +1,241 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: quotepb/quotes.proto
+
+package quotepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	QuoteService_ListQuotes_FullMethodName   = "/quotes.v1.QuoteService/ListQuotes"
+	QuoteService_GetQuote_FullMethodName     = "/quotes.v1.QuoteService/GetQuote"
+	QuoteService_SearchQuotes_FullMethodName = "/quotes.v1.QuoteService/SearchQuotes"
+	QuoteService_Convert_FullMethodName      = "/quotes.v1.QuoteService/Convert"
+)
+
+// QuoteServiceClient is the client API for QuoteService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// QuoteService serves a quotes dataset over gRPC, like the HTTP API of `toJson serve`
+type QuoteServiceClient interface {
+	// ListQuotes lists the quotes matching the filters, a page at a time
+	ListQuotes(ctx context.Context, in *ListQuotesRequest, opts ...grpc.CallOption) (*ListQuotesResponse, error)
+	// GetQuote returns the quote with an ID, or NOT_FOUND
+	GetQuote(ctx context.Context, in *GetQuoteRequest, opts ...grpc.CallOption) (*Quote, error)
+	// SearchQuotes returns the quotes whose text contains the query, ignoring case
+	SearchQuotes(ctx context.Context, in *SearchQuotesRequest, opts ...grpc.CallOption) (*SearchQuotesResponse, error)
+	// Convert converts an uploaded input file, streamed in chunks after its options, to quotes
+	Convert(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ConvertRequest, ConvertResponse], error)
+}
+
+type quoteServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewQuoteServiceClient(cc grpc.ClientConnInterface) QuoteServiceClient {
+	return &quoteServiceClient{cc}
+}
+
+func (c *quoteServiceClient) ListQuotes(ctx context.Context, in *ListQuotesRequest, opts ...grpc.CallOption) (*ListQuotesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListQuotesResponse)
+	err := c.cc.Invoke(ctx, QuoteService_ListQuotes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quoteServiceClient) GetQuote(ctx context.Context, in *GetQuoteRequest, opts ...grpc.CallOption) (*Quote, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Quote)
+	err := c.cc.Invoke(ctx, QuoteService_GetQuote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quoteServiceClient) SearchQuotes(ctx context.Context, in *SearchQuotesRequest, opts ...grpc.CallOption) (*SearchQuotesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchQuotesResponse)
+	err := c.cc.Invoke(ctx, QuoteService_SearchQuotes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quoteServiceClient) Convert(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ConvertRequest, ConvertResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &QuoteService_ServiceDesc.Streams[0], QuoteService_Convert_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ConvertRequest, ConvertResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type QuoteService_ConvertClient = grpc.ClientStreamingClient[ConvertRequest, ConvertResponse]
+
+// QuoteServiceServer is the server API for QuoteService service.
+// All implementations must embed UnimplementedQuoteServiceServer
+// for forward compatibility.
+//
+// QuoteService serves a quotes dataset over gRPC, like the HTTP API of `toJson serve`
+type QuoteServiceServer interface {
+	// ListQuotes lists the quotes matching the filters, a page at a time
+	ListQuotes(context.Context, *ListQuotesRequest) (*ListQuotesResponse, error)
+	// GetQuote returns the quote with an ID, or NOT_FOUND
+	GetQuote(context.Context, *GetQuoteRequest) (*Quote, error)
+	// SearchQuotes returns the quotes whose text contains the query, ignoring case
+	SearchQuotes(context.Context, *SearchQuotesRequest) (*SearchQuotesResponse, error)
+	// Convert converts an uploaded input file, streamed in chunks after its options, to quotes
+	Convert(grpc.ClientStreamingServer[ConvertRequest, ConvertResponse]) error
+	mustEmbedUnimplementedQuoteServiceServer()
+}
+
+// UnimplementedQuoteServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedQuoteServiceServer struct{}
+
+func (UnimplementedQuoteServiceServer) ListQuotes(context.Context, *ListQuotesRequest) (*ListQuotesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListQuotes not implemented")
+}
+func (UnimplementedQuoteServiceServer) GetQuote(context.Context, *GetQuoteRequest) (*Quote, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetQuote not implemented")
+}
+func (UnimplementedQuoteServiceServer) SearchQuotes(context.Context, *SearchQuotesRequest) (*SearchQuotesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchQuotes not implemented")
+}
+func (UnimplementedQuoteServiceServer) Convert(grpc.ClientStreamingServer[ConvertRequest, ConvertResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Convert not implemented")
+}
+func (UnimplementedQuoteServiceServer) mustEmbedUnimplementedQuoteServiceServer() {}
+func (UnimplementedQuoteServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeQuoteServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to QuoteServiceServer will
+// result in compilation errors.
+type UnsafeQuoteServiceServer interface {
+	mustEmbedUnimplementedQuoteServiceServer()
+}
+
+func RegisterQuoteServiceServer(s grpc.ServiceRegistrar, srv QuoteServiceServer) {
+	// If the following call pancis, it indicates UnimplementedQuoteServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&QuoteService_ServiceDesc, srv)
+}
+
+func _QuoteService_ListQuotes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListQuotesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuoteServiceServer).ListQuotes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuoteService_ListQuotes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuoteServiceServer).ListQuotes(ctx, req.(*ListQuotesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuoteService_GetQuote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetQuoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuoteServiceServer).GetQuote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuoteService_GetQuote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuoteServiceServer).GetQuote(ctx, req.(*GetQuoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuoteService_SearchQuotes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchQuotesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuoteServiceServer).SearchQuotes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuoteService_SearchQuotes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuoteServiceServer).SearchQuotes(ctx, req.(*SearchQuotesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuoteService_Convert_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(QuoteServiceServer).Convert(&grpc.GenericServerStream[ConvertRequest, ConvertResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type QuoteService_ConvertServer = grpc.ClientStreamingServer[ConvertRequest, ConvertResponse]
+
+// QuoteService_ServiceDesc is the grpc.ServiceDesc for QuoteService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var QuoteService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "quotes.v1.QuoteService",
+	HandlerType: (*QuoteServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListQuotes",
+			Handler:    _QuoteService_ListQuotes_Handler,
+		},
+		{
+			MethodName: "GetQuote",
+			Handler:    _QuoteService_GetQuote_Handler,
+		},
+		{
+			MethodName: "SearchQuotes",
+			Handler:    _QuoteService_SearchQuotes_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Convert",
+			Handler:       _QuoteService_Convert_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "quotepb/quotes.proto",
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// airtableAPI is the public Airtable API
+	airtableAPI = "https://api.airtable.com/v0"
+	// airtablePageSize is the most records Airtable returns per request
+	airtablePageSize = 100
+)
+
+// importAirtable lists every record of the table named by args[1], by name or ID, in the
+// Airtable base whose ID is args[0], authenticating with opts.Token or the AIRTABLE_TOKEN
+// environment variable. Each record is a quote and its fields are columns, recognised by name
+// like the header of a sheet; --column maps fields named otherwise.
+func importAirtable(args []string, opts ImportOptions) (*sliceRows, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("airtable needs the ID of a base and the name of a table")
+	}
+	token := opts.Token
+	if token == "" {
+		token = os.Getenv("AIRTABLE_TOKEN")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("airtable needs a personal access token, set with --token or AIRTABLE_TOKEN")
+	}
+	api := opts.API
+	if api == "" {
+		api = airtableAPI
+	}
+	source := newHTTPSource(opts.Interval)
+	source.headers["Authorization"] = "Bearer " + token
+
+	var records []map[string]string
+	query := url.Values{"pageSize": {strconv.Itoa(airtablePageSize)}}
+	for {
+		var response struct {
+			Records []struct {
+				Fields map[string]json.RawMessage `json:"fields"`
+			} `json:"records"`
+			Offset string `json:"offset"`
+		}
+		endpoint := strings.TrimSuffix(api, "/") + "/" + url.PathEscape(args[0]) + "/" + url.PathEscape(args[1]) + "?" + query.Encode()
+		if err := source.getJSON(endpoint, &response); err != nil {
+			return nil, err
+		}
+		for _, record := range response.Records {
+			fields := make(map[string]string, len(record.Fields))
+			for name, value := range record.Fields {
+				fields[name] = airtableText(value)
+			}
+			records = append(records, fields)
+		}
+		if response.Offset == "" {
+			break
+		}
+		query.Set("offset", response.Offset)
+	}
+	return &sliceRows{rows: fieldRows(records)}, nil
+}
+
+// airtableText returns the value of an Airtable field as the text of a cell. The items of a
+// list, such as a multiple select, are joined with ", "; collaborators and attachments are
+// read by their name.
+func airtableText(value json.RawMessage) string {
+	var decoded any
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return ""
+	}
+	return airtableValue(decoded)
+}
+
+func airtableValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if text := airtableValue(item); text != "" {
+				items = append(items, text)
+			}
+		}
+		return strings.Join(items, ", ")
+	case map[string]any:
+		for _, key := range []string{"name", "filename", "email"} {
+			if text, ok := v[key].(string); ok {
+				return text
+			}
+		}
+	}
+	return ""
+}
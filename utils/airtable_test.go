@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAirtableText tests reading the values of the field types
+func TestAirtableText(t *testing.T) {
+	tests := map[string]string{
+		`"Know thyself."`:                "Know thyself.",
+		`1950`:                           "1950",
+		`true`:                           "true",
+		`["wisdom", "life"]`:             "wisdom, life",
+		`{"id": "usr1", "name": "Ada"}`:  "Ada",
+		`[{"filename": "portrait.png"}]`: "portrait.png",
+		`{"specialValue": "NaN"}`:        "",
+		`null`:                           "",
+	}
+	for value, want := range tests {
+		assert.Equal(t, want, airtableText(json.RawMessage(value)), value)
+	}
+}
+
+// TestImportAirtable tests listing every page of records of a table
+func TestImportAirtable(t *testing.T) {
+	var offsets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app123/Quote Backlog", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "100", r.URL.Query().Get("pageSize"))
+		offsets = append(offsets, r.URL.Query().Get("offset"))
+		if r.URL.Query().Get("offset") == "" {
+			w.Write([]byte(`{"records": [{"id": "rec1", "fields": {"Quote": "Know thyself.", "Author": "Socrates", "Tags": ["wisdom", "life"]}}], "offset": "itr2"}`))
+			return
+		}
+		w.Write([]byte(`{"records": [{"id": "rec2", "fields": {"Quote": "Carpe diem.", "Year": 23}}]}`))
+	}))
+	defer server.Close()
+
+	rows, err := importAirtable([]string{"app123", "Quote Backlog"}, ImportOptions{API: server.URL, Token: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"Author", "Quote", "Tags", "Year"}, {"Socrates", "Know thyself.", "wisdom, life", ""}, {"", "Carpe diem.", "", "23"}}, rows.rows)
+	assert.Equal(t, []string{"", "itr2"}, offsets)
+
+	quotes, err := readQuotes(rows.rows, DefaultOptions())
+	require.NoError(t, err)
+	require.Len(t, quotes, 2)
+	assert.Equal(t, "Socrates", quotes[0].Author)
+
+	t.Setenv("AIRTABLE_TOKEN", "")
+	_, err = importAirtable([]string{"app123", "Quotes"}, ImportOptions{API: server.URL})
+	assert.ErrorContains(t, err, "AIRTABLE_TOKEN")
+	_, err = importAirtable([]string{"app123"}, ImportOptions{Token: "secret"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// aliasMap maps the normalized spelling of an author or tag to its canonical name
+type aliasMap map[string]string
+
+// aliasKey normalizes a name for lookup, ignoring case and repeated whitespace
+func aliasKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// loadAliases reads a YAML file mapping every canonical name to its aliases, such as authors.yaml:
+//
+//	Albert Einstein:
+//	  - A. Einstein
+//	  - Einstein, Albert
+//
+// kind names what is being aliased in error messages.
+func loadAliases(fileName string, kind string) (aliasMap, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s aliases %s: %w", kind, fileName, err)
+	}
+	var canonical map[string][]string
+	if err := yaml.Unmarshal(data, &canonical); err != nil {
+		return nil, fmt.Errorf("failed to parse %s aliases %s: %w", kind, fileName, err)
+	}
+
+	aliases := aliasMap{}
+	add := func(alias, name string) error {
+		key := aliasKey(alias)
+		if existing, ok := aliases[key]; ok && existing != name {
+			return fmt.Errorf("%s alias %q in %s maps to both %q and %q", kind, alias, fileName, existing, name)
+		}
+		aliases[key] = name
+		return nil
+	}
+	for name, names := range canonical {
+		// The canonical name also fixes differently cased spellings of itself
+		if err := add(name, name); err != nil {
+			return nil, err
+		}
+		for _, alias := range names {
+			if err := add(alias, name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return aliases, nil
+}
+
+// canonical returns the canonical spelling of name, or name itself if it has none
+func (a aliasMap) canonical(name string) string {
+	if canonical, ok := a[aliasKey(name)]; ok {
+		return canonical
+	}
+	return name
+}
+
+// canonicalTags maps every tag to its canonical spelling, dropping tags that fold into one
+// already present, and returns the number of tags rewritten
+func (a aliasMap) canonicalTags(tags []string) ([]string, int) {
+	rewritten := 0
+	folded := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	foldedInto := make(map[string]bool) // canonical tags produced by a rewrite
+	for _, tag := range tags {
+		canonical := a.canonical(tag)
+		if canonical != tag {
+			rewritten++
+		}
+		if seen[canonical] && (canonical != tag || foldedInto[canonical]) {
+			continue
+		}
+		seen[canonical] = true
+		foldedInto[canonical] = foldedInto[canonical] || canonical != tag
+		folded = append(folded, canonical)
+	}
+	return folded, rewritten
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadAliases tests alias lookups, ignoring case and spacing
+func TestLoadAliases(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "authors.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+Albert Einstein:
+  - A. Einstein
+  - Einstein, Albert
+Rumi: [Jalal ad-Din Rumi, Mevlana]
+`), 0644))
+
+	aliases, err := loadAliases(file, "author")
+	require.NoError(t, err)
+
+	tests := map[string]string{
+		"A. Einstein":       "Albert Einstein",
+		"einstein,  albert": "Albert Einstein",
+		"ALBERT EINSTEIN":   "Albert Einstein",
+		"Mevlana":           "Rumi",
+		"Marcus Aurelius":   "Marcus Aurelius",
+	}
+	for author, expected := range tests {
+		assert.Equal(t, expected, aliases.canonical(author), author)
+	}
+}
+
+// TestLoadAliasesErrors tests missing, malformed and conflicting alias files
+func TestLoadAliasesErrors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := loadAliases(filepath.Join(dir, "missing.yaml"), "author")
+	assert.Error(t, err)
+
+	malformed := filepath.Join(dir, "malformed.yaml")
+	require.NoError(t, os.WriteFile(malformed, []byte("- just\n- a list\n"), 0644))
+	_, err = loadAliases(malformed, "author")
+	assert.Error(t, err)
+
+	conflict := filepath.Join(dir, "conflict.yaml")
+	require.NoError(t, os.WriteFile(conflict, []byte("Albert Einstein: [Einstein]\nAlfred Einstein: [Einstein]\n"), 0644))
+	_, err = loadAliases(conflict, "author")
+	assert.Error(t, err)
+}
+
+// TestCanonicalTags tests tag folding and the rewrite count
+func TestCanonicalTags(t *testing.T) {
+	aliases := aliasMap{"motivational": "motivation", "phil": "philosophy", "motivation": "motivation"}
+
+	tags, rewritten := aliases.canonicalTags([]string{"Motivational", "motivation", "phil", "life"})
+	assert.Equal(t, []string{"motivation", "philosophy", "life"}, tags)
+	assert.Equal(t, 2, rewritten)
+
+	tags, rewritten = aliases.canonicalTags([]string{""})
+	assert.Equal(t, []string{""}, tags)
+	assert.Equal(t, 0, rewritten)
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// attributionDashes separate a quote from a trailing attribution; a plain hyphen only counts
+// with whitespace on both sides so hyphenated words are left alone
+var attributionDashes = []string{"—", "–", "―", "--", " - "}
+
+// nameParticles may appear in lower case inside a name, as in Ludwig van Beethoven
+var nameParticles = map[string]bool{
+	"al": true, "bin": true, "da": true, "de": true, "del": true, "der": true, "di": true,
+	"du": true, "ibn": true, "la": true, "le": true, "of": true, "the": true, "van": true, "von": true,
+}
+
+// maxNameWords is the longest attribution recognised as a name
+const maxNameWords = 5
+
+// extractAttribution splits `"Some quote" — Albert Einstein` into the quote and the author. It
+// only succeeds when the text after the last dash looks like a name: at most five words that each
+// start with a capital (or uncased) letter, apart from particles such as "van". Quotation marks
+// around the remaining text are removed.
+func extractAttribution(text string) (string, string, bool) {
+	trimmed := strings.TrimSpace(text)
+	cut := -1
+	sepLen := 0
+	for _, dash := range attributionDashes {
+		if i := strings.LastIndex(trimmed, dash); i > cut {
+			cut, sepLen = i, len(dash)
+		}
+	}
+	if cut <= 0 {
+		return text, "", false
+	}
+
+	author := strings.TrimSpace(trimmed[cut+sepLen:])
+	quote := strings.TrimSpace(trimmed[:cut])
+	if quote == "" || !looksLikeName(author) {
+		return text, "", false
+	}
+	return unquoteText(quote), author, true
+}
+
+// looksLikeName reports whether s could be a person's name
+func looksLikeName(s string) bool {
+	words := strings.Fields(s)
+	if len(words) == 0 || len(words) > maxNameWords {
+		return false
+	}
+	for i, word := range words {
+		if i > 0 && i < len(words)-1 && nameParticles[word] {
+			continue
+		}
+		for j, r := range word {
+			if j == 0 && (!unicode.IsLetter(r) || unicode.IsLower(r)) {
+				return false
+			}
+			if !unicode.IsLetter(r) && !unicode.IsMark(r) && !strings.ContainsRune(".'’-", r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// quotePairs are the quotation marks removed from around an extracted quote
+var quotePairs = [][2]string{{`"`, `"`}, {"“", "”"}, {"'", "'"}, {"‘", "’"}, {"«", "»"}, {"„", "“"}}
+
+// unquoteText removes one pair of quotation marks enclosing the whole of s
+func unquoteText(s string) string {
+	for _, pair := range quotePairs {
+		if len(s) > len(pair[0])+len(pair[1]) && strings.HasPrefix(s, pair[0]) && strings.HasSuffix(s, pair[1]) {
+			inner := s[len(pair[0]) : len(s)-len(pair[1])]
+			if !strings.Contains(inner, pair[0]) && !strings.Contains(inner, pair[1]) {
+				return strings.TrimSpace(inner)
+			}
+		}
+	}
+	return s
+}
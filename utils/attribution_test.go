@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExtractAttribution tests recognised attributions and text that must be left alone
+func TestExtractAttribution(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		text   string
+		author string
+	}{
+		{name: "em_dash", input: `"Imagination is more important than knowledge." — Albert Einstein`, text: "Imagination is more important than knowledge.", author: "Albert Einstein"},
+		{name: "curly_quotes_en_dash", input: "“Be the change.” – M. K. Gandhi", text: "Be the change.", author: "M. K. Gandhi"},
+		{name: "double_hyphen_unquoted", input: "Know thyself --Socrates", text: "Know thyself", author: "Socrates"},
+		{name: "spaced_hyphen_particle", input: "Music is ... - Ludwig van Beethoven", text: "Music is ...", author: "Ludwig van Beethoven"},
+		{name: "inner_quotes_kept", input: `"Say "yes" often" — Anon`, text: `"Say "yes" often"`, author: "Anon"},
+		{name: "uncased_script", input: "யாதும் ஊரே யாவரும் கேளிர் — கணியன் பூங்குன்றனார்", text: "யாதும் ஊரே யாவரும் கேளிர்", author: "கணியன் பூங்குன்றனார்"},
+		{name: "dash_in_sentence", input: "When you feel weak - surrender, when you feel strong - do seva", text: "When you feel weak - surrender, when you feel strong - do seva"},
+		{name: "lowercase_words", input: "The company of the Truth - Satsang is the resting place for the mind", text: "The company of the Truth - Satsang is the resting place for the mind"},
+		{name: "hyphenated_word", input: "See the ever-full Presence", text: "See the ever-full Presence"},
+		{name: "quoted_phrase", input: "which is innocence - 'in no sense'", text: "which is innocence - 'in no sense'"},
+		{name: "too_many_words", input: "It ends — One Two Three Four Five Six", text: "It ends — One Two Three Four Five Six"},
+		{name: "only_attribution", input: "— Albert Einstein", text: "— Albert Einstein"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, author, ok := extractAttribution(tt.input)
+			assert.Equal(t, tt.text, text)
+			assert.Equal(t, tt.author, author)
+			assert.Equal(t, tt.author != "", ok)
+		})
+	}
+}
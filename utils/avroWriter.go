@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+)
+
+// avroSchema is embedded in every Avro file. Optional fields are unions with null so that
+// readers can evolve the schema, and new fields must likewise be added with a default.
+const avroSchema = `{"type":"record","name":"Quote","namespace":"toJson","fields":[` +
+	`{"name":"id","type":"long"},` +
+	`{"name":"text","type":"string"},` +
+	`{"name":"author","type":["null","string"],"default":null},` +
+	`{"name":"year","type":["null","int"],"default":null},` +
+	`{"name":"context","type":["null","string"],"default":null},` +
+	`{"name":"tags","type":{"type":"array","items":"string"}},` +
+	`{"name":"lang","type":"string"}]}`
+
+// avroBlockSize is the number of records per container block
+const avroBlockSize = 1000
+
+// avroEncoder writes an Avro object container file with deflate-compressed blocks
+type avroEncoder struct {
+	sync    [16]byte
+	block   bytes.Buffer
+	records int
+	started bool
+}
+
+// start writes the file header on first use
+func (e *avroEncoder) start(w *bufio.Writer) error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	if _, err := rand.Read(e.sync[:]); err != nil {
+		return err
+	}
+
+	var header bytes.Buffer
+	header.WriteString("Obj\x01")
+	writeAvroLong(&header, 2) // metadata map with two entries
+	writeAvroString(&header, "avro.schema")
+	writeAvroString(&header, avroSchema)
+	writeAvroString(&header, "avro.codec")
+	writeAvroString(&header, "deflate")
+	writeAvroLong(&header, 0) // end of map
+	header.Write(e.sync[:])
+	_, err := w.Write(header.Bytes())
+	return err
+}
+
+func (e *avroEncoder) writeQuote(w *bufio.Writer, quote Quote, index int) error {
+	if err := e.start(w); err != nil {
+		return err
+	}
+
+	writeAvroLong(&e.block, quote.ID)
+	writeAvroString(&e.block, quote.Text)
+	writeAvroOptionalString(&e.block, quote.Author)
+	if quote.Year == 0 {
+		writeAvroLong(&e.block, 0)
+	} else {
+		writeAvroLong(&e.block, 1)
+		writeAvroLong(&e.block, int64(quote.Year))
+	}
+	writeAvroOptionalString(&e.block, quote.Context)
+	if len(quote.Tags) > 0 {
+		writeAvroLong(&e.block, int64(len(quote.Tags)))
+		for _, tag := range quote.Tags {
+			writeAvroString(&e.block, tag)
+		}
+	}
+	writeAvroLong(&e.block, 0) // end of tags array
+	writeAvroString(&e.block, quote.Language)
+
+	e.records++
+	if e.records >= avroBlockSize {
+		return e.writeBlock(w)
+	}
+	return nil
+}
+
+// writeBlock compresses the pending records into a container block
+func (e *avroEncoder) writeBlock(w *bufio.Writer) error {
+	if e.records == 0 {
+		return nil
+	}
+
+	var compressed bytes.Buffer
+	fw, err := flate.NewWriter(&compressed, flate.DefaultCompression)
+	if err != nil {
+		return err
+	}
+	fw.Write(e.block.Bytes())
+	if err := fw.Close(); err != nil {
+		return err
+	}
+
+	var head bytes.Buffer
+	writeAvroLong(&head, int64(e.records))
+	writeAvroLong(&head, int64(compressed.Len()))
+	w.Write(head.Bytes())
+	w.Write(compressed.Bytes())
+	_, err = w.Write(e.sync[:])
+
+	e.block.Reset()
+	e.records = 0
+	return err
+}
+
+func (e *avroEncoder) finish(w *bufio.Writer, count int) error {
+	if err := e.start(w); err != nil {
+		return err
+	}
+	return e.writeBlock(w)
+}
+
+// writeAvroLong writes a zig-zag encoded variable-length integer
+func writeAvroLong(buf *bytes.Buffer, v int64) {
+	var raw [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(raw[:], v) // PutVarint uses the same zig-zag encoding as Avro
+	buf.Write(raw[:n])
+}
+
+// writeAvroString writes a length-prefixed string (also used for bytes)
+func writeAvroString(buf *bytes.Buffer, s string) {
+	writeAvroLong(buf, int64(len(s)))
+	buf.WriteString(s)
+}
+
+// writeAvroOptionalString writes a ["null","string"] union, using null for empty strings
+func writeAvroOptionalString(buf *bytes.Buffer, s string) {
+	if s == "" {
+		writeAvroLong(buf, 0)
+		return
+	}
+	writeAvroLong(buf, 1)
+	writeAvroString(buf, s)
+}
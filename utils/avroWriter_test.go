@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// avroReader decodes the container files written by avroEncoder
+type avroReader struct {
+	r *bufio.Reader
+}
+
+func (a avroReader) long() int64 {
+	v, _ := binary.ReadVarint(a.r)
+	return v
+}
+
+func (a avroReader) str() string {
+	b := make([]byte, a.long())
+	io.ReadFull(a.r, b)
+	return string(b)
+}
+
+// readAvroFile returns the header metadata and all records
+func readAvroFile(t *testing.T, data []byte) (map[string]string, []Quote) {
+	a := avroReader{bufio.NewReader(bytes.NewReader(data))}
+	magic := make([]byte, 4)
+	io.ReadFull(a.r, magic)
+	require.Equal(t, "Obj\x01", string(magic))
+
+	meta := map[string]string{}
+	for n := a.long(); n != 0; n = a.long() {
+		for i := int64(0); i < n; i++ {
+			meta[a.str()] = a.str()
+		}
+	}
+	sync := make([]byte, 16)
+	io.ReadFull(a.r, sync)
+
+	var quotes []Quote
+	for {
+		count, err := binary.ReadVarint(a.r)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		block := make([]byte, a.long())
+		io.ReadFull(a.r, block)
+		marker := make([]byte, 16)
+		io.ReadFull(a.r, marker)
+		require.Equal(t, sync, marker)
+
+		raw, err := io.ReadAll(flate.NewReader(bytes.NewReader(block)))
+		require.NoError(t, err)
+		b := avroReader{bufio.NewReader(bytes.NewReader(raw))}
+		for i := int64(0); i < count; i++ {
+			var q Quote
+			q.ID = b.long()
+			q.Text = b.str()
+			if b.long() == 1 {
+				q.Author = b.str()
+			}
+			if b.long() == 1 {
+				q.Year = int(b.long())
+			}
+			if b.long() == 1 {
+				q.Context = b.str()
+			}
+			for n := b.long(); n != 0; n = b.long() {
+				for j := int64(0); j < n; j++ {
+					q.Tags = append(q.Tags, b.str())
+				}
+			}
+			q.Language = b.str()
+			quotes = append(quotes, q)
+		}
+	}
+	return meta, quotes
+}
+
+// TestAvroEncoder tests that records spanning several blocks decode back to the quotes
+func TestAvroEncoder(t *testing.T) {
+	var quotes []Quote
+	for i := 1; i <= avroBlockSize+5; i++ {
+		quotes = append(quotes, Quote{ID: int64(i), Text: fmt.Sprintf("Quote %d", i), Tags: []string{"a"}, Language: "en-US"})
+	}
+	quotes[1].Author = "Someone"
+	quotes[2].Year = -50
+	quotes[3].Context = "Book"
+	quotes[4].Tags = nil
+
+	var buf bytes.Buffer
+	s := NewQuoteStreamWriter(&buf, StreamAvro)
+	for _, q := range quotes {
+		require.NoError(t, s.WriteQuote(q))
+	}
+	require.NoError(t, s.Close())
+
+	meta, decoded := readAvroFile(t, buf.Bytes())
+	assert.Equal(t, avroSchema, meta["avro.schema"])
+	assert.Equal(t, "deflate", meta["avro.codec"])
+	assert.Equal(t, quotes, decoded)
+}
+
+// TestAvroEncoderEmpty tests that an empty dataset still has a valid header
+func TestAvroEncoderEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewQuoteStreamWriter(&buf, StreamAvro).Close())
+
+	meta, decoded := readAvroFile(t, buf.Bytes())
+	assert.Equal(t, avroSchema, meta["avro.schema"])
+	assert.Empty(t, decoded)
+}
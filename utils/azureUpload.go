@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+)
+
+// azureUploader uploads the output files into an Azure Blob Storage container, under a prefix
+type azureUploader struct {
+	client       *azblob.Client
+	container    string
+	prefix       string
+	cacheControl string
+}
+
+// openAzureUploader opens the uploader of --to azblob://container/path/. The storage account and
+// its key come from AZURE_STORAGE_CONNECTION_STRING when set; otherwise the account is named by
+// AZURE_STORAGE_ACCOUNT and authenticated with a managed identity, or the other credentials of
+// DefaultAzureCredential such as az login.
+func openAzureUploader(target string, opts Options) (uploader, error) {
+	container, prefix := uploadBucketPath(target)
+	u := &azureUploader{container: container, prefix: prefix, cacheControl: opts.UploadCacheControl}
+	if connection := os.Getenv("AZURE_STORAGE_CONNECTION_STRING"); connection != "" {
+		client, err := azblob.NewClientFromConnectionString(connection, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error reading AZURE_STORAGE_CONNECTION_STRING: %w", err)
+		}
+		u.client = client
+		return u, nil
+	}
+
+	account := os.Getenv("AZURE_STORAGE_ACCOUNT")
+	if account == "" {
+		return nil, fmt.Errorf("azblob needs a storage account, set with AZURE_STORAGE_CONNECTION_STRING or AZURE_STORAGE_ACCOUNT")
+	}
+	credential, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, fmt.Errorf("error loading the Azure credentials: %w", err)
+	}
+	client, err := azblob.NewClient("https://"+account+".blob.core.windows.net/", credential, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating the Azure Blob Storage client: %w", err)
+	}
+	u.client = client
+	return u, nil
+}
+
+func (u *azureUploader) upload(ctx context.Context, files []uploadFile) error {
+	for _, file := range files {
+		if err := u.put(ctx, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *azureUploader) location(fileName string) string {
+	return strings.TrimSuffix(u.client.URL(), "/") + "/" + u.container + "/" + u.prefix + filepath.Base(fileName)
+}
+
+// put uploads one file, replacing the blob of the same name
+func (u *azureUploader) put(ctx context.Context, file uploadFile) error {
+	body, err := os.Open(file.path)
+	if err != nil {
+		return fmt.Errorf("error opening %s for upload: %w", file.path, err)
+	}
+	defer body.Close()
+	name := u.prefix + filepath.Base(file.path)
+	headers := &blob.HTTPHeaders{BlobContentType: &file.contentType}
+	if u.cacheControl != "" {
+		headers.BlobCacheControl = &u.cacheControl
+	}
+	if _, err := u.client.UploadFile(ctx, u.container, name, body, &azblob.UploadFileOptions{HTTPHeaders: headers}); err != nil {
+		return fmt.Errorf("error uploading %s to azblob://%s/%s: %w", file.path, u.container, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// azureBlob is a blob uploaded to newFakeAzure
+type azureBlob struct {
+	contentType, cacheControl, body string
+}
+
+// newFakeAzure serves the Put Blob requests of Azure Blob Storage for the account of the
+// connection string it sets, like Azurite
+func newFakeAzure(t *testing.T) map[string]azureBlob {
+	var mu sync.Mutex
+	blobs := map[string]azureBlob{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.Header.Get("x-ms-blob-type") != "BlockBlob" || !strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey ") {
+			http.Error(w, "unexpected request "+r.Method+" "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/devstoreaccount1/forbidden/") {
+			w.Header().Set("x-ms-error-code", "AuthorizationPermissionMismatch")
+			http.Error(w, "", http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		blobs[strings.TrimPrefix(r.URL.Path, "/devstoreaccount1/")] = azureBlob{
+			contentType:  r.Header.Get("x-ms-blob-content-type"),
+			cacheControl: r.Header.Get("x-ms-blob-cache-control"),
+			body:         string(body),
+		}
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(server.Close)
+	key := base64.StdEncoding.EncodeToString([]byte("not a real account key"))
+	t.Setenv("AZURE_STORAGE_CONNECTION_STRING", "DefaultEndpointsProtocol=http;AccountName=devstoreaccount1;AccountKey="+key+";BlobEndpoint="+server.URL+"/devstoreaccount1;")
+	return blobs
+}
+
+func TestConvertFileAzureUpload(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	blobs := newFakeAzure(t)
+	input := filepath.Join(t.TempDir(), "quotes.csv")
+	require.NoError(t, os.WriteFile(input, []byte("Tags,Quote,Author\nwisdom,Know thyself.,Socrates\n"), 0644))
+
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(t.TempDir(), "quotes.json")
+	opts.Sinks = SinkList{"azblob://quotes/data/"}
+	opts.UploadCacheControl = "public, max-age=60"
+	require.NoError(t, ConvertFile(input, opts))
+
+	require.Len(t, blobs, 2)
+	require.Contains(t, blobs, "quotes/data/quotes.json")
+	quotes := blobs["quotes/data/quotes.json"]
+	assert.Equal(t, "application/json", quotes.contentType)
+	assert.Equal(t, "public, max-age=60", quotes.cacheControl)
+	assert.Contains(t, quotes.body, "Know thyself.")
+	assert.Contains(t, blobs["quotes/data/quotesMetadata.json"].body, `"totalQuotes": 1`)
+}
+
+func TestAzureUploaderErrors(t *testing.T) {
+	newFakeAzure(t)
+	file := filepath.Join(t.TempDir(), "quotes.json")
+	require.NoError(t, os.WriteFile(file, []byte("[]"), 0644))
+	u, err := openAzureUploader("//forbidden", DefaultOptions())
+	require.NoError(t, err)
+	err = u.upload(context.Background(), []uploadFile{{path: file, contentType: "application/json"}})
+	assert.ErrorContains(t, err, "error uploading "+file+" to azblob://forbidden/quotes.json")
+	err = u.upload(context.Background(), []uploadFile{{path: filepath.Join(t.TempDir(), "missing.json")}})
+	assert.ErrorContains(t, err, "error opening")
+
+	t.Setenv("AZURE_STORAGE_CONNECTION_STRING", "not a connection string")
+	_, err = openAzureUploader("//quotes", DefaultOptions())
+	assert.ErrorContains(t, err, "error reading AZURE_STORAGE_CONNECTION_STRING")
+
+	t.Setenv("AZURE_STORAGE_CONNECTION_STRING", "")
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "")
+	_, err = openAzureUploader("//quotes", DefaultOptions())
+	assert.ErrorContains(t, err, "azblob needs a storage account")
+}
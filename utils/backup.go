@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// backupTimeLayout names timestamped backups; it sorts chronologically as a string
+const backupTimeLayout = "20060102T150405Z"
+
+// backupFile copies filename aside before it is overwritten, keeping at most keep backups.
+// Numbered backups rotate filename.1 (newest) through filename.<keep>; timestamped backups are
+// named filename.<UTC time> and the oldest are pruned. A missing filename is not an error.
+func backupFile(filename string, keep int, timestamped bool, now time.Time) error {
+	if keep < 1 {
+		return nil
+	}
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil
+	}
+
+	if timestamped {
+		backup := filename + "." + now.UTC().Format(backupTimeLayout)
+		if err := copyFile(filename, backup); err != nil {
+			return err
+		}
+		return pruneTimestampedBackups(filename, keep)
+	}
+
+	// Shift the existing backups up by one, dropping the oldest
+	for i := keep - 1; i >= 1; i-- {
+		from := fmt.Sprintf("%s.%d", filename, i)
+		if err := os.Rename(from, fmt.Sprintf("%s.%d", filename, i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error rotating backup %s: %w", from, err)
+		}
+	}
+	return copyFile(filename, filename+".1")
+}
+
+// pruneTimestampedBackups removes all but the newest keep timestamped backups of filename
+func pruneTimestampedBackups(filename string, keep int) error {
+	matches, err := filepath.Glob(filename + ".*")
+	if err != nil {
+		return err
+	}
+	var backups []string
+	for _, match := range matches {
+		suffix := match[len(filename)+1:]
+		if _, err := time.Parse(backupTimeLayout, suffix); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	sort.Strings(backups)
+	for len(backups) > keep {
+		if err := os.Remove(backups[0]); err != nil {
+			return fmt.Errorf("error pruning backup %s: %w", backups[0], err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// copyFile copies src to dst, replacing dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("error opening %s for backup: %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("error creating backup %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("error writing backup %s: %w", dst, err)
+	}
+	return out.Close()
+}
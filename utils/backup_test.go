@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBackupFileRotation tests numbered backups rotating up to the configured depth
+func TestBackupFileRotation(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "quotes.json")
+	now := time.Now()
+
+	// Nothing to back up yet
+	require.NoError(t, backupFile(file, 2, false, now))
+	assert.NoFileExists(t, file+".1")
+
+	for _, content := range []string{"one", "two", "three"} {
+		require.NoError(t, backupFile(file, 2, false, now))
+		require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	}
+
+	for suffix, expected := range map[string]string{"": "three", ".1": "two", ".2": "one"} {
+		data, err := os.ReadFile(file + suffix)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(data))
+	}
+
+	require.NoError(t, backupFile(file, 2, false, now))
+	data, err := os.ReadFile(file + ".2")
+	require.NoError(t, err)
+	assert.Equal(t, "two", string(data))
+	assert.NoFileExists(t, file+".3")
+}
+
+// TestBackupFileTimestamped tests timestamped backups and pruning of the oldest
+func TestBackupFileTimestamped(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "quotes.json")
+	require.NoError(t, os.WriteFile(file+".checkpoint", []byte("unrelated"), 0644))
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, os.WriteFile(file, []byte{byte('a' + i)}, 0644))
+		require.NoError(t, backupFile(file, 2, true, start.Add(time.Duration(i)*time.Minute)))
+	}
+
+	assert.NoFileExists(t, file+".20240102T030405Z")
+	assert.FileExists(t, file+".20240102T030505Z")
+	data, err := os.ReadFile(file + ".20240102T030605Z")
+	require.NoError(t, err)
+	assert.Equal(t, "c", string(data))
+	assert.FileExists(t, file+".checkpoint")
+}
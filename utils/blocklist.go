@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BlocklistAction selects what happens to quotes containing a blocklisted term
+type BlocklistAction string
+
+const (
+	BlocklistExclude BlocklistAction = "exclude"
+	BlocklistFlag    BlocklistAction = "flag"
+)
+
+// blocklistReviewFile lists the quotes caught by the blocklist when no other name is given
+const blocklistReviewFile = "blocked.json"
+
+// String implements flag.Value
+func (a *BlocklistAction) String() string {
+	return string(*a)
+}
+
+// Set implements flag.Value, accepting only the supported actions
+func (a *BlocklistAction) Set(name string) error {
+	switch action := BlocklistAction(strings.ToLower(name)); action {
+	case BlocklistExclude, BlocklistFlag:
+		*a = action
+		return nil
+	}
+	return fmt.Errorf("unknown blocklist action %q (supported: exclude, flag)", name)
+}
+
+// blockedQuote is an entry of the review file. The quote of a flagged row has the ID it got in
+// the dataset, and an excluded one has none.
+type blockedQuote struct {
+	Row    int             `json:"row"`
+	Action BlocklistAction `json:"action"`
+	Terms  []string        `json:"terms"`
+	Quote  Quote           `json:"quote"`
+}
+
+// MarshalJSON leaves the ID out of the quote of an excluded row, it isn't in the dataset
+func (b blockedQuote) MarshalJSON() ([]byte, error) {
+	type entry blockedQuote
+	if b.Action == BlocklistFlag {
+		return json.Marshal(entry(b))
+	}
+	// The shallower ID field hides the one of the quote
+	return json.Marshal(struct {
+		entry
+		Quote struct {
+			ID *int64 `json:"id,omitempty"`
+			quoteJSON
+		} `json:"quote"`
+	}{entry: entry(b), Quote: struct {
+		ID *int64 `json:"id,omitempty"`
+		quoteJSON
+	}{quoteJSON: quoteJSON(b.Quote)}})
+}
+
+// blocklist catches quotes whose text, context or tags contain a prohibited term, and records
+// them for review. check is called concurrently by the worker pool.
+type blocklist struct {
+	pattern *regexp.Regexp
+	action  BlocklistAction
+	mu      sync.Mutex
+	blocked []blockedQuote
+	// final are the quotes of the flagged entries as written, by the key the entry has
+	final map[string]Quote
+}
+
+// loadBlocklist reads a file holding one term per line, matched like --tag-rules keywords
+func loadBlocklist(fileName string, action BlocklistAction) (*blocklist, error) {
+	terms, err := loadList(fileName, "blocklist")
+	if err != nil {
+		return nil, err
+	}
+	keywords := make([]string, 0, len(terms))
+	for term := range terms {
+		keywords = append(keywords, term)
+	}
+	// Longer terms first so phrases are reported whole rather than as their first word
+	sort.Slice(keywords, func(i, j int) bool {
+		if len(keywords[i]) != len(keywords[j]) {
+			return len(keywords[i]) > len(keywords[j])
+		}
+		return keywords[i] < keywords[j]
+	})
+	pattern, err := keywordPattern(keywords)
+	if err != nil {
+		return nil, fmt.Errorf("blocklist %s: %w", fileName, err)
+	}
+	if action == "" {
+		action = BlocklistExclude
+	}
+	return &blocklist{pattern: pattern, action: action, final: make(map[string]Quote)}, nil
+}
+
+// check records the quote of row i if it contains a blocklisted term, returning its review
+// entry, nil when it has none, and whether it should still be written
+func (b *blocklist) check(i int, quote Quote) (*blockedQuote, bool) {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, field := range append([]string{quote.Text, quote.Context}, quote.Tags...) {
+		for _, match := range b.pattern.FindAllString(field, -1) {
+			if term := aliasKey(match); !seen[term] {
+				seen[term] = true
+				terms = append(terms, term)
+			}
+		}
+	}
+	if len(terms) == 0 {
+		return nil, true
+	}
+
+	entry := blockedQuote{Row: i, Action: b.action, Terms: terms, Quote: quote}
+	b.add(entry)
+	return &entry, b.action == BlocklistFlag
+}
+
+// add records an entry of the review
+func (b *blocklist) add(entry blockedQuote) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.blocked = append(b.blocked, entry)
+	if entry.Action == BlocklistFlag {
+		b.final[entry.Quote.key()] = entry.Quote
+	}
+}
+
+// written records the ID a flagged quote was written with, parsed being the key it had when
+// checked, before --preserve-ids or --id-strategy uuid gave it its final one
+func (b *blocklist) written(parsed string, quote Quote) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.final[parsed]; ok {
+		b.final[parsed] = quote
+	}
+}
+
+// writeReview writes the caught quotes in row order to fileName, compressed and encrypted like
+// the quotes file
+func (b *blocklist) writeReview(fileName string, opts Options) error {
+	sort.Slice(b.blocked, func(i, j int) bool { return b.blocked[i].Row < b.blocked[j].Row })
+	for i, entry := range b.blocked {
+		if final, ok := b.final[entry.Quote.key()]; ok && entry.Action == BlocklistFlag {
+			b.blocked[i].Quote.ID, b.blocked[i].Quote.UUID = final.ID, final.UUID
+		}
+	}
+	data, err := json.MarshalIndent(struct {
+		Quotes []blockedQuote `json:"quotes"`
+	}{Quotes: append([]blockedQuote{}, b.blocked...)}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode blocklist review: %w", err)
+	}
+	if err := writeEnvelopeFile(fileName, append(data, '\n'), opts); err != nil {
+		return fmt.Errorf("failed to write blocklist review %s: %w", fileName, err)
+	}
+	return nil
+}
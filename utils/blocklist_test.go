@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeBlocklist writes a blocklist file and loads it
+func writeBlocklist(t *testing.T, content string, action BlocklistAction) *blocklist {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	b, err := loadBlocklist(file, action)
+	require.NoError(t, err)
+	return b
+}
+
+// TestBlocklistCheck tests that quotes with blocklisted terms are excluded and recorded
+func TestBlocklistCheck(t *testing.T) {
+	b := writeBlocklist(t, "# prohibited\ndamn*\nhell\nshut up\n", "")
+	assert.Equal(t, BlocklistExclude, b.action)
+
+	_, keep := b.check(1, Quote{Text: "Hello there, shell."})
+	assert.True(t, keep)
+	entry, keep := b.check(3, Quote{Text: "Damned, SHUT  UP!", Tags: []string{"rude"}})
+	assert.False(t, keep)
+	assert.Equal(t, []string{"damned", "shut up"}, entry.Terms)
+	_, keep = b.check(2, Quote{Text: "Fine.", Tags: []string{"hell"}})
+	assert.False(t, keep)
+	entry, keep = b.check(4, Quote{Text: "Shut the door."})
+	assert.True(t, keep)
+	assert.Nil(t, entry)
+
+	file := filepath.Join(t.TempDir(), "blocked.json")
+	require.NoError(t, b.writeReview(file, DefaultOptions()))
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"quotes": [
+		{"row": 2, "action": "exclude", "terms": ["hell"], "quote": {"text": "Fine.", "tags": ["hell"], "lang": ""}},
+		{"row": 3, "action": "exclude", "terms": ["damned", "shut up"], "quote": {"text": "Damned, SHUT  UP!", "tags": ["rude"], "lang": ""}}
+	]}`, string(data))
+}
+
+// TestBlocklistFlag tests that flagged quotes are kept but still recorded
+func TestBlocklistFlag(t *testing.T) {
+	b := writeBlocklist(t, "damn\n", BlocklistFlag)
+	_, keep := b.check(1, Quote{Text: "Damn."})
+	assert.True(t, keep)
+	require.Len(t, b.blocked, 1)
+	assert.Equal(t, BlocklistFlag, b.blocked[0].Action)
+}
+
+// TestReadExcelFileBlocklistIDs tests that flagged quotes are reviewed with their final ID and row
+func TestReadExcelFileBlocklistIDs(t *testing.T) {
+	f, _ := createTestExcelFile(t)
+	defer os.Remove("quotesMetadata.json")
+
+	dir := t.TempDir()
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(dir, "quotes.json")
+	opts.IDStrategy = IDUUID
+	opts.Incremental = true
+	opts.Blocklist = filepath.Join(dir, "blocklist.txt")
+	opts.BlocklistAction = BlocklistFlag
+	opts.BlocklistReview = filepath.Join(dir, "blocked.json")
+	require.NoError(t, os.WriteFile(opts.Blocklist, []byte("philosophy\n"), 0644))
+
+	// The second run reuses the rows from the cache
+	for run := 0; run < 2; run++ {
+		require.NoError(t, ReadExcelFileWithOptions(f, opts))
+		data, err := ReadQuotesFromJSON(opts.Output)
+		require.NoError(t, err)
+		require.Len(t, data.Quotes, 3)
+		content, err := os.ReadFile(opts.BlocklistReview)
+		require.NoError(t, err)
+		var review struct {
+			Quotes []blockedQuote `json:"quotes"`
+		}
+		require.NoError(t, json.Unmarshal(content, &review))
+		require.Len(t, review.Quotes, 1)
+		assert.Equal(t, 3, review.Quotes[0].Row)
+		assert.Equal(t, data.Quotes[2].UUID, review.Quotes[0].Quote.UUID)
+		assert.Equal(t, "Test quote 3", review.Quotes[0].Quote.Text)
+	}
+}
+
+// TestBlocklistActionSet tests parsing the --blocklist-action flag
+func TestBlocklistActionSet(t *testing.T) {
+	var a BlocklistAction
+	require.NoError(t, a.Set("FLAG"))
+	assert.Equal(t, BlocklistFlag, a)
+	assert.Error(t, a.Set("delete"))
+
+	_, err := loadBlocklist(filepath.Join(t.TempDir(), "missing.txt"), "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// CBOR major types (RFC 8949 section 3.1)
+const (
+	cborUnsigned = 0 << 5
+	cborNegative = 1 << 5
+	cborText     = 3 << 5
+	cborArray    = 4 << 5
+	cborMap      = 5 << 5
+)
+
+// cborEncoder writes the same {"quotes": [...]} structure as the JSON output in CBOR.
+// Only definite lengths are used because small embedded decoders often lack indefinite-length
+// support, so encoded quotes are buffered until the final count is known.
+type cborEncoder struct {
+	buf bytes.Buffer
+}
+
+func (e *cborEncoder) writeQuote(w *bufio.Writer, quote Quote, index int) error {
+	tree, err := quoteTree(quote)
+	if err != nil {
+		return err
+	}
+	return writeCBOR(&e.buf, tree)
+}
+
+func (e *cborEncoder) finish(w *bufio.Writer, count int) error {
+	var header bytes.Buffer
+	writeCBORHead(&header, cborMap, 1)
+	writeCBORHead(&header, cborText, uint64(len("quotes")))
+	header.WriteString("quotes")
+	writeCBORHead(&header, cborArray, uint64(count))
+	w.Write(header.Bytes())
+	_, err := w.Write(e.buf.Bytes())
+	return err
+}
+
+// writeCBOR encodes a value produced by decodeJSONTree
+func writeCBOR(buf *bytes.Buffer, value any) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteByte(0xf6)
+	case bool:
+		if v {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+	case int64:
+		if v >= 0 {
+			writeCBORHead(buf, cborUnsigned, uint64(v))
+		} else {
+			writeCBORHead(buf, cborNegative, uint64(-1-v))
+		}
+	case float64:
+		buf.WriteByte(0xfb)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(v))
+	case string:
+		writeCBORHead(buf, cborText, uint64(len(v)))
+		buf.WriteString(v)
+	case []any:
+		writeCBORHead(buf, cborArray, uint64(len(v)))
+		for _, item := range v {
+			if err := writeCBOR(buf, item); err != nil {
+				return err
+			}
+		}
+	case jsonObject:
+		writeCBORHead(buf, cborMap, uint64(len(v)))
+		for _, member := range v {
+			writeCBORHead(buf, cborText, uint64(len(member.Key)))
+			buf.WriteString(member.Key)
+			if err := writeCBOR(buf, member.Value); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cannot encode %T as cbor", value)
+	}
+	return nil
+}
+
+// writeCBORHead writes a data item head with the shortest argument encoding
+func writeCBORHead(buf *bytes.Buffer, major byte, n uint64) {
+	switch {
+	case n < 24:
+		buf.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		buf.Write([]byte{major | 24, byte(n)})
+	case n <= math.MaxUint16:
+		buf.WriteByte(major | 25)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	case n <= math.MaxUint32:
+		buf.WriteByte(major | 26)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	default:
+		buf.WriteByte(major | 27)
+		binary.Write(buf, binary.BigEndian, n)
+	}
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readCBOR decodes the subset of CBOR produced by writeCBOR
+func readCBOR(r *bytes.Reader) (any, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch b {
+	case 0xf4:
+		return false, nil
+	case 0xf5:
+		return true, nil
+	case 0xf6:
+		return nil, nil
+	case 0xfb:
+		var bits uint64
+		err := binary.Read(r, binary.BigEndian, &bits)
+		return math.Float64frombits(bits), err
+	}
+
+	major, info := b&0xe0, b&0x1f
+	n := uint64(info)
+	if info >= 24 {
+		raw := make([]byte, 8)
+		size := 1 << (info - 24)
+		r.Read(raw[8-size:])
+		n = binary.BigEndian.Uint64(raw)
+	}
+
+	switch major {
+	case cborUnsigned:
+		return int64(n), nil
+	case cborNegative:
+		return -1 - int64(n), nil
+	case cborText:
+		s := make([]byte, n)
+		r.Read(s)
+		return string(s), nil
+	case cborArray:
+		array := []any{}
+		for i := uint64(0); i < n; i++ {
+			v, err := readCBOR(r)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, v)
+		}
+		return array, nil
+	case cborMap:
+		object := jsonObject{}
+		for i := uint64(0); i < n; i++ {
+			k, err := readCBOR(r)
+			if err != nil {
+				return nil, err
+			}
+			v, err := readCBOR(r)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, jsonMember{Key: k.(string), Value: v})
+		}
+		return object, nil
+	}
+	return nil, fmt.Errorf("unexpected cbor byte 0x%x", b)
+}
+
+// TestCBOREncoderRoundTrip tests that the CBOR output decodes to exactly the JSON structure
+func TestCBOREncoderRoundTrip(t *testing.T) {
+	data := QuotesData{Quotes: []Quote{
+		{ID: 1, Text: "First", Tags: []string{"a", "b"}, Language: "en-US"},
+		{ID: 70000, Text: "Second", Author: "Someone", Year: -350, Tags: []string{""}, Language: "ta-IN"},
+	}}
+
+	var buf bytes.Buffer
+	s := NewQuoteStreamWriter(&buf, StreamCBOR)
+	for _, q := range data.Quotes {
+		require.NoError(t, s.WriteQuote(q))
+	}
+	require.NoError(t, s.Close())
+
+	decoded, err := readCBOR(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	jsonData, err := json.Marshal(data)
+	require.NoError(t, err)
+	expected, err := decodeJSONTree(jsonData)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, decoded)
+}
+
+// TestWriteCBORHead tests head encodings against RFC 8949 appendix A examples
+func TestWriteCBORHead(t *testing.T) {
+	for _, tc := range []struct {
+		value any
+		want  string
+	}{
+		{int64(0), "00"},
+		{int64(23), "17"},
+		{int64(24), "1818"},
+		{int64(1000), "1903e8"},
+		{int64(1000000), "1a000f4240"},
+		{int64(1000000000000), "1b000000e8d4a51000"},
+		{int64(-1), "20"},
+		{int64(-1000), "3903e7"},
+		{"IETF", "6449455446"},
+	} {
+		var buf bytes.Buffer
+		require.NoError(t, writeCBOR(&buf, tc.value))
+		assert.Equal(t, tc.want, hex.EncodeToString(buf.Bytes()), "%v", tc.value)
+	}
+}
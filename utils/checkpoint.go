@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// checkpoint records how far a conversion got, so an interrupted run can pick up where it stopped
+type checkpoint struct {
+	Source string      `json:"source"` // sheet the rows were read from
+	Format string      `json:"format"` // output format the partial file is written in
+	Row    int         `json:"row"`    // index of the last row whose quotes are in the output
+	Offset int64       `json:"offset"` // size of the output file once that row was flushed
+	Count  int         `json:"count"`  // number of quotes in the output up to Offset
+	Stats  quoteTotals `json:"stats"`  // lengths of those quotes, for the metadata
+}
+
+// checkpointFile returns the checkpoint path belonging to an output file
+func checkpointFile(output string) string {
+	return output + ".checkpoint"
+}
+
+// saveCheckpoint atomically replaces the checkpoint file
+func saveCheckpoint(filename string, cp checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("error marshalling checkpoint: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a half-written checkpoint
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, filename); err != nil {
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+	return nil
+}
+
+// loadCheckpoint reads a checkpoint file, returning nil if there is none
+func loadCheckpoint(filename string) (*checkpoint, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading checkpoint: %w", err)
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("error parsing checkpoint %s: %w", filename, err)
+	}
+	return &cp, nil
+}
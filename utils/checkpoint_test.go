@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResumeFromCheckpoint tests that a resumed conversion produces the same output as an uninterrupted one
+func TestResumeFromCheckpoint(t *testing.T) {
+	f, _ := createTestExcelFile(t)
+	defer os.Remove("quotes.json")
+	defer os.Remove("quotesMetadata.json")
+
+	opts := DefaultOptions()
+	opts.BatchSize = 1
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	expected, err := os.ReadFile("quotes.json")
+	require.NoError(t, err)
+	expectedStats := readMetadataStats(t)
+	assert.NoFileExists(t, checkpointFile("quotes.json"))
+
+	// Simulate a run that flushed the first row, checkpointed, then died part way through the next batch
+	stream, err := CreateQuoteStreamFile("quotes.json", StreamArray)
+	require.NoError(t, err)
+	first := Quote{ID: 1, Text: "Test quote 1", Tags: []string{"inspiration", "motivation"}, Language: "en-US"}
+	countWords(&first)
+	var totals quoteTotals
+	totals.add(first)
+	require.NoError(t, stream.WriteQuote(first))
+	require.NoError(t, stream.Flush())
+	require.NoError(t, saveCheckpoint(checkpointFile("quotes.json"), checkpoint{
+		Source: "Sheet1",
+		Format: "json",
+		Row:    1,
+		Offset: stream.Offset(),
+		Count:  stream.Count(),
+		Stats:  totals,
+	}))
+	_, err = stream.w.WriteString(",\n    {\"id\": 2, \"te")
+	require.NoError(t, err)
+	require.NoError(t, stream.Flush())
+
+	opts.Resume = true
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	actual, err := os.ReadFile("quotes.json")
+	require.NoError(t, err)
+
+	assert.Equal(t, string(expected), string(actual))
+	assert.Equal(t, expectedStats, readMetadataStats(t))
+	assert.NoFileExists(t, checkpointFile("quotes.json"))
+}
+
+// readMetadataStats returns the stats of quotesMetadata.json
+func readMetadataStats(t *testing.T) *QuoteStats {
+	t.Helper()
+	data, err := os.ReadFile("quotesMetadata.json")
+	require.NoError(t, err)
+	var metadata Metadata
+	require.NoError(t, json.Unmarshal(data, &metadata))
+	require.NotNil(t, metadata.Stats)
+	return metadata.Stats
+}
+
+// TestResumeFormatMismatch tests that a checkpoint cannot be resumed in a different output format
+func TestResumeFormatMismatch(t *testing.T) {
+	f, _ := createTestExcelFile(t)
+	defer os.Remove(checkpointFile("quotes.ndjson"))
+
+	require.NoError(t, saveCheckpoint(checkpointFile("quotes.ndjson"), checkpoint{Source: "Sheet1", Format: "json"}))
+
+	opts := DefaultOptions()
+	opts.Format = StreamNDJSON
+	opts.Resume = true
+	assert.Error(t, ReadExcelFileWithOptions(f, opts))
+}
+
+// TestLoadCheckpointMissing tests that a missing checkpoint is not an error
+func TestLoadCheckpointMissing(t *testing.T) {
+	cp, err := loadCheckpoint("does-not-exist.checkpoint")
+	assert.NoError(t, err)
+	assert.Nil(t, cp)
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// checksumFile is the manifest written by --checksums, in the format read by sha256sum -c
+const checksumFile = "checksums.txt"
+
+// fileSHA256 returns the hex encoded SHA-256 digest of a file's contents
+func fileSHA256(filename string) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", fmt.Errorf("error opening %s for checksum: %w", filename, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("error reading %s for checksum: %w", filename, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// writeChecksums writes a sha256sum style manifest of files to manifest
+func writeChecksums(manifest string, files []string) error {
+	var b strings.Builder
+	for _, file := range files {
+		sum, err := fileSHA256(file)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "%s  %s\n", sum, file)
+	}
+	if err := os.WriteFile(manifest, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", manifest, err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWriteChecksums tests the manifest against known SHA-256 digests
+func TestWriteChecksums(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.json")
+	abc := filepath.Join(dir, "abc.json")
+	require.NoError(t, os.WriteFile(empty, nil, 0644))
+	require.NoError(t, os.WriteFile(abc, []byte("abc"), 0644))
+
+	manifest := filepath.Join(dir, "checksums.txt")
+	require.NoError(t, writeChecksums(manifest, []string{empty, abc}))
+
+	data, err := os.ReadFile(manifest)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  "+empty+"\n"+
+			"ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad  "+abc+"\n",
+		string(data))
+
+	assert.Error(t, writeChecksums(manifest, []string{filepath.Join(dir, "missing.json")}))
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// chunkManifestFile lists the files written by --chunk-size
+const chunkManifestFile = "manifest.json"
+
+// chunkManifest is the structure of chunkManifestFile
+type chunkManifest struct {
+	ChunkSize   int          `json:"chunkSize"`
+	TotalQuotes int          `json:"totalQuotes"`
+	Chunks      []chunkEntry `json:"chunks"`
+}
+
+type chunkEntry struct {
+	File   string `json:"file"`
+	Count  int    `json:"count"`
+	SHA256 string `json:"sha256"`
+}
+
+// chunkSink pages the quotes into numbered QuotesData files of at most size quotes each
+type chunkSink struct {
+	opts     Options
+	dir      string
+	manifest chunkManifest
+	current  *QuoteStreamWriter
+}
+
+// openChunkSink prepares the directory for opts.ChunkSize chunks, defaulting to chunks
+func openChunkSink(opts Options) (QuoteSink, error) {
+	dir := opts.SplitDir
+	if dir == "" {
+		dir = "chunks"
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create chunk directory %s: %w", dir, err)
+	}
+
+	// Like the split files, chunks are JSON regardless of the main output format, compressed
+	// and encrypted like it
+	streamOpts := DefaultOptions()
+	streamOpts.Indent = opts.Indent
+	streamOpts.Compact = opts.Compact
+	streamOpts.Compress = opts.Compress
+	streamOpts.Encrypt = opts.Encrypt
+	return &chunkSink{opts: streamOpts, dir: dir, manifest: chunkManifest{ChunkSize: opts.ChunkSize}}, nil
+}
+
+func (s *chunkSink) WriteQuotes(quotes []Quote) error {
+	for _, quote := range quotes {
+		if s.current == nil {
+			file := fmt.Sprintf("quotes-%04d.json", len(s.manifest.Chunks)+1) + envelopeExtension(s.opts)
+			writer, err := CreateQuoteStreamFileWithOptions(filepath.Join(s.dir, file), s.opts)
+			if err != nil {
+				return err
+			}
+			s.current = writer
+			s.manifest.Chunks = append(s.manifest.Chunks, chunkEntry{File: file})
+		}
+		if err := s.current.WriteQuote(quote); err != nil {
+			return err
+		}
+		if s.current.Count() >= s.manifest.ChunkSize {
+			if err := s.closeChunk(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// closeChunk finishes the current chunk and records its count and checksum
+func (s *chunkSink) closeChunk() error {
+	writer := s.current
+	s.current = nil
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	entry := &s.manifest.Chunks[len(s.manifest.Chunks)-1]
+	sum, err := fileSHA256(filepath.Join(s.dir, entry.File))
+	if err != nil {
+		return err
+	}
+	entry.Count = writer.Count()
+	entry.SHA256 = sum
+	s.manifest.TotalQuotes += entry.Count
+	return nil
+}
+
+func (s *chunkSink) Commit() error {
+	if s.current != nil {
+		if err := s.closeChunk(); err != nil {
+			return err
+		}
+	}
+	if s.manifest.Chunks == nil {
+		s.manifest.Chunks = []chunkEntry{}
+	}
+
+	data, err := json.MarshalIndent(s.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling chunk manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, chunkManifestFile), data, 0644); err != nil {
+		return fmt.Errorf("error writing chunk manifest: %w", err)
+	}
+	return nil
+}
+
+func (s *chunkSink) Rollback() error {
+	if s.current != nil {
+		s.current.Close()
+	}
+	for _, chunk := range s.manifest.Chunks {
+		os.Remove(filepath.Join(s.dir, chunk.File))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChunkSink tests that quotes are paged into numbered files listed in the manifest
+func TestChunkSink(t *testing.T) {
+	dir := t.TempDir()
+	opts := DefaultOptions()
+	opts.ChunkSize = 2
+	opts.SplitDir = dir
+
+	sink, err := openChunkSink(opts)
+	require.NoError(t, err)
+	require.NoError(t, sink.WriteQuotes([]Quote{{ID: 1, Text: "One"}, {ID: 2, Text: "Two"}, {ID: 3, Text: "Three"}}))
+	require.NoError(t, sink.WriteQuotes([]Quote{{ID: 4, Text: "Four"}, {ID: 5, Text: "Five"}}))
+	require.NoError(t, sink.Commit())
+
+	data, err := os.ReadFile(filepath.Join(dir, chunkManifestFile))
+	require.NoError(t, err)
+	var manifest chunkManifest
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	assert.Equal(t, 2, manifest.ChunkSize)
+	assert.Equal(t, 5, manifest.TotalQuotes)
+	require.Len(t, manifest.Chunks, 3)
+
+	for i, expected := range []int{2, 2, 1} {
+		chunk := manifest.Chunks[i]
+		assert.Equal(t, expected, chunk.Count)
+		sum, err := fileSHA256(filepath.Join(dir, chunk.File))
+		require.NoError(t, err)
+		assert.Equal(t, sum, chunk.SHA256)
+	}
+	assert.Equal(t, "quotes-0003.json", manifest.Chunks[2].File)
+	last := readSplitFile(t, filepath.Join(dir, "quotes-0003.json"))
+	assert.Equal(t, int64(5), last.Quotes[0].ID)
+}
+
+// TestChunkSinkEncrypted tests that the chunks are compressed and encrypted like the quotes file
+func TestChunkSinkEncrypted(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	dir := t.TempDir()
+	opts := DefaultOptions()
+	opts.ChunkSize = 10
+	opts.SplitDir = dir
+	opts.Compress = CompressZstd
+	opts.Encrypt = RecipientList{identity.Recipient()}
+
+	sink, err := openChunkSink(opts)
+	require.NoError(t, err)
+	require.NoError(t, sink.WriteQuotes([]Quote{{ID: 1, Text: "Secret"}}))
+	require.NoError(t, sink.Commit())
+
+	var quotes QuotesData
+	require.NoError(t, json.Unmarshal(decryptFile(t, identity, CompressZstd, filepath.Join(dir, "quotes-0001.json.zst.age")), &quotes))
+	require.Len(t, quotes.Quotes, 1)
+	assert.Equal(t, "Secret", quotes.Quotes[0].Text)
+}
+
+// TestChunkSinkRollback tests that a failed conversion leaves no chunks behind
+func TestChunkSinkRollback(t *testing.T) {
+	dir := t.TempDir()
+	opts := DefaultOptions()
+	opts.ChunkSize = 1
+	opts.SplitDir = dir
+
+	sink, err := openChunkSink(opts)
+	require.NoError(t, err)
+	require.NoError(t, sink.WriteQuotes([]Quote{{ID: 1, Text: "One"}, {ID: 2, Text: "Two"}}))
+	require.NoError(t, sink.Rollback())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
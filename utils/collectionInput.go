@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// collectionFields lists the keys of a quote in a YAML or TOML collection, by its column in
+// quoteColumns; they are the keys of the JSON output
+var collectionFields = []string{"tags", "text", "author", "year", "context", "lang"}
+
+// readCollection loads a YAML or TOML quote collection, either a list of quotes under a quotes
+// key or, in YAML, a top-level list
+func readCollection(fileName string, format string) ([]map[string]any, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s file %s: %w", format, fileName, err)
+	}
+	var collection struct {
+		Quotes []map[string]any `yaml:"quotes" toml:"quotes"`
+	}
+	if format == "TOML" {
+		err = toml.Unmarshal(data, &collection)
+	} else if err = yaml.Unmarshal(data, &collection.Quotes); err != nil {
+		// Not a top-level list, so the list is under the quotes key
+		err = yaml.Unmarshal(data, &collection)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s file %s: %w", format, fileName, err)
+	}
+	return collection.Quotes, nil
+}
+
+// collectionRows lays out the quotes of a collection as rows under quoteColumns, header first
+func collectionRows(entries []map[string]any) (*sliceRows, error) {
+	rows := [][]string{quoteColumns}
+	for i, entry := range entries {
+		row := make([]string, len(collectionFields))
+		for key, value := range entry {
+			column := -1
+			for j, field := range collectionFields {
+				if strings.EqualFold(key, field) {
+					column = j
+				}
+			}
+			if column < 0 {
+				return nil, fmt.Errorf("quote %d has unknown field %q (supported: %s)", i+1, key, strings.Join(sortedCopy(collectionFields), ", "))
+			}
+			cell, err := collectionCell(value)
+			if err != nil {
+				return nil, fmt.Errorf("quote %d field %q: %w", i+1, key, err)
+			}
+			row[column] = cell
+		}
+		rows = append(rows, row)
+	}
+	return &sliceRows{rows: rows}, nil
+}
+
+// collectionCell turns the value of a field into the text of a cell. Scalars are written as
+// they are, so years can be numbers or text such as "c. 1950", and lists of tags are joined.
+func collectionCell(value any) (string, error) {
+	switch value := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case []any:
+		tags := make([]string, len(value))
+		for i, tag := range value {
+			text, ok := tag.(string)
+			if !ok {
+				return "", fmt.Errorf("expected a list of strings, found %v", tag)
+			}
+			tags[i] = text
+		}
+		return strings.Join(tags, ", "), nil
+	case map[string]any:
+		return "", fmt.Errorf("expected a value, found a table")
+	}
+	return fmt.Sprint(value), nil
+}
+
+// sortedCopy returns the strings sorted, leaving the original alone
+func sortedCopy(values []string) []string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// readCollectionRows returns the reader of YAML or TOML quote collections
+func readCollectionRows(format string) func(fileName string, opts Options) (*sliceRows, error) {
+	return func(fileName string, opts Options) (*sliceRows, error) {
+		entries, err := readCollection(fileName, format)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := collectionRows(entries)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s file %s: %w", format, fileName, err)
+		}
+		return rows, nil
+	}
+}
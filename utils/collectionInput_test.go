@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReadCollection tests the YAML and TOML layouts of a quote collection
+func TestReadCollection(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"list.yaml":   "- text: Be.\n  tags: [a, b]\n  year: 1950\n",
+		"quotes.yaml": "quotes:\n  - text: Be.\n    tags: [a, b]\n    year: 1950\n",
+		"quotes.toml": "[[quotes]]\ntext = \"Be.\"\ntags = [\"a\", \"b\"]\nyear = 1950\n",
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			fileName := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(fileName, []byte(content), 0644))
+			format := "YAML"
+			if filepath.Ext(name) == ".toml" {
+				format = "TOML"
+			}
+			entries, err := readCollection(fileName, format)
+			require.NoError(t, err)
+			rows, err := collectionRows(entries)
+			require.NoError(t, err)
+			assert.Equal(t, [][]string{quoteColumns, {"a, b", "Be.", "", "1950", "", ""}}, rows.rows)
+		})
+	}
+
+	fileName := filepath.Join(dir, "bad.toml")
+	require.NoError(t, os.WriteFile(fileName, []byte("[[quotes]\n"), 0644))
+	_, err := readCollection(fileName, "TOML")
+	assert.Error(t, err)
+}
+
+// TestCollectionRows tests the fields accepted for each quote
+func TestCollectionRows(t *testing.T) {
+	rows, err := collectionRows([]map[string]any{
+		{"Text": "Know thyself.", "author": "Socrates", "year": "c. 400 BCE", "context": "Delphi", "lang": "en", "tags": "wisdom"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"wisdom", "Know thyself.", "Socrates", "c. 400 BCE", "Delphi", "en"}, rows.rows[1])
+
+	_, err = collectionRows([]map[string]any{{"text": "a", "autor": "b"}})
+	assert.ErrorContains(t, err, `quote 1 has unknown field "autor"`)
+	_, err = collectionRows([]map[string]any{{"text": "a", "tags": []any{1}}})
+	assert.Error(t, err)
+	_, err = collectionRows([]map[string]any{{"text": map[string]any{"a": "b"}}})
+	assert.Error(t, err)
+}
+
+// TestConvertCollectionFile tests converting a YAML collection through the pipeline
+func TestConvertCollectionFile(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	input := filepath.Join(t.TempDir(), "quotes.yml")
+	require.NoError(t, os.WriteFile(input, []byte(`quotes:
+  - text: "Know thyself. — Socrates"
+    tags: [wisdom, self]
+    year: -400
+  - text: Carpe diem.
+    lang: la
+`), 0644))
+
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(t.TempDir(), "out.json")
+	require.NoError(t, ConvertFile(input, opts))
+	data, err := ReadQuotesFromJSON(opts.Output)
+	require.NoError(t, err)
+	require.Len(t, data.Quotes, 2)
+	assert.Equal(t, Quote{ID: 1, Text: "Know thyself.", Author: "Socrates", Year: -400, Tags: []string{"wisdom", "self"}, Language: "en-US", WordCount: 2, ReadingTimeSeconds: 1}, data.Quotes[0])
+	assert.Equal(t, int64(2), data.Quotes[1].ID)
+	assert.Equal(t, "la", data.Quotes[1].Language)
+
+	opts.Columns = ColumnList{"text=B"}
+	assert.Error(t, ConvertFile(input, opts))
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// columnFields lists the quote fields that can be read from a column, with the header names
+// recognised for each
+var columnFields = map[string][]string{
+	"tags":    {"tags", "tag", "category", "categories"},
+	"text":    {"text", "quote", "quotes"},
+	"lang":    {"lang", "language", "locale"},
+	"author":  {"author", "by", "attribution"},
+	"year":    {"year", "date"},
+	"context": {"context", "source", "work", "book"},
+	"id":      {"id"},
+}
+
+// columnMap maps a quote field to the index of the column holding it
+type columnMap map[string]int
+
+// defaultColumns is the original layout: tags in column A and the quote text in column B
+var defaultColumns = columnMap{"tags": 0, "text": 1}
+
+// ColumnList collects repeated --column flags of the form field=column, where column is a
+// column letter or a header name
+type ColumnList []string
+
+// String implements flag.Value
+func (c *ColumnList) String() string {
+	return strings.Join(*c, ",")
+}
+
+// Set implements flag.Value, checking the field name
+func (c *ColumnList) Set(value string) error {
+	field, column, ok := strings.Cut(value, "=")
+	if !ok || column == "" {
+		return fmt.Errorf("invalid column mapping %q, expected field=column", value)
+	}
+	if _, ok := columnFields[strings.ToLower(field)]; !ok {
+		return fmt.Errorf("unknown column field %q (supported: %s)", field, strings.Join(columnFieldNames(), ", "))
+	}
+	*c = append(*c, value)
+	return nil
+}
+
+// columnFieldNames returns the names of all fields that can be mapped to a column
+func columnFieldNames() []string {
+	names := make([]string, 0, len(columnFields))
+	for name := range columnFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newColumnMap works out where each field is from the header row. Fields are found by their
+// header names, then overrides are applied; tags and text fall back to columns A and B so
+// sheets without a recognisable header keep the original layout.
+func newColumnMap(header []string, overrides ColumnList) (columnMap, error) {
+	m := columnMap{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for field, aliases := range columnFields {
+			if _, ok := m[field]; ok {
+				continue
+			}
+			for _, alias := range aliases {
+				if name == alias {
+					m[field] = i
+				}
+			}
+		}
+	}
+
+	for _, override := range overrides {
+		field, column, _ := strings.Cut(override, "=")
+		index, err := columnIndex(header, column)
+		if err != nil {
+			return nil, err
+		}
+		m[strings.ToLower(field)] = index
+	}
+
+	for field, index := range defaultColumns {
+		if _, ok := m[field]; !ok && !m.uses(index) {
+			m[field] = index
+		}
+	}
+	if _, ok := m["text"]; !ok {
+		return nil, fmt.Errorf("no column holds the quote text, map one with --column text=<column>")
+	}
+	return m, nil
+}
+
+// columnIndex resolves a header name or column letter to a column index
+func columnIndex(header []string, column string) (int, error) {
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			return i, nil
+		}
+	}
+	number, err := excelize.ColumnNameToNumber(column)
+	if err != nil {
+		return 0, fmt.Errorf("unknown column %q, expected a header name or column letter", column)
+	}
+	return number - 1, nil
+}
+
+// uses reports whether any field is mapped to the column
+func (m columnMap) uses(index int) bool {
+	for _, i := range m {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
+
+// cell returns the value of a field in row, or "" if the field is unmapped or the cell is empty
+func (m columnMap) cell(row []string, field string) string {
+	index, ok := m[field]
+	if !ok || index >= len(row) {
+		return ""
+	}
+	return row[index]
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewColumnMap tests header detection, overrides and the positional fallback
+func TestNewColumnMap(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    []string
+		overrides ColumnList
+		expected  columnMap
+		wantErr   bool
+	}{
+		{name: "original_sheet", header: []string{"", "QUOTES"}, expected: columnMap{"tags": 0, "text": 1}},
+		{name: "no_header", header: nil, expected: columnMap{"tags": 0, "text": 1}},
+		{name: "reordered", header: []string{"Language", "Quote", "Tags"}, expected: columnMap{"lang": 0, "text": 1, "tags": 2}},
+		{name: "text_in_a", header: []string{"Text", "Notes"}, expected: columnMap{"text": 0}},
+		{name: "override_letter", header: []string{"Tags", "Quote", "Locale code"}, overrides: ColumnList{"lang=C"}, expected: columnMap{"tags": 0, "text": 1, "lang": 2}},
+		{name: "override_header", header: []string{"Tags", "Quote", "Locale code"}, overrides: ColumnList{"LANG=locale code"}, expected: columnMap{"tags": 0, "text": 1, "lang": 2}},
+		{name: "bad_column", header: []string{"Tags", "Quote"}, overrides: ColumnList{"lang=not a column"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := newColumnMap(tt.header, tt.overrides)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, m)
+		})
+	}
+}
+
+// TestColumnListSet tests validation of --column values
+func TestColumnListSet(t *testing.T) {
+	var columns ColumnList
+	require.NoError(t, columns.Set("lang=C"))
+	assert.Error(t, columns.Set("lang"))
+	assert.Error(t, columns.Set("colour=D"))
+	assert.Equal(t, ColumnList{"lang=C"}, columns)
+}
+
+// TestRowProcessorLanguageColumn tests per-row languages overriding the default
+func TestRowProcessorLanguageColumn(t *testing.T) {
+	opts := DefaultOptions()
+	processor := newRowProcessor(opts)
+	processor.columns = columnMap{"tags": 0, "text": 1, "lang": 2}
+
+	quote, ok := processor.parse(1, []string{"a", "Vanakkam", "ta-in"})
+	require.True(t, ok)
+	assert.Equal(t, "ta-IN", quote.Language)
+
+	quote, ok = processor.parse(2, []string{"a", "Hello"})
+	require.True(t, ok)
+	assert.Equal(t, "en-US", quote.Language)
+
+	_, ok = processor.parse(3, []string{"a", "Junk", "klingon!"})
+	assert.False(t, ok)
+	assert.Equal(t, int64(1), processor.invalid.Load())
+}
+
+// TestRowProcessorYearColumn tests years read from a column and unparseable values being reported
+func TestRowProcessorYearColumn(t *testing.T) {
+	processor := newRowProcessor(DefaultOptions())
+	processor.columns = columnMap{"tags": 0, "text": 1, "year": 2}
+
+	quote, ok := processor.parse(1, []string{"a", "Know thyself", "c. 400 BCE"})
+	require.True(t, ok)
+	assert.Equal(t, -400, quote.Year)
+
+	_, ok = processor.parse(2, []string{"a", "Sometime", "long ago"})
+	assert.False(t, ok)
+	assert.Equal(t, int64(1), processor.invalid.Load())
+}
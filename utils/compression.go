@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression selects how the output files are compressed by --compress
+type Compression string
+
+const (
+	CompressNone Compression = ""
+	CompressGzip Compression = "gzip"
+	CompressZstd Compression = "zstd"
+)
+
+// String implements flag.Value
+func (c *Compression) String() string {
+	return string(*c)
+}
+
+// Set implements flag.Value, accepting only the supported algorithms
+func (c *Compression) Set(name string) error {
+	switch compression := Compression(strings.ToLower(name)); compression {
+	case "none":
+		*c = CompressNone
+		return nil
+	case CompressNone, CompressGzip, CompressZstd:
+		*c = compression
+		return nil
+	}
+	return fmt.Errorf("unknown compression %q (supported: gzip, zstd, none)", name)
+}
+
+// Extension returns the suffix appended to compressed file names
+func (c Compression) Extension() string {
+	switch c {
+	case CompressGzip:
+		return ".gz"
+	case CompressZstd:
+		return ".zst"
+	}
+	return ""
+}
+
+// encoding returns the value recorded in Metadata.Schema.Encoding
+func (c Compression) encoding() string {
+	if c == CompressNone {
+		return "UTF-8"
+	}
+	return "UTF-8, " + string(c)
+}
+
+// newWriter wraps w so everything written to it is compressed; closing the returned writer
+// terminates the compressed stream but leaves w open
+func (c Compression) newWriter(w io.Writer) io.WriteCloser {
+	switch c {
+	case CompressGzip:
+		return gzip.NewWriter(w)
+	case CompressZstd:
+		zw, _ := zstd.NewWriter(w) // only fails for invalid encoder options
+		return zw
+	}
+	return nopWriteCloser{w}
+}
+
+// writeFile is os.WriteFile compressing data on the way
+func (c Compression) writeFile(filename string, data []byte, perm os.FileMode) error {
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	zw := c.newWriter(file)
+	_, err = zw.Write(data)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// readFile is os.ReadFile decompressing data on the way
+func (c Compression) readFile(filename string) ([]byte, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	switch c {
+	case CompressGzip:
+		gr, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		r = gr
+	case CompressZstd:
+		zr, err := zstd.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	}
+	return io.ReadAll(r)
+}
+
+// nopWriteCloser is a writer whose Close does nothing
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decompress reads back data written with the given compression
+func decompress(t *testing.T, compression Compression, data []byte) []byte {
+	t.Helper()
+	var r io.Reader
+	switch compression {
+	case CompressGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		r = zr
+	case CompressZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		defer zr.Close()
+		r = zr
+	default:
+		return data
+	}
+	plain, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return plain
+}
+
+// TestCompressionSet tests parsing of --compress values
+func TestCompressionSet(t *testing.T) {
+	var c Compression
+	require.NoError(t, c.Set("GZIP"))
+	assert.Equal(t, CompressGzip, c)
+	require.NoError(t, c.Set("zstd"))
+	assert.Equal(t, ".zst", c.Extension())
+	require.NoError(t, c.Set("none"))
+	assert.Equal(t, CompressNone, c)
+	assert.Equal(t, "", c.Extension())
+	assert.Error(t, c.Set("brotli"))
+}
+
+// TestCompressedStream tests that compressed streams decompress to the plain output
+func TestCompressedStream(t *testing.T) {
+	quotes := []Quote{
+		{ID: 1, Text: "First", Tags: []string{"a"}, Language: "en-US"},
+		{ID: 2, Text: "Second", Tags: []string{""}, Language: "en-US"},
+	}
+	expected, err := json.MarshalIndent(QuotesData{Quotes: quotes}, "", "  ")
+	require.NoError(t, err)
+
+	for _, compression := range []Compression{CompressGzip, CompressZstd} {
+		t.Run(string(compression), func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Compress = compression
+			var buf bytes.Buffer
+			s := NewQuoteStreamWriterWithOptions(&buf, opts)
+			for _, q := range quotes {
+				require.NoError(t, s.WriteQuote(q))
+			}
+			require.NoError(t, s.Close())
+
+			assert.NotEqual(t, expected, buf.Bytes())
+			assert.Equal(t, string(expected), string(decompress(t, compression, buf.Bytes())))
+
+			file := filepath.Join(t.TempDir(), "metadata.json"+compression.Extension())
+			require.NoError(t, compression.writeFile(file, expected, 0644))
+			data, err := os.ReadFile(file)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(decompress(t, compression, data)))
+		})
+	}
+}
+
+// TestCompressedConversion tests the file names and metadata of a compressed conversion
+func TestCompressedConversion(t *testing.T) {
+	f, _ := createTestExcelFile(t)
+	defer os.Remove("quotes.json.gz")
+	defer os.Remove("quotesMetadata.json.gz")
+
+	opts := DefaultOptions()
+	opts.Compress = CompressGzip
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+
+	data, err := os.ReadFile("quotes.json.gz")
+	require.NoError(t, err)
+	var quotesData QuotesData
+	require.NoError(t, json.Unmarshal(decompress(t, CompressGzip, data), &quotesData))
+	assert.Len(t, quotesData.Quotes, 3)
+
+	data, err = os.ReadFile("quotesMetadata.json.gz")
+	require.NoError(t, err)
+	var metadata Metadata
+	require.NoError(t, json.Unmarshal(decompress(t, CompressGzip, data), &metadata))
+	assert.Equal(t, "UTF-8, gzip", metadata.Schema.Encoding)
+
+	opts.Resume = true
+	assert.Error(t, ReadExcelFileWithOptions(f, opts))
+}
+
+// TestCompressionReadFile tests that readFile reverses writeFile
+func TestCompressionReadFile(t *testing.T) {
+	for _, compression := range []Compression{CompressNone, CompressGzip, CompressZstd} {
+		t.Run(compression.String(), func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "data")
+			require.NoError(t, compression.writeFile(name, []byte("quotes"), 0644))
+			data, err := compression.readFile(name)
+			require.NoError(t, err)
+			assert.Equal(t, "quotes", string(data))
+		})
+	}
+}
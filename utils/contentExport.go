@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// contentFrontMatter is the YAML front matter of an exported quote page
+type contentFrontMatter struct {
+	ID      any      `yaml:"id"` // the UUID of the quote when it has one
+	Tags    []string `yaml:"tags"`
+	Author  string   `yaml:"author,omitempty"`
+	Year    int      `yaml:"year,omitempty"`
+	Context string   `yaml:"context,omitempty"`
+	Lang    string   `yaml:"lang"`
+}
+
+// ExportContentFiles writes one Markdown file per quote into outDir, with the quote's
+// fields as YAML front matter, so the dataset can be used as Hugo or Jekyll content
+func ExportContentFiles(data QuotesData, outDir string) error {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("error creating directory %s: %w", outDir, err)
+	}
+
+	for _, quote := range data.Quotes {
+		tags := nonEmptyTags(quote.Tags)
+		if tags == nil {
+			tags = []string{}
+		}
+		frontMatter := contentFrontMatter{
+			ID:      quote.ref(),
+			Tags:    tags,
+			Author:  quote.Author,
+			Year:    quote.Year,
+			Context: quote.Context,
+			Lang:    quote.Language,
+		}
+
+		filename := filepath.Join(outDir, "quote-"+quote.key()+".md")
+		if err := writeFrontMatterFile(filename, frontMatter, strings.TrimSpace(quote.Text)+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFrontMatterFile writes a Markdown file consisting of YAML front matter and a body
+func writeFrontMatterFile(filename string, frontMatter any, body string) error {
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(frontMatter); err != nil {
+		return fmt.Errorf("error encoding front matter for %s: %w", filename, err)
+	}
+	enc.Close()
+	buf.WriteString("---\n\n")
+	buf.WriteString(body)
+
+	if err := os.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", filename, err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// TestExportContentFiles tests the front matter and body of the exported files
+func TestExportContentFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "content", "quotes")
+	data := QuotesData{Quotes: []Quote{
+		{ID: 1, Text: "Test: quote #1\n", Author: "Someone", Tags: []string{"wisdom", "life"}, Language: "en-US"},
+		{ID: 2, Text: "Untagged", Tags: []string{""}, Language: "en-US"},
+	}}
+
+	require.NoError(t, ExportContentFiles(data, dir))
+
+	content, err := os.ReadFile(filepath.Join(dir, "quote-1.md"))
+	require.NoError(t, err)
+	parts := strings.SplitN(string(content), "---\n", 3)
+	require.Len(t, parts, 3)
+
+	var frontMatter contentFrontMatter
+	require.NoError(t, yaml.Unmarshal([]byte(parts[1]), &frontMatter))
+	assert.Equal(t, contentFrontMatter{ID: 1, Tags: []string{"wisdom", "life"}, Author: "Someone", Lang: "en-US"}, frontMatter)
+	assert.Equal(t, "\nTest: quote #1\n", parts[2])
+
+	content, err = os.ReadFile(filepath.Join(dir, "quote-2.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "tags: []\n")
+	assert.NotContains(t, string(content), "author:")
+}
+
+// TestExportContentFilesUUIDs tests that quotes keyed by UUID get a file each, named after it
+func TestExportContentFilesUUIDs(t *testing.T) {
+	dir := t.TempDir()
+	data := QuotesData{Quotes: []Quote{
+		{UUID: "0190a0e4-0000-7000-8000-000000000001", Text: "First", Language: "en-US"},
+		{UUID: "0190a0e4-0000-7000-8000-000000000002", Text: "Second", Language: "en-US"},
+	}}
+	require.NoError(t, ExportContentFiles(data, dir))
+
+	content, err := os.ReadFile(filepath.Join(dir, "quote-0190a0e4-0000-7000-8000-000000000002.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "id: 0190a0e4-0000-7000-8000-000000000002\n")
+	assert.True(t, strings.HasSuffix(string(content), "\nSecond\n"))
+	assert.FileExists(t, filepath.Join(dir, "quote-0190a0e4-0000-7000-8000-000000000001.md"))
+}
@@ -0,0 +1,287 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// serverMaxConversions is how many conversions the server remembers, the oldest finished
+	// ones being forgotten first
+	serverMaxConversions = 100
+	// serverConversionTTL is how long a finished conversion is kept when no other time is set
+	serverConversionTTL = 15 * time.Minute
+)
+
+// ConversionEvent reports the progress of a conversion started through the server
+type ConversionEvent struct {
+	Type    string `json:"type"`            // progress after each batch, then done or error
+	Rows    int    `json:"rows"`            // rows read so far
+	Quotes  int    `json:"quotes"`          // quotes kept so far
+	Skipped int    `json:"skipped"`         // rows skipped so far, blank or failing validation
+	Error   string `json:"error,omitempty"` // why the conversion failed, for error
+}
+
+// conversion is a conversion started by POST /conversions, running in the background
+type conversion struct {
+	mu      sync.Mutex
+	events  []ConversionEvent
+	changed chan struct{} // closed, and replaced, when an event is added
+	quotes  []Quote
+	err     error
+	done    bool
+	ended   time.Time // when it finished
+}
+
+// add records an event and wakes those waiting for it
+func (c *conversion) add(event ConversionEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, event)
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// since returns the events after the first n, whether the conversion has finished and a
+// channel closed when there are more
+func (c *conversion) since(n int) ([]ConversionEvent, bool, <-chan struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.events[n:], c.done, c.changed
+}
+
+// run converts the uploaded file like ReadInputQuotes, reporting its progress and recording it
+// in metrics
+func (c *conversion) run(fileName string, opts Options, metrics *ConversionMetrics) {
+	defer os.RemoveAll(filepath.Dir(fileName))
+	opts.Progress = func(rows, quotes int) {
+		c.add(ConversionEvent{Type: "progress", Rows: rows, Quotes: quotes, Skipped: rows - quotes})
+	}
+	done := metrics.Track(&opts)
+	quotes, err := ReadInputQuotes(fileName, opts)
+	done(err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	last := ConversionEvent{Type: "done", Quotes: len(quotes)}
+	if n := len(c.events); n > 0 {
+		last.Rows, last.Skipped = c.events[n-1].Rows, c.events[n-1].Rows-len(quotes)
+	}
+	if err != nil {
+		last.Type, last.Error = "error", err.Error()
+	}
+	c.events = append(c.events, last)
+	c.quotes, c.err, c.done, c.ended = quotes, err, true, time.Now()
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// conversions are the conversions started through a Server, by ID. At most cap(slots) run at
+// once, and finished ones are forgotten ttl after they ended.
+type conversions struct {
+	slots chan struct{} // held by the running conversions
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu    sync.Mutex
+	byID  map[string]*conversion
+	order []string // IDs, oldest first
+}
+
+func newConversions(running int, ttl time.Duration, now func() time.Time) *conversions {
+	return &conversions{slots: make(chan struct{}, running), ttl: ttl, now: now, byID: map[string]*conversion{}}
+}
+
+// acquire reserves a slot for a new conversion, reporting false when all of them are taken
+func (cs *conversions) acquire() bool {
+	select {
+	case cs.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// release frees the slot of a conversion that ended
+func (cs *conversions) release() {
+	<-cs.slots
+}
+
+// start registers a new conversion and returns its ID
+func (cs *conversions) start() (string, *conversion) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.expire()
+	for i := 0; len(cs.order) >= serverMaxConversions && i < len(cs.order); i++ {
+		if _, done := cs.byID[cs.order[i]].finished(); done {
+			cs.forget(i)
+			i--
+		}
+	}
+	id := uuid.NewString()
+	c := &conversion{changed: make(chan struct{})}
+	cs.byID[id] = c
+	cs.order = append(cs.order, id)
+	return id, c
+}
+
+func (cs *conversions) get(id string) *conversion {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.expire()
+	return cs.byID[id]
+}
+
+// expire forgets the conversions that ended more than ttl ago, with cs.mu held
+func (cs *conversions) expire() {
+	for i := 0; i < len(cs.order); i++ {
+		if ended, done := cs.byID[cs.order[i]].finished(); done && cs.now().Sub(ended) > cs.ttl {
+			cs.forget(i)
+			i--
+		}
+	}
+}
+
+// forget drops the conversion at index i of cs.order, with cs.mu held
+func (cs *conversions) forget(i int) {
+	delete(cs.byID, cs.order[i])
+	cs.order = append(cs.order[:i], cs.order[i+1:]...)
+}
+
+// finished returns when the conversion ended, and whether it has
+func (c *conversion) finished() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ended, c.done
+}
+
+// handleStartConversion starts converting the request body, an input file named by ?name=
+// whose extension picks the format like convert, or by ?from=. It answers 202 Accepted with the
+// ID of the conversion and where to follow it.
+func (s *Server) handleStartConversion(w http.ResponseWriter, r *http.Request) {
+	opts := DefaultOptions()
+	if from := r.URL.Query().Get("from"); from != "" {
+		if err := opts.InputFormat.Set(from); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	name := filepath.Base(r.URL.Query().Get("name"))
+	if name == "." || name == string(filepath.Separator) {
+		name = "upload"
+	}
+	// The slot is taken before the upload is read, so a busy server doesn't store it
+	if !s.conversions.acquire() {
+		writeJSONError(w, http.StatusServiceUnavailable, "too many conversions are running, try again later")
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "toJson-upload")
+	if err != nil {
+		s.conversions.release()
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	fileName := filepath.Join(dir, name)
+	if err := saveUpload(http.MaxBytesReader(w, r.Body, serverMaxUpload), fileName); err != nil {
+		s.conversions.release()
+		os.RemoveAll(dir)
+		status := http.StatusBadRequest
+		if errors.As(err, new(*http.MaxBytesError)) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+
+	id, c := s.conversions.start()
+	// The conversion outlives the request, its spans stay in the trace of the request
+	opts.Context = context.WithoutCancel(r.Context())
+	go func() {
+		defer s.conversions.release()
+		c.run(fileName, opts, s.converted)
+	}()
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"id":     id,
+		"events": "/conversions/" + id + "/events",
+		"result": "/conversions/" + id,
+	})
+}
+
+// saveUpload writes the content of an upload to fileName
+func saveUpload(r io.Reader, fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return fmt.Errorf("error reading the upload: %w", err)
+	}
+	return file.Close()
+}
+
+// handleConversionEvents streams the events of a conversion as server-sent events, those
+// already past first, until it is done
+func (s *Server) handleConversionEvents(w http.ResponseWriter, r *http.Request) {
+	c := s.conversions.get(r.PathValue("id"))
+	if c == nil {
+		writeJSONError(w, http.StatusNotFound, fmt.Sprintf("no conversion with ID %s", r.PathValue("id")))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	controller := http.NewResponseController(w)
+
+	sent := 0
+	for {
+		events, done, changed := c.since(sent)
+		for _, event := range events {
+			data, _ := json.Marshal(event)
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+		}
+		sent += len(events)
+		controller.Flush()
+		if done {
+			return
+		}
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handleConversionResult returns the quotes of a finished conversion as {"quotes": [...]},
+// 202 Accepted while it runs and 422 with the error when it failed
+func (s *Server) handleConversionResult(w http.ResponseWriter, r *http.Request) {
+	c := s.conversions.get(r.PathValue("id"))
+	if c == nil {
+		writeJSONError(w, http.StatusNotFound, fmt.Sprintf("no conversion with ID %s", r.PathValue("id")))
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case !c.done:
+		writeJSON(w, http.StatusAccepted, map[string]string{"status": "running"})
+	case c.err != nil:
+		writeJSONError(w, http.StatusUnprocessableEntity, c.err.Error())
+	default:
+		writeJSON(w, http.StatusOK, QuotesData{Quotes: c.quotes})
+	}
+}
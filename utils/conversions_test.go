@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readEvents reads a stream of server-sent events until it ends
+func readEvents(t *testing.T, url string) []ConversionEvent {
+	t.Helper()
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	var events []ConversionEvent
+	scanner := bufio.NewScanner(resp.Body)
+	name := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		if value, ok := strings.CutPrefix(line, "event: "); ok {
+			name = value
+		} else if value, ok := strings.CutPrefix(line, "data: "); ok {
+			var event ConversionEvent
+			require.NoError(t, json.Unmarshal([]byte(value), &event))
+			assert.Equal(t, name, event.Type)
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// TestServerConversion tests converting an upload and following its progress
+func TestServerConversion(t *testing.T) {
+	server := httptest.NewServer(testServer())
+	defer server.Close()
+
+	var csv strings.Builder
+	csv.WriteString("Tags,Quote,Author\n")
+	for i := 1; i <= 250; i++ {
+		if i == 7 {
+			csv.WriteString("wisdom\n") // skipped, it has no text column
+			continue
+		}
+		fmt.Fprintf(&csv, "wisdom,Quote number %d,Someone\n", i)
+	}
+	resp, err := http.Post(server.URL+"/conversions?name=quotes.csv", "text/csv", strings.NewReader(csv.String()))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	var started map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&started))
+
+	events := readEvents(t, server.URL+started["events"])
+	assert.Equal(t, []ConversionEvent{
+		{Type: "progress", Rows: 100, Quotes: 99, Skipped: 1},
+		{Type: "progress", Rows: 200, Quotes: 199, Skipped: 1},
+		{Type: "progress", Rows: 250, Quotes: 249, Skipped: 1},
+		{Type: "done", Rows: 250, Quotes: 249, Skipped: 1},
+	}, events)
+	// A finished conversion replays its events
+	assert.Equal(t, events, readEvents(t, server.URL+started["events"]))
+
+	result, err := http.Get(server.URL + started["result"])
+	require.NoError(t, err)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	var data QuotesData
+	require.NoError(t, json.NewDecoder(result.Body).Decode(&data))
+	require.Len(t, data.Quotes, 249)
+	assert.Equal(t, "Quote number 1", data.Quotes[0].Text)
+}
+
+// TestServerConversionErrors tests the conversions the server rejects or fails
+func TestServerConversionErrors(t *testing.T) {
+	server := httptest.NewServer(testServer())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/conversions?from=docx", "text/plain", strings.NewReader(""))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/conversions/unknown/events")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Post(server.URL+"/conversions?name=quotes.json", "application/json", strings.NewReader("not json"))
+	require.NoError(t, err)
+	var started map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&started))
+	resp.Body.Close()
+	events := readEvents(t, server.URL+started["events"])
+	require.Len(t, events, 1)
+	assert.Equal(t, "error", events[0].Type)
+	assert.NotEmpty(t, events[0].Error)
+
+	resp, err = http.Get(server.URL + started["result"])
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+}
+
+// TestServerConversionLimits tests that conversions are off by default, bounded while enabled and
+// forgotten once expired
+func TestServerConversionLimits(t *testing.T) {
+	disabled := httptest.NewServer(NewServer(QuotesData{}, ServerOptions{}))
+	defer disabled.Close()
+	resp, err := http.Post(disabled.URL+"/conversions?name=quotes.csv", "text/csv", strings.NewReader("Quote\nHi\n"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	s := testServer()
+	server := httptest.NewServer(s)
+	defer server.Close()
+	for s.conversions.acquire() {
+		// Take every slot, as if as many conversions were running
+	}
+	resp, err = http.Post(server.URL+"/conversions?name=quotes.csv", "text/csv", strings.NewReader("Quote\nHi\n"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	s.conversions.release()
+	resp, err = http.Post(server.URL+"/conversions?name=quotes.csv", "text/csv", strings.NewReader("Quote\nHi\n"))
+	require.NoError(t, err)
+	var started map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&started))
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	readEvents(t, server.URL+started["events"])
+
+	result, err := http.Get(server.URL + started["result"])
+	require.NoError(t, err)
+	result.Body.Close()
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+
+	s.now = func() time.Time { return time.Now().Add(serverConversionTTL + time.Minute) }
+	result, err = http.Get(server.URL + started["result"])
+	require.NoError(t, err)
+	result.Body.Close()
+	assert.Equal(t, http.StatusNotFound, result.StatusCode, "the result expired")
+}
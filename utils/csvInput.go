@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// readCSV loads the records of a CSV file, header first. Records may have different lengths and
+// stray quotes inside unquoted fields are kept, as spreadsheet exports often have them.
+func readCSV(fileName string) ([][]string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV file %s: %w", fileName, err)
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV file %s: %w", fileName, err)
+	}
+	if len(records) > 0 && len(records[0]) > 0 {
+		records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff")
+	}
+	return records, nil
+}
+
+// readCSVRows reads a CSV file as rows; its header row decides the columns like a sheet's
+func readCSVRows(fileName string, opts Options) (*sliceRows, error) {
+	records, err := readCSV(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return &sliceRows{rows: records}, nil
+}
+
+// csvHeader returns the lower-cased header of a CSV file, or nil if it can't be read
+func csvHeader(fileName string) []string {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	header, err := reader.Read()
+	if err != nil && err != io.EOF {
+		return nil
+	}
+	for i, name := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+	}
+	return header
+}
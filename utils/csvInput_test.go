@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReadCSV tests loading CSV records
+func TestReadCSV(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "quotes.csv")
+	require.NoError(t, os.WriteFile(fileName, []byte("\ufeffText,Author\n\"Be, yourself.\",Oscar Wilde\nShort row\nA 5\" quote,Anon,extra\n"), 0644))
+	records, err := readCSV(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"Text", "Author"}, {"Be, yourself.", "Oscar Wilde"}, {"Short row"}, {`A 5" quote`, "Anon", "extra"}}, records)
+	assert.Equal(t, []string{"text", "author"}, csvHeader(fileName))
+
+	_, err = readCSV(filepath.Join(t.TempDir(), "missing.csv"))
+	assert.Error(t, err)
+	assert.Nil(t, csvHeader(filepath.Join(t.TempDir(), "missing.csv")))
+}
+
+// TestConvertCSVFile tests that a CSV file is read like a sheet, --column included
+func TestConvertCSVFile(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	input := filepath.Join(t.TempDir(), "quotes.csv")
+	require.NoError(t, os.WriteFile(input, []byte("Category,Quote,By,Lang\nwisdom,Know thyself.,Socrates,el\n"), 0644))
+
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(t.TempDir(), "out.json")
+	opts.Columns = ColumnList{"author=C"}
+	require.NoError(t, ConvertFile(input, opts))
+	data, err := ReadQuotesFromJSON(opts.Output)
+	require.NoError(t, err)
+	require.Len(t, data.Quotes, 1)
+	assert.Equal(t, Quote{ID: 1, Text: "Know thyself.", Author: "Socrates", Tags: []string{"wisdom"}, Language: "el", WordCount: 2, ReadingTimeSeconds: 1}, data.Quotes[0])
+}
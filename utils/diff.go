@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// QuoteChange is a quote present in both datasets with different fields
+type QuoteChange struct {
+	Old    Quote    `json:"old"`
+	New    Quote    `json:"new"`
+	Fields []string `json:"fields"` // JSON names of the fields that differ
+}
+
+// QuotesDiff lists what changed between two datasets
+type QuotesDiff struct {
+	Added     []Quote       `json:"added"`
+	Removed   []Quote       `json:"removed"`
+	Modified  []QuoteChange `json:"modified"`
+	Unchanged int           `json:"unchanged"`
+}
+
+// LoadQuotes reads the quotes of a spreadsheet, processed with opts, or of a JSON conversion
+func LoadQuotes(fileName string, opts Options) ([]Quote, error) {
+	if strings.EqualFold(filepath.Ext(fileName), ".xlsx") {
+		file, err := OpenExcelFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return ReadExcelQuotes(file, opts)
+	}
+	data, err := ReadQuotesFromJSON(fileName)
+	return data.Quotes, err
+}
+
+// DiffQuotes pairs the quotes of two datasets like MergeQuotes and reports the added, removed
+// and modified ones, each in dataset order
+func DiffQuotes(old, new []Quote, match MergeMatch) (QuotesDiff, error) {
+	diff := QuotesDiff{Added: []Quote{}, Removed: []Quote{}, Modified: []QuoteChange{}}
+	matches := make(map[string][]int)
+	for i, quote := range old {
+		key := mergeKey(quote, match)
+		matches[key] = append(matches[key], i)
+	}
+
+	paired := make([]bool, len(old))
+	for _, quote := range new {
+		key := mergeKey(quote, match)
+		candidates := matches[key]
+		if len(candidates) == 0 {
+			diff.Added = append(diff.Added, quote)
+			continue
+		}
+		matches[key] = candidates[1:]
+		paired[candidates[0]] = true
+
+		previous := old[candidates[0]]
+		fields, err := changedFields(previous, quote)
+		if err != nil {
+			return diff, err
+		}
+		if len(fields) == 0 {
+			diff.Unchanged++
+			continue
+		}
+		diff.Modified = append(diff.Modified, QuoteChange{Old: previous, New: quote, Fields: fields})
+	}
+	for i, quote := range old {
+		if !paired[i] {
+			diff.Removed = append(diff.Removed, quote)
+		}
+	}
+	return diff, nil
+}
+
+// changedFields compares the JSON encodings of two quotes field by field
+func changedFields(old, new Quote) ([]string, error) {
+	oldTree, err := quoteTree(old)
+	if err != nil {
+		return nil, err
+	}
+	newTree, err := quoteTree(new)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]any)
+	for _, member := range oldTree.(jsonObject) {
+		values[member.Key] = member.Value
+	}
+	var fields []string
+	for _, member := range newTree.(jsonObject) {
+		previous, ok := values[member.Key]
+		delete(values, member.Key)
+		if !ok || !reflect.DeepEqual(previous, member.Value) {
+			fields = append(fields, member.Key)
+		}
+	}
+	// Fields only the old quote had, in their old order
+	for _, member := range oldTree.(jsonObject) {
+		if _, ok := values[member.Key]; ok {
+			fields = append(fields, member.Key)
+		}
+	}
+	return fields, nil
+}
+
+// Empty reports whether the datasets hold the same quotes
+func (d QuotesDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// WriteJSON writes the diff as an indented JSON document
+func (d QuotesDiff) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling diff: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WriteText writes one line per change, prefixed with + (added), - (removed) or ~ (modified),
+// followed by a summary line
+func (d QuotesDiff) WriteText(w io.Writer) error {
+	for _, quote := range d.Removed {
+		fmt.Fprintf(w, "- %s %q\n", quote.key(), quote.Text)
+	}
+	for _, quote := range d.Added {
+		fmt.Fprintf(w, "+ %s %q\n", quote.key(), quote.Text)
+	}
+	for _, change := range d.Modified {
+		fmt.Fprintf(w, "~ %s %q (%s)\n", change.New.key(), change.New.Text, strings.Join(change.Fields, ", "))
+	}
+	_, err := fmt.Fprintf(w, "%d added, %d removed, %d modified, %d unchanged\n", len(d.Added), len(d.Removed), len(d.Modified), d.Unchanged)
+	return err
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDiffQuotes tests reporting added, removed and modified quotes
+func TestDiffQuotes(t *testing.T) {
+	old := []Quote{
+		{ID: 1, Text: "Know thyself.", Tags: []string{"wisdom"}, Language: "en"},
+		{ID: 2, Text: "Carpe diem", Tags: []string{"time"}, Language: "en"},
+		{ID: 3, Text: "Gone", Tags: []string{}, Language: "en"},
+	}
+	new := []Quote{
+		{ID: 1, Text: "Know thyself.", Tags: []string{"wisdom"}, Language: "en"},
+		{ID: 2, Text: "Fresh", Tags: []string{}, Language: "en"},
+		{ID: 3, Text: "carpe diem", Tags: []string{"time", "life"}, Author: "Horace", Language: "en"},
+	}
+
+	diff, err := DiffQuotes(old, new, MergeByText)
+	require.NoError(t, err)
+	assert.Equal(t, []Quote{new[1]}, diff.Added)
+	assert.Equal(t, []Quote{old[2]}, diff.Removed)
+	require.Len(t, diff.Modified, 1)
+	assert.Equal(t, []string{"id", "text", "author", "tags"}, diff.Modified[0].Fields)
+	assert.Equal(t, 1, diff.Unchanged)
+	assert.False(t, diff.Empty())
+
+	var text bytes.Buffer
+	require.NoError(t, diff.WriteText(&text))
+	assert.Equal(t, `- 3 "Gone"
++ 2 "Fresh"
+~ 3 "carpe diem" (id, text, author, tags)
+1 added, 1 removed, 1 modified, 1 unchanged
+`, text.String())
+
+	var data bytes.Buffer
+	require.NoError(t, diff.WriteJSON(&data))
+	var decoded QuotesDiff
+	require.NoError(t, json.Unmarshal(data.Bytes(), &decoded))
+	assert.Equal(t, diff, decoded)
+
+	diff, err = DiffQuotes(old, new, MergeByID)
+	require.NoError(t, err)
+	assert.Len(t, diff.Modified, 2)
+	assert.Empty(t, diff.Added)
+	assert.Empty(t, diff.Removed)
+
+	diff, err = DiffQuotes(old, old, MergeByText)
+	require.NoError(t, err)
+	assert.True(t, diff.Empty())
+	assert.Equal(t, 3, diff.Unchanged)
+}
+
+// TestLoadQuotes tests loading quotes from spreadsheets and JSON files
+func TestLoadQuotes(t *testing.T) {
+	_, excelFile := createTestExcelFile(t)
+	fromExcel, err := LoadQuotes(excelFile, DefaultOptions())
+	require.NoError(t, err)
+	require.Len(t, fromExcel, 3)
+
+	jsonFile := filepath.Join(t.TempDir(), "quotes.json")
+	require.NoError(t, WriteJSONToFile(jsonFile, QuotesData{Quotes: fromExcel}))
+	fromJSON, err := LoadQuotes(jsonFile, DefaultOptions())
+	require.NoError(t, err)
+
+	diff, err := DiffQuotes(fromJSON, fromExcel, MergeByID)
+	require.NoError(t, err)
+	assert.True(t, diff.Empty())
+}
@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeElasticsearch serves the requests of the elasticsearch sink, keeping the documents of
+// every index and which indices the aliases point to
+type fakeElasticsearch struct {
+	mu        sync.Mutex
+	indices   map[string][]string // IDs of the documents of each index
+	aliases   map[string][]string
+	requests  []string
+	auth      []string
+	rejectIDs map[string]bool
+}
+
+func newFakeElasticsearch(t *testing.T) (*fakeElasticsearch, *httptest.Server) {
+	es := &fakeElasticsearch{indices: make(map[string][]string), aliases: make(map[string][]string), rejectIDs: make(map[string]bool)}
+	server := httptest.NewServer(es)
+	t.Cleanup(server.Close)
+	return es, server
+}
+
+func (es *fakeElasticsearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	es.requests = append(es.requests, r.Method+" "+r.URL.Path)
+	es.auth = append(es.auth, r.Header.Get("Authorization"))
+	body, _ := io.ReadAll(r.Body)
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.Method == http.MethodPut && len(parts) == 1:
+		if _, ok := es.indices[parts[0]]; ok {
+			http.Error(w, `{"error": {"type": "resource_already_exists_exception"}}`, http.StatusBadRequest)
+			return
+		}
+		es.indices[parts[0]] = []string{}
+	case r.Method == http.MethodDelete && len(parts) == 1:
+		delete(es.indices, parts[0])
+	case r.Method == http.MethodPost && len(parts) == 2 && parts[1] == "_bulk":
+		var items []map[string]any
+		scanner := bufio.NewScanner(strings.NewReader(string(body)))
+		for scanner.Scan() {
+			var action esBulkAction
+			json.Unmarshal(scanner.Bytes(), &action)
+			scanner.Scan()
+			result := map[string]any{"_id": action.Index.ID, "status": 201}
+			if es.rejectIDs[action.Index.ID] {
+				result["error"] = map[string]string{"type": "mapper_parsing_exception", "reason": "failed to parse field [year]"}
+			} else {
+				es.indices[action.Index.Index] = append(es.indices[action.Index.Index], action.Index.ID)
+			}
+			items = append(items, map[string]any{"index": result})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"errors": len(es.rejectIDs) > 0, "items": items})
+		return
+	case r.Method == http.MethodGet && parts[0] == "_alias":
+		indices, ok := es.aliases[parts[1]]
+		if !ok {
+			http.Error(w, `{"error": "alias [`+parts[1]+`] missing", "status": 404}`, http.StatusNotFound)
+			return
+		}
+		response := make(map[string]any)
+		for _, index := range indices {
+			response[index] = map[string]any{"aliases": map[string]any{parts[1]: map[string]any{}}}
+		}
+		json.NewEncoder(w).Encode(response)
+		return
+	case r.Method == http.MethodPost && parts[0] == "_aliases":
+		var request struct {
+			Actions []map[string]map[string]string `json:"actions"`
+		}
+		json.Unmarshal(body, &request)
+		for _, action := range request.Actions {
+			if add, ok := action["add"]; ok {
+				es.aliases[add["alias"]] = []string{add["index"]}
+			}
+			if remove, ok := action["remove_index"]; ok {
+				delete(es.indices, remove["index"])
+			}
+		}
+	}
+	w.Write([]byte(`{"acknowledged": true}`))
+}
+
+// TestElasticsearchSink tests that a conversion indexes the quotes and swaps the alias over
+func TestElasticsearchSink(t *testing.T) {
+	es, server := newFakeElasticsearch(t)
+	es.indices["quotes-old"] = []string{"9"}
+	es.aliases["quotes"] = []string{"quotes-old"}
+
+	sink, err := OpenSink("elasticsearch:"+strings.Replace(server.URL, "http://", "http://elastic:secret@", 1), DefaultOptions())
+	require.NoError(t, err)
+	require.NoError(t, sink.WriteQuotes([]Quote{{ID: 1, Text: "Know thyself.", Language: "en"}, {ID: 2, Text: "Carpe diem.", Language: "la"}}))
+	require.NoError(t, sink.Commit())
+
+	index := sink.(*elasticsearchSink).index
+	assert.True(t, strings.HasPrefix(index, "quotes-"))
+	assert.Equal(t, []string{index}, es.aliases["quotes"])
+	assert.Equal(t, map[string][]string{index: {"1", "2"}}, es.indices, "the previous index is deleted")
+	assert.Equal(t, []string{
+		"PUT /" + index, "POST /" + index + "/_bulk", "PUT /" + index + "/_settings", "POST /" + index + "/_refresh",
+		"GET /_alias/quotes", "POST /_aliases",
+	}, es.requests)
+	assert.True(t, strings.HasPrefix(es.auth[0], "Basic "))
+}
+
+// TestElasticsearchSinkRollback tests that a failed conversion deletes its index and the alias
+// keeps pointing to the previous one
+func TestElasticsearchSinkRollback(t *testing.T) {
+	t.Setenv("ELASTICSEARCH_API_KEY", "a2V5")
+	es, server := newFakeElasticsearch(t)
+	es.rejectIDs["2"] = true
+
+	opts := DefaultOptions()
+	opts.ESIndex = "daily"
+	sink, err := OpenSink("opensearch:"+server.URL, opts)
+	require.NoError(t, err)
+	err = sink.WriteQuotes([]Quote{{ID: 1, Text: "Know thyself."}, {ID: 2, Text: "Carpe diem."}})
+	assert.EqualError(t, err, "error indexing quote 2: mapper_parsing_exception: failed to parse field [year]")
+	require.NoError(t, sink.Rollback())
+
+	assert.Empty(t, es.indices)
+	assert.Empty(t, es.aliases)
+	assert.True(t, strings.HasPrefix(es.requests[0], "PUT /daily-"))
+	assert.Equal(t, "ApiKey a2V5", es.auth[0])
+}
+
+// TestElasticsearchSinkFirstPublish tests publishing an alias that doesn't exist yet
+func TestElasticsearchSinkFirstPublish(t *testing.T) {
+	es, server := newFakeElasticsearch(t)
+	sink, err := OpenSink("elasticsearch:"+server.URL+"/site-quotes", DefaultOptions())
+	require.NoError(t, err)
+	require.NoError(t, sink.WriteQuotes([]Quote{{ID: 1, Text: "Know thyself."}}))
+	require.NoError(t, sink.Commit())
+	assert.Equal(t, []string{sink.(*elasticsearchSink).index}, es.aliases["site-quotes"])
+}
+
+func TestElasticsearchSinkErrors(t *testing.T) {
+	_, err := OpenSink("elasticsearch:localhost:9200", DefaultOptions())
+	assert.ErrorContains(t, err, "expected elasticsearch:https://host:9200/alias")
+	_, err = OpenSink("elasticsearch:http://localhost:9200/Quotes", DefaultOptions())
+	assert.ErrorContains(t, err, `invalid elasticsearch alias "Quotes"`)
+
+	es, server := newFakeElasticsearch(t)
+	sink, err := OpenSink("elasticsearch:"+server.URL, DefaultOptions())
+	require.NoError(t, err)
+	_, err = OpenSink("elasticsearch:"+server.URL, DefaultOptions())
+	assert.ErrorContains(t, err, "error creating elasticsearch index quotes-")
+	assert.ErrorContains(t, err, "400 Bad Request")
+	require.NoError(t, sink.Rollback())
+	assert.Empty(t, es.indices)
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// embeddedMetadata is the part of a combined quotes file written by --embed-metadata that
+// holds the metadata
+type embeddedMetadata struct {
+	Metadata *Metadata `json:"metadata"`
+}
+
+// checkEmbedMetadata rejects the options a combined quotes file can't be written with
+func checkEmbedMetadata(opts Options) error {
+	switch {
+	case !opts.EmbedMetadata:
+		return nil
+	case opts.Format != StreamArray:
+		return fmt.Errorf("--embed-metadata requires --format json")
+	case opts.Compress != CompressNone || len(opts.Encrypt) > 0:
+		return fmt.Errorf("--embed-metadata cannot be combined with --compress or --encrypt-recipient")
+	case opts.Output == "-":
+		return fmt.Errorf("--embed-metadata cannot be combined with --out -, the metadata is known only after the quotes")
+	}
+	return nil
+}
+
+// embedMetadata rewrites the json format quotes file fileName into a single
+// {"metadata": ..., "quotes": [...]} document laid out like the rest of the file. The file is
+// replaced atomically, so readers never see it half written.
+func embedMetadata(fileName string, metadata Metadata, opts Options) error {
+	in, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("error embedding metadata in %s: %w", fileName, err)
+	}
+	defer in.Close()
+
+	// The streamed document begins with the brace opening {"quotes": ...}, the metadata goes right after it
+	r := bufio.NewReader(in)
+	if brace, err := r.ReadByte(); err != nil || brace != '{' {
+		return fmt.Errorf("error embedding metadata in %s: not a json format quotes file", fileName)
+	}
+	var data []byte
+	if opts.Compact {
+		data, err = json.Marshal(metadata)
+	} else {
+		data, err = json.MarshalIndent(metadata, opts.Indent, opts.Indent)
+	}
+	if err != nil {
+		return fmt.Errorf("error marshalling metadata to JSON: %v", err)
+	}
+
+	tmp := fileName + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("error embedding metadata in %s: %w", fileName, err)
+	}
+	w := bufio.NewWriter(out)
+	if opts.Compact {
+		w.WriteString(`{"metadata":`)
+	} else {
+		w.WriteString("{\n" + opts.Indent + "\"metadata\": ")
+	}
+	w.Write(data)
+	w.WriteByte(',')
+	_, err = io.Copy(w, r)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, fileName)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error embedding metadata in %s: %w", fileName, err)
+	}
+	return nil
+}
+
+// readEmbeddedMetadata loads the metadata of a combined quotes file, or returns nil if the file
+// doesn't exist or holds no metadata
+func readEmbeddedMetadata(fileName string) (*Metadata, error) {
+	content, err := os.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON file %s: %w", fileName, err)
+	}
+	var embedded embeddedMetadata
+	if err := json.Unmarshal(content, &embedded); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON file %s: %w", fileName, err)
+	}
+	if embedded.Metadata != nil && embedded.Metadata.Version == "" {
+		embedded.Metadata.Version = initialVersion
+	}
+	return embedded.Metadata, nil
+}
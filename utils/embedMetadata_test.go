@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+// TestEmbedMetadata tests combining the quotes and metadata in both json layouts
+func TestEmbedMetadata(t *testing.T) {
+	metadata := Metadata{Version: "1.2.0", TotalQuotes: 1, URL: "https://example.com"}
+	quotes := QuotesData{Quotes: []Quote{{ID: 1, Text: "Be.", Tags: []string{"a"}, Language: "en-US"}}}
+	for _, compact := range []bool{false, true} {
+		opts := DefaultOptions()
+		opts.Compact = compact
+		name := filepath.Join(t.TempDir(), "quotes.json")
+		require.NoError(t, WriteJSONToFileWithOptions(name, quotes, opts))
+		require.NoError(t, embedMetadata(name, metadata, opts))
+
+		content, err := os.ReadFile(name)
+		require.NoError(t, err)
+		var combined struct {
+			Metadata Metadata `json:"metadata"`
+			Quotes   []Quote  `json:"quotes"`
+		}
+		require.NoError(t, json.Unmarshal(content, &combined), string(content))
+		assert.Equal(t, metadata, combined.Metadata)
+		assert.Equal(t, quotes.Quotes, combined.Quotes)
+		if compact {
+			assert.Contains(t, string(content), `{"metadata":{"version":"1.2.0",`)
+		} else {
+			assert.Contains(t, string(content), "{\n  \"metadata\": {\n    \"version\": \"1.2.0\",")
+			assert.Contains(t, string(content), "\n  },\n  \"quotes\": [\n")
+		}
+
+		embedded, err := readEmbeddedMetadata(name)
+		require.NoError(t, err)
+		assert.Equal(t, &metadata, embedded)
+		assert.NoFileExists(t, name+".tmp")
+	}
+
+	embedded, err := readEmbeddedMetadata(filepath.Join(t.TempDir(), "missing.json"))
+	require.NoError(t, err)
+	assert.Nil(t, embedded)
+}
+
+// TestReadExcelFileEmbedMetadata tests writing a single combined file and bumping its version
+func TestReadExcelFileEmbedMetadata(t *testing.T) {
+	f, _ := createTestExcelFile(t)
+	os.Remove("quotesMetadata.json")
+	defer os.Remove("quotes.json")
+
+	opts := DefaultOptions()
+	opts.EmbedMetadata = true
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	assert.NoFileExists(t, "quotesMetadata.json")
+	data, err := ReadQuotesFromJSON("quotes.json")
+	require.NoError(t, err)
+	assert.Len(t, data.Quotes, 3)
+	metadata, err := readEmbeddedMetadata("quotes.json")
+	require.NoError(t, err)
+	require.NotNil(t, metadata)
+	assert.Equal(t, "1.0", metadata.Version)
+	assert.Equal(t, 3, metadata.TotalQuotes)
+
+	require.NoError(t, f.SetCellValue("Sheet1", "B5", "Test quote 4"))
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	metadata, err = readEmbeddedMetadata("quotes.json")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", metadata.Version)
+
+	for _, invalid := range []func(*Options){
+		func(o *Options) { o.Format = StreamNDJSON },
+		func(o *Options) { o.Compress = CompressGzip },
+		func(o *Options) { o.Output = "-" },
+	} {
+		bad := opts
+		invalid(&bad)
+		assert.Error(t, ReadExcelFileWithOptions(f, bad))
+	}
+}
+
+// TestMergeFileEmbedMetadata tests merging into a combined file keeps its metadata
+func TestMergeFileEmbedMetadata(t *testing.T) {
+	dir := t.TempDir()
+	into := filepath.Join(dir, "quotes.json")
+	excelFile := filepath.Join(dir, "new.xlsx")
+	f := excelize.NewFile()
+	require.NoError(t, f.SetSheetRow("Sheet1", "A1", &[]string{"Tags", "Quote"}))
+	require.NoError(t, f.SetSheetRow("Sheet1", "A2", &[]string{"a", "New quote"}))
+	require.NoError(t, f.SaveAs(excelFile))
+	require.NoError(t, f.Close())
+
+	opts := DefaultOptions()
+	opts.EmbedMetadata = true
+	require.NoError(t, WriteJSONToFile(into, QuotesData{Quotes: []Quote{{ID: 1, Text: "Old quote", Tags: []string{"b"}, Language: "en-US"}}}))
+	require.NoError(t, embedMetadata(into, Metadata{Version: "2.0.0", URL: "https://example.com"}, opts))
+
+	metadataFile := filepath.Join(dir, "quotesMetadata.json")
+	_, err := MergeFile(excelFile, into, metadataFile, MergeByText, opts)
+	require.NoError(t, err)
+	assert.NoFileExists(t, metadataFile)
+	metadata, err := readEmbeddedMetadata(into)
+	require.NoError(t, err)
+	require.NotNil(t, metadata)
+	assert.Equal(t, "2.1.0", metadata.Version)
+	assert.Equal(t, "https://example.com", metadata.URL)
+	assert.Equal(t, 2, metadata.TotalQuotes)
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// RecipientList collects repeated --encrypt-recipient flags as parsed age X25519 recipients
+type RecipientList []*age.X25519Recipient
+
+// String implements flag.Value
+func (r *RecipientList) String() string {
+	names := make([]string, len(*r))
+	for i, recipient := range *r {
+		names[i] = recipient.String()
+	}
+	return strings.Join(names, ",")
+}
+
+// Set implements flag.Value, accepting age1... public keys
+func (r *RecipientList) Set(value string) error {
+	recipient, err := age.ParseX25519Recipient(value)
+	if err != nil {
+		return fmt.Errorf("invalid age recipient %q: %w", value, err)
+	}
+	*r = append(*r, recipient)
+	return nil
+}
+
+// newWriter wraps w so everything written to it is encrypted to all recipients; closing the
+// returned writer finishes the age file but leaves w open
+func (r RecipientList) newWriter(w io.Writer) io.WriteCloser {
+	recipients := make([]age.Recipient, len(r))
+	for i, recipient := range r {
+		recipients[i] = recipient
+	}
+	aw, err := age.Encrypt(w, recipients...)
+	if err != nil {
+		// The header could not be written, report it on the first write instead
+		return failedWriter{err: fmt.Errorf("error encrypting output: %w", err)}
+	}
+	return aw
+}
+
+// writeEnvelopeFile is os.WriteFile compressing data with opts.Compress and then encrypting it
+// to opts.Encrypt, like the quotes file, for the side outputs holding quotes
+func writeEnvelopeFile(filename string, data []byte, opts Options) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = file
+	var closers []io.Closer
+	if len(opts.Encrypt) > 0 {
+		aw := opts.Encrypt.newWriter(w)
+		closers = append([]io.Closer{aw}, closers...)
+		w = aw
+	}
+	zw := opts.Compress.newWriter(w)
+	closers = append([]io.Closer{zw}, closers...)
+	_, err = zw.Write(data)
+	for _, closer := range append(closers, file) {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// failedWriter fails every write and close with err
+type failedWriter struct {
+	err error
+}
+
+func (f failedWriter) Write(p []byte) (int, error) {
+	return 0, f.err
+}
+
+func (f failedWriter) Close() error {
+	return f.err
+}
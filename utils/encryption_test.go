@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decryptFile reads a file encrypted to identity and compressed with compression
+func decryptFile(t *testing.T, identity age.Identity, compression Compression, file string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	r, err := age.Decrypt(bytes.NewReader(data), identity)
+	require.NoError(t, err)
+	compressed, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return decompress(t, compression, compressed)
+}
+
+// TestRecipientListSet tests parsing of --encrypt-recipient values
+func TestRecipientListSet(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	var recipients RecipientList
+	require.NoError(t, recipients.Set(identity.Recipient().String()))
+	assert.Equal(t, identity.Recipient().String(), recipients.String())
+	assert.Error(t, recipients.Set("not-a-key"))
+	assert.Len(t, recipients, 1)
+}
+
+// TestEncryptedStream tests that every recipient can decrypt compressed, encrypted output
+func TestEncryptedStream(t *testing.T) {
+	first, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	second, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	quotes := []Quote{{ID: 1, Text: "Secret", Tags: []string{"a"}, Language: "en-US"}}
+	expected, err := json.MarshalIndent(QuotesData{Quotes: quotes}, "", "  ")
+	require.NoError(t, err)
+
+	opts := DefaultOptions()
+	opts.Encrypt = RecipientList{first.Recipient(), second.Recipient()}
+	opts.Compress = CompressGzip
+	var buf bytes.Buffer
+	s := NewQuoteStreamWriterWithOptions(&buf, opts)
+	require.NoError(t, s.WriteQuote(quotes[0]))
+	require.NoError(t, s.Close())
+	assert.NotContains(t, buf.String(), "Secret")
+
+	for _, identity := range []*age.X25519Identity{first, second} {
+		r, err := age.Decrypt(bytes.NewReader(buf.Bytes()), identity)
+		require.NoError(t, err)
+		compressed, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(decompress(t, CompressGzip, compressed)))
+	}
+
+	_, err = age.Decrypt(bytes.NewReader(buf.Bytes()), other)
+	assert.Error(t, err)
+	assert.Equal(t, "quotes.json.gz.age", outputFileName(opts))
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+)
+
+// esBulkAction is the action line preceding each document in a _bulk request
+type esBulkAction struct {
+	Index struct {
+		Index string `json:"_index"`
+		ID    string `json:"_id"`
+	} `json:"index"`
+}
+
+// esBulkEncoder writes an index action line and the quote document for every quote,
+// ready to POST to the Elasticsearch or OpenSearch _bulk endpoint
+type esBulkEncoder struct {
+	index string
+}
+
+func (e esBulkEncoder) writeQuote(w *bufio.Writer, quote Quote, index int) error {
+	var action esBulkAction
+	action.Index.Index = e.index
+	action.Index.ID = quote.key()
+
+	actionLine, err := json.Marshal(action)
+	if err != nil {
+		return err
+	}
+	document, err := json.Marshal(quote)
+	if err != nil {
+		return err
+	}
+	w.Write(actionLine)
+	w.WriteByte('\n')
+	w.Write(document)
+	return w.WriteByte('\n')
+}
+
+func (esBulkEncoder) finish(w *bufio.Writer, count int) error {
+	return nil
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestESBulkEncoder tests the action/document line pairs and the configurable index
+func TestESBulkEncoder(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Format = StreamESBulk
+	opts.ESIndex = "quotes-v2"
+
+	quotes := []Quote{
+		{ID: 1, Text: "First", Tags: []string{"a"}, Language: "en-US"},
+		{ID: 2, Text: "Second\nline", Tags: []string{"b"}, Language: "en-US"},
+	}
+	var buf bytes.Buffer
+	s := NewQuoteStreamWriterWithOptions(&buf, opts)
+	for _, q := range quotes {
+		require.NoError(t, s.WriteQuote(q))
+	}
+	require.NoError(t, s.Close())
+
+	lines := strings.Split(buf.String(), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, `{"index":{"_index":"quotes-v2","_id":"1"}}`, lines[0])
+	assert.Equal(t, `{"index":{"_index":"quotes-v2","_id":"2"}}`, lines[2])
+	assert.Equal(t, "", lines[4], "the bulk body must end with a newline")
+
+	var doc Quote
+	require.NoError(t, json.Unmarshal([]byte(lines[3]), &doc))
+	assert.Equal(t, quotes[1], doc)
+}
@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FeedOptions configures ExportFeed
+type FeedOptions struct {
+	Format  string // "rss" or "atom"
+	BaseURL string // site the entry links point into, as BaseURL/quotes/{id}
+	Title   string
+	Limit   int // number of newest quotes to include
+}
+
+// rssFeed is an RSS 2.0 document
+type rssFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel struct {
+		Title         string    `xml:"title"`
+		Link          string    `xml:"link"`
+		Description   string    `xml:"description"`
+		LastBuildDate string    `xml:"lastBuildDate"`
+		Items         []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        string   `xml:"guid"`
+	Description string   `xml:"description"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+}
+
+// atomFeed is an Atom (RFC 4287) document
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Link    atomLink    `xml:"link"`
+	Updated string      `xml:"updated"`
+	Author  atomPerson  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Updated    string         `xml:"updated"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Content    string         `xml:"content"`
+	Categories []atomCategory `xml:"category"`
+}
+
+// ExportFeed writes an RSS or Atom feed of the newest quotes to w. Quotes carry no timestamps
+// of their own, so the newest are those with the highest IDs, or the latest UUIDv7s, and every
+// entry is dated with the dataset's LastUpdated time.
+func ExportFeed(data QuotesData, metadata Metadata, opts FeedOptions, w io.Writer) error {
+	updated, err := time.Parse(time.RFC3339, metadata.LastUpdated)
+	if err != nil {
+		return fmt.Errorf("invalid metadata lastUpdated %q: %w", metadata.LastUpdated, err)
+	}
+	if opts.BaseURL == "" {
+		return fmt.Errorf("a base URL is required for feed links")
+	}
+	baseURL := strings.TrimRight(opts.BaseURL, "/")
+
+	quotes := append([]Quote(nil), data.Quotes...)
+	sort.SliceStable(quotes, func(i, j int) bool {
+		// UUIDv7s sort in the order they were generated
+		if quotes[i].UUID != "" || quotes[j].UUID != "" {
+			return quotes[i].UUID > quotes[j].UUID
+		}
+		return quotes[i].ID > quotes[j].ID
+	})
+	if opts.Limit > 0 && len(quotes) > opts.Limit {
+		quotes = quotes[:opts.Limit]
+	}
+
+	var doc any
+	switch opts.Format {
+	case "rss":
+		feed := rssFeed{Version: "2.0"}
+		feed.Channel.Title = opts.Title
+		feed.Channel.Link = baseURL + "/"
+		feed.Channel.Description = fmt.Sprintf("The newest of %d quotes", len(data.Quotes))
+		feed.Channel.LastBuildDate = updated.Format(time.RFC1123Z)
+		for _, quote := range quotes {
+			link := baseURL + "/quotes/" + quote.key()
+			feed.Channel.Items = append(feed.Channel.Items, rssItem{
+				Title:       feedEntryTitle(quote),
+				Link:        link,
+				GUID:        link,
+				Description: feedEntryContent(quote),
+				PubDate:     updated.Format(time.RFC1123Z),
+				Categories:  nonEmptyTags(quote.Tags),
+			})
+		}
+		doc = feed
+	case "atom":
+		feed := atomFeed{
+			Title:   opts.Title,
+			ID:      baseURL + "/",
+			Link:    atomLink{Href: baseURL + "/"},
+			Updated: updated.Format(time.RFC3339),
+			Author:  atomPerson{Name: opts.Title},
+		}
+		for _, quote := range quotes {
+			link := baseURL + "/quotes/" + quote.key()
+			entry := atomEntry{
+				Title:   feedEntryTitle(quote),
+				ID:      link,
+				Link:    atomLink{Href: link},
+				Updated: updated.Format(time.RFC3339),
+				Content: feedEntryContent(quote),
+			}
+			if quote.Author != "" {
+				entry.Author = &atomPerson{Name: quote.Author}
+			}
+			for _, tag := range nonEmptyTags(quote.Tags) {
+				entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+			}
+			feed.Entries = append(feed.Entries, entry)
+		}
+		doc = feed
+	default:
+		return fmt.Errorf("unknown feed format %q, expected rss or atom", opts.Format)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("error writing feed: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("error writing feed: %w", err)
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// feedEntryTitle uses the author when known, otherwise the start of the quote
+func feedEntryTitle(quote Quote) string {
+	if quote.Author != "" {
+		return quote.Author
+	}
+	words := strings.Fields(quote.Text)
+	if len(words) > 8 {
+		return strings.Join(words[:8], " ") + "…"
+	}
+	return strings.Join(words, " ")
+}
+
+// feedEntryContent is the quote text followed by its attribution
+func feedEntryContent(quote Quote) string {
+	if attribution := quoteAttribution(quote); attribution != "" {
+		return fmt.Sprintf("%s\n— %s", quote.Text, attribution)
+	}
+	return quote.Text
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// feedTestData returns three quotes and matching metadata
+func feedTestData() (QuotesData, Metadata) {
+	data := QuotesData{Quotes: []Quote{
+		{ID: 1, Text: "Oldest", Tags: []string{"a"}},
+		{ID: 3, Text: "Newest quote with quite a few words in it, more than eight", Tags: []string{""}},
+		{ID: 2, Text: "Middle", Author: "Someone", Tags: []string{"b", "c"}},
+	}}
+	return data, Metadata{LastUpdated: "2024-11-20T11:18:03+05:30"}
+}
+
+// TestExportFeedRSS tests ordering, limit and item fields of the RSS feed
+func TestExportFeedRSS(t *testing.T) {
+	data, metadata := feedTestData()
+	var buf bytes.Buffer
+	require.NoError(t, ExportFeed(data, metadata, FeedOptions{Format: "rss", BaseURL: "https://example.com/", Title: "Quotes", Limit: 2}, &buf))
+
+	var feed rssFeed
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &feed))
+	require.Len(t, feed.Channel.Items, 2)
+	assert.Equal(t, "https://example.com/quotes/3", feed.Channel.Items[0].Link)
+	assert.Equal(t, "Newest quote with quite a few words in…", feed.Channel.Items[0].Title)
+	assert.Empty(t, feed.Channel.Items[0].Categories)
+	assert.Equal(t, "Someone", feed.Channel.Items[1].Title)
+	assert.Equal(t, "Middle\n— Someone", feed.Channel.Items[1].Description)
+	assert.Equal(t, []string{"b", "c"}, feed.Channel.Items[1].Categories)
+	assert.Equal(t, "Wed, 20 Nov 2024 11:18:03 +0530", feed.Channel.LastBuildDate)
+}
+
+// TestExportFeedAtom tests the Atom feed entries
+func TestExportFeedAtom(t *testing.T) {
+	data, metadata := feedTestData()
+	var buf bytes.Buffer
+	require.NoError(t, ExportFeed(data, metadata, FeedOptions{Format: "atom", BaseURL: "https://example.com", Title: "Quotes"}, &buf))
+
+	var feed atomFeed
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &feed))
+	assert.Equal(t, "2024-11-20T11:18:03+05:30", feed.Updated)
+	require.Len(t, feed.Entries, 3)
+	assert.Equal(t, "https://example.com/quotes/2", feed.Entries[1].ID)
+	require.NotNil(t, feed.Entries[1].Author)
+	assert.Equal(t, "Someone", feed.Entries[1].Author.Name)
+	assert.Nil(t, feed.Entries[2].Author)
+}
+
+// TestExportFeedUUIDs tests that quotes keyed by UUID are linked by it, newest first
+func TestExportFeedUUIDs(t *testing.T) {
+	data := QuotesData{Quotes: []Quote{
+		{UUID: "0190a0e4-0000-7000-8000-000000000002", Text: "Newest"},
+		{UUID: "0190a0e4-0000-7000-8000-000000000001", Text: "Oldest"},
+	}}
+	_, metadata := feedTestData()
+	var buf bytes.Buffer
+	require.NoError(t, ExportFeed(data, metadata, FeedOptions{Format: "rss", BaseURL: "https://example.com", Title: "Quotes", Limit: 1}, &buf))
+
+	var feed rssFeed
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &feed))
+	require.Len(t, feed.Channel.Items, 1)
+	assert.Equal(t, "https://example.com/quotes/0190a0e4-0000-7000-8000-000000000002", feed.Channel.Items[0].Link)
+	assert.Equal(t, feed.Channel.Items[0].Link, feed.Channel.Items[0].GUID)
+}
+
+// TestExportFeedErrors tests invalid options and metadata
+func TestExportFeedErrors(t *testing.T) {
+	data, metadata := feedTestData()
+	var buf bytes.Buffer
+	assert.Error(t, ExportFeed(data, metadata, FeedOptions{Format: "json", BaseURL: "https://example.com"}, &buf))
+	assert.Error(t, ExportFeed(data, metadata, FeedOptions{Format: "rss"}, &buf))
+	assert.Error(t, ExportFeed(data, Metadata{LastUpdated: "yesterday"}, FeedOptions{Format: "rss", BaseURL: "https://example.com"}, &buf))
+}
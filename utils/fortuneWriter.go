@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// fortuneEncoder writes quotes in fortune(6) format: each quote, with an indented attribution
+// line when one is known, followed by a line holding only "%"
+type fortuneEncoder struct{}
+
+func (fortuneEncoder) writeQuote(w *bufio.Writer, quote Quote, index int) error {
+	for _, line := range strings.Split(strings.TrimSpace(quote.Text), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "%" {
+			line = " %" // a bare % would end the quote early
+		}
+		w.WriteString(line + "\n")
+	}
+	if attribution := quoteAttribution(quote); attribution != "" {
+		fmt.Fprintf(w, "\t\t-- %s\n", attribution)
+	}
+	_, err := w.WriteString("%\n")
+	return err
+}
+
+func (fortuneEncoder) finish(w *bufio.Writer, count int) error {
+	return nil
+}
+
+// strfile header constants, see strfile(8)
+const (
+	strfileVersion = 2
+	strfileDelim   = '%'
+)
+
+// WriteStrfileIndex writes the strfile(8) compatible index for a fortune file to fileName + ".dat",
+// so the file can be used by fortune without running strfile
+func WriteStrfileIndex(fileName string) error {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("error reading fortune file %s: %w", fileName, err)
+	}
+
+	// Each entry starts at the beginning of the file or just after a "%" line
+	var offsets []uint32
+	var longest, shortest uint32
+	start := 0
+	for pos := 0; pos < len(content); {
+		end := bytes.IndexByte(content[pos:], '\n')
+		if end < 0 {
+			end = len(content) - pos
+		}
+		if string(content[pos:pos+end]) == string(strfileDelim) {
+			length := uint32(pos - start)
+			if length > 0 {
+				offsets = append(offsets, uint32(start))
+				if length > longest {
+					longest = length
+				}
+				if shortest == 0 || length < shortest {
+					shortest = length
+				}
+			}
+			start = pos + end + 1
+		}
+		pos += end + 1
+	}
+	offsets = append(offsets, uint32(len(content))) // strfile also records the end of the last entry
+
+	var buf bytes.Buffer
+	header := []uint32{strfileVersion, uint32(len(offsets) - 1), longest, shortest, 0}
+	binary.Write(&buf, binary.BigEndian, header)
+	buf.Write([]byte{strfileDelim, 0, 0, 0})
+	binary.Write(&buf, binary.BigEndian, offsets)
+
+	if err := os.WriteFile(fileName+".dat", buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error writing strfile index: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFortuneEncoder tests the % separated layout and attribution lines
+func TestFortuneEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewQuoteStreamWriter(&buf, StreamFortune)
+	require.NoError(t, s.WriteQuote(Quote{ID: 1, Text: "Be the change.", Author: "Gandhi"}))
+	require.NoError(t, s.WriteQuote(Quote{ID: 2, Text: "Line one\n%\nLine two"}))
+	require.NoError(t, s.Close())
+
+	assert.Equal(t, "Be the change.\n\t\t-- Gandhi\n%\nLine one\n %\nLine two\n%\n", buf.String())
+}
+
+// TestWriteStrfileIndex tests the strfile header and offset table
+func TestWriteStrfileIndex(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "quotes.fortune")
+	content := "One\n%\nTwo two\n%\nThree\n%\n"
+	require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+
+	require.NoError(t, WriteStrfileIndex(file))
+	dat, err := os.ReadFile(file + ".dat")
+	require.NoError(t, err)
+
+	var header [5]uint32
+	r := bytes.NewReader(dat)
+	require.NoError(t, binary.Read(r, binary.BigEndian, &header))
+	assert.Equal(t, [5]uint32{2, 3, 8, 4, 0}, header)
+
+	delim := make([]byte, 4)
+	r.Read(delim)
+	assert.Equal(t, []byte{'%', 0, 0, 0}, delim)
+
+	offsets := make([]uint32, 4)
+	require.NoError(t, binary.Read(r, binary.BigEndian, offsets))
+	assert.Equal(t, []uint32{0, 6, 16, uint32(len(content))}, offsets)
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"cloud.google.com/go/storage"
+)
+
+// gcsUploader uploads the output files into a Google Cloud Storage bucket, under a prefix
+type gcsUploader struct {
+	client       *storage.Client
+	bucket       string
+	prefix       string
+	cacheControl string
+}
+
+// openGCSUploader opens the uploader of --to gs://bucket/path/. The credentials are the
+// Application Default Credentials: GOOGLE_APPLICATION_CREDENTIALS, those of gcloud auth
+// application-default login, or the service account of the instance or function.
+func openGCSUploader(target string, opts Options) (uploader, error) {
+	bucket, prefix := uploadBucketPath(target)
+	client, err := storage.NewClient(optsContext(opts))
+	if err != nil {
+		return nil, fmt.Errorf("error creating the Cloud Storage client: %w", err)
+	}
+	return &gcsUploader{client: client, bucket: bucket, prefix: prefix, cacheControl: opts.UploadCacheControl}, nil
+}
+
+func (u *gcsUploader) upload(ctx context.Context, files []uploadFile) error {
+	defer u.client.Close()
+	for _, file := range files {
+		if err := u.put(ctx, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *gcsUploader) location(fileName string) string {
+	return "gs://" + u.bucket + "/" + u.prefix + filepath.Base(fileName)
+}
+
+// put uploads one file, replacing the object of the same name
+func (u *gcsUploader) put(ctx context.Context, file uploadFile) error {
+	body, err := os.Open(file.path)
+	if err != nil {
+		return fmt.Errorf("error opening %s for upload: %w", file.path, err)
+	}
+	defer body.Close()
+	name := u.prefix + filepath.Base(file.path)
+	w := u.client.Bucket(u.bucket).Object(name).NewWriter(ctx)
+	w.ContentType = file.contentType
+	w.CacheControl = u.cacheControl
+	if _, err := io.Copy(w, body); err != nil {
+		w.Close()
+		return fmt.Errorf("error uploading %s to gs://%s/%s: %w", file.path, u.bucket, name, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error uploading %s to gs://%s/%s: %w", file.path, u.bucket, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gcsObject is an object uploaded to fakeGCS
+type gcsObject struct {
+	Bucket       string `json:"bucket"`
+	Name         string `json:"name"`
+	ContentType  string `json:"contentType"`
+	CacheControl string `json:"cacheControl"`
+	body         string
+}
+
+// newFakeGCS serves the multipart uploads of the Cloud Storage JSON API, pointing the client at
+// it like the storage emulator
+func newFakeGCS(t *testing.T) map[string]*gcsObject {
+	var mu sync.Mutex
+	objects := map[string]*gcsObject{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if r.Method != http.MethodPost || !strings.HasPrefix(r.URL.Path, "/upload/storage/v1/b/") || err != nil {
+			http.Error(w, "unexpected request "+r.Method+" "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		parts := multipart.NewReader(r.Body, params["boundary"])
+		var object gcsObject
+		for i := 0; i < 2; i++ {
+			part, err := parts.NextPart()
+			require.NoError(t, err)
+			content, _ := io.ReadAll(part)
+			if i == 0 {
+				require.NoError(t, json.Unmarshal(content, &object))
+			} else {
+				object.body = string(content)
+			}
+		}
+		object.Bucket = strings.Split(strings.TrimPrefix(r.URL.Path, "/upload/storage/v1/b/"), "/")[0]
+		if object.Bucket == "forbidden" {
+			http.Error(w, `{"error": {"code": 403, "message": "permission denied"}}`, http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		objects[object.Bucket+"/"+object.Name] = &object
+		mu.Unlock()
+		json.NewEncoder(w).Encode(object)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("STORAGE_EMULATOR_HOST", strings.TrimPrefix(server.URL, "http://"))
+	return objects
+}
+
+func TestConvertFileGCSUpload(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	objects := newFakeGCS(t)
+	input := filepath.Join(t.TempDir(), "quotes.csv")
+	require.NoError(t, os.WriteFile(input, []byte("Tags,Quote,Author\nwisdom,Know thyself.,Socrates\n"), 0644))
+
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(t.TempDir(), "quotes.json")
+	opts.Sinks = SinkList{"gs://quotes-app/data/"}
+	opts.UploadCacheControl = "public, max-age=60"
+	require.NoError(t, ConvertFile(input, opts))
+
+	require.Len(t, objects, 2)
+	quotes := objects["quotes-app/data/quotes.json"]
+	require.NotNil(t, quotes, "objects: %v", objects)
+	assert.Equal(t, "application/json", quotes.ContentType)
+	assert.Equal(t, "public, max-age=60", quotes.CacheControl)
+	assert.Contains(t, quotes.body, "Know thyself.")
+	require.Contains(t, objects, "quotes-app/data/quotesMetadata.json")
+	assert.Contains(t, objects["quotes-app/data/quotesMetadata.json"].body, `"totalQuotes": 1`)
+}
+
+func TestGCSUploaderErrors(t *testing.T) {
+	newFakeGCS(t)
+	u, err := openGCSUploader("//quotes-app", DefaultOptions())
+	require.NoError(t, err)
+	err = u.upload(context.Background(), []uploadFile{{path: filepath.Join(t.TempDir(), "missing.json")}})
+	assert.ErrorContains(t, err, "error opening")
+
+	file := filepath.Join(t.TempDir(), "quotes.json")
+	require.NoError(t, os.WriteFile(file, []byte("[]"), 0644))
+	u, err = openGCSUploader("//forbidden/data", DefaultOptions())
+	require.NoError(t, err)
+	err = u.upload(context.Background(), []uploadFile{{path: file, contentType: "application/json"}})
+	assert.ErrorContains(t, err, "error uploading "+file+" to gs://forbidden/data/quotes.json")
+}
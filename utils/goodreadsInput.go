@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"strings"
+)
+
+// goodreadsColumns is the header of a Goodreads quotes export
+var goodreadsColumns = []string{"quote", "author", "book", "tags"}
+
+// isGoodreadsExport reports whether header, lower-cased, is that of a Goodreads quotes export
+func isGoodreadsExport(header []string) bool {
+	for _, column := range goodreadsColumns {
+		if !containsTag(header, column) {
+			return false
+		}
+	}
+	return true
+}
+
+// readGoodreadsRows reads a Goodreads quotes export, with quote, author, book and tags columns,
+// as rows. The book is the context of the quote, and the quotation marks around the quote and
+// the comma Goodreads leaves after the author are removed; other columns, such as likes, are
+// ignored.
+func readGoodreadsRows(fileName string, opts Options) (*sliceRows, error) {
+	records, err := readCSV(fileName)
+	if err != nil || len(records) == 0 {
+		return &sliceRows{rows: records}, err
+	}
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	cell := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	rows := [][]string{quoteColumns}
+	for _, record := range records[1:] {
+		rows = append(rows, []string{
+			cell(record, "tags"),
+			unquoteText(cell(record, "quote")),
+			strings.TrimSpace(strings.TrimSuffix(cell(record, "author"), ",")),
+			"",
+			cell(record, "book"),
+			"",
+		})
+	}
+	return &sliceRows{rows: rows}, nil
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// goodreadsSample is a Goodreads quotes export
+const goodreadsSample = `Quote,Author,Book,Tags,Likes
+"“Be yourself; everyone else is already taken.”","Oscar Wilde,",,"attributed-no-source, be-yourself, honesty",163542
+"“So many books, so little time.”",Frank Zappa,,"books, humor",150388
+"“A room without books is like a body without a soul.”",Marcus Tullius Cicero,Pro Archia Poeta,books,128233
+`
+
+// TestIsGoodreadsExport tests recognising the header of a Goodreads export
+func TestIsGoodreadsExport(t *testing.T) {
+	assert.True(t, isGoodreadsExport([]string{"quote", "author", "book", "tags", "likes"}))
+	assert.False(t, isGoodreadsExport([]string{"quote", "author", "tags"}))
+	assert.False(t, isGoodreadsExport(nil))
+}
+
+// TestReadGoodreadsRows tests mapping the Goodreads columns
+func TestReadGoodreadsRows(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "goodreads.csv")
+	require.NoError(t, os.WriteFile(fileName, []byte(goodreadsSample), 0644))
+	assert.Equal(t, InputFormat("goodreads"), detectInputFormat(fileName))
+
+	rows, err := readGoodreadsRows(fileName, DefaultOptions())
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		quoteColumns,
+		{"attributed-no-source, be-yourself, honesty", "Be yourself; everyone else is already taken.", "Oscar Wilde", "", "", ""},
+		{"books, humor", "So many books, so little time.", "Frank Zappa", "", "", ""},
+		{"books", "A room without books is like a body without a soul.", "Marcus Tullius Cicero", "", "Pro Archia Poeta", ""},
+	}, rows.rows)
+
+	_, err = readGoodreadsRows(filepath.Join(t.TempDir(), "missing.csv"), DefaultOptions())
+	assert.Error(t, err)
+}
+
+// TestMergeGoodreadsFile tests merging a Goodreads library into a quotes file
+func TestMergeGoodreadsFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "goodreads.csv")
+	require.NoError(t, os.WriteFile(input, []byte(goodreadsSample), 0644))
+	into := filepath.Join(dir, "quotes.json")
+	existing := QuotesData{Quotes: []Quote{{ID: 7, Text: "So many books, so little time.", Author: "Frank Zappa", Tags: []string{"books"}, Language: "en-US"}}}
+	content, err := json.Marshal(existing)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(into, content, 0644))
+
+	opts := DefaultOptions()
+	opts.TagPolicy = tagPolicyPresets["clean"]
+	result, err := MergeFile(input, into, filepath.Join(dir, "quotesMetadata.json"), MergeByText, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Added)
+	assert.Equal(t, 1, result.Updated)
+
+	data, err := ReadQuotesFromJSON(into)
+	require.NoError(t, err)
+	require.Len(t, data.Quotes, 3)
+	assert.Equal(t, int64(7), data.Quotes[0].ID)
+	assert.Equal(t, []string{"books", "humor"}, data.Quotes[0].Tags)
+	assert.Equal(t, "Pro Archia Poeta", data.Quotes[2].Context)
+	assert.Equal(t, int64(9), data.Quotes[2].ID)
+}
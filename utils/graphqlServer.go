@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"net/http"
+	"sort"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+// graphqlSchema is the GraphQL schema of the quotes served at /graphql
+const graphqlSchema = `
+schema {
+	query: Query
+}
+
+type Query {
+	# The quotes matching every filter given, ignoring case: tag and author like /quotes,
+	# lang matching en-US for en, and text a part of the text. page counts from 1.
+	quotes(tag: String, author: String, lang: String, text: String, page: Int = 1, limit: Int = 20): QuotePage!
+	# The quote with the ID, numeric or a UUID
+	quote(id: ID!): Quote
+	# The tags of the quotes, most used first
+	tags: [Count!]!
+	# The authors of the quotes, most quoted first
+	authors: [Count!]!
+}
+
+type QuotePage {
+	quotes: [Quote!]!
+	# Number of quotes matching the filters, across pages
+	total: Int!
+	page: Int!
+	limit: Int!
+}
+
+type Quote {
+	id: ID!
+	text: String!
+	author: String
+	year: Int
+	context: String
+	tags: [String!]!
+	lang: String!
+	wordCount: Int
+}
+
+type Count {
+	name: String!
+	count: Int!
+}
+`
+
+// graphqlMaxDepth is the deepest query /graphql answers
+const graphqlMaxDepth = 8
+
+// newGraphQLHandler returns the handler answering GraphQL queries over the quotes of s
+func newGraphQLHandler(s *Server) http.Handler {
+	schema := graphql.MustParseSchema(graphqlSchema, &graphqlResolver{server: s}, graphql.MaxDepth(graphqlMaxDepth))
+	return &relay.Handler{Schema: schema}
+}
+
+// graphqlResolver resolves the Query type
+type graphqlResolver struct {
+	server *Server
+}
+
+func (r *graphqlResolver) Quotes(args struct {
+	Tag, Author, Lang, Text *string
+	Page, Limit             int32
+}) (*graphqlQuotePage, error) {
+	if err := checkPage(int(args.Page), int(args.Limit)); err != nil {
+		return nil, err
+	}
+	value := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	matching := filterQuotes(r.server.quotes, quoteFilter{Tag: value(args.Tag), Author: value(args.Author), Lang: value(args.Lang), Text: value(args.Text)})
+	return &graphqlQuotePage{quotes: pageOf(matching, int(args.Page), int(args.Limit)), total: len(matching), page: args.Page, limit: args.Limit}, nil
+}
+
+func (r *graphqlResolver) Quote(args struct{ ID graphql.ID }) *graphqlQuote {
+	quote, ok := r.server.findQuote(string(args.ID))
+	if !ok {
+		return nil
+	}
+	return &graphqlQuote{quote}
+}
+
+func (r *graphqlResolver) Tags() []graphqlCount {
+	var totals quoteTotals
+	for _, quote := range r.server.quotes {
+		totals.add(quote)
+	}
+	return sortedCounts(totals.Tags)
+}
+
+func (r *graphqlResolver) Authors() []graphqlCount {
+	var totals quoteTotals
+	for _, quote := range r.server.quotes {
+		totals.add(quote)
+	}
+	return sortedCounts(totals.Authors)
+}
+
+type graphqlQuotePage struct {
+	quotes      []Quote
+	total       int
+	page, limit int32
+}
+
+func (p *graphqlQuotePage) Quotes() []*graphqlQuote {
+	quotes := make([]*graphqlQuote, len(p.quotes))
+	for i, quote := range p.quotes {
+		quotes[i] = &graphqlQuote{quote}
+	}
+	return quotes
+}
+
+func (p *graphqlQuotePage) Total() int32 { return int32(p.total) }
+func (p *graphqlQuotePage) Page() int32  { return p.page }
+func (p *graphqlQuotePage) Limit() int32 { return p.limit }
+
+// graphqlQuote resolves the Quote type; the fields a quote doesn't have are null
+type graphqlQuote struct {
+	quote Quote
+}
+
+func (q *graphqlQuote) ID() graphql.ID { return graphql.ID(q.quote.key()) }
+
+func (q *graphqlQuote) Text() string      { return q.quote.Text }
+func (q *graphqlQuote) Author() *string   { return optionalString(q.quote.Author) }
+func (q *graphqlQuote) Year() *int32      { return optionalInt(q.quote.Year) }
+func (q *graphqlQuote) Context() *string  { return optionalString(q.quote.Context) }
+func (q *graphqlQuote) Tags() []string    { return append([]string{}, nonEmptyTags(q.quote.Tags)...) }
+func (q *graphqlQuote) Lang() string      { return q.quote.Language }
+func (q *graphqlQuote) WordCount() *int32 { return optionalInt(q.quote.WordCount) }
+
+// graphqlCount resolves the Count type
+type graphqlCount struct {
+	name  string
+	count int
+}
+
+func (c graphqlCount) Name() string { return c.name }
+func (c graphqlCount) Count() int32 { return int32(c.count) }
+
+// sortedCounts lists counts by decreasing count, then name
+func sortedCounts(counts map[string]int) []graphqlCount {
+	sorted := make([]graphqlCount, 0, len(counts))
+	for name, count := range counts {
+		sorted = append(sorted, graphqlCount{name, count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		return sorted[i].name < sorted[j].name
+	})
+	return sorted
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func optionalInt(n int) *int32 {
+	if n == 0 {
+		return nil
+	}
+	v := int32(n)
+	return &v
+}
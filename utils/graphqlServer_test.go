@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// graphqlQuery posts a query to /graphql and decodes its response
+func graphqlQuery(t *testing.T, server *Server, query string) (data map[string]any, errors []map[string]any) {
+	t.Helper()
+	body, err := json.Marshal(map[string]string{"query": query})
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var response struct {
+		Data   map[string]any   `json:"data"`
+		Errors []map[string]any `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	return response.Data, response.Errors
+}
+
+// TestGraphQLQuotes tests filtering and paging the quotes query
+func TestGraphQLQuotes(t *testing.T) {
+	server := testServer()
+	data, errors := graphqlQuery(t, server, `{ quotes(tag: "life", text: "DIEM") { total page quotes { id text author year tags } } }`)
+	require.Empty(t, errors)
+	assert.Equal(t, map[string]any{"quotes": map[string]any{
+		"total":  1.0,
+		"page":   1.0,
+		"quotes": []any{map[string]any{"id": "2", "text": "Carpe diem.", "author": "Horace", "year": nil, "tags": []any{"life", "Wisdom"}}},
+	}}, data)
+
+	data, errors = graphqlQuery(t, server, `{ quotes(lang: "en", limit: 1, page: 2) { total quotes { id } } }`)
+	require.Empty(t, errors)
+	assert.Equal(t, map[string]any{"quotes": map[string]any{"total": 2.0, "quotes": []any{map[string]any{"id": "3"}}}}, data)
+
+	_, errors = graphqlQuery(t, server, `{ quotes(limit: 1000) { total } }`)
+	require.Len(t, errors, 1)
+	assert.Contains(t, errors[0]["message"], "limit must be")
+}
+
+// TestGraphQLLookups tests the quote, tags and authors queries
+func TestGraphQLLookups(t *testing.T) {
+	server := testServer()
+	data, errors := graphqlQuery(t, server, `{ quote(id: "1") { text lang } missing: quote(id: "9") { text } tags { name count } authors { name } }`)
+	require.Empty(t, errors)
+	assert.Equal(t, map[string]any{"text": "Know thyself.", "lang": "en-US"}, data["quote"])
+	assert.Nil(t, data["missing"])
+	assert.Equal(t, []any{
+		map[string]any{"name": "life", "count": 2.0},
+		map[string]any{"name": "Wisdom", "count": 1.0},
+		map[string]any{"name": "wisdom", "count": 1.0},
+	}, data["tags"])
+	assert.Len(t, data["authors"], 3)
+
+	_, errors = graphqlQuery(t, server, `{ nothing }`)
+	assert.NotEmpty(t, errors)
+}
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"toJson/quotepb"
+)
+
+// GRPCMaxMessageSize is the largest response of the gRPC server, those of Convert holding every
+// quote of an upload of up to 64 MiB. Clients raise their limit to match, with
+// grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(GRPCMaxMessageSize)), or calls with
+// responses over gRPC's default of 4 MiB fail.
+const GRPCMaxMessageSize = 4 * serverMaxUpload
+
+// NewGRPCServer returns a gRPC server with the QuoteService of quotepb serving the quotes of
+// server, the same data as its HTTP API behind the same API keys and rate limit
+func NewGRPCServer(server *Server) *grpc.Server {
+	options := append(server.grpcGuardInterceptors(), grpc.MaxSendMsgSize(GRPCMaxMessageSize))
+	s := grpc.NewServer(options...)
+	quotepb.RegisterQuoteServiceServer(s, &quoteService{server: server})
+	return s
+}
+
+// quoteService implements quotepb.QuoteServiceServer
+type quoteService struct {
+	quotepb.UnimplementedQuoteServiceServer
+	server *Server
+}
+
+func (q *quoteService) ListQuotes(ctx context.Context, req *quotepb.ListQuotesRequest) (*quotepb.ListQuotesResponse, error) {
+	page, limit := int(req.Page), int(req.Limit)
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = serverPageSize
+	}
+	if err := checkPage(page, limit); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	matching := filterQuotes(q.server.quotes, quoteFilter{Tag: req.Tag, Author: req.Author, Lang: req.Lang})
+	return &quotepb.ListQuotesResponse{Quotes: quoteMessages(pageOf(matching, page, limit)), Total: int32(len(matching)), Page: int32(page), Limit: int32(limit)}, nil
+}
+
+func (q *quoteService) GetQuote(ctx context.Context, req *quotepb.GetQuoteRequest) (*quotepb.Quote, error) {
+	quote, ok := q.server.findQuote(req.Id)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no quote with ID %s", req.Id)
+	}
+	return quoteMessage(quote), nil
+}
+
+func (q *quoteService) SearchQuotes(ctx context.Context, req *quotepb.SearchQuotesRequest) (*quotepb.SearchQuotesResponse, error) {
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = serverPageSize
+	}
+	if req.Query == "" {
+		return nil, status.Error(codes.InvalidArgument, "query must not be empty")
+	}
+	if err := checkPage(1, limit); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	matching := filterQuotes(q.server.quotes, quoteFilter{Tag: req.Tag, Text: req.Query})
+	return &quotepb.SearchQuotesResponse{Quotes: quoteMessages(pageOf(matching, 1, limit)), Total: int32(len(matching))}, nil
+}
+
+// Convert reads the uploaded file into a temporary one named like it, so its extension picks the
+// input format, and converts it with the default options like ReadInputQuotes. It takes one of
+// the slots of POST /conversions, and is disabled with it.
+func (q *quoteService) Convert(stream grpc.ClientStreamingServer[quotepb.ConvertRequest, quotepb.ConvertResponse]) error {
+	if q.server.conversions == nil {
+		return status.Error(codes.Unimplemented, "converting uploads is disabled")
+	}
+	if !q.server.conversions.acquire() {
+		return status.Error(codes.ResourceExhausted, "too many conversions are running, try again later")
+	}
+	defer q.server.conversions.release()
+
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	options := first.GetOptions()
+	if options == nil {
+		return status.Error(codes.InvalidArgument, "the first message must hold the options")
+	}
+	opts := DefaultOptions()
+	if options.Format != "" {
+		if err := opts.InputFormat.Set(options.Format); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "toJson-upload")
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Base(options.FileName)
+	if name == "." || name == string(filepath.Separator) {
+		name = "upload"
+	}
+	fileName := filepath.Join(dir, name)
+	file, err := os.Create(fileName)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer file.Close()
+	size := 0
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		chunk := req.GetChunk()
+		if size += len(chunk); size > serverMaxUpload {
+			return status.Errorf(codes.ResourceExhausted, "the upload is larger than %d bytes", serverMaxUpload)
+		}
+		if _, err := file.Write(chunk); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+	if err := file.Close(); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	quotes, err := ReadInputQuotes(fileName, opts)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	response := &quotepb.ConvertResponse{Quotes: quoteMessages(quotes)}
+	if size := proto.Size(response); size > GRPCMaxMessageSize {
+		return status.Errorf(codes.ResourceExhausted, "the converted quotes take %d bytes, more than the %d of a response", size, GRPCMaxMessageSize)
+	}
+	return stream.SendAndClose(response)
+}
+
+// quoteMessage returns the quotepb message of a quote
+func quoteMessage(quote Quote) *quotepb.Quote {
+	return &quotepb.Quote{
+		Id:        quote.key(),
+		Text:      quote.Text,
+		Author:    quote.Author,
+		Year:      int32(quote.Year),
+		Context:   quote.Context,
+		Tags:      nonEmptyTags(quote.Tags),
+		Lang:      quote.Language,
+		WordCount: int32(quote.WordCount),
+	}
+}
+
+func quoteMessages(quotes []Quote) []*quotepb.Quote {
+	messages := make([]*quotepb.Quote, len(quotes))
+	for i, quote := range quotes {
+		messages[i] = quoteMessage(quote)
+	}
+	return messages
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+
+	"toJson/quotepb"
+)
+
+// grpcClient serves the test quotes over an in-memory connection and returns a client of it
+func grpcClient(t *testing.T) quotepb.QuoteServiceClient {
+	return grpcClientOf(t, testServer())
+}
+
+// grpcClientOf serves the quotes of s over an in-memory connection and returns a client of it
+func grpcClientOf(t *testing.T, s *Server) quotepb.QuoteServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	server := NewGRPCServer(s)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(GRPCMaxMessageSize)))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return quotepb.NewQuoteServiceClient(conn)
+}
+
+func messageIDs(quotes []*quotepb.Quote) []string {
+	ids := []string{}
+	for _, quote := range quotes {
+		ids = append(ids, quote.Id)
+	}
+	return ids
+}
+
+// TestGRPCListAndGet tests the ListQuotes and GetQuote calls
+func TestGRPCListAndGet(t *testing.T) {
+	client := grpcClient(t)
+	ctx := context.Background()
+
+	list, err := client.ListQuotes(ctx, &quotepb.ListQuotesRequest{Tag: "life"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2", "3"}, messageIDs(list.Quotes))
+	assert.Equal(t, int32(2), list.Total)
+	assert.Equal(t, int32(20), list.Limit)
+
+	list, err = client.ListQuotes(ctx, &quotepb.ListQuotesRequest{Lang: "en", Page: 2, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3"}, messageIDs(list.Quotes))
+
+	_, err = client.ListQuotes(ctx, &quotepb.ListQuotesRequest{Limit: 1000})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	quote, err := client.GetQuote(ctx, &quotepb.GetQuoteRequest{Id: "1"})
+	require.NoError(t, err)
+	assert.Equal(t, "Know thyself.", quote.Text)
+	assert.Equal(t, []string{"wisdom"}, quote.Tags)
+	_, err = client.GetQuote(ctx, &quotepb.GetQuoteRequest{Id: "9"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// TestGRPCSearch tests the SearchQuotes call
+func TestGRPCSearch(t *testing.T) {
+	client := grpcClient(t)
+	ctx := context.Background()
+
+	result, err := client.SearchQuotes(ctx, &quotepb.SearchQuotesRequest{Query: "BE"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3"}, messageIDs(result.Quotes))
+
+	result, err = client.SearchQuotes(ctx, &quotepb.SearchQuotesRequest{Query: ".", Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, result.Quotes, 1)
+	assert.Equal(t, int32(3), result.Total)
+
+	_, err = client.SearchQuotes(ctx, &quotepb.SearchQuotesRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// TestGRPCConvert tests converting a file uploaded in chunks
+func TestGRPCConvert(t *testing.T) {
+	client := grpcClient(t)
+
+	stream, err := client.Convert(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&quotepb.ConvertRequest{Payload: &quotepb.ConvertRequest_Options{Options: &quotepb.ConvertOptions{FileName: "../quotes.csv"}}}))
+	for _, chunk := range []string{"Tags,Quote,Author\nwisdom,Know thy", "self.,Socrates\n"} {
+		require.NoError(t, stream.Send(&quotepb.ConvertRequest{Payload: &quotepb.ConvertRequest_Chunk{Chunk: []byte(chunk)}}))
+	}
+	response, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	require.Len(t, response.Quotes, 1)
+	assert.Equal(t, "Know thyself.", response.Quotes[0].Text)
+	assert.Equal(t, "Socrates", response.Quotes[0].Author)
+
+	// Conversions answer with more than gRPC's default message size of 4 MiB
+	var upload strings.Builder
+	upload.WriteString("Quote\n")
+	for i := 0; upload.Len() < 5<<20; i++ {
+		fmt.Fprintf(&upload, "Quote number %d is long enough to make the response larger than four mebibytes.\n", i)
+	}
+	stream, err = client.Convert(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&quotepb.ConvertRequest{Payload: &quotepb.ConvertRequest_Options{Options: &quotepb.ConvertOptions{FileName: "large.csv"}}}))
+	data := []byte(upload.String())
+	for len(data) > 0 {
+		n := min(len(data), 1<<20)
+		require.NoError(t, stream.Send(&quotepb.ConvertRequest{Payload: &quotepb.ConvertRequest_Chunk{Chunk: data[:n]}}))
+		data = data[n:]
+	}
+	response, err = stream.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Greater(t, proto.Size(response), 4<<20)
+
+	stream, err = client.Convert(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&quotepb.ConvertRequest{Payload: &quotepb.ConvertRequest_Options{Options: &quotepb.ConvertOptions{Format: "docx"}}}))
+	_, err = stream.CloseAndRecv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Like POST /conversions, converting is disabled unless the server runs conversions
+	client = grpcClientOf(t, NewServer(QuotesData{}, ServerOptions{}))
+	stream, err = client.Convert(context.Background())
+	require.NoError(t, err)
+	_, err = stream.CloseAndRecv()
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
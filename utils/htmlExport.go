@@ -0,0 +1,227 @@
+package utils
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// htmlTemplates renders the static site; every page shares the "page" layout
+var htmlTemplates = template.Must(template.New("site").Funcs(template.FuncMap{
+	"attribution": quoteAttribution,
+}).Parse(`
+{{define "page"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+</head>
+<body>
+<header><a href="{{.Root}}index.html">{{.SiteTitle}}</a></header>
+<main>
+<h1>{{.Title}}</h1>
+{{template "content" .}}
+</main>
+</body>
+</html>
+{{end}}
+
+{{define "links"}}<ul class="links">
+{{range .}}<li><a href="{{.Href}}">{{.Name}}</a> <span class="count">{{.Count}}</span></li>
+{{end}}</ul>{{end}}
+
+{{define "quotes"}}{{$root := .Root}}{{range .Quotes}}{{$quote := .}}<figure class="quote">
+<blockquote>{{.Quote.Text}}</blockquote>
+{{with attribution .Quote}}<figcaption>— {{if $quote.AuthorHref}}<a href="{{$root}}{{$quote.AuthorHref}}">{{.}}</a>{{else}}{{.}}{{end}}</figcaption>{{end}}
+{{if .Tags}}<p class="tags">{{range .Tags}}<a href="{{$root}}{{.Href}}">#{{.Name}}</a> {{end}}</p>{{end}}
+</figure>
+{{end}}{{end}}
+`))
+
+// htmlIndexTemplate and htmlListTemplate supply the "content" block for the two kinds of page
+var (
+	htmlIndexTemplate = template.Must(template.Must(htmlTemplates.Clone()).Parse(`{{define "content"}}<p>{{.Total}} quotes</p>
+<h2>Tags</h2>
+{{template "links" .Tags}}
+{{if .Authors}}<h2>Authors</h2>
+{{template "links" .Authors}}{{end}}{{end}}`))
+	htmlListTemplate = template.Must(template.Must(htmlTemplates.Clone()).Parse(`{{define "content"}}{{template "quotes" .}}{{end}}`))
+)
+
+// htmlStyle is written to style.css
+const htmlStyle = `body { font-family: Georgia, serif; max-width: 42rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+header a { text-decoration: none; color: #666; }
+.links { columns: 3; list-style: none; padding: 0; }
+.count { color: #999; font-size: 0.8em; }
+.quote { margin: 2rem 0; }
+blockquote { white-space: pre-line; font-size: 1.2em; margin: 0; }
+figcaption { color: #555; margin-top: 0.5rem; }
+.tags a { color: #07c; font-size: 0.9em; text-decoration: none; }
+`
+
+// htmlLink is an entry in the tag and author lists
+type htmlLink struct {
+	Name  string
+	Href  string
+	Count int
+}
+
+// htmlQuote is a quote together with links to its tag and author pages
+type htmlQuote struct {
+	Quote      Quote
+	Tags       []htmlLink
+	AuthorHref string
+}
+
+// htmlPage is the data passed to the templates
+type htmlPage struct {
+	SiteTitle string
+	Title     string
+	Root      string // relative path from the page back to the site root
+	Total     int
+	Tags      []htmlLink
+	Authors   []htmlLink
+	Quotes    []htmlQuote
+}
+
+// ExportHTMLSite renders the quotes into a static site in outDir: an index listing every tag and
+// author, one page per tag under tags/ and one page per author under authors/
+func ExportHTMLSite(data QuotesData, outDir string, title string) error {
+	byTag := make(map[string][]Quote)
+	byAuthor := make(map[string][]Quote)
+	for _, quote := range data.Quotes {
+		for _, tag := range nonEmptyTags(quote.Tags) {
+			byTag[tag] = append(byTag[tag], quote)
+		}
+		if quote.Author != "" {
+			byAuthor[quote.Author] = append(byAuthor[quote.Author], quote)
+		}
+	}
+	tagLinks := groupLinks(byTag, "tags/")
+	authorLinks := groupLinks(byAuthor, "authors/")
+
+	tagHref := make(map[string]string, len(tagLinks))
+	for _, link := range tagLinks {
+		tagHref[link.Name] = link.Href
+	}
+	authorHref := make(map[string]string, len(authorLinks))
+	for _, link := range authorLinks {
+		authorHref[link.Name] = link.Href
+	}
+
+	for _, dir := range []string{outDir, filepath.Join(outDir, "tags"), filepath.Join(outDir, "authors")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("error creating directory %s: %w", dir, err)
+		}
+	}
+
+	index := htmlPage{SiteTitle: title, Title: title, Total: len(data.Quotes), Tags: tagLinks, Authors: authorLinks}
+	if err := renderHTMLPage(filepath.Join(outDir, "index.html"), htmlIndexTemplate, index); err != nil {
+		return err
+	}
+
+	render := func(links []htmlLink, groups map[string][]Quote, heading string) error {
+		for _, link := range links {
+			page := htmlPage{SiteTitle: title, Title: fmt.Sprintf(heading, link.Name), Root: "../"}
+			for _, quote := range groups[link.Name] {
+				page.Quotes = append(page.Quotes, htmlQuote{Quote: quote, Tags: quoteTagLinks(quote, tagHref), AuthorHref: authorHref[quote.Author]})
+			}
+			if err := renderHTMLPage(filepath.Join(outDir, link.Href), htmlListTemplate, page); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := render(tagLinks, byTag, "#%s"); err != nil {
+		return err
+	}
+	if err := render(authorLinks, byAuthor, "%s"); err != nil {
+		return err
+	}
+
+	// GitHub Pages would otherwise run the output through Jekyll
+	files := map[string]string{"style.css": htmlStyle, ".nojekyll": ""}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(outDir, name), []byte(content), 0644); err != nil {
+			return fmt.Errorf("error writing %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// renderHTMLPage executes a page template into filename
+func renderHTMLPage(filename string, tmpl *template.Template, page htmlPage) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", filename, err)
+	}
+	defer file.Close()
+
+	if err := tmpl.ExecuteTemplate(file, "page", page); err != nil {
+		return fmt.Errorf("error rendering %s: %w", filename, err)
+	}
+	return file.Close()
+}
+
+// groupLinks lists the groups alphabetically with a unique page for each
+func groupLinks(groups map[string][]Quote, dir string) []htmlLink {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	used := make(map[string]bool)
+	links := make([]htmlLink, 0, len(names))
+	for _, name := range names {
+		links = append(links, htmlLink{Name: name, Href: dir + uniqueSlug(name, used) + ".html", Count: len(groups[name])})
+	}
+	return links
+}
+
+// quoteTagLinks returns the tag page links of a quote
+func quoteTagLinks(quote Quote, tagHref map[string]string) []htmlLink {
+	var links []htmlLink
+	for _, tag := range nonEmptyTags(quote.Tags) {
+		links = append(links, htmlLink{Name: tag, Href: tagHref[tag]})
+	}
+	return links
+}
+
+// slugify turns a name into a lowercase, dash-separated file name
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		return "untitled"
+	}
+	return b.String()
+}
+
+// uniqueSlug slugifies name, appending a counter if another name already produced the same slug
+func uniqueSlug(name string, used map[string]bool) string {
+	slug := slugify(name)
+	candidate := slug
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d", slug, i)
+	}
+	used[candidate] = true
+	return candidate
+}
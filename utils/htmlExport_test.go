@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExportHTMLSite tests the generated pages and their links
+func TestExportHTMLSite(t *testing.T) {
+	dir := t.TempDir()
+	data := QuotesData{Quotes: []Quote{
+		{ID: 1, Text: "Be <bold>", Author: "Albert Einstein", Tags: []string{"wisdom", "life"}},
+		{ID: 2, Text: "Second", Tags: []string{"Life"}},
+		{ID: 3, Text: "Untagged", Tags: []string{""}},
+	}}
+
+	require.NoError(t, ExportHTMLSite(data, dir, "My Quotes"))
+
+	index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(index), "<p>3 quotes</p>")
+	assert.Contains(t, string(index), `<a href="tags/wisdom.html">wisdom</a>`)
+	assert.Contains(t, string(index), `<a href="authors/albert-einstein.html">Albert Einstein</a>`)
+
+	// "Life" and "life" slugify to the same name, so one of them gets a suffix
+	assert.FileExists(t, filepath.Join(dir, "tags", "life.html"))
+	assert.FileExists(t, filepath.Join(dir, "tags", "life-2.html"))
+	assert.FileExists(t, filepath.Join(dir, "style.css"))
+	assert.FileExists(t, filepath.Join(dir, ".nojekyll"))
+
+	author, err := os.ReadFile(filepath.Join(dir, "authors", "albert-einstein.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(author), "Be &lt;bold&gt;")
+	assert.Contains(t, string(author), `<a href="../tags/wisdom.html">#wisdom</a>`)
+	assert.Contains(t, string(author), `<a href="../authors/albert-einstein.html">Albert Einstein</a>`)
+}
+
+// TestSlugify tests file name generation
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "albert-einstein", slugify("Albert  Einstein"))
+	assert.Equal(t, "c", slugify("C++"))
+	assert.Equal(t, "தமிழ்", slugify("தமிழ்"))
+	assert.Equal(t, "untitled", slugify("!!!"))
+}
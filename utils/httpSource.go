@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpRetries is how many times a rate limited or unavailable request is retried
+const httpRetries = 3
+
+// httpSource fetches from the API of a remote quote source, leaving at least interval between
+// requests so imports stay within the rate limits of public APIs
+type httpSource struct {
+	client   *http.Client
+	interval time.Duration
+	headers  map[string]string // sent with every request, such as an authorization
+	last     time.Time
+}
+
+// newHTTPSource returns an httpSource waiting interval between requests
+func newHTTPSource(interval time.Duration) *httpSource {
+	return &httpSource{client: &http.Client{Timeout: 30 * time.Second}, interval: interval, headers: map[string]string{}}
+}
+
+// userAgent identifies the importer to the APIs, as their etiquette asks
+func userAgent() string {
+	return "toJson/" + generatorInfo().Version + " (quote importer)"
+}
+
+// wait sleeps until interval has passed since the previous request
+func (s *httpSource) wait() {
+	if !s.last.IsZero() {
+		time.Sleep(time.Until(s.last.Add(s.interval)))
+	}
+	s.last = time.Now()
+}
+
+// getJSON fetches url and decodes its JSON body into v
+func (s *httpSource) getJSON(url string, v any) error {
+	return s.doJSON(http.MethodGet, url, nil, v)
+}
+
+// doJSON sends a request with the JSON body, if any, and decodes the JSON response into v. A
+// request answered with 429 Too Many Requests or 503 Service Unavailable is retried after the
+// Retry-After the server asked for, or an increasing delay.
+func (s *httpSource) doJSON(method, url string, body any, v any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("error marshalling request to %s: %w", url, err)
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		s.wait()
+		req, err := http.NewRequest(method, url, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("User-Agent", userAgent())
+		req.Header.Set("Accept", "application/json")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for name, value := range s.headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("error fetching %s: %w", url, err)
+		}
+		content, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("error fetching %s: %w", url, err)
+		}
+
+		if (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) && attempt < httpRetries {
+			delay := time.Duration(1<<attempt) * time.Second
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				delay = time.Duration(seconds) * time.Second
+			}
+			time.Sleep(delay)
+			continue
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			message := strings.TrimSpace(string(content))
+			if len(message) > 200 {
+				message = message[:200] + "..."
+			}
+			return fmt.Errorf("error fetching %s: %s: %s", url, resp.Status, message)
+		}
+		if err := json.Unmarshal(content, v); err != nil {
+			return fmt.Errorf("error decoding the response of %s: %w", url, err)
+		}
+		return nil
+	}
+}
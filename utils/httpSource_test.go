@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHTTPSourceGetJSON tests fetching JSON with the importer's headers
+func TestHTTPSourceGetJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.UserAgent(), "toJson/"))
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"name": "value"}`))
+	}))
+	defer server.Close()
+
+	source := newHTTPSource(0)
+	source.headers["Authorization"] = "Bearer secret"
+	var response map[string]string
+	require.NoError(t, source.getJSON(server.URL, &response))
+	assert.Equal(t, map[string]string{"name": "value"}, response)
+}
+
+// TestHTTPSourceRetries tests retrying rate limited requests and reporting failures
+func TestHTTPSourceRetries(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/limited":
+			if requests == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Write([]byte(`{}`))
+		case "/missing":
+			http.Error(w, "no such page", http.StatusNotFound)
+		default:
+			w.Write([]byte(`not json`))
+		}
+	}))
+	defer server.Close()
+
+	source := newHTTPSource(0)
+	var response map[string]any
+	require.NoError(t, source.doJSON(http.MethodPost, server.URL+"/limited", map[string]int{"page": 1}, &response))
+	assert.Equal(t, 2, requests)
+	assert.ErrorContains(t, source.getJSON(server.URL+"/missing", &response), "404 Not Found: no such page")
+	assert.ErrorContains(t, source.getJSON(server.URL+"/invalid", &response), "error decoding")
+}
+
+// TestHTTPSourceInterval tests the wait between requests
+func TestHTTPSourceInterval(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	source := newHTTPSource(50 * time.Millisecond)
+	var response map[string]any
+	begin := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, source.getJSON(server.URL, &response))
+	}
+	assert.GreaterOrEqual(t, time.Since(begin), 100*time.Millisecond)
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// IDStrategy selects the IDs written for the quotes
+type IDStrategy string
+
+const (
+	// IDRow numbers quotes by their spreadsheet row
+	IDRow IDStrategy = "row"
+	// IDUUID keys quotes by a time-ordered UUIDv7, written as a string
+	IDUUID IDStrategy = "uuid"
+)
+
+// String implements flag.Value
+func (s *IDStrategy) String() string {
+	return string(*s)
+}
+
+// Set implements flag.Value, accepting only the supported strategies
+func (s *IDStrategy) Set(name string) error {
+	switch strategy := IDStrategy(strings.ToLower(name)); strategy {
+	case IDRow, IDUUID:
+		*s = strategy
+		return nil
+	}
+	return fmt.Errorf("unknown ID strategy %q (supported: row, uuid)", name)
+}
+
+// newQuoteUUID returns a UUIDv7. The uuid package keeps them increasing within the process, so
+// quotes assigned in output order sort in output order.
+func newQuoteUUID() (string, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate a UUID: %w", err)
+	}
+	return id.String(), nil
+}
+
+// setColumnID gives a quote the ID read from its id column, such as the one of the xlsx export,
+// when it is of the strategy in use: a number with IDRow or a UUID with IDUUID. The quote keeps
+// its row number, or gets a fresh UUID, when the ID is of the other strategy.
+func setColumnID(quote *Quote, value string, strategy IDStrategy) error {
+	if id, err := strconv.ParseInt(value, 10, 64); err == nil && id > 0 {
+		if strategy != IDUUID {
+			quote.ID = id
+		}
+		return nil
+	}
+	if id, err := uuid.Parse(value); err == nil {
+		if strategy == IDUUID {
+			quote.UUID = id.String()
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid id %q, expected a positive number or a UUID", value)
+}
+
+// key returns the ID of the quote as a string, its UUID when it has one
+func (q Quote) key() string {
+	if q.UUID != "" {
+		return q.UUID
+	}
+	return strconv.FormatInt(q.ID, 10)
+}
+
+// ref returns the ID of the quote as written to JSON
+func (q Quote) ref() any {
+	if q.UUID != "" {
+		return q.UUID
+	}
+	return q.ID
+}
+
+// quoteJSON is Quote without its JSON methods
+type quoteJSON Quote
+
+// MarshalJSON writes the UUID of the quote as its id when it has one
+func (q Quote) MarshalJSON() ([]byte, error) {
+	if q.UUID == "" {
+		return json.Marshal(quoteJSON(q))
+	}
+	// The shallower ID field hides the embedded one
+	return json.Marshal(struct {
+		ID string `json:"id"`
+		quoteJSON
+	}{ID: q.UUID, quoteJSON: quoteJSON(q)})
+}
+
+// UnmarshalJSON reads numeric IDs into ID and string IDs into UUID
+func (q *Quote) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		ID json.RawMessage `json:"id"`
+		*quoteJSON
+	}{quoteJSON: (*quoteJSON)(q)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if len(aux.ID) == 0 || string(aux.ID) == "null" {
+		return nil
+	}
+	if aux.ID[0] == '"' {
+		return json.Unmarshal(aux.ID, &q.UUID)
+	}
+	return json.Unmarshal(aux.ID, &q.ID)
+}
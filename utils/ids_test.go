@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewQuoteUUID tests that UUIDs are version 7 and increase
+func TestNewQuoteUUID(t *testing.T) {
+	previous := ""
+	for i := 0; i < 100; i++ {
+		id, err := newQuoteUUID()
+		require.NoError(t, err)
+		assert.Equal(t, uuid.Version(7), uuid.MustParse(id).Version())
+		assert.Greater(t, id, previous)
+		previous = id
+	}
+}
+
+// TestQuoteJSONIDs tests that quotes round trip with numeric and UUID IDs
+func TestQuoteJSONIDs(t *testing.T) {
+	tests := []struct {
+		name  string
+		quote Quote
+		json  string
+	}{
+		{
+			name:  "row",
+			quote: Quote{ID: 4, Text: "a", Tags: []string{"b"}, Language: "en"},
+			json:  `{"id":4,"text":"a","tags":["b"],"lang":"en"}`,
+		},
+		{
+			name:  "uuid",
+			quote: Quote{UUID: "01890a5d-ac96-774b-bcce-b302099a8057", Text: "a", Tags: []string{"b"}, Language: "en"},
+			json:  `{"id":"01890a5d-ac96-774b-bcce-b302099a8057","text":"a","tags":["b"],"lang":"en"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.quote)
+			require.NoError(t, err)
+			assert.Equal(t, tt.json, string(data))
+
+			var quote Quote
+			require.NoError(t, json.Unmarshal(data, &quote))
+			assert.Equal(t, tt.quote, quote)
+			assert.Equal(t, tt.quote.ref(), quote.ref())
+		})
+	}
+}
+
+// TestIDStrategySet tests parsing the --id-strategy flag
+func TestIDStrategySet(t *testing.T) {
+	var s IDStrategy
+	require.NoError(t, s.Set("UUID"))
+	assert.Equal(t, IDUUID, s)
+	assert.Error(t, s.Set("serial"))
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ImportOptions configures how ImportQuotes fetches from a remote source
+type ImportOptions struct {
+	API      string        // base URL of the source's API, its public API when empty
+	Interval time.Duration // time left between two requests to the API
+	Limit    int           // most quotes fetched, all of them when zero
+	Token    string        // API token of sources needing one, read from their environment variable when empty
+}
+
+// importer fetches the quotes named by the arguments of `toJson import <source>` as rows,
+// header first
+type importer struct {
+	fetch func(args []string, opts ImportOptions) (*sliceRows, error)
+	// knownFields is set when the rows are laid out under quoteColumns, so --column doesn't apply
+	knownFields bool
+}
+
+// importers are the sources ImportQuotes fetches from, by name
+var importers = map[string]importer{
+	"wikiquote": {fetch: importWikiquote, knownFields: true},
+	"quotable":  {fetch: importQuotable, knownFields: true},
+	"zenquotes": {fetch: importZenQuotes, knownFields: true},
+	"notion":    {fetch: importNotion},
+	"airtable":  {fetch: importAirtable},
+}
+
+// ImportSourceNames lists the sources ImportQuotes can fetch from, sorted
+func ImportSourceNames() []string {
+	names := make([]string, 0, len(importers))
+	for name := range importers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ImportQuotes fetches quotes from a remote source, processes them with opts like a converted
+// input and merges them into into like MergeFile
+func ImportQuotes(source string, args []string, into, metadataFile string, match MergeMatch, opts Options, importOpts ImportOptions) (MergeResult, error) {
+	start := time.Now()
+	importer, ok := importers[strings.ToLower(source)]
+	if !ok {
+		return MergeResult{}, fmt.Errorf("unknown import source %q (supported: %s)", source, strings.Join(ImportSourceNames(), ", "))
+	}
+	if importer.knownFields && len(opts.Columns) > 0 {
+		return MergeResult{}, fmt.Errorf("--column cannot be used with %s, its fields are known", source)
+	}
+	rows, err := importer.fetch(args, importOpts)
+	if err != nil {
+		return MergeResult{}, err
+	}
+	incoming, err := readQuotes(rows.rows, opts)
+	if err != nil {
+		return MergeResult{}, err
+	}
+	return mergeInto(incoming, into, metadataFile, match, opts, start)
+}
+
+// tagSlug turns a name such as "Famous Quotes" into the tag famous-quotes, as tags can't hold
+// spaces under the legacy tag policy
+func tagSlug(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), "-")
+}
+
+// fieldRows lays out records of named fields, such as the rows of a database, with one column
+// per field, sorted by name, in a header row first. A field missing from a record is empty.
+func fieldRows(records []map[string]string) [][]string {
+	names := map[string]bool{}
+	for _, record := range records {
+		for name := range record {
+			names[name] = true
+		}
+	}
+	header := make([]string, 0, len(names))
+	for name := range names {
+		header = append(header, name)
+	}
+	sort.Strings(header)
+
+	rows := [][]string{header}
+	for _, record := range records {
+		row := make([]string, len(header))
+		for i, name := range header {
+			row[i] = record[name]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
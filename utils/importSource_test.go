@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestImportQuotesErrors tests the sources and options ImportQuotes rejects
+func TestImportQuotesErrors(t *testing.T) {
+	assert.Contains(t, ImportSourceNames(), "wikiquote")
+	_, err := ImportQuotes("nowhere", nil, "quotes.json", "quotesMetadata.json", MergeByText, DefaultOptions(), ImportOptions{})
+	assert.ErrorContains(t, err, `unknown import source "nowhere"`)
+
+	opts := DefaultOptions()
+	opts.Columns = ColumnList{"text=B"}
+	_, err = ImportQuotes("wikiquote", []string{"Albert Einstein"}, "quotes.json", "quotesMetadata.json", MergeByText, opts, ImportOptions{})
+	assert.ErrorContains(t, err, "--column cannot be used")
+}
+
+// TestTagSlug tests turning names into tags
+func TestTagSlug(t *testing.T) {
+	assert.Equal(t, "famous-quotes", tagSlug(" Famous  Quotes "))
+	assert.Equal(t, "wisdom", tagSlug("Wisdom"))
+}
+
+// TestFieldRows tests laying out records with a column per field
+func TestFieldRows(t *testing.T) {
+	rows := fieldRows([]map[string]string{{"Quote": "Know thyself.", "Author": "Socrates"}, {"Quote": "Carpe diem.", "Tags": "life"}})
+	assert.Equal(t, [][]string{{"Author", "Quote", "Tags"}, {"Socrates", "Know thyself.", ""}, {"", "Carpe diem.", "life"}}, rows)
+	assert.Equal(t, [][]string{{}}, fieldRows(nil))
+}
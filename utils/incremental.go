@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// rowCacheFile returns the path of the row fingerprints belonging to an output file
+func rowCacheFile(output string) string {
+	return output + ".rows.json"
+}
+
+// rowCacheEntry is what a source row produced, nil when it was skipped
+type rowCacheEntry struct {
+	Hash   string     `json:"hash"`
+	Quote  *Quote     `json:"quote"`
+	Report *rowReport `json:"report,omitempty"`
+}
+
+// rowReport is what processing a row added to the side reports, replayed when the row is reused
+type rowReport struct {
+	Blocked *blockedQuote `json:"blocked,omitempty"`
+	PII     []string      `json:"pii,omitempty"` // kinds of personal data found
+	Typos   []typo        `json:"typos,omitempty"`
+}
+
+// rowReplayer redoes for the rows reused by the row cache what processing them did besides
+// producing their quote
+type rowReplayer interface {
+	// takeReport returns the side report of row i once it's processed, nil when there is none
+	takeReport(i int) *rowReport
+	// replayReport adds the cached side report of a row reused at row i
+	replayReport(i int, row []string, report *rowReport)
+	// renumber gives the quote of a row reused at row i the ID that row would get
+	renumber(i int, row []string, quote *Quote)
+}
+
+// rowCache remembers the quote produced by every source row in the previous --incremental run,
+// so rows whose cells didn't change are not processed again. Rows are matched by the hash of
+// their cells, so moved rows are reused too and only get the ID of their new position.
+// parse is called concurrently by the worker pool.
+type rowCache struct {
+	fingerprint string // processing options and header of the run the cache belongs to
+	parse       rowParser
+	replayer    rowReplayer
+
+	mu       sync.Mutex
+	previous map[string][]rowCacheEntry // previous results by row hash
+	hashes   map[int]string             // hashes of the rows of this run by index
+	kept     []int                      // indexes of the rows that produced a quote not written yet
+	sorted   bool                       // whether kept is in order
+	rows     []rowCacheEntry            // results of this run
+	reused   int
+}
+
+// loadRowCache reads the fingerprints of the previous run, returning an empty cache if there are none
+func loadRowCache(fileName string) (*rowCache, error) {
+	c := &rowCache{previous: make(map[string][]rowCacheEntry), hashes: make(map[int]string)}
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading row fingerprints: %w", err)
+	}
+
+	var saved struct {
+		Fingerprint string          `json:"fingerprint"`
+		Rows        []rowCacheEntry `json:"rows"`
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("error parsing row fingerprints %s: %w", fileName, err)
+	}
+	c.fingerprint = saved.Fingerprint
+	for _, row := range saved.Rows {
+		c.previous[row.Hash] = append(c.previous[row.Hash], row)
+	}
+	return c, nil
+}
+
+// start checks the fingerprint of this run, dropping the cached rows when the header or the
+// processing options changed since they were cached, and reprocesses the remaining rows with parse.
+// The side reports of the rows are kept and replayed through replayer.
+func (c *rowCache) start(fingerprint string, parse rowParser, replayer rowReplayer) {
+	if fingerprint != c.fingerprint {
+		c.previous = make(map[string][]rowCacheEntry)
+	}
+	c.fingerprint = fingerprint
+	c.parse = parse
+	c.replayer = replayer
+}
+
+// hashCells returns the fingerprint of a row or header
+func hashCells(cells []string) string {
+	data, _ := json.Marshal(cells)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// parseRow is the rowParser handed to processRows, returning the previous result of unchanged rows
+func (c *rowCache) parseRow(i int, row []string) (Quote, bool) {
+	if row == nil {
+		// Blank rows are cheap and never cached
+		quote, ok := c.parse(i, row)
+		return c.keep(i, quote, ok)
+	}
+	hash := hashCells(row)
+
+	c.mu.Lock()
+	c.hashes[i] = hash
+	candidates := c.previous[hash]
+	if len(candidates) > 0 {
+		c.previous[hash] = candidates[1:]
+		c.reused++
+	}
+	c.mu.Unlock()
+
+	if len(candidates) > 0 {
+		entry := candidates[0]
+		if entry.Report != nil {
+			c.replayer.replayReport(i, row, entry.Report)
+		}
+		if entry.Quote == nil {
+			c.record(hash, nil, c.replayer.takeReport(i))
+			return Quote{}, false
+		}
+		quote := *entry.Quote
+		c.replayer.renumber(i, row, &quote)
+		return c.keep(i, quote, true)
+	}
+
+	quote, ok := c.parse(i, row)
+	if !ok {
+		c.record(hash, nil, c.replayer.takeReport(i))
+	}
+	return c.keep(i, quote, ok)
+}
+
+// keep remembers that row i produced a quote, for written, and returns the result of the row
+func (c *rowCache) keep(i int, quote Quote, ok bool) (Quote, bool) {
+	if ok {
+		c.mu.Lock()
+		c.kept = append(c.kept, i)
+		c.sorted = false
+		c.mu.Unlock()
+	}
+	return quote, ok
+}
+
+// written records a quote once its final ID is assigned. Quotes are written in row order, so it
+// is the quote of the first row kept and not written yet, whatever its ID.
+func (c *rowCache) written(quote Quote) {
+	c.mu.Lock()
+	if len(c.kept) == 0 {
+		c.mu.Unlock()
+		return
+	}
+	if !c.sorted {
+		sort.Ints(c.kept)
+		c.sorted = true
+	}
+	i := c.kept[0]
+	c.kept = c.kept[1:]
+	hash, ok := c.hashes[i]
+	c.mu.Unlock()
+	if ok {
+		report := c.replayer.takeReport(i)
+		if report != nil && report.Blocked != nil {
+			// Reused, the row's quote keeps the UUID it was written with, and so does its review
+			blocked := *report.Blocked
+			blocked.Quote.ID, blocked.Quote.UUID = quote.ID, quote.UUID
+			report.Blocked = &blocked
+		}
+		c.record(hash, &quote, report)
+	}
+}
+
+// record adds the result and side report of a row to the fingerprints of this run
+func (c *rowCache) record(hash string, quote *Quote, report *rowReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rows = append(c.rows, rowCacheEntry{Hash: hash, Quote: quote, Report: report})
+}
+
+// save atomically replaces the fingerprints file with the results of this run
+func (c *rowCache) save(fileName string) error {
+	data, err := json.Marshal(struct {
+		Fingerprint string          `json:"fingerprint"`
+		Rows        []rowCacheEntry `json:"rows"`
+	}{Fingerprint: c.fingerprint, Rows: c.rows})
+	if err != nil {
+		return fmt.Errorf("error marshalling row fingerprints: %w", err)
+	}
+	tmp := fileName + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error writing row fingerprints: %w", err)
+	}
+	if err := os.Rename(tmp, fileName); err != nil {
+		return fmt.Errorf("error writing row fingerprints: %w", err)
+	}
+	return nil
+}
+
+// processingFingerprint hashes everything besides the row itself that decides what a row turns
+// into: the header, the processing options and the contents of the files they name
+func processingFingerprint(header []string, opts Options) (string, error) {
+	settings := struct {
+		Header             []string
+		Language           string
+		Columns            ColumnList
+		KeepAttribution    bool
+		TagPolicy          TagPolicy
+		Normalize          NormalizeList
+		KeepInvisible      bool
+		Validation         ValidationRules
+		DropFilteredQuotes bool
+		BlocklistAction    BlocklistAction
+		PII                PIIAction
+		Sentiment          bool
+		IDStrategy         IDStrategy
+		Translator         string // the translations of reused quotes are kept, unless they came from another translator
+		TranslatorURL      string
+		Files              map[string]string
+	}{
+		Header: header, Language: opts.Language, Columns: opts.Columns, KeepAttribution: opts.KeepAttribution,
+		TagPolicy: opts.TagPolicy, Normalize: opts.Normalize, KeepInvisible: opts.KeepInvisible, Validation: opts.Validation,
+		DropFilteredQuotes: opts.DropFilteredQuotes, BlocklistAction: opts.BlocklistAction, PII: opts.PII,
+		Sentiment: opts.Sentiment, IDStrategy: opts.IDStrategy, Translator: opts.Translator, TranslatorURL: opts.TranslatorURL,
+		Files: make(map[string]string),
+	}
+	for name, file := range map[string]string{
+		"authorAliases": opts.AuthorAliases, "tagAliases": opts.TagAliases, "tagRules": opts.TagRules,
+		"allowedTags": opts.AllowedTags, "bannedTags": opts.BannedTags, "blocklist": opts.Blocklist, "taxonomy": opts.Taxonomy,
+		"spellcheck": opts.Spellcheck, "spellcheckIgnore": opts.SpellcheckIgnore,
+	} {
+		if file == "" {
+			continue
+		}
+		sum, err := fileSHA256(file)
+		if err != nil {
+			return "", err
+		}
+		settings.Files[name] = sum
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIncrementalConversion tests that unchanged rows are reused and changed ones reprocessed
+func TestIncrementalConversion(t *testing.T) {
+	f, _ := createTestExcelFile(t)
+	defer os.Remove("quotesMetadata.json")
+
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(t.TempDir(), "quotes.json")
+	opts.Incremental = true
+	opts.IDStrategy = IDUUID
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	first, err := ReadQuotesFromJSON(opts.Output)
+	require.NoError(t, err)
+	require.FileExists(t, rowCacheFile(opts.Output))
+
+	// Edit the second quote and move the third one down a row
+	require.NoError(t, f.SetCellValue("Sheet1", "B3", "Edited quote 2"))
+	require.NoError(t, f.SetSheetRow("Sheet1", "A4", &[]any{"", ""}))
+	require.NoError(t, f.SetSheetRow("Sheet1", "A5", &[]any{"wisdom, life, philosophy", "Test quote 3"}))
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	second, err := ReadQuotesFromJSON(opts.Output)
+	require.NoError(t, err)
+
+	require.Len(t, second.Quotes, 3)
+	assert.Equal(t, first.Quotes[0], second.Quotes[0])
+	assert.Equal(t, "Edited quote 2", second.Quotes[1].Text)
+	assert.NotEqual(t, first.Quotes[1].UUID, second.Quotes[1].UUID)
+	assert.Equal(t, first.Quotes[2].UUID, second.Quotes[2].UUID)
+	assert.Equal(t, "Test quote 3", second.Quotes[2].Text)
+
+	cache, err := loadRowCache(rowCacheFile(opts.Output))
+	require.NoError(t, err)
+	assert.Len(t, cache.previous, 3)
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	opts.Encrypt = RecipientList{identity.Recipient()}
+	assert.ErrorContains(t, ReadExcelFileWithOptions(f, opts), "--incremental cannot be combined with --encrypt-recipient")
+}
+
+// TestIncrementalBlocklistReview tests that blocked rows reused from the cache are still reviewed
+func TestIncrementalBlocklistReview(t *testing.T) {
+	f, _ := createTestExcelFile(t)
+	defer os.Remove("quotesMetadata.json")
+
+	dir := t.TempDir()
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(dir, "quotes.json")
+	opts.Incremental = true
+	opts.Blocklist = filepath.Join(dir, "blocklist.txt")
+	opts.BlocklistReview = filepath.Join(dir, "blocked.json")
+	require.NoError(t, os.WriteFile(opts.Blocklist, []byte("philosophy\n"), 0644))
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	first, err := os.ReadFile(opts.BlocklistReview)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Remove(opts.BlocklistReview))
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	second, err := os.ReadFile(opts.BlocklistReview)
+	require.NoError(t, err)
+	assert.Contains(t, string(second), `"Test quote 3"`)
+	assert.JSONEq(t, string(first), string(second))
+
+	data, err := ReadQuotesFromJSON(opts.Output)
+	require.NoError(t, err)
+	assert.Len(t, data.Quotes, 2)
+}
+
+// TestIncrementalSpellcheckReport tests that the typos of rows reused from the cache are still reported
+func TestIncrementalSpellcheckReport(t *testing.T) {
+	f, _ := createTestExcelFile(t)
+	defer os.Remove("quotesMetadata.json")
+
+	dir := t.TempDir()
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(dir, "quotes.json")
+	opts.Incremental = true
+	opts.Spellcheck = filepath.Join(dir, "words.txt")
+	opts.SpellcheckReport = filepath.Join(dir, "typos.json")
+	require.NoError(t, os.WriteFile(opts.Spellcheck, []byte("test\n"), 0644))
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	first, err := os.ReadFile(opts.SpellcheckReport)
+	require.NoError(t, err)
+	assert.Contains(t, string(first), `"word": "quote"`)
+
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	second, err := os.ReadFile(opts.SpellcheckReport)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(first), string(second))
+}
+
+// TestIncrementalColumnIDs tests that reused rows keep the ID of their id column when they move
+func TestIncrementalColumnIDs(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	dir := t.TempDir()
+	input := filepath.Join(dir, "quotes.csv")
+	require.NoError(t, os.WriteFile(input, []byte("Tags,Quote,ID\nlove,Love is patient.,7\nhope,Hope floats.,3\n"), 0644))
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(dir, "quotes.json")
+	opts.Incremental = true
+	require.NoError(t, ConvertFile(input, opts))
+
+	require.NoError(t, os.WriteFile(input, []byte("Tags,Quote,ID\nnew,Brand new.,\nlove,Love is patient.,7\nhope,Hope floats.,3\n"), 0644))
+	require.NoError(t, ConvertFile(input, opts))
+	data, err := ReadQuotesFromJSON(opts.Output)
+	require.NoError(t, err)
+	require.Len(t, data.Quotes, 3)
+	assert.Equal(t, []int64{1, 7, 3}, []int64{data.Quotes[0].ID, data.Quotes[1].ID, data.Quotes[2].ID})
+
+	cache, err := loadRowCache(rowCacheFile(opts.Output))
+	require.NoError(t, err)
+	love := cache.previous[hashCells([]string{"love", "Love is patient.", "7"})]
+	require.Len(t, love, 1)
+	assert.Equal(t, "Love is patient.", love[0].Quote.Text, "quotes are cached under the hash of their own row")
+}
+
+// TestRowCacheFingerprint tests that changing the processing options drops the cached rows
+func TestRowCacheFingerprint(t *testing.T) {
+	cached := rowCacheEntry{Hash: hashCells([]string{"a", "b"}), Quote: &Quote{Text: "cached"}}
+	cache := &rowCache{previous: map[string][]rowCacheEntry{cached.Hash: {cached}}, hashes: make(map[int]string)}
+	parsed := 0
+	parse := func(i int, row []string) (Quote, bool) {
+		parsed++
+		return Quote{ID: int64(i), Text: row[1]}, true
+	}
+
+	header := []string{"Tags", "Quote"}
+	fingerprint, err := processingFingerprint(header, DefaultOptions())
+	require.NoError(t, err)
+	opts := DefaultOptions()
+	opts.Sentiment = true
+	changed, err := processingFingerprint(header, opts)
+	require.NoError(t, err)
+	assert.NotEqual(t, fingerprint, changed)
+
+	cache.fingerprint = fingerprint
+	cache.start(changed, parse, newRowProcessor(DefaultOptions()))
+	quote, ok := cache.parseRow(1, []string{"a", "b"})
+	require.True(t, ok)
+	assert.Equal(t, "b", quote.Text)
+	assert.Equal(t, 1, parsed)
+	assert.Equal(t, 0, cache.reused)
+
+	// The spellcheck dictionary is part of the fingerprint, contents included
+	opts = DefaultOptions()
+	opts.Spellcheck = filepath.Join(t.TempDir(), "words.txt")
+	require.NoError(t, os.WriteFile(opts.Spellcheck, []byte("love\n"), 0644))
+	spellchecked, err := processingFingerprint(header, opts)
+	require.NoError(t, err)
+	assert.NotEqual(t, fingerprint, spellchecked)
+	require.NoError(t, os.WriteFile(opts.Spellcheck, []byte("love\nhope\n"), 0644))
+	edited, err := processingFingerprint(header, opts)
+	require.NoError(t, err)
+	assert.NotEqual(t, spellchecked, edited)
+
+	_, err = processingFingerprint(header, Options{TagRules: filepath.Join(t.TempDir(), "missing.yaml")})
+	assert.Error(t, err)
+}
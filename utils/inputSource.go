@@ -0,0 +1,250 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// quoteColumns is the header of the rows made from quotes, by the JSON input and the xlsx
+// export; every name is recognised by newColumnMap, so the rows parse back into the same fields
+var quoteColumns = []string{"Tags", "Quote", "Author", "Year", "Context", "Language"}
+
+// rowReader iterates the rows of an input, header first
+type rowReader interface {
+	Next() bool
+	Columns() ([]string, error)
+	Error() error
+	Close() error
+}
+
+// excelRows is a rowReader over the rows of a sheet
+type excelRows struct {
+	rows *excelize.Rows
+}
+
+func (r excelRows) Next() bool                 { return r.rows.Next() }
+func (r excelRows) Columns() ([]string, error) { return r.rows.Columns() }
+func (r excelRows) Error() error               { return r.rows.Error() }
+func (r excelRows) Close() error               { return r.rows.Close() }
+
+// sliceRows is a rowReader over rows held in memory
+type sliceRows struct {
+	rows [][]string
+	next int
+}
+
+func (r *sliceRows) Next() bool {
+	r.next++
+	return r.next <= len(r.rows)
+}
+
+func (r *sliceRows) Columns() ([]string, error) { return r.rows[r.next-1], nil }
+func (r *sliceRows) Error() error               { return nil }
+func (r *sliceRows) Close() error               { return nil }
+
+// quoteCells lays out a quote as a row under quoteColumns. Tags are joined with ", ", which
+// the legacy and clean tag policies both split again.
+func quoteCells(quote Quote) []string {
+	year := ""
+	if quote.Year != 0 {
+		year = strconv.Itoa(quote.Year)
+	}
+	return []string{strings.Join(nonEmptyTags(quote.Tags), ", "), quote.Text, quote.Author, year, quote.Context, quote.Language}
+}
+
+// quoteRows turns quotes into rows, header first, so they can be processed like a sheet
+func quoteRows(quotes []Quote) *sliceRows {
+	rows := make([][]string, 0, len(quotes)+1)
+	rows = append(rows, quoteColumns)
+	for _, quote := range quotes {
+		rows = append(rows, quoteCells(quote))
+	}
+	return &sliceRows{rows: rows}
+}
+
+// InputFormat selects how the input of a conversion is read
+type InputFormat string
+
+// InputXLSX reads the first sheet of a spreadsheet, the default for unknown extensions
+const InputXLSX InputFormat = "xlsx"
+
+// inputReader reads an input other than a spreadsheet into rows, header first
+type inputReader struct {
+	title string // name of the format in messages
+	read  func(fileName string, opts Options) (*sliceRows, error)
+	// knownFields is set when the rows are laid out under quoteColumns, so --column doesn't apply
+	knownFields bool
+}
+
+// inputReaders are the readers of every InputFormat but InputXLSX, by their --from name
+var inputReaders = map[InputFormat]inputReader{
+	"json":      {title: "JSON", read: readJSONRows, knownFields: true},
+	"yaml":      {title: "YAML", read: readCollectionRows("YAML"), knownFields: true},
+	"toml":      {title: "TOML", read: readCollectionRows("TOML"), knownFields: true},
+	"markdown":  {title: "Markdown", read: readMarkdownRows, knownFields: true},
+	"text":      {title: "text", read: readTextRows, knownFields: true},
+	"kindle":    {title: "Kindle", read: readKindleRows, knownFields: true},
+	"csv":       {title: "CSV", read: readCSVRows},
+	"goodreads": {title: "Goodreads", read: readGoodreadsRows, knownFields: true},
+	"readwise":  {title: "Readwise", read: readReadwiseRows, knownFields: true},
+}
+
+// InputFormatNames lists the --from names, sorted
+func InputFormatNames() []string {
+	names := []string{string(InputXLSX)}
+	for format := range inputReaders {
+		names = append(names, string(format))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String implements flag.Value
+func (f *InputFormat) String() string {
+	return string(*f)
+}
+
+func (f *InputFormat) Set(name string) error {
+	format := InputFormat(strings.ToLower(name))
+	if _, ok := inputReaders[format]; !ok && format != InputXLSX {
+		return unknownInputFormat(name)
+	}
+	*f = format
+	return nil
+}
+
+func unknownInputFormat(name string) error {
+	return fmt.Errorf("unknown input format %q (supported: %s)", name, strings.Join(InputFormatNames(), ", "))
+}
+
+// detectInputFormat picks the InputFormat of fileName by its extension, and tells Kindle
+// clippings and Goodreads and Readwise exports from other text, CSV and JSON files by their
+// content. Unknown extensions are read as spreadsheets.
+func detectInputFormat(fileName string) InputFormat {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".json":
+		if isReadwiseJSON(fileName) {
+			return "readwise"
+		}
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	case ".md", ".markdown":
+		return "markdown"
+	case ".txt", ".text", ".fortune":
+		if isKindleClippings(fileName) {
+			return "kindle"
+		}
+		return "text"
+	case ".csv":
+		switch header := csvHeader(fileName); {
+		case isGoodreadsExport(header):
+			return "goodreads"
+		case isReadwiseCSV(header):
+			return "readwise"
+		}
+		return "csv"
+	}
+	return InputXLSX
+}
+
+// inputFormat returns opts.InputFormat, or the format detected from fileName when it is empty
+func inputFormat(fileName string, opts Options) InputFormat {
+	if opts.InputFormat != "" {
+		return opts.InputFormat
+	}
+	return detectInputFormat(fileName)
+}
+
+// readInputRows reads fileName, in a format other than InputXLSX, into rows
+func readInputRows(fileName string, format InputFormat, opts Options) (rows *sliceRows, err error) {
+	_, span := startSpan(opts, "open input", attribute.String("input.file", fileName))
+	defer func() { endSpan(span, err) }()
+	reader, ok := inputReaders[format]
+	if !ok {
+		return nil, unknownInputFormat(string(format))
+	}
+	if reader.knownFields && len(opts.Columns) > 0 {
+		return nil, fmt.Errorf("--column cannot be used with %s input, its fields are known", reader.title)
+	}
+	return reader.read(fileName, opts)
+}
+
+// ConvertFile converts fileName into the outputs configured in opts, reading it as
+// opts.InputFormat or, when that is empty, by its extension: a spreadsheet, a YAML or TOML quote
+// collection, a Markdown draft, a plain-text file, Kindle clippings, a CSV file such as a
+// Goodreads export, a Readwise export, or a quotes.json written by an earlier conversion, whose quotes are processed
+// again as if they were rows. Quotes of inputs other than spreadsheets are numbered in the order
+// they appear.
+func ConvertFile(fileName string, opts Options) (err error) {
+	opts, span := startSpan(opts, "convert", attribute.String("input.file", fileName))
+	defer func() { endSpan(span, err) }()
+	if len(opts.Webhooks) > 0 {
+		report := trackWebhook(fileName, &opts)
+		defer func() {
+			// A failed notification fails a conversion that succeeded, or it would go unnoticed
+			if notifyErr := report.notify(err, opts); notifyErr != nil && err == nil {
+				err = notifyErr
+			} else if notifyErr != nil {
+				log.Print(notifyErr)
+			}
+		}()
+	}
+	format := inputFormat(fileName, opts)
+	span.SetAttributes(attribute.String("input.format", string(format)))
+	if format == InputXLSX {
+		return ReadQuotesFromExcelWithOptions(fileName, opts)
+	}
+	rows, err := readInputRows(fileName, format, opts)
+	if err != nil {
+		return err
+	}
+	// Unless other IDs were asked for, the IDs of a JSON conversion are kept by matching the text
+	if format == "json" && opts.PreserveIDs == "" && !opts.Resume && !opts.Incremental {
+		log.Printf("Keeping the IDs of the quotes in %s", fileName)
+		opts.PreserveIDs = fileName
+	}
+	return convertRows(rows, string(format), fileName, opts)
+}
+
+// ReadInputQuotes processes the quotes of fileName like ConvertFile, but returns them instead of
+// writing them. IDs are the row numbers and the output options are ignored.
+func ReadInputQuotes(fileName string, opts Options) (quotes []Quote, err error) {
+	opts, span := startSpan(opts, "read quotes", attribute.String("input.file", fileName))
+	defer func() { endSpan(span, err) }()
+	format := inputFormat(fileName, opts)
+	span.SetAttributes(attribute.String("input.format", string(format)))
+	if format == InputXLSX {
+		_, openSpan := startSpan(opts, "open input", attribute.String("input.file", fileName))
+		file, err := OpenExcelFile(fileName)
+		endSpan(openSpan, err)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return ReadExcelQuotes(file, opts)
+	}
+	rows, err := readInputRows(fileName, format, opts)
+	if err != nil {
+		return nil, err
+	}
+	return readQuotes(rows.rows, opts)
+}
+
+// readJSONRows reads the quotes of a JSON conversion as rows
+func readJSONRows(fileName string, opts Options) (*sliceRows, error) {
+	data, err := ReadQuotesFromJSON(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return quoteRows(data.Quotes), nil
+}
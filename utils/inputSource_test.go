@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestQuoteRows tests laying out quotes as rows under the header
+func TestQuoteRows(t *testing.T) {
+	rows := quoteRows([]Quote{
+		{ID: 4, Text: "Know thyself.", Tags: []string{"wisdom", "self"}, Author: "Socrates", Year: -400, Context: "Delphi", Language: "en-US"},
+		{ID: 9, Text: "Carpe diem.", Tags: []string{""}, Language: "la"},
+	})
+	var read [][]string
+	for rows.Next() {
+		row, err := rows.Columns()
+		require.NoError(t, err)
+		read = append(read, row)
+	}
+	assert.NoError(t, rows.Error())
+	assert.Equal(t, [][]string{
+		quoteColumns,
+		{"wisdom, self", "Know thyself.", "Socrates", "-400", "Delphi", "en-US"},
+		{"", "Carpe diem.", "", "", "", "la"},
+	}, read)
+}
+
+// TestConvertJSONFile tests re-processing an earlier conversion, keeping its IDs
+func TestConvertJSONFile(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	input := filepath.Join(t.TempDir(), "quotes.json")
+	require.NoError(t, WriteJSONToFile(input, QuotesData{Quotes: []Quote{
+		{ID: 7, Text: "  Know   thyself. ", Tags: []string{"wisdom"}, Author: "Socrates", Year: -400, Language: "en-US"},
+		{ID: 12, Text: "Carpe diem.", Tags: []string{""}, Context: "Odes", Language: "la"},
+		{ID: 13, Text: "x", Tags: []string{""}, Language: "en-US"},
+	}}))
+
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(t.TempDir(), "out.json")
+	require.NoError(t, opts.Normalize.Set("whitespace,trim"))
+	opts.Validation.MinLength = 2
+	require.NoError(t, ConvertFile(input, opts))
+
+	data, err := ReadQuotesFromJSON(opts.Output)
+	require.NoError(t, err)
+	require.Len(t, data.Quotes, 2)
+	assert.Equal(t, int64(7), data.Quotes[0].ID)
+	assert.Equal(t, "Know thyself.", data.Quotes[0].Text)
+	assert.Equal(t, "Socrates", data.Quotes[0].Author)
+	assert.Equal(t, -400, data.Quotes[0].Year)
+	assert.Equal(t, []string{"wisdom"}, data.Quotes[0].Tags)
+	assert.Equal(t, int64(12), data.Quotes[1].ID)
+	assert.Equal(t, "Odes", data.Quotes[1].Context)
+	assert.Equal(t, "la", data.Quotes[1].Language)
+	assert.Equal(t, []string{""}, data.Quotes[1].Tags)
+
+	metadata, err := ReadMetadataFromJSON("quotesMetadata.json")
+	require.NoError(t, err)
+	require.NotNil(t, metadata.Source)
+	assert.Equal(t, "quotes.json", metadata.Source.File)
+
+	require.NoError(t, opts.Columns.Set("text=B"))
+	assert.Error(t, ConvertFile(input, opts))
+	assert.Error(t, ConvertFile(filepath.Join(t.TempDir(), "missing.json"), DefaultOptions()))
+}
+
+// TestDetectInputFormat tests picking the reader of an input by its file
+func TestDetectInputFormat(t *testing.T) {
+	dir := t.TempDir()
+	clippings := filepath.Join(dir, "My Clippings.txt")
+	require.NoError(t, os.WriteFile(clippings, []byte("Book (Author)\n- Your Highlight on page 1\n\nText\n==========\n"), 0644))
+	text := filepath.Join(dir, "legacy.txt")
+	require.NoError(t, os.WriteFile(text, []byte("Text\n%\n"), 0644))
+
+	tests := map[string]InputFormat{
+		"quotes.xlsx":   "xlsx",
+		"quotes":        "xlsx",
+		"quotes.JSON":   "json",
+		"quotes.yml":    "yaml",
+		"quotes.toml":   "toml",
+		"drafts.md":     "markdown",
+		"missing.txt":   "text",
+		text:            "text",
+		clippings:       "kindle",
+		"fortunes.text": "text",
+		"quotes.csv":    "csv",
+	}
+	for fileName, want := range tests {
+		assert.Equal(t, want, detectInputFormat(fileName), fileName)
+	}
+}
+
+// TestInputFormatSet tests the --from flag
+func TestInputFormatSet(t *testing.T) {
+	var format InputFormat
+	require.NoError(t, format.Set("Kindle"))
+	assert.Equal(t, InputFormat("kindle"), format)
+	assert.ErrorContains(t, format.Set("docx"), "supported: csv, goodreads, json, kindle, markdown, readwise, text, toml, xlsx, yaml")
+	assert.Contains(t, InputFormatNames(), "yaml")
+}
+
+// TestConvertFileInputFormat tests that --from overrides the extension
+func TestConvertFileInputFormat(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	input := filepath.Join(t.TempDir(), "quotes.list")
+	require.NoError(t, os.WriteFile(input, []byte("Know thyself.\n— Socrates\n"), 0644))
+
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(t.TempDir(), "out.json")
+	opts.InputFormat = "text"
+	require.NoError(t, ConvertFile(input, opts))
+	data, err := ReadQuotesFromJSON(opts.Output)
+	require.NoError(t, err)
+	require.Len(t, data.Quotes, 1)
+	assert.Equal(t, "Socrates", data.Quotes[0].Author)
+
+	opts.InputFormat = "docx"
+	assert.ErrorContains(t, ConvertFile(input, opts), "unknown input format")
+}
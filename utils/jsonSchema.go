@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// jsonSchemaDialect is the JSON Schema version the generated documents declare
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// SchemaRoots lists the documents JSONSchema can describe, by the --type of `toJson schema`
+var SchemaRoots = []string{"quotes", "metadata", "combined"}
+
+// fieldSchemas replaces the reflected schema of fields whose JSON isn't described by their Go
+// type, keyed by type name and JSON field name
+var fieldSchemas = map[string]map[string]any{
+	"Quote": {
+		"id": map[string]any{
+			"description": "spreadsheet row number, or a UUIDv7 string with --id-strategy uuid",
+			"oneOf": []any{
+				map[string]any{"type": "integer"},
+				map[string]any{"type": "string", "format": "uuid"},
+			},
+		},
+		"relatedIds": map[string]any{
+			"description": "IDs of the most similar quotes, the most similar first, with --related",
+			"type":        "array",
+			"items": map[string]any{"oneOf": []any{
+				map[string]any{"type": "integer"},
+				map[string]any{"type": "string", "format": "uuid"},
+			}},
+		},
+	},
+	"Sentiment": {
+		"label": map[string]any{"type": "string", "enum": []any{"positive", "neutral", "negative"}},
+		"score": map[string]any{"type": "number", "minimum": -1, "maximum": 1},
+	},
+}
+
+// JSONSchema returns a JSON Schema document for root, one of SchemaRoots: quotes describes
+// quotes.json, metadata quotesMetadata.json and combined the file written by --embed-metadata.
+// The schema is reflected from the Go structs, so it can't drift from what is written.
+func JSONSchema(root string) ([]byte, error) {
+	b := schemaBuilder{defs: map[string]any{}}
+	quotes, metadata := b.typeSchema(reflect.TypeOf(QuotesData{})), b.typeSchema(reflect.TypeOf(Metadata{}))
+
+	document := map[string]any{"$schema": jsonSchemaDialect, "$defs": b.defs}
+	switch root {
+	case "quotes":
+		document["title"] = "Quotes"
+		document["$ref"] = quotes["$ref"]
+	case "metadata":
+		document["title"] = "Metadata"
+		document["$ref"] = metadata["$ref"]
+	case "combined":
+		document["title"] = "Quotes with embedded metadata"
+		document["type"] = "object"
+		document["properties"] = map[string]any{"metadata": metadata, "quotes": b.typeSchema(reflect.TypeOf([]Quote{}))}
+		document["required"] = []string{"metadata", "quotes"}
+	default:
+		return nil, fmt.Errorf("unknown schema type %q (supported: %s)", root, strings.Join(SchemaRoots, ", "))
+	}
+	return json.MarshalIndent(document, "", "  ")
+}
+
+// schemaBuilder collects the definitions of the named structs a schema refers to
+type schemaBuilder struct {
+	defs map[string]any
+}
+
+// typeSchema returns the schema of values of type t, referring to named structs by $ref
+func (b *schemaBuilder) typeSchema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return b.typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": b.typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		if _, ok := b.defs[t.Name()]; !ok {
+			b.defs[t.Name()] = nil // reserve the name, in case the struct refers to itself
+			b.defs[t.Name()] = b.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	}
+	return map[string]any{}
+}
+
+// structSchema describes the JSON object of struct type t. Fields without omitempty are
+// always written, so they are required.
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		if schema, ok := fieldSchemas[t.Name()][name]; ok {
+			properties[name] = schema
+		} else {
+			properties[name] = b.typeSchema(field.Type)
+		}
+		if !strings.Contains(","+options+",", ",omitempty,") {
+			required = append(required, name)
+		}
+	}
+	return map[string]any{"type": "object", "properties": properties, "required": required}
+}
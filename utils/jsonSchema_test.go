@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// schemaDocument is the part of a generated schema the tests inspect
+type schemaDocument struct {
+	Schema     string                    `json:"$schema"`
+	Ref        string                    `json:"$ref"`
+	Properties map[string]map[string]any `json:"properties"`
+	Defs       map[string]struct {
+		Properties map[string]map[string]any `json:"properties"`
+		Required   []string                  `json:"required"`
+	} `json:"$defs"`
+}
+
+// TestJSONSchema tests the definitions reflected from the Go structs
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema("quotes")
+	require.NoError(t, err)
+	var document schemaDocument
+	require.NoError(t, json.Unmarshal(data, &document))
+	assert.Equal(t, jsonSchemaDialect, document.Schema)
+	assert.Equal(t, "#/$defs/QuotesData", document.Ref)
+
+	quote := document.Defs["Quote"]
+	assert.Equal(t, []string{"id", "text", "tags", "lang"}, quote.Required)
+	assert.Equal(t, "string", quote.Properties["text"]["type"])
+	assert.Equal(t, "integer", quote.Properties["year"]["type"])
+	assert.Equal(t, "#/$defs/Sentiment", quote.Properties["sentiment"]["$ref"])
+	assert.Contains(t, quote.Properties["id"], "oneOf")
+	assert.NotContains(t, quote.Properties, "UUID")
+	assert.Equal(t, map[string]any{"type": "array", "items": map[string]any{"type": "string"}}, quote.Properties["tags"])
+
+	// Every reference resolves to a definition
+	for _, ref := range strings.Split(string(data), `"$ref": "#/$defs/`)[1:] {
+		name, _, _ := strings.Cut(ref, `"`)
+		assert.Contains(t, document.Defs, name)
+	}
+
+	data, err = JSONSchema("metadata")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &document))
+	assert.Equal(t, "#/$defs/Metadata", document.Ref)
+	assert.Equal(t, "object", document.Defs["Metadata"].Properties["schema"]["type"])
+	assert.Equal(t, "object", document.Defs["QuoteCounts"].Properties["byTag"]["type"])
+
+	data, err = JSONSchema("combined")
+	require.NoError(t, err)
+	document = schemaDocument{}
+	require.NoError(t, json.Unmarshal(data, &document))
+	assert.Equal(t, "#/$defs/Metadata", document.Properties["metadata"]["$ref"])
+	assert.Equal(t, "array", document.Properties["quotes"]["type"])
+
+	_, err = JSONSchema("sheet")
+	assert.Error(t, err)
+}
+
+// TestJSONSchemaInSync tests that every key written for a fully populated quote and metadata
+// is described by the schema
+func TestJSONSchemaInSync(t *testing.T) {
+	data, err := JSONSchema("quotes")
+	require.NoError(t, err)
+	var document schemaDocument
+	require.NoError(t, json.Unmarshal(data, &document))
+
+	check := func(def string, value any) {
+		encoded, err := json.Marshal(value)
+		require.NoError(t, err)
+		var fields map[string]any
+		require.NoError(t, json.Unmarshal(encoded, &fields))
+		for name := range fields {
+			assert.Contains(t, document.Defs[def].Properties, name, def)
+		}
+	}
+	check("Quote", Quote{ID: 1, Text: "a", Author: "b", Year: 1, Context: "c", Tags: []string{"d"}, Language: "en",
+		WordCount: 1, ReadingTimeSeconds: 1, Sentiment: &Sentiment{Label: "positive", Score: 1}})
+	metadata := newMetadata(DefaultOptions(), 1)
+	metadata.SHA256, metadata.Duration, metadata.Extra = "x", 1, map[string]string{"a": "b"}
+	metadata.Stats, metadata.Counts, metadata.Source = &QuoteStats{}, &QuoteCounts{}, &SourceInfo{}
+	check("Metadata", metadata)
+}
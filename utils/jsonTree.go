@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// jsonMember is a single key/value pair of a decoded JSON object
+type jsonMember struct {
+	Key   string
+	Value any
+}
+
+// jsonObject is a decoded JSON object that keeps its keys in document order
+type jsonObject []jsonMember
+
+// decodeJSONTree decodes JSON into nil, bool, int64, float64, string, []any and jsonObject values,
+// so binary encoders can reproduce the exact JSON structure including key order
+func decodeJSONTree(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return decodeJSONValue(dec)
+}
+
+// quoteTree decodes the JSON encoding of a quote
+func quoteTree(quote Quote) (any, error) {
+	data, err := json.Marshal(quote)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSONTree(data)
+}
+
+// decodeJSONValue reads the next complete value from dec
+func decodeJSONValue(dec *json.Decoder) (any, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '[' {
+			array := []any{}
+			for dec.More() {
+				value, err := decodeJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			}
+			_, err := dec.Token() // consume ']'
+			return array, err
+		}
+		if t == '{' {
+			object := jsonObject{}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				object = append(object, jsonMember{Key: key.(string), Value: value})
+			}
+			_, err := dec.Token() // consume '}'
+			return object, err
+		}
+		return nil, fmt.Errorf("unexpected delimiter %v", t)
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	default:
+		// nil, bool and string are already in their final form
+		return t, nil
+	}
+}
+
+// MarshalJSON writes the object with its keys in their original order
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, member := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(member.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(member.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
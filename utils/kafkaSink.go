@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaWriter is the part of kafka.Writer used by kafkaSink
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, messages ...kafka.Message) error
+	Close() error
+}
+
+// kafkaSink publishes every quote as a message of a Kafka topic, keyed by its ID so the
+// versions of a quote land on the same partition and a compacted topic keeps the latest
+type kafkaSink struct {
+	writer kafkaWriter
+	ctx    context.Context
+	topic  string
+}
+
+// openKafkaSink opens the sink of --to kafka://broker:9092,broker2:9092/topic. Messages are
+// acknowledged by all the in-sync replicas before the next batch is read.
+func openKafkaSink(target string, opts Options) (QuoteSink, error) {
+	brokers, topic, err := kafkaTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: 10 * time.Millisecond,
+		MaxAttempts:  httpRetries + 1,
+	}
+	return &kafkaSink{writer: writer, ctx: optsContext(opts), topic: topic}, nil
+}
+
+// kafkaTarget reads the brokers and the topic of the target of a kafka sink,
+// //broker:9092,broker2:9092/topic; the port of a broker defaults to 9092
+func kafkaTarget(target string) ([]string, string, error) {
+	hosts, topic, _ := strings.Cut(strings.TrimPrefix(target, "//"), "/")
+	topic = strings.TrimSuffix(topic, "/")
+	if !strings.HasPrefix(target, "//") || hosts == "" || topic == "" || strings.Contains(topic, "/") {
+		return nil, "", fmt.Errorf("invalid sink %q, expected kafka://broker:9092/topic", "kafka:"+target)
+	}
+	brokers := strings.Split(hosts, ",")
+	for i, broker := range brokers {
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			brokers[i] = net.JoinHostPort(broker, "9092")
+		}
+	}
+	return brokers, topic, nil
+}
+
+// WriteQuotes publishes a batch of quotes, the JSON of each quote as the value of its message
+func (s *kafkaSink) WriteQuotes(quotes []Quote) error {
+	if len(quotes) == 0 {
+		return nil
+	}
+	messages := make([]kafka.Message, len(quotes))
+	for i, quote := range quotes {
+		value, err := json.Marshal(quote)
+		if err != nil {
+			return fmt.Errorf("error marshalling quote %s: %w", quote.key(), err)
+		}
+		messages[i] = kafka.Message{
+			Key:     []byte(quote.key()),
+			Value:   value,
+			Headers: []kafka.Header{{Key: "content-type", Value: []byte("application/json")}},
+		}
+	}
+	if err := s.writer.WriteMessages(s.ctx, messages...); err != nil {
+		return fmt.Errorf("error publishing to Kafka topic %s: %w", s.topic, err)
+	}
+	return nil
+}
+
+// Commit flushes the messages still buffered and closes the connections
+func (s *kafkaSink) Commit() error {
+	if err := s.writer.Close(); err != nil {
+		return fmt.Errorf("error publishing to Kafka topic %s: %w", s.topic, err)
+	}
+	return nil
+}
+
+// Rollback closes the connections. Messages already published can't be taken back, so
+// consumers may see the quotes of a conversion that failed later on.
+func (s *kafkaSink) Rollback() error {
+	return s.writer.Close()
+}
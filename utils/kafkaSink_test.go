@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKafkaWriter records the messages published through it
+type fakeKafkaWriter struct {
+	messages []kafka.Message
+	err      error
+	closed   bool
+}
+
+func (w *fakeKafkaWriter) WriteMessages(ctx context.Context, messages ...kafka.Message) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.messages = append(w.messages, messages...)
+	return nil
+}
+
+func (w *fakeKafkaWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestKafkaTarget(t *testing.T) {
+	tests := []struct {
+		target  string
+		brokers []string
+		topic   string
+		wantErr bool
+	}{
+		{target: "//localhost:9092/quotes", brokers: []string{"localhost:9092"}, topic: "quotes"},
+		{target: "//kafka-1,kafka-2:9093/quotes.v1/", brokers: []string{"kafka-1:9092", "kafka-2:9093"}, topic: "quotes.v1"},
+		{target: "//[::1]:9092/quotes", brokers: []string{"[::1]:9092"}, topic: "quotes"},
+		{target: "//localhost:9092", wantErr: true},
+		{target: "//localhost:9092/quotes/extra", wantErr: true},
+		{target: "localhost:9092/quotes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			brokers, topic, err := kafkaTarget(tt.target)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "expected kafka://broker:9092/topic")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.brokers, brokers)
+			assert.Equal(t, tt.topic, topic)
+		})
+	}
+}
+
+func TestKafkaSink(t *testing.T) {
+	writer := &fakeKafkaWriter{}
+	sink := &kafkaSink{writer: writer, ctx: context.Background(), topic: "quotes"}
+	require.NoError(t, sink.WriteQuotes([]Quote{
+		{ID: 1, Text: "Know thyself.", Author: "Socrates", Tags: []string{"wisdom"}, Language: "en-US"},
+		{ID: 2, UUID: "0b5e7d6a-8f7e-4c1e-9a51-2f1c3a0b9d11", Text: "Carpe diem.", Tags: []string{}, Language: "la"},
+	}))
+	require.NoError(t, sink.WriteQuotes(nil))
+	require.NoError(t, sink.Commit())
+	assert.True(t, writer.closed)
+
+	require.Len(t, writer.messages, 2)
+	assert.Equal(t, "1", string(writer.messages[0].Key))
+	assert.Equal(t, "0b5e7d6a-8f7e-4c1e-9a51-2f1c3a0b9d11", string(writer.messages[1].Key))
+	var quote Quote
+	require.NoError(t, json.Unmarshal(writer.messages[0].Value, &quote))
+	assert.Equal(t, "Know thyself.", quote.Text)
+	assert.Equal(t, []kafka.Header{{Key: "content-type", Value: []byte("application/json")}}, writer.messages[0].Headers)
+
+	writer.err = errors.New("leader not available")
+	assert.ErrorContains(t, sink.WriteQuotes([]Quote{{ID: 3, Text: "Be yourself."}}), "error publishing to Kafka topic quotes: leader not available")
+}
+
+func TestOpenKafkaSink(t *testing.T) {
+	sink, err := OpenSink("kafka://localhost:9092/quotes", DefaultOptions())
+	require.NoError(t, err)
+	require.NoError(t, sink.Rollback())
+
+	_, err = OpenSink("kafka://localhost:9092", DefaultOptions())
+	assert.ErrorContains(t, err, "expected kafka://broker:9092/topic")
+}
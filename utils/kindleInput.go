@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// kindleSeparator is the line ending every clipping in My Clippings.txt
+const kindleSeparator = "=========="
+
+// kindleTitlePattern splits the first line of a clipping into the book title and its authors
+var kindleTitlePattern = regexp.MustCompile(`^(.*?)\s*\(([^()]*)\)$`)
+
+// kindleLocationPattern matches the location of a clipping, "Location 180-182" or "Loc. 180"
+var kindleLocationPattern = regexp.MustCompile(`(?i)\bloc(?:ation|\.)?\s+(\d+)(?:-(\d+))?`)
+
+// kindleClipping is a highlight from Kindle's My Clippings.txt
+type kindleClipping struct {
+	text, title, author string
+	start, end          int // locations the highlight spans, 0 when unknown
+}
+
+// overlaps reports whether two clippings of a book span some of the same locations
+func (c kindleClipping) overlaps(other kindleClipping) bool {
+	return c.start > 0 && other.start > 0 && c.start <= other.end && other.start <= c.end
+}
+
+// parseKindleLocation returns the locations of the metadata line of a clipping, 0 when it has
+// none
+func parseKindleLocation(line string) (int, int) {
+	match := kindleLocationPattern.FindStringSubmatch(line)
+	if match == nil {
+		return 0, 0
+	}
+	start, _ := strconv.Atoi(match[1])
+	end, _ := strconv.Atoi(match[2])
+	return start, max(start, end)
+}
+
+// isKindleClippings reports whether fileName looks like Kindle's My Clippings.txt, whose
+// clippings end with a line of ten = signs
+func isKindleClippings(fileName string) bool {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(io.LimitReader(file, 64*1024))
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == kindleSeparator {
+			return true
+		}
+	}
+	return false
+}
+
+// readKindleClippings parses Kindle's My Clippings.txt, where each clipping is the book title
+// with its authors in parentheses, a "- Your Highlight on page 12 | Location 180-182 | Added
+// on ..." line, a blank line and the highlighted text. Bookmarks and notes are skipped, and so
+// are highlights that were later extended, which Kindle keeps alongside the longer one.
+func readKindleClippings(fileName string) ([]kindleClipping, error) {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Kindle clippings %s: %w", fileName, err)
+	}
+
+	var clippings []kindleClipping
+	for _, entry := range strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), kindleSeparator) {
+		lines := strings.Split(strings.Trim(entry, "\n"), "\n")
+		if len(lines) < 3 {
+			continue
+		}
+		kind := strings.ToLower(lines[1])
+		if strings.Contains(kind, "bookmark") || strings.Contains(kind, "note") {
+			continue
+		}
+		text := strings.TrimSpace(strings.Join(lines[2:], "\n"))
+		if text == "" {
+			continue
+		}
+		title, author := parseKindleTitle(lines[0])
+		start, end := parseKindleLocation(lines[1])
+		clippings = append(clippings, kindleClipping{text: text, title: title, author: author, start: start, end: end})
+	}
+
+	// Keep the last version of a highlight, dropping those another one of the book extends: it
+	// contains their text at overlapping locations, or starts with it. A short highlight found
+	// elsewhere in a longer one is a highlight of its own.
+	var kept []kindleClipping
+	for i, clipping := range clippings {
+		superseded := false
+		for j, other := range clippings {
+			extends := (clipping.overlaps(other) && strings.Contains(other.text, clipping.text)) ||
+				strings.HasPrefix(other.text, clipping.text)
+			if i != j && other.title == clipping.title && extends && (len(other.text) > len(clipping.text) || j > i) {
+				superseded = true
+				break
+			}
+		}
+		if !superseded {
+			kept = append(kept, clipping)
+		}
+	}
+	return kept, nil
+}
+
+// parseKindleTitle splits "Nineteen Eighty-Four (Orwell, George)" into the title and the author
+// written first name first
+func parseKindleTitle(line string) (string, string) {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+	match := kindleTitlePattern.FindStringSubmatch(line)
+	if match == nil {
+		return line, ""
+	}
+	authors := strings.Split(match[2], ";")
+	for i, author := range authors {
+		author = strings.TrimSpace(author)
+		if last, first, ok := strings.Cut(author, ","); ok && !strings.Contains(first, ",") {
+			author = strings.TrimSpace(first) + " " + strings.TrimSpace(last)
+		}
+		authors[i] = author
+	}
+	return match[1], strings.Join(authors, ", ")
+}
+
+// kindleRows lays out Kindle highlights as rows under quoteColumns, header first, with the book
+// title as the context
+func kindleRows(clippings []kindleClipping) *sliceRows {
+	rows := [][]string{quoteColumns}
+	for _, clipping := range clippings {
+		rows = append(rows, []string{"", clipping.text, clipping.author, "", clipping.title, ""})
+	}
+	return &sliceRows{rows: rows}
+}
+
+// readKindleRows reads the highlights of Kindle's My Clippings.txt as rows
+func readKindleRows(fileName string, opts Options) (*sliceRows, error) {
+	clippings, err := readKindleClippings(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return kindleRows(clippings), nil
+}
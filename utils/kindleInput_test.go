@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// kindleSample is a My Clippings.txt with a highlight extended later, a note and a bookmark
+const kindleSample = "\ufeffNineteen Eighty-Four (Orwell, George)\r\n" +
+	"- Your Highlight on page 3 | Location 40-41 | Added on Monday, 3 April 2017 10:14:23\r\n\r\n" +
+	"Who controls the past controls the future.\r\n==========\r\n" +
+	"Nineteen Eighty-Four (Orwell, George)\r\n" +
+	"- Your Note on page 3 | Location 41 | Added on Monday, 3 April 2017 10:15:00\r\n\r\n" +
+	"remember this\r\n==========\r\n" +
+	"Nineteen Eighty-Four (Orwell, George)\r\n" +
+	"- Your Highlight on page 3 | Location 40-42 | Added on Monday, 3 April 2017 10:16:00\r\n\r\n" +
+	"Who controls the past controls the future. Who controls the present controls the past.\r\n==========\r\n" +
+	"Meditations (Penguin Classics) (Marcus Aurelius)\r\n" +
+	"- Your Bookmark on page 10 | Added on Tuesday, 4 April 2017 08:00:00\r\n\r\n\r\n==========\r\n" +
+	"Meditations (Penguin Classics) (Marcus Aurelius)\r\n" +
+	"- Your Highlight on page 12 | Location 180-182 | Added on Tuesday, 4 April 2017 08:01:00\r\n\r\n" +
+	"The happiness of your life depends upon the quality of your thoughts.\r\n==========\r\n"
+
+// TestReadKindleClippings tests reading highlights and skipping notes, bookmarks and earlier versions
+func TestReadKindleClippings(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "My Clippings.txt")
+	require.NoError(t, os.WriteFile(fileName, []byte(kindleSample), 0644))
+	assert.True(t, isKindleClippings(fileName))
+
+	clippings, err := readKindleClippings(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, []kindleClipping{
+		{text: "Who controls the past controls the future. Who controls the present controls the past.", title: "Nineteen Eighty-Four", author: "George Orwell", start: 40, end: 42},
+		{text: "The happiness of your life depends upon the quality of your thoughts.", title: "Meditations (Penguin Classics)", author: "Marcus Aurelius", start: 180, end: 182},
+	}, clippings)
+
+	_, err = readKindleClippings(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+	assert.False(t, isKindleClippings(filepath.Join(t.TempDir(), "missing.txt")))
+}
+
+// TestReadKindleClippingsDistinct tests that a highlight found elsewhere in a longer one of the
+// book is kept
+func TestReadKindleClippingsDistinct(t *testing.T) {
+	clippings := "Walden (Henry David Thoreau)\n" +
+		"- Your Highlight on page 90 | Location 1370-1371 | Added on Friday, 7 April 2017 09:00:00\n\n" +
+		"Simplify, simplify.\n==========\n" +
+		"Walden (Henry David Thoreau)\n" +
+		"- Your Highlight on page 91 | Location 1380-1382 | Added on Friday, 7 April 2017 09:05:00\n\n" +
+		"Our life is frittered away by detail. Simplify, simplify.\n==========\n" +
+		"Walden (Henry David Thoreau)\n" +
+		"- Your Highlight on page 92 | Location 1390 | Added on Friday, 7 April 2017 09:06:00\n\n" +
+		"Our life is frittered away\n==========\n"
+	fileName := filepath.Join(t.TempDir(), "My Clippings.txt")
+	require.NoError(t, os.WriteFile(fileName, []byte(clippings), 0644))
+
+	kept, err := readKindleClippings(fileName)
+	require.NoError(t, err)
+	texts := []string{}
+	for _, clipping := range kept {
+		texts = append(texts, clipping.text)
+	}
+	assert.Equal(t, []string{"Simplify, simplify.", "Our life is frittered away by detail. Simplify, simplify."}, texts,
+		"the prefix of a highlight is an earlier version of it, wherever it was saved")
+}
+
+// TestParseKindleTitle tests splitting the title line of a clipping
+func TestParseKindleTitle(t *testing.T) {
+	tests := []struct {
+		line, title, author string
+	}{
+		{"Walden (Henry David Thoreau)", "Walden", "Henry David Thoreau"},
+		{"Good Omens (Pratchett, Terry; Gaiman, Neil)", "Good Omens", "Terry Pratchett, Neil Gaiman"},
+		{"Untitled document", "Untitled document", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			title, author := parseKindleTitle(tt.line)
+			assert.Equal(t, tt.title, title)
+			assert.Equal(t, tt.author, author)
+		})
+	}
+}
+
+// TestConvertKindleFile tests converting clippings through the pipeline
+func TestConvertKindleFile(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	input := filepath.Join(t.TempDir(), "My Clippings.txt")
+	require.NoError(t, os.WriteFile(input, []byte(kindleSample), 0644))
+
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(t.TempDir(), "out.json")
+	require.NoError(t, ConvertFile(input, opts))
+	data, err := ReadQuotesFromJSON(opts.Output)
+	require.NoError(t, err)
+	require.Len(t, data.Quotes, 2)
+	assert.Equal(t, "George Orwell", data.Quotes[0].Author)
+	assert.Equal(t, "Nineteen Eighty-Four", data.Quotes[0].Context)
+	assert.Equal(t, "Meditations (Penguin Classics)", data.Quotes[1].Context)
+
+	opts.Columns = ColumnList{"text=B"}
+	assert.Error(t, ConvertFile(input, opts))
+}
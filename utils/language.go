@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// NormalizeLanguageTag validates a BCP 47 language tag and returns it in canonical casing, so
+// en-us becomes en-US; unknown or malformed tags are an error
+func NormalizeLanguageTag(value string) (string, error) {
+	tag, err := language.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return "", fmt.Errorf("invalid language tag %q: %w", value, err)
+	}
+	return tag.String(), nil
+}
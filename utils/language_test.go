@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNormalizeLanguageTag tests canonical casing and rejection of junk tags
+func TestNormalizeLanguageTag(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+		wantErr  bool
+	}{
+		{value: "en-us", expected: "en-US"},
+		{value: "TA-in", expected: "ta-IN"},
+		{value: "en_GB", expected: "en-GB"},
+		{value: "zh-hant-tw", expected: "zh-Hant-TW"},
+		{value: " fr ", expected: "fr"},
+		{value: "english", wantErr: true},
+		{value: "xx", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			tag, err := NormalizeLanguageTag(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, tag)
+		})
+	}
+}
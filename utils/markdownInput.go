@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// attributionYearPattern matches the "(1950)" ending an attribution line
+var attributionYearPattern = regexp.MustCompile(`\s*\(([^()]+)\)$`)
+
+// markdownQuote is a quote drafted in Markdown, with its year as written
+type markdownQuote struct {
+	text, author, context, year string
+	tags                        []string
+}
+
+// readMarkdownQuotes parses a Markdown draft in which each quote is a blockquote, followed by an
+// attribution line starting with a dash ("— Author, Context (Year)") and a line of hashtags.
+// The attribution may also be the last line of the blockquote, as the markdown format writes
+// it. Headings, prose and code blocks between quotes are ignored, and a quote appearing more than
+// once, as it does under every tag in the markdown format, is read once with all its tags.
+func readMarkdownQuotes(fileName string) ([]markdownQuote, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Markdown file %s: %w", fileName, err)
+	}
+	defer file.Close()
+
+	var quotes []markdownQuote
+	current := -1 // the quote the lines after a blockquote belong to
+	var block []string
+	finishBlock := func() {
+		if len(block) == 0 {
+			return
+		}
+		quote := markdownQuote{}
+		if last := block[len(block)-1]; startsWithDash(last) {
+			quote.author, quote.context, quote.year = parseAttributionLine(last)
+			block = block[:len(block)-1]
+		}
+		quote.text = strings.TrimSpace(strings.Join(block, "\n"))
+		block = nil
+		if quote.text != "" {
+			quotes = append(quotes, quote)
+			current = len(quotes) - 1
+		}
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	fenced := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+		if strings.HasPrefix(line, ">") {
+			if len(block) == 0 {
+				current = -1
+			}
+			block = append(block, strings.TrimSpace(strings.TrimPrefix(line, ">")))
+			continue
+		}
+		finishBlock()
+		if current < 0 || line == "" {
+			continue
+		}
+		quote := &quotes[current]
+		switch {
+		case startsWithDash(line) && quote.author == "" && quote.context == "" && quote.year == "":
+			quote.author, quote.context, quote.year = parseAttributionLine(line)
+		case isHashtagLine(line):
+			for _, word := range strings.Fields(line) {
+				quote.tags = append(quote.tags, strings.TrimPrefix(word, "#"))
+			}
+		default:
+			// Prose after a quote ends it, so a later dash line isn't taken as its attribution
+			current = -1
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Markdown file %s: %w", fileName, err)
+	}
+	finishBlock()
+
+	// Merge repeated quotes; quotes are only complete now that their hashtags are read
+	var merged []markdownQuote
+	seen := make(map[string]int)
+	for _, quote := range quotes {
+		key := quote.text + "\x00" + quote.author
+		if i, ok := seen[key]; ok {
+			for _, tag := range quote.tags {
+				if !containsTag(merged[i].tags, tag) {
+					merged[i].tags = append(merged[i].tags, tag)
+				}
+			}
+			continue
+		}
+		seen[key] = len(merged)
+		merged = append(merged, quote)
+	}
+	return merged, nil
+}
+
+// markdownDashes start an attribution line; a single hyphen starts a list item instead
+var markdownDashes = []string{"—", "–", "-- "}
+
+// startsWithDash reports whether line begins with one of the markdownDashes
+func startsWithDash(line string) bool {
+	for _, dash := range markdownDashes {
+		if strings.HasPrefix(line, dash) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAttributionLine splits "— Author, Context (Year)" into its parts; each can be missing
+func parseAttributionLine(line string) (author, context, year string) {
+	line = strings.TrimLeft(line, "—–―- ")
+	if match := attributionYearPattern.FindStringSubmatch(line); match != nil {
+		if _, err := parseYear(match[1]); err == nil {
+			year = match[1]
+			line = strings.TrimSuffix(line, match[0])
+		}
+	}
+	author, context, _ = strings.Cut(line, ",")
+	return strings.TrimSpace(author), strings.TrimSpace(context), year
+}
+
+// isHashtagLine reports whether line holds only hashtags such as "#life #self-knowledge"; a
+// heading has a space after its #
+func isHashtagLine(line string) bool {
+	for _, word := range strings.Fields(line) {
+		if len(word) < 2 || word[0] != '#' || word[1] == '#' {
+			return false
+		}
+	}
+	return true
+}
+
+// containsTag reports whether tags holds tag
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// markdownRows lays out Markdown quotes as rows under quoteColumns, header first
+func markdownRows(quotes []markdownQuote) *sliceRows {
+	rows := [][]string{quoteColumns}
+	for _, quote := range quotes {
+		rows = append(rows, []string{strings.Join(quote.tags, ", "), quote.text, quote.author, quote.year, quote.context, ""})
+	}
+	return &sliceRows{rows: rows}
+}
+
+// readMarkdownRows reads a Markdown draft of quotes as rows
+func readMarkdownRows(fileName string, opts Options) (*sliceRows, error) {
+	quotes, err := readMarkdownQuotes(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return markdownRows(quotes), nil
+}
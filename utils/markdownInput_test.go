@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReadMarkdownQuotes tests the layouts of a Markdown draft
+func TestReadMarkdownQuotes(t *testing.T) {
+	draft := `# Drafts
+
+Some notes about this collection.
+
+> The unexamined life
+> is not worth living.
+— Socrates, Apology (399 BCE)
+#philosophy #life
+
+> Carpe diem.
+
+-- Horace
+
+> Stay hungry, stay foolish.
+
+This was on the back cover.
+— Not an attribution
+
+` + "```\n> not a quote\n```\n" + `
+> Be yourself.
+>
+> — Oscar Wilde
+
+> Three things:
+> - courage
+- not an attribution either
+
+> Love is patient.
+– Paul, 1 Corinthians
+`
+
+	fileName := filepath.Join(t.TempDir(), "drafts.md")
+	require.NoError(t, os.WriteFile(fileName, []byte(draft), 0644))
+	quotes, err := readMarkdownQuotes(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, []markdownQuote{
+		{text: "The unexamined life\nis not worth living.", author: "Socrates", context: "Apology", year: "399 BCE", tags: []string{"philosophy", "life"}},
+		{text: "Carpe diem.", author: "Horace"},
+		{text: "Stay hungry, stay foolish."},
+		{text: "Be yourself.", author: "Oscar Wilde"},
+		{text: "Three things:\n- courage"},
+		{text: "Love is patient.", author: "Paul", context: "1 Corinthians"},
+	}, quotes)
+
+	_, err = readMarkdownQuotes(filepath.Join(t.TempDir(), "missing.md"))
+	assert.Error(t, err)
+}
+
+// TestReadMarkdownQuotesRoundTrip tests reading the output of the markdown format
+func TestReadMarkdownQuotesRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	encoder := &markdownEncoder{}
+	require.NoError(t, encoder.writeQuote(w, Quote{Text: "Know thyself.", Author: "Socrates", Year: -400, Tags: []string{"wisdom", "self"}}, 0))
+	require.NoError(t, encoder.writeQuote(w, Quote{Text: "Untagged."}, 1))
+	require.NoError(t, encoder.finish(w, 2))
+	require.NoError(t, w.Flush())
+
+	fileName := filepath.Join(t.TempDir(), "quotes.md")
+	require.NoError(t, os.WriteFile(fileName, buf.Bytes(), 0644))
+	quotes, err := readMarkdownQuotes(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, []markdownQuote{
+		{text: "Know thyself.", author: "Socrates", year: "400 BCE", tags: []string{"wisdom", "self"}},
+		{text: "Untagged."},
+	}, quotes)
+}
+
+// TestParseAttributionLine tests splitting attribution lines
+func TestParseAttributionLine(t *testing.T) {
+	tests := []struct {
+		line                  string
+		author, context, year string
+	}{
+		{"— Albert Einstein", "Albert Einstein", "", ""},
+		{"– Marcus Aurelius, Meditations", "Marcus Aurelius", "Meditations", ""},
+		{"— Oscar Wilde, Lady Windermere's Fan (1892)", "Oscar Wilde", "Lady Windermere's Fan", "1892"},
+		{"-- Anonymous (c. 1950)", "Anonymous", "", "c. 1950"},
+		{"— Authors (various)", "Authors (various)", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			author, context, year := parseAttributionLine(tt.line)
+			assert.Equal(t, []string{tt.author, tt.context, tt.year}, []string{author, context, year})
+		})
+	}
+}
+
+// TestIsHashtagLine tests telling hashtags from headings
+func TestIsHashtagLine(t *testing.T) {
+	assert.True(t, isHashtagLine("#life #self-knowledge"))
+	assert.False(t, isHashtagLine("# Quotes"))
+	assert.False(t, isHashtagLine("## life"))
+	assert.False(t, isHashtagLine("#life and more"))
+}
+
+// TestConvertMarkdownFile tests converting a Markdown draft through the pipeline
+func TestConvertMarkdownFile(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	input := filepath.Join(t.TempDir(), "drafts.markdown")
+	require.NoError(t, os.WriteFile(input, []byte("> Know thyself.\n— Socrates (c. 400 BCE)\n#wisdom\n\n> Carpe diem. — Horace\n"), 0644))
+
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(t.TempDir(), "out.json")
+	require.NoError(t, ConvertFile(input, opts))
+	data, err := ReadQuotesFromJSON(opts.Output)
+	require.NoError(t, err)
+	require.Len(t, data.Quotes, 2)
+	assert.Equal(t, "Socrates", data.Quotes[0].Author)
+	assert.Equal(t, -400, data.Quotes[0].Year)
+	assert.Equal(t, []string{"wisdom"}, data.Quotes[0].Tags)
+	assert.Equal(t, "Carpe diem.", data.Quotes[1].Text)
+	assert.Equal(t, "Horace", data.Quotes[1].Author)
+
+	opts.Columns = ColumnList{"text=B"}
+	assert.Error(t, ConvertFile(input, opts))
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// markdownEncoder renders quotes as blockquotes grouped under one heading per tag.
+// Grouping needs every quote, so they are collected and rendered in finish.
+type markdownEncoder struct {
+	quotes []Quote
+}
+
+func (e *markdownEncoder) writeQuote(w *bufio.Writer, quote Quote, index int) error {
+	e.quotes = append(e.quotes, quote)
+	return nil
+}
+
+func (e *markdownEncoder) finish(w *bufio.Writer, count int) error {
+	// A quote is listed under every one of its tags
+	groups := make(map[string][]Quote)
+	var untagged []Quote
+	for _, quote := range e.quotes {
+		tags := nonEmptyTags(quote.Tags)
+		if len(tags) == 0 {
+			untagged = append(untagged, quote)
+		}
+		for _, tag := range tags {
+			groups[tag] = append(groups[tag], quote)
+		}
+	}
+	tags := make([]string, 0, len(groups))
+	for tag := range groups {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	w.WriteString("# Quotes\n")
+	for _, tag := range tags {
+		fmt.Fprintf(w, "\n## %s\n", tag)
+		for _, quote := range groups[tag] {
+			writeMarkdownQuote(w, quote)
+		}
+	}
+	if len(untagged) > 0 {
+		w.WriteString("\n## Untagged\n")
+		for _, quote := range untagged {
+			writeMarkdownQuote(w, quote)
+		}
+	}
+	return nil
+}
+
+// writeMarkdownQuote writes one quote as a blockquote followed by its attribution and hashtags
+func writeMarkdownQuote(w *bufio.Writer, quote Quote) {
+	w.WriteString("\n")
+	for _, line := range strings.Split(strings.TrimSpace(quote.Text), "\n") {
+		w.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+	}
+	if attribution := quoteAttribution(quote); attribution != "" {
+		fmt.Fprintf(w, ">\n> — %s\n", attribution)
+	}
+
+	var hashtags []string
+	for _, tag := range nonEmptyTags(quote.Tags) {
+		hashtags = append(hashtags, "#"+strings.Join(strings.Fields(tag), "-"))
+	}
+	if len(hashtags) > 0 {
+		fmt.Fprintf(w, "\n%s\n", strings.Join(hashtags, " "))
+	}
+}
+
+// quoteAttribution formats "Author, Context (Year)", leaving out whatever is unknown
+func quoteAttribution(quote Quote) string {
+	var parts []string
+	if quote.Author != "" {
+		parts = append(parts, quote.Author)
+	}
+	if quote.Context != "" {
+		parts = append(parts, quote.Context)
+	}
+	attribution := strings.Join(parts, ", ")
+	if quote.Year != 0 {
+		attribution = strings.TrimSpace(fmt.Sprintf("%s (%s)", attribution, formatYear(quote.Year)))
+	}
+	return attribution
+}
+
+// formatYear renders negative years as BCE
+func formatYear(year int) string {
+	if year < 0 {
+		return fmt.Sprintf("%d BCE", -year)
+	}
+	return fmt.Sprint(year)
+}
+
+// nonEmptyTags drops the empty tags produced by blank tag cells
+func nonEmptyTags(tags []string) []string {
+	var result []string
+	for _, tag := range tags {
+		if tag != "" {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMarkdownEncoder tests grouping by tag, attribution and hashtags
+func TestMarkdownEncoder(t *testing.T) {
+	quotes := []Quote{
+		{ID: 1, Text: "Be the change.", Author: "Gandhi", Tags: []string{"wisdom", "change"}},
+		{ID: 2, Text: "Line one\nLine two", Author: "Someone", Context: "A Book", Year: -300, Tags: []string{"wisdom"}},
+		{ID: 3, Text: "No tags here", Tags: []string{""}},
+	}
+
+	var buf bytes.Buffer
+	s := NewQuoteStreamWriter(&buf, StreamMarkdown)
+	for _, q := range quotes {
+		require.NoError(t, s.WriteQuote(q))
+	}
+	require.NoError(t, s.Close())
+
+	expected := `# Quotes
+
+## change
+
+> Be the change.
+>
+> — Gandhi
+
+#wisdom #change
+
+## wisdom
+
+> Be the change.
+>
+> — Gandhi
+
+#wisdom #change
+
+> Line one
+> Line two
+>
+> — Someone, A Book (300 BCE)
+
+#wisdom
+
+## Untagged
+
+> No tags here
+`
+	assert.Equal(t, expected, buf.String())
+}
@@ -0,0 +1,249 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// MergeMatch selects how merged quotes are matched to the existing ones
+type MergeMatch string
+
+const (
+	// MergeByText matches quotes whose text is the same ignoring case, punctuation and spacing
+	MergeByText MergeMatch = "text"
+	// MergeByID matches quotes with the same ID, for a re-export of the same spreadsheet
+	MergeByID MergeMatch = "id"
+)
+
+// String implements flag.Value
+func (m *MergeMatch) String() string {
+	return string(*m)
+}
+
+// Set implements flag.Value, accepting only the supported matches
+func (m *MergeMatch) Set(name string) error {
+	switch match := MergeMatch(strings.ToLower(name)); match {
+	case MergeByText, MergeByID:
+		*m = match
+		return nil
+	}
+	return fmt.Errorf("unknown merge match %q (supported: text, id)", name)
+}
+
+// MergeResult counts what MergeQuotes did
+type MergeResult struct {
+	Added     int
+	Updated   int
+	Unchanged int
+	Removed   int // existing quotes missing from the input, dropped with Options.Replace
+}
+
+// bump resolves BumpAuto for the merge like a conversion: removing quotes, which only
+// Options.Replace does, is a major bump, adding some a minor bump and only updating them a patch
+func (r MergeResult) bump(bump VersionBump) VersionBump {
+	if bump != BumpAuto && bump != "" {
+		return bump
+	}
+	switch {
+	case r.Removed > 0:
+		return BumpMajor
+	case r.Added > 0:
+		return BumpMinor
+	case r.Updated > 0:
+		return BumpPatch
+	}
+	return BumpNone
+}
+
+// MergeQuotes upserts incoming quotes into existing ones. Matched quotes keep their ID and are
+// replaced when any other field changed, quotes without a match are appended and existing
+// quotes without a match are left untouched. Appended quotes keep their own ID when matching by
+// ID, and otherwise get the next ID above the existing ones, or a new UUID when the existing
+// quotes or strategy use UUIDs.
+func MergeQuotes(existing []Quote, incoming []Quote, match MergeMatch, strategy IDStrategy) ([]Quote, MergeResult, error) {
+	merged := append([]Quote(nil), existing...)
+	var result MergeResult
+
+	useUUIDs := strategy == IDUUID
+	nextID := int64(1)
+	matches := make(map[string][]int)
+	for i, quote := range merged {
+		useUUIDs = useUUIDs || quote.UUID != ""
+		nextID = max(nextID, quote.ID+1)
+		key := mergeKey(quote, match)
+		matches[key] = append(matches[key], i)
+	}
+
+	for _, quote := range incoming {
+		key := mergeKey(quote, match)
+		if candidates := matches[key]; len(candidates) > 0 {
+			matches[key] = candidates[1:]
+			previous := &merged[candidates[0]]
+			quote.ID, quote.UUID = previous.ID, previous.UUID
+			if reflect.DeepEqual(*previous, quote) {
+				result.Unchanged++
+				continue
+			}
+			*previous = quote
+			result.Updated++
+			continue
+		}
+
+		if match == MergeByText {
+			quote.ID, quote.UUID = nextID, ""
+			nextID++
+			if useUUIDs {
+				id, err := newQuoteUUID()
+				if err != nil {
+					return nil, result, err
+				}
+				quote.ID, quote.UUID = 0, id
+			}
+		}
+		merged = append(merged, quote)
+		result.Added++
+	}
+	return merged, result, nil
+}
+
+// mergeKey returns the key quotes are matched by
+func mergeKey(quote Quote, match MergeMatch) string {
+	if match == MergeByID {
+		return quote.key()
+	}
+	return textKey(quote.Text)
+}
+
+// withoutExisting drops the incoming quotes matching one of the existing quotes, returning how
+// many were dropped
+func withoutExisting(existing []Quote, incoming []Quote, match MergeMatch) ([]Quote, int) {
+	keys := make(map[string]bool, len(existing))
+	for _, quote := range existing {
+		keys[mergeKey(quote, match)] = true
+	}
+	var kept []Quote
+	for _, quote := range incoming {
+		if !keys[mergeKey(quote, match)] {
+			kept = append(kept, quote)
+		}
+	}
+	return kept, len(incoming) - len(kept)
+}
+
+// onlyMatching keeps the merged quotes matching one of the incoming quotes, returning how many
+// were dropped
+func onlyMatching(merged []Quote, incoming []Quote, match MergeMatch) ([]Quote, int) {
+	keys := make(map[string]bool, len(incoming))
+	for _, quote := range incoming {
+		keys[mergeKey(quote, match)] = true
+	}
+	var kept []Quote
+	for _, quote := range merged {
+		if keys[mergeKey(quote, match)] {
+			kept = append(kept, quote)
+		}
+	}
+	return kept, len(merged) - len(kept)
+}
+
+// MergeFile converts input, a spreadsheet or any other input read by ReadInputQuotes, and
+// merges its quotes into the JSON file into, then updates the counts, stats, generator, duration
+// and LastUpdated of metadataFile and applies the metadata options of opts. A missing into file
+// is treated as an empty dataset. With opts.KeepExisting the quotes already in into are never
+// updated, with opts.Replace those missing from input are dropped, and with opts.EmbedMetadata
+// the metadata is embedded in into instead.
+func MergeFile(input, into, metadataFile string, match MergeMatch, opts Options) (MergeResult, error) {
+	start := time.Now()
+	incoming, err := ReadInputQuotes(input, opts)
+	if err != nil {
+		return MergeResult{}, err
+	}
+	return mergeInto(incoming, into, metadataFile, match, opts, start)
+}
+
+// mergeInto merges incoming quotes into into and updates metadataFile for MergeFile, timing the
+// merge from start
+func mergeInto(incoming []Quote, into, metadataFile string, match MergeMatch, opts Options, start time.Time) (MergeResult, error) {
+	metadataConfig, err := loadMetadataConfig(opts)
+	if err != nil {
+		return MergeResult{}, err
+	}
+
+	var existing QuotesData
+	if _, err := os.Stat(into); err == nil {
+		if existing, err = ReadQuotesFromJSON(into); err != nil {
+			return MergeResult{}, err
+		}
+	}
+	wanted, skipped := incoming, 0
+	if opts.KeepExisting {
+		incoming, skipped = withoutExisting(existing.Quotes, incoming, match)
+	}
+	merged, result, err := MergeQuotes(existing.Quotes, incoming, match, opts.IDStrategy)
+	if err != nil {
+		return result, err
+	}
+	result.Unchanged += skipped
+	if opts.Replace {
+		merged, result.Removed = onlyMatching(merged, wanted, match)
+	}
+
+	// Read the previous metadata first, a combined into file is about to be overwritten
+	opts.Format = StreamArray
+	metadata := newMetadata(opts, len(merged))
+	var previousMetadata *Metadata
+	if opts.EmbedMetadata {
+		if previousMetadata, err = readEmbeddedMetadata(into); err != nil {
+			return result, err
+		}
+	}
+	if previousMetadata == nil {
+		if previousMetadata, err = readPreviousMetadata(metadataFile, CompressNone); err != nil {
+			return result, err
+		}
+	}
+
+	now := time.Now()
+	for _, previous := range []string{into, metadataFile} {
+		if err := backupFile(previous, opts.Backups, opts.BackupTime, now); err != nil {
+			return result, err
+		}
+	}
+	if err := WriteJSONToFileWithOptions(into, QuotesData{Quotes: merged}, opts); err != nil {
+		return result, err
+	}
+
+	if previousMetadata != nil {
+		// Keep everything else from the previous metadata and bump its version
+		metadata = *previousMetadata
+		metadata.TotalQuotes = len(merged)
+		metadata.LastUpdated = now.Format(time.RFC3339)
+		// The quotes no longer all come from the spreadsheet it names
+		metadata.Source = nil
+		if metadata.Version, err = bumpVersion(metadata.Version, result.bump(opts.VersionBump)); err != nil {
+			return result, err
+		}
+	}
+	var totals quoteTotals
+	for _, quote := range merged {
+		totals.add(quote)
+	}
+	metadata.Stats = totals.stats()
+	metadata.Counts = totals.counts()
+	metadata.Generator = generatorInfo()
+	metadataConfig.apply(&metadata)
+	metadata.Duration = durationSeconds(start)
+	if opts.EmbedMetadata {
+		metadata.SHA256 = ""
+		return result, embedMetadata(into, metadata, opts)
+	}
+	if metadata.SHA256 != "" {
+		if metadata.SHA256, err = fileSHA256(into); err != nil {
+			return result, err
+		}
+	}
+	return result, writeMetadataFile(metadataFile, metadata, CompressNone)
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMergeQuotesByText tests that matching quotes are updated in place and new ones appended
+func TestMergeQuotesByText(t *testing.T) {
+	existing := []Quote{
+		{ID: 3, Text: "Know thyself.", Tags: []string{"wisdom"}},
+		{ID: 5, Text: "Carpe diem", Tags: []string{"time"}},
+		{ID: 9, Text: "Untouched", Tags: []string{}},
+	}
+	incoming := []Quote{
+		{ID: 1, Text: "carpe diem!", Tags: []string{"time"}},
+		{ID: 2, Text: "Know thyself", Tags: []string{"wisdom"}, Author: "Socrates"},
+		{ID: 3, Text: "Brand new", Tags: []string{"new"}},
+	}
+	merged, result, err := MergeQuotes(existing, incoming, MergeByText, IDRow)
+	require.NoError(t, err)
+	assert.Equal(t, MergeResult{Added: 1, Updated: 2}, result)
+	assert.Equal(t, []Quote{
+		{ID: 3, Text: "Know thyself", Tags: []string{"wisdom"}, Author: "Socrates"},
+		{ID: 5, Text: "carpe diem!", Tags: []string{"time"}},
+		{ID: 9, Text: "Untouched", Tags: []string{}},
+		{ID: 10, Text: "Brand new", Tags: []string{"new"}},
+	}, merged)
+	assert.Equal(t, "Know thyself.", existing[0].Text, "the existing slice is not modified")
+
+	_, result, err = MergeQuotes(merged, incoming, MergeByText, IDRow)
+	require.NoError(t, err)
+	assert.Equal(t, MergeResult{Unchanged: 3}, result)
+}
+
+// TestMergeQuotesByID tests matching a re-export of the same spreadsheet by row ID
+func TestMergeQuotesByID(t *testing.T) {
+	existing := []Quote{{ID: 1, Text: "Old text"}, {ID: 2, Text: "Same"}}
+	incoming := []Quote{{ID: 1, Text: "New text"}, {ID: 2, Text: "Same"}, {ID: 4, Text: "Added"}}
+	merged, result, err := MergeQuotes(existing, incoming, MergeByID, IDRow)
+	require.NoError(t, err)
+	assert.Equal(t, MergeResult{Added: 1, Updated: 1, Unchanged: 1}, result)
+	assert.Equal(t, []Quote{{ID: 1, Text: "New text"}, {ID: 2, Text: "Same"}, {ID: 4, Text: "Added"}}, merged)
+}
+
+// TestMergeQuotesUUIDs tests that new quotes get UUIDs when the dataset uses them
+func TestMergeQuotesUUIDs(t *testing.T) {
+	existing := []Quote{{UUID: "01890a5d-ac96-774b-bcce-b302099a8057", Text: "Old"}}
+	merged, _, err := MergeQuotes(existing, []Quote{{ID: 1, Text: "New"}}, MergeByText, IDRow)
+	require.NoError(t, err)
+	require.Len(t, merged, 2)
+	assert.NotEmpty(t, merged[1].UUID)
+	assert.Equal(t, int64(0), merged[1].ID)
+}
+
+// TestMergeFile tests merging a spreadsheet into a quotes file and its metadata
+func TestMergeFile(t *testing.T) {
+	_, excelFile := createTestExcelFile(t)
+	dir := t.TempDir()
+	into := filepath.Join(dir, "quotes.json")
+	metadataFile := filepath.Join(dir, "quotesMetadata.json")
+	require.NoError(t, WriteJSONToFile(into, QuotesData{Quotes: []Quote{{ID: 7, Text: "Kept", Tags: []string{"a"}, Language: "en-US"}}}))
+	metadata := Metadata{Version: "1.4", TotalQuotes: 1, Source: &SourceInfo{File: "old.xlsx", SHA256: "abc"}}
+	require.NoError(t, writeMetadataFile(metadataFile, metadata, CompressNone))
+
+	result, err := MergeFile(excelFile, into, metadataFile, MergeByText, DefaultOptions())
+	require.NoError(t, err)
+	assert.Equal(t, MergeResult{Added: 3}, result)
+
+	data, err := ReadQuotesFromJSON(into)
+	require.NoError(t, err)
+	require.Len(t, data.Quotes, 4)
+	assert.Equal(t, "Kept", data.Quotes[0].Text)
+	assert.Equal(t, int64(8), data.Quotes[1].ID)
+	assert.Equal(t, "Test quote 1", data.Quotes[1].Text)
+
+	metadata, err = ReadMetadataFromJSON(metadataFile)
+	require.NoError(t, err)
+	assert.Equal(t, "1.5.0", metadata.Version)
+	assert.Equal(t, 4, metadata.TotalQuotes)
+	assert.NotEmpty(t, metadata.LastUpdated)
+	require.NotNil(t, metadata.Stats)
+	assert.Nil(t, metadata.Source, "the merged quotes don't all come from the old spreadsheet")
+
+	_, err = MergeFile(filepath.Join(dir, "missing.xlsx"), into, metadataFile, MergeByText, DefaultOptions())
+	assert.Error(t, err)
+	_, err = os.Stat(into)
+	assert.NoError(t, err)
+}
+
+// TestMergeFileReplace tests that --replace drops the quotes missing from the input
+func TestMergeFileReplace(t *testing.T) {
+	_, excelFile := createTestExcelFile(t)
+	dir := t.TempDir()
+	into := filepath.Join(dir, "quotes.json")
+	metadataFile := filepath.Join(dir, "quotesMetadata.json")
+	require.NoError(t, WriteJSONToFile(into, QuotesData{Quotes: []Quote{
+		{ID: 1, Text: "Test quote 1", Tags: []string{"tag1"}, Language: "en-US"},
+		{ID: 2, Text: "Dropped", Tags: []string{"a"}, Language: "en-US"},
+	}}))
+	require.NoError(t, writeMetadataFile(metadataFile, Metadata{Version: "1.4", TotalQuotes: 2}, CompressNone))
+
+	opts := DefaultOptions()
+	opts.Replace = true
+	result, err := MergeFile(excelFile, into, metadataFile, MergeByText, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Removed)
+	assert.Equal(t, 2, result.Added)
+
+	data, err := ReadQuotesFromJSON(into)
+	require.NoError(t, err)
+	require.Len(t, data.Quotes, 3)
+	for _, quote := range data.Quotes {
+		assert.NotEqual(t, "Dropped", quote.Text)
+	}
+	metadata, err := ReadMetadataFromJSON(metadataFile)
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", metadata.Version)
+}
+
+// TestMergeMatchSet tests parsing the --match flag
+func TestMergeMatchSet(t *testing.T) {
+	var m MergeMatch
+	require.NoError(t, m.Set("ID"))
+	assert.Equal(t, MergeByID, m)
+	assert.Error(t, m.Set("hash"))
+}
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultMetadataURL is the placeholder URL of metadata nobody configured
+const defaultMetadataURL = "path/to/file"
+
+// SourceInfo identifies the spreadsheet a dataset was converted from
+type SourceInfo struct {
+	File   string `json:"file"` // base name, the directory is not part of the dataset
+	SHA256 string `json:"sha256"`
+}
+
+// GeneratorInfo identifies the build of the tool that wrote a dataset
+type GeneratorInfo struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`          // module version, "(devel)" for local builds
+	Commit    string `json:"commit,omitempty"` // VCS revision, when built from a checkout
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"goVersion"`
+}
+
+// QuoteCounts breaks the number of quotes down for the metadata. A quote counts once for each
+// of its tags; quotes without an author or tags are left out of those breakdowns.
+type QuoteCounts struct {
+	ByTag      map[string]int `json:"byTag"`
+	ByAuthor   map[string]int `json:"byAuthor"`
+	ByLanguage map[string]int `json:"byLanguage"`
+}
+
+// generatorInfo describes the running binary from its embedded build information
+func generatorInfo() *GeneratorInfo {
+	info := &GeneratorInfo{Name: "toJson", Version: "(devel)", GoVersion: runtime.Version()}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if build.Main.Path != "" {
+		info.Name = build.Main.Path
+	}
+	if build.Main.Version != "" {
+		info.Version = build.Main.Version
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Commit = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+// sourceInfo fingerprints the spreadsheet fileName, or returns nil for a workbook that was
+// never saved to disk
+func sourceInfo(fileName string) (*SourceInfo, error) {
+	if fileName == "" {
+		return nil, nil
+	}
+	digest, err := fileSHA256(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return &SourceInfo{File: filepath.Base(fileName), SHA256: digest}, nil
+}
+
+// durationSeconds rounds the time elapsed since start to milliseconds
+func durationSeconds(start time.Time) float64 {
+	return time.Since(start).Round(time.Millisecond).Seconds()
+}
+
+// MetadataFields holds extra key=value pairs published in the metadata, set with --meta
+type MetadataFields map[string]string
+
+// String implements flag.Value
+func (f *MetadataFields) String() string {
+	pairs := make([]string, 0, len(*f))
+	for key, value := range *f {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set implements flag.Value, adding one key=value pair
+func (f *MetadataFields) Set(pair string) error {
+	key, value, ok := strings.Cut(pair, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return fmt.Errorf("invalid metadata field %q, expected key=value", pair)
+	}
+	if *f == nil {
+		*f = MetadataFields{}
+	}
+	(*f)[key] = value
+	return nil
+}
+
+// metadataConfig is the part of the metadata describing the deployment rather than the quotes
+type metadataConfig struct {
+	URL     string         `yaml:"url"`
+	Version string         `yaml:"version"` // fixed version, replacing the bumped one
+	Extra   MetadataFields `yaml:"extra"`
+}
+
+// loadMetadataConfig reads the MetadataConfig file of opts, if any, and overlays the metadata
+// flags, which take precedence over the file
+func loadMetadataConfig(opts Options) (metadataConfig, error) {
+	var config metadataConfig
+	if opts.MetadataConfig != "" {
+		data, err := os.ReadFile(opts.MetadataConfig)
+		if err != nil {
+			return config, fmt.Errorf("failed to read metadata config %s: %w", opts.MetadataConfig, err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&config); err != nil {
+			return config, fmt.Errorf("failed to parse metadata config %s: %w", opts.MetadataConfig, err)
+		}
+	}
+	if opts.MetadataURL != "" {
+		config.URL = opts.MetadataURL
+	}
+	if opts.MetadataVersion != "" {
+		config.Version = opts.MetadataVersion
+	}
+	for key, value := range opts.MetadataExtra {
+		if config.Extra == nil {
+			config.Extra = MetadataFields{}
+		}
+		config.Extra[key] = value
+	}
+	if config.Version != "" {
+		if _, err := parseVersion(config.Version); err != nil {
+			return config, err
+		}
+	}
+	return config, nil
+}
+
+// apply sets the configured fields of metadata, leaving the others alone
+func (c metadataConfig) apply(metadata *Metadata) {
+	if c.URL != "" {
+		metadata.URL = c.URL
+	}
+	if c.Version != "" {
+		metadata.Version = c.Version
+	}
+	for key, value := range c.Extra {
+		if metadata.Extra == nil {
+			metadata.Extra = map[string]string{}
+		}
+		metadata.Extra[key] = value
+	}
+}
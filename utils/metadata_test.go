@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGeneratorInfo tests that the build of the running binary is described
+func TestGeneratorInfo(t *testing.T) {
+	info := generatorInfo()
+	assert.NotEmpty(t, info.Name)
+	assert.NotEmpty(t, info.Version)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+}
+
+// TestSourceInfo tests fingerprinting the source spreadsheet
+func TestSourceInfo(t *testing.T) {
+	info, err := sourceInfo("")
+	require.NoError(t, err)
+	assert.Nil(t, info)
+
+	name := filepath.Join(t.TempDir(), "quotes.xlsx")
+	require.NoError(t, os.WriteFile(name, []byte("abc"), 0644))
+	info, err = sourceInfo(name)
+	require.NoError(t, err)
+	assert.Equal(t, &SourceInfo{
+		File:   "quotes.xlsx",
+		SHA256: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+	}, info)
+
+	_, err = sourceInfo(filepath.Join(t.TempDir(), "missing.xlsx"))
+	assert.Error(t, err)
+}
+
+// TestDurationSeconds tests rounding the conversion time
+func TestDurationSeconds(t *testing.T) {
+	seconds := durationSeconds(time.Now().Add(-1500 * time.Millisecond))
+	assert.InDelta(t, 1.5, seconds, 0.1)
+}
+
+// TestMetadataFieldsSet tests parsing of --meta values
+func TestMetadataFieldsSet(t *testing.T) {
+	var fields MetadataFields
+	require.NoError(t, fields.Set("license=CC-BY-4.0"))
+	require.NoError(t, fields.Set(" name =quotes=all"))
+	assert.Equal(t, MetadataFields{"license": "CC-BY-4.0", "name": "quotes=all"}, fields)
+	assert.Equal(t, "license=CC-BY-4.0,name=quotes=all", fields.String())
+	assert.Error(t, fields.Set("license"))
+	assert.Error(t, fields.Set("=x"))
+}
+
+// TestLoadMetadataConfig tests that the metadata flags override the config file
+func TestLoadMetadataConfig(t *testing.T) {
+	opts := DefaultOptions()
+	opts.MetadataConfig = filepath.Join(t.TempDir(), "metadata.yaml")
+	require.NoError(t, os.WriteFile(opts.MetadataConfig, []byte(
+		"url: https://cdn.example.com/quotes.json\nversion: 2.0.0\nextra:\n  name: quotes\n  license: MIT\n"), 0644))
+	opts.MetadataVersion = "3.1"
+	require.NoError(t, opts.MetadataExtra.Set("license=CC-BY-4.0"))
+	config, err := loadMetadataConfig(opts)
+	require.NoError(t, err)
+	assert.Equal(t, metadataConfig{
+		URL:     "https://cdn.example.com/quotes.json",
+		Version: "3.1",
+		Extra:   MetadataFields{"name": "quotes", "license": "CC-BY-4.0"},
+	}, config)
+
+	metadata := newMetadata(DefaultOptions(), 1)
+	config.apply(&metadata)
+	assert.Equal(t, "https://cdn.example.com/quotes.json", metadata.URL)
+	assert.Equal(t, "3.1", metadata.Version)
+	assert.Equal(t, map[string]string{"name": "quotes", "license": "CC-BY-4.0"}, metadata.Extra)
+
+	// Unconfigured fields are left alone
+	metadata = newMetadata(DefaultOptions(), 1)
+	metadataConfig{}.apply(&metadata)
+	assert.Equal(t, defaultMetadataURL, metadata.URL)
+	assert.Nil(t, metadata.Extra)
+
+	opts.MetadataVersion = "three"
+	_, err = loadMetadataConfig(opts)
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(opts.MetadataConfig, []byte("licence: MIT\n"), 0644))
+	opts.MetadataVersion = ""
+	_, err = loadMetadataConfig(opts)
+	assert.Error(t, err)
+}
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// metricsJob is the job label of the metrics pushed to a Pushgateway
+const metricsJob = "toJson"
+
+// ConversionMetrics are the Prometheus metrics of conversions: the rows processed, the quotes
+// emitted, the rows skipped and how long they took
+type ConversionMetrics struct {
+	rows        prometheus.Counter
+	quotes      prometheus.Counter
+	skipped     prometheus.Counter
+	duration    prometheus.Histogram
+	results     *prometheus.CounterVec
+	lastSuccess prometheus.Gauge
+}
+
+// NewConversionMetrics creates the metrics of conversions and registers them with registry
+func NewConversionMetrics(registry prometheus.Registerer) *ConversionMetrics {
+	m := &ConversionMetrics{
+		rows: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "tojson_conversion_rows_processed_total",
+			Help: "Rows read by conversions, header excluded.",
+		}),
+		quotes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "tojson_conversion_quotes_emitted_total",
+			Help: "Quotes written by conversions.",
+		}),
+		skipped: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "tojson_conversion_rows_skipped_total",
+			Help: "Rows skipped by conversions, blank or failing validation.",
+		}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "tojson_conversion_duration_seconds",
+			Help:    "How long conversions took.",
+			Buckets: prometheus.ExponentialBuckets(0.05, 4, 8),
+		}),
+		results: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tojson_conversions_total",
+			Help: "Conversions run, by result: success or failure.",
+		}, []string{"result"}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "tojson_conversion_last_success_timestamp_seconds",
+			Help: "Unix time the last successful conversion finished.",
+		}),
+	}
+	registry.MustRegister(m.rows, m.quotes, m.skipped, m.duration, m.results, m.lastSuccess)
+	return m
+}
+
+// Track measures the conversion run with opts, chaining its Progress. The returned function
+// records the result once the conversion returns err.
+func (m *ConversionMetrics) Track(opts *Options) func(err error) {
+	start := time.Now()
+	rows, quotes := 0, 0
+	progress := opts.Progress
+	opts.Progress = func(r, q int) {
+		rows, quotes = r, q
+		if progress != nil {
+			progress(r, q)
+		}
+	}
+	return func(err error) {
+		m.rows.Add(float64(rows))
+		m.quotes.Add(float64(quotes))
+		m.skipped.Add(float64(max(0, rows-quotes)))
+		m.duration.Observe(time.Since(start).Seconds())
+		if err != nil {
+			m.results.WithLabelValues("failure").Inc()
+			return
+		}
+		m.results.WithLabelValues("success").Inc()
+		m.lastSuccess.SetToCurrentTime()
+	}
+}
+
+// WriteMetrics writes the metrics of gatherer to fileName, in the text format read by the
+// textfile collector of node_exporter, and pushes them to the Pushgateway at pushURL; either
+// may be empty
+func WriteMetrics(gatherer prometheus.Gatherer, fileName, pushURL string) error {
+	if fileName != "" {
+		if err := prometheus.WriteToTextfile(fileName, gatherer); err != nil {
+			return fmt.Errorf("error writing metrics to %s: %w", fileName, err)
+		}
+	}
+	if pushURL != "" {
+		if err := push.New(pushURL, metricsJob).Gatherer(gatherer).Push(); err != nil {
+			return fmt.Errorf("error pushing metrics to %s: %w", pushURL, err)
+		}
+	}
+	return nil
+}
+
+// requestMetrics are the Prometheus metrics of the requests to a Server
+type requestMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func newRequestMetrics(registry prometheus.Registerer) *requestMetrics {
+	m := &requestMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tojson_http_requests_total",
+			Help: "HTTP requests served, by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "tojson_http_request_duration_seconds",
+			Help:    "How long HTTP requests took to serve, by route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route"}),
+	}
+	registry.MustRegister(m.requests, m.duration)
+	return m
+}
+
+// observe records a request to route, the pattern it matched, answered with status
+func (m *requestMetrics) observe(route, method string, status int, duration time.Duration) {
+	m.requests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	m.duration.WithLabelValues(route).Observe(duration.Seconds())
+}
+
+// statusRecorder remembers the status code written through it
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the flusher of the wrapped writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversionMetricsTrack(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics := NewConversionMetrics(registry)
+
+	reported := 0
+	opts := Options{Progress: func(rows, quotes int) { reported++ }}
+	done := metrics.Track(&opts)
+	opts.Progress(100, 97)
+	opts.Progress(150, 146)
+	done(nil)
+	assert.Equal(t, 2, reported, "the previous Progress is still called")
+
+	done = metrics.Track(&Options{})
+	done(errors.New("failed"))
+
+	assert.Equal(t, 150.0, testutil.ToFloat64(metrics.rows))
+	assert.Equal(t, 146.0, testutil.ToFloat64(metrics.quotes))
+	assert.Equal(t, 4.0, testutil.ToFloat64(metrics.skipped))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.results.WithLabelValues("success")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.results.WithLabelValues("failure")))
+	assert.NotZero(t, testutil.ToFloat64(metrics.lastSuccess))
+	var duration dto.Metric
+	require.NoError(t, metrics.duration.Write(&duration))
+	assert.Equal(t, uint64(2), duration.GetHistogram().GetSampleCount())
+}
+
+func TestWriteMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	NewConversionMetrics(registry).Track(&Options{})(nil)
+
+	var pushed string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		pushed = r.Method + " " + r.URL.Path + " " + string(body)
+	}))
+	defer gateway.Close()
+
+	fileName := filepath.Join(t.TempDir(), "tojson.prom")
+	require.NoError(t, WriteMetrics(registry, fileName, gateway.URL))
+	content, err := os.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `tojson_conversions_total{result="success"} 1`)
+	assert.True(t, strings.HasPrefix(pushed, "PUT /metrics/job/toJson "), pushed)
+
+	assert.ErrorContains(t, WriteMetrics(registry, filepath.Join(t.TempDir(), "missing", "tojson.prom"), ""), "error writing metrics")
+	assert.NoError(t, WriteMetrics(registry, "", ""))
+}
+
+func TestServerMetrics(t *testing.T) {
+	s := testServer()
+	for _, target := range []string{"/quotes/1", "/quotes/9", "/missing"} {
+		s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(s.requests.requests.WithLabelValues("GET /quotes/{id}", "GET", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(s.requests.requests.WithLabelValues("GET /quotes/{id}", "GET", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(s.requests.requests.WithLabelValues("unmatched", "GET", "404")))
+
+	r := httptest.NewRecorder()
+	s.ServeHTTP(r, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, r.Code)
+	assert.Contains(t, r.Body.String(), `tojson_http_requests_total{code="200",method="GET",route="GET /quotes/{id}"} 1`)
+	assert.Contains(t, r.Body.String(), "tojson_conversion_rows_processed_total 0")
+	assert.Contains(t, r.Body.String(), "go_goroutines")
+}
+
+func TestServerMetricsGuarded(t *testing.T) {
+	s := NewServer(QuotesData{}, ServerOptions{APIKeys: []string{"secret"}})
+	r := httptest.NewRecorder()
+	s.ServeHTTP(r, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusUnauthorized, r.Code)
+	assert.Equal(t, 1.0, testutil.ToFloat64(s.requests.requests.WithLabelValues("GET /metrics", "GET", "401")))
+}
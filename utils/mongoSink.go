@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// mongoStore is the part of a MongoDB database used by mongoSink
+type mongoStore interface {
+	write(ctx context.Context, collection string, models []mongo.WriteModel) error
+	drop(ctx context.Context, collection string) error
+	rename(ctx context.Context, from, to string) error
+	disconnect(ctx context.Context) error
+}
+
+// mongoDatabase is the mongoStore of a database of a connected client
+type mongoDatabase struct {
+	client *mongo.Client
+	name   string
+}
+
+func (d mongoDatabase) write(ctx context.Context, collection string, models []mongo.WriteModel) error {
+	_, err := d.client.Database(d.name).Collection(collection).BulkWrite(ctx, models)
+	return err
+}
+
+func (d mongoDatabase) drop(ctx context.Context, collection string) error {
+	return d.client.Database(d.name).Collection(collection).Drop(ctx)
+}
+
+// rename renames a collection over another, which renameCollection does atomically
+func (d mongoDatabase) rename(ctx context.Context, from, to string) error {
+	return d.client.Database("admin").RunCommand(ctx, bson.D{
+		{Key: "renameCollection", Value: d.name + "." + from},
+		{Key: "to", Value: d.name + "." + to},
+		{Key: "dropTarget", Value: true},
+	}).Err()
+}
+
+func (d mongoDatabase) disconnect(ctx context.Context) error {
+	return d.client.Disconnect(ctx)
+}
+
+// mongoSink writes the quotes into a MongoDB collection with their ID as _id, in the layout of
+// --format mongo. Quotes are upserted as they are converted, which a failed conversion can't
+// take back, unless replace is set: the quotes then go to a staging collection renamed over
+// the collection once the conversion succeeds.
+type mongoSink struct {
+	store      mongoStore
+	ctx        context.Context
+	collection string
+	staging    string // empty unless the collection is replaced
+	tagsField  string
+	written    int
+}
+
+// openMongoSink returns the opener of the sinks of --to scheme://host:27017/db?collection=quotes&replace=true,
+// scheme being mongodb or mongodb+srv
+func openMongoSink(scheme string) func(target string, opts Options) (QuoteSink, error) {
+	return func(target string, opts Options) (QuoteSink, error) {
+		uri, database, collection, replace, err := mongoTarget(scheme + ":" + target)
+		if err != nil {
+			return nil, err
+		}
+		client, err := mongo.Connect(options.Client().ApplyURI(uri).SetAppName("toJson"))
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to mongodb: %w", err)
+		}
+		s := &mongoSink{store: mongoDatabase{client: client, name: database}, ctx: optsContext(opts), collection: collection, tagsField: opts.MongoTagsField}
+		// Connecting is lazy, the server is reached before converting rather than at the first batch
+		if err := client.Ping(s.ctx, nil); err != nil {
+			client.Disconnect(s.ctx)
+			return nil, fmt.Errorf("error connecting to mongodb: %w", err)
+		}
+		if replace {
+			s.staging = collection + "_staging"
+			// A staging collection left by an interrupted conversion would be renamed along
+			if err := s.store.drop(s.ctx, s.staging); err != nil {
+				client.Disconnect(s.ctx)
+				return nil, fmt.Errorf("error connecting to mongodb: %w", err)
+			}
+		}
+		return s, nil
+	}
+}
+
+// mongoTarget reads the connection string, the database, the collection and whether it is
+// replaced from the --to value of a mongodb sink; the collection defaults to quotes
+func mongoTarget(spec string) (uri, database, collection string, replace bool, err error) {
+	u, err := url.Parse(spec)
+	if err == nil {
+		database = strings.Trim(u.Path, "/")
+	}
+	if err != nil || u.Host == "" || database == "" || strings.Contains(database, "/") {
+		return "", "", "", false, fmt.Errorf("invalid sink %q, expected mongodb://host:27017/database", spec)
+	}
+	query := u.Query()
+	collection = query.Get("collection")
+	if collection == "" {
+		collection = "quotes"
+	}
+	if strings.HasPrefix(collection, "system.") || strings.ContainsAny(collection, "$\x00") {
+		return "", "", "", false, fmt.Errorf("invalid mongodb collection %q", collection)
+	}
+	if value := query.Get("replace"); value != "" {
+		if replace, err = strconv.ParseBool(value); err != nil {
+			return "", "", "", false, fmt.Errorf("invalid mongodb replace %q, expected true or false", value)
+		}
+	}
+
+	// The parameters of the sink are not connection options the driver would understand
+	query.Del("collection")
+	query.Del("replace")
+	u.RawQuery = query.Encode()
+	return u.String(), database, collection, replace, nil
+}
+
+// WriteQuotes upserts a batch of quotes by their _id, or inserts them into the staging
+// collection when the collection is replaced
+func (s *mongoSink) WriteQuotes(quotes []Quote) error {
+	if len(quotes) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, len(quotes))
+	for i, quote := range quotes {
+		data, err := mongoDocument(quote, s.tagsField)
+		if err != nil {
+			return fmt.Errorf("error marshalling quote %s: %w", quote.key(), err)
+		}
+		var document bson.D
+		if err := bson.UnmarshalExtJSON(data, true, &document); err != nil {
+			return fmt.Errorf("error marshalling quote %s: %w", quote.key(), err)
+		}
+		if s.staging != "" {
+			models[i] = mongo.NewInsertOneModel().SetDocument(document)
+		} else {
+			models[i] = mongo.NewReplaceOneModel().SetFilter(bson.D{document[0]}).SetReplacement(document).SetUpsert(true)
+		}
+	}
+
+	collection := s.collection
+	if s.staging != "" {
+		collection = s.staging
+	}
+	if err := s.store.write(s.ctx, collection, models); err != nil {
+		return fmt.Errorf("error writing quotes %s to %s to mongodb collection %s: %w",
+			quotes[0].key(), quotes[len(quotes)-1].key(), collection, err)
+	}
+	s.written += len(quotes)
+	return nil
+}
+
+// Commit renames the staging collection over the collection when it is replaced, or drops the
+// collection when there were no quotes to replace it with
+func (s *mongoSink) Commit() error {
+	defer s.store.disconnect(s.ctx)
+	if s.staging == "" {
+		return nil
+	}
+	if s.written == 0 {
+		if err := s.store.drop(s.ctx, s.collection); err != nil {
+			return fmt.Errorf("error replacing mongodb collection %s: %w", s.collection, err)
+		}
+		return nil
+	}
+	if err := s.store.rename(s.ctx, s.staging, s.collection); err != nil {
+		return fmt.Errorf("error replacing mongodb collection %s: %w", s.collection, err)
+	}
+	return nil
+}
+
+// Rollback drops the staging collection; quotes already upserted stay
+func (s *mongoSink) Rollback() error {
+	defer s.store.disconnect(s.ctx)
+	if s.staging == "" {
+		return nil
+	}
+	return s.store.drop(s.ctx, s.staging)
+}
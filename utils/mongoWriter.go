@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"strconv"
+)
+
+// mongoEncoder writes one MongoDB Extended JSON document per line for mongoimport.
+// The quote ID becomes _id as a canonical $numberLong, or $uuid with --id-strategy uuid, so every
+// document keys with the same BSON type, and empty tags are dropped so the tags field is a real array.
+type mongoEncoder struct {
+	tagsField string
+}
+
+func (e mongoEncoder) writeQuote(w *bufio.Writer, quote Quote, index int) error {
+	data, err := mongoDocument(quote, e.tagsField)
+	if err != nil {
+		return err
+	}
+	w.Write(data)
+	return w.WriteByte('\n')
+}
+
+// mongoDocument returns the canonical Extended JSON document of a quote, with its tags array
+// named tagsField
+func mongoDocument(quote Quote, tagsField string) ([]byte, error) {
+	quote.Tags = nonEmptyTags(quote.Tags)
+	if quote.Tags == nil {
+		quote.Tags = []string{}
+	}
+	tree, err := quoteTree(quote)
+	if err != nil {
+		return nil, err
+	}
+
+	id := jsonObject{{Key: "$numberLong", Value: strconv.FormatInt(quote.ID, 10)}}
+	if quote.UUID != "" {
+		id = jsonObject{{Key: "$uuid", Value: quote.UUID}}
+	}
+	document := jsonObject{{Key: "_id", Value: id}}
+	for _, member := range tree.(jsonObject) {
+		switch member.Key {
+		case "id":
+			continue
+		case "tags":
+			member.Key = tagsField
+		}
+		document = append(document, member)
+	}
+	return json.Marshal(document)
+}
+
+func (mongoEncoder) finish(w *bufio.Writer, count int) error {
+	return nil
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMongoEncoder tests _id conversion, tag cleanup and the renamed tags field
+func TestMongoEncoder(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Format = StreamMongo
+	opts.MongoTagsField = "categories"
+
+	var buf bytes.Buffer
+	s := NewQuoteStreamWriterWithOptions(&buf, opts)
+	require.NoError(t, s.WriteQuote(Quote{ID: 7, Text: "First", Author: "Someone", Tags: []string{"a", "", "b"}, Language: "en-US"}))
+	require.NoError(t, s.WriteQuote(Quote{ID: 8, Text: "Second", Tags: []string{""}, Language: "en-US"}))
+	require.NoError(t, s.Close())
+
+	assert.Equal(t, `{"_id":{"$numberLong":"7"},"text":"First","author":"Someone","categories":["a","b"],"lang":"en-US"}
+{"_id":{"$numberLong":"8"},"text":"Second","categories":[],"lang":"en-US"}
+`, buf.String())
+}
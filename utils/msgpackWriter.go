@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// msgpackEncoder writes the same {"quotes": [...]} structure as the JSON output in MessagePack.
+// The array header needs the final count, so encoded quotes are buffered until finish.
+type msgpackEncoder struct {
+	buf bytes.Buffer
+}
+
+func (e *msgpackEncoder) writeQuote(w *bufio.Writer, quote Quote, index int) error {
+	tree, err := quoteTree(quote)
+	if err != nil {
+		return err
+	}
+	return writeMsgpack(&e.buf, tree)
+}
+
+func (e *msgpackEncoder) finish(w *bufio.Writer, count int) error {
+	var header bytes.Buffer
+	writeMsgpackHeader(&header, 0x80, 0xde, 0xdf, 1) // map with one key
+	writeMsgpackString(&header, "quotes")
+	writeMsgpackHeader(&header, 0x90, 0xdc, 0xdd, count)
+	w.Write(header.Bytes())
+	_, err := w.Write(e.buf.Bytes())
+	return err
+}
+
+// writeMsgpack encodes a value produced by decodeJSONTree
+func writeMsgpack(buf *bytes.Buffer, value any) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case int64:
+		writeMsgpackInt(buf, v)
+	case float64:
+		buf.WriteByte(0xcb)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(v))
+	case string:
+		writeMsgpackString(buf, v)
+	case []any:
+		writeMsgpackHeader(buf, 0x90, 0xdc, 0xdd, len(v))
+		for _, item := range v {
+			if err := writeMsgpack(buf, item); err != nil {
+				return err
+			}
+		}
+	case jsonObject:
+		writeMsgpackHeader(buf, 0x80, 0xde, 0xdf, len(v))
+		for _, member := range v {
+			writeMsgpackString(buf, member.Key)
+			if err := writeMsgpack(buf, member.Value); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cannot encode %T as msgpack", value)
+	}
+	return nil
+}
+
+// writeMsgpackInt uses the smallest integer encoding that fits
+func writeMsgpackInt(buf *bytes.Buffer, v int64) {
+	switch {
+	case v >= 0 && v <= 0x7f:
+		buf.WriteByte(byte(v)) // positive fixint
+	case v < 0 && v >= -32:
+		buf.WriteByte(byte(v)) // negative fixint
+	case v >= 0 && v <= math.MaxUint8:
+		buf.Write([]byte{0xcc, byte(v)})
+	case v >= 0 && v <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		binary.Write(buf, binary.BigEndian, uint16(v))
+	case v >= 0 && v <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		binary.Write(buf, binary.BigEndian, uint32(v))
+	case v >= 0:
+		buf.WriteByte(0xcf)
+		binary.Write(buf, binary.BigEndian, uint64(v))
+	case v >= math.MinInt8:
+		buf.Write([]byte{0xd0, byte(int8(v))})
+	case v >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		binary.Write(buf, binary.BigEndian, int16(v))
+	case v >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		binary.Write(buf, binary.BigEndian, int32(v))
+	default:
+		buf.WriteByte(0xd3)
+		binary.Write(buf, binary.BigEndian, v)
+	}
+}
+
+// writeMsgpackString writes a UTF-8 string with the shortest str header
+func writeMsgpackString(buf *bytes.Buffer, s string) {
+	switch n := len(s); {
+	case n < 32:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.Write([]byte{0xd9, byte(n)})
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdb)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+	buf.WriteString(s)
+}
+
+// writeMsgpackHeader writes an array or map header given its fix, 16-bit and 32-bit type bytes
+func writeMsgpackHeader(buf *bytes.Buffer, fix, type16, type32 byte, n int) {
+	switch {
+	case n < 16:
+		buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(type16)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(type32)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
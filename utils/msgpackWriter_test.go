@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readMsgpack decodes the subset of MessagePack produced by writeMsgpack
+func readMsgpack(r *bytes.Reader) (any, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	readN := func(size int) uint64 {
+		raw := make([]byte, 8)
+		r.Read(raw[8-size:])
+		return binary.BigEndian.Uint64(raw)
+	}
+	readString := func(n uint64) string {
+		s := make([]byte, n)
+		r.Read(s)
+		return string(s)
+	}
+	readArray := func(n uint64) (any, error) {
+		array := []any{}
+		for i := uint64(0); i < n; i++ {
+			v, err := readMsgpack(r)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, v)
+		}
+		return array, nil
+	}
+	readMap := func(n uint64) (any, error) {
+		object := jsonObject{}
+		for i := uint64(0); i < n; i++ {
+			k, err := readMsgpack(r)
+			if err != nil {
+				return nil, err
+			}
+			v, err := readMsgpack(r)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, jsonMember{Key: k.(string), Value: v})
+		}
+		return object, nil
+	}
+
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xe0 == 0xa0:
+		return readString(uint64(b & 0x1f)), nil
+	case b&0xf0 == 0x90:
+		return readArray(uint64(b & 0x0f))
+	case b&0xf0 == 0x80:
+		return readMap(uint64(b & 0x0f))
+	}
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc:
+		return int64(readN(1)), nil
+	case 0xcd:
+		return int64(readN(2)), nil
+	case 0xce:
+		return int64(readN(4)), nil
+	case 0xcf:
+		return int64(readN(8)), nil
+	case 0xd0:
+		return int64(int8(readN(1))), nil
+	case 0xd1:
+		return int64(int16(readN(2))), nil
+	case 0xd2:
+		return int64(int32(readN(4))), nil
+	case 0xd3:
+		return int64(readN(8)), nil
+	case 0xcb:
+		return math.Float64frombits(readN(8)), nil
+	case 0xd9:
+		return readString(readN(1)), nil
+	case 0xda:
+		return readString(readN(2)), nil
+	case 0xdb:
+		return readString(readN(4)), nil
+	case 0xdc:
+		return readArray(readN(2))
+	case 0xdd:
+		return readArray(readN(4))
+	case 0xde:
+		return readMap(readN(2))
+	case 0xdf:
+		return readMap(readN(4))
+	}
+	return nil, fmt.Errorf("unexpected msgpack type byte 0x%x", b)
+}
+
+// TestMsgpackEncoderRoundTrip tests that the MessagePack output decodes to exactly the JSON structure
+func TestMsgpackEncoderRoundTrip(t *testing.T) {
+	data := QuotesData{}
+	for i := 0; i < 20; i++ {
+		data.Quotes = append(data.Quotes, Quote{
+			ID:       int64(i * 1000),
+			Text:     strings.Repeat("long text ", i*4),
+			Tags:     []string{"a", fmt.Sprint(i)},
+			Language: "en-US",
+		})
+	}
+	data.Quotes[3].Author = "Someone"
+	data.Quotes[4].Year = -350
+
+	var buf bytes.Buffer
+	s := NewQuoteStreamWriter(&buf, StreamMsgpack)
+	for _, q := range data.Quotes {
+		require.NoError(t, s.WriteQuote(q))
+	}
+	require.NoError(t, s.Close())
+
+	decoded, err := readMsgpack(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	jsonData, err := json.Marshal(data)
+	require.NoError(t, err)
+	expected, err := decodeJSONTree(jsonData)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, decoded)
+}
+
+// TestWriteMsgpackInt tests the integer size boundaries
+func TestWriteMsgpackInt(t *testing.T) {
+	for _, v := range []int64{0, 127, 128, 255, 256, 65535, 65536, math.MaxUint32, math.MaxUint32 + 1, -1, -32, -33, -128, -129, -32768, -32769, math.MinInt32, math.MinInt32 - 1} {
+		var buf bytes.Buffer
+		writeMsgpackInt(&buf, v)
+		decoded, err := readMsgpack(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, v, decoded)
+	}
+}
@@ -0,0 +1,226 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlBatchRows is the most quotes upserted by one INSERT, well within the 65535
+// placeholders a MySQL statement may have
+const mysqlBatchRows = 1000
+
+// mysqlTx is the part of sql.Tx used by mysqlSink
+type mysqlTx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	Commit() error
+	Rollback() error
+}
+
+// mysqlSink upserts quotes into a table of a MySQL or MariaDB database inside a single
+// transaction, with the same tables as the postgres sink; the tags are a JSON array since MySQL
+// has no array type
+type mysqlSink struct {
+	tx    mysqlTx
+	ctx   context.Context
+	close func() error
+	table string
+	// tagTable is the join table holding the tags of the quotes, empty when they are stored in
+	// the tags JSON column of table
+	tagTable string
+}
+
+// openMySQLSink opens the sink of --to mysql://user@host:3306/db?table=quotes&tags=json. The
+// password is read from the URL or the MYSQL_PWD environment variable.
+func openMySQLSink(target string, opts Options) (QuoteSink, error) {
+	config, table, tagTable, err := mysqlTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	connector, err := mysql.NewConnector(config)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to mysql: %w", err)
+	}
+	db := sql.OpenDB(connector)
+	ctx := optsContext(opts)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error connecting to mysql: %w", err)
+	}
+
+	s := &mysqlSink{tx: tx, ctx: ctx, close: db.Close, table: table, tagTable: tagTable}
+	if err := s.createTables(); err != nil {
+		s.Rollback()
+		return nil, err
+	}
+	return s, nil
+}
+
+// mysqlTarget reads the driver configuration, the table and the join table of the tags, empty
+// for a JSON column, from the target of a mysql sink. The identifiers are quoted.
+func mysqlTarget(target string) (*mysql.Config, string, string, error) {
+	u, err := url.Parse("mysql:" + target)
+	if err != nil || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return nil, "", "", fmt.Errorf("invalid sink %q, expected mysql://user@host:3306/database", "mysql:"+target)
+	}
+	config := mysql.NewConfig()
+	config.Net = "tcp"
+	config.Addr = u.Host
+	if u.Port() == "" {
+		config.Addr = net.JoinHostPort(u.Hostname(), "3306")
+	}
+	config.DBName = strings.Trim(u.Path, "/")
+	config.User = u.User.Username()
+	config.Passwd, _ = u.User.Password()
+	if config.Passwd == "" {
+		config.Passwd = os.Getenv("MYSQL_PWD")
+	}
+
+	query := u.Query()
+	name := query.Get("table")
+	if name == "" {
+		name = "quotes"
+	}
+	parts := strings.Split(name, ".")
+	for _, part := range parts {
+		if part == "" || len(parts) > 2 {
+			return nil, "", "", fmt.Errorf("invalid mysql table %q, expected table or database.table", name)
+		}
+	}
+	table := mysqlIdent(parts...)
+
+	tagTable := ""
+	switch query.Get("tags") {
+	case "", "json":
+	case "join":
+		parts[len(parts)-1] += "_tags"
+		tagTable = mysqlIdent(parts...)
+	default:
+		return nil, "", "", fmt.Errorf("invalid mysql tags %q, expected json or join", query.Get("tags"))
+	}
+	return config, table, tagTable, nil
+}
+
+// mysqlIdent quotes the parts of a qualified identifier with backticks
+func mysqlIdent(parts ...string) string {
+	quoted := make([]string, len(parts))
+	for i, part := range parts {
+		quoted[i] = "`" + strings.ReplaceAll(part, "`", "``") + "`"
+	}
+	return strings.Join(quoted, ".")
+}
+
+// createTables creates the table of the quotes and the join table of their tags, if missing
+func (s *mysqlSink) createTables() error {
+	tagsColumn := ",\n\t\ttags    JSON NOT NULL"
+	if s.tagTable != "" {
+		tagsColumn = ""
+	}
+	statements := []string{fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id      VARCHAR(64) PRIMARY KEY,
+		text    TEXT NOT NULL,
+		author  TEXT,
+		year    INTEGER,
+		context TEXT,
+		lang    VARCHAR(35) NOT NULL%s
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`, s.table, tagsColumn)}
+	if s.tagTable != "" {
+		statements = append(statements, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		quote_id VARCHAR(64) NOT NULL,
+		tag      VARCHAR(255) NOT NULL,
+		PRIMARY KEY (quote_id, tag),
+		FOREIGN KEY (quote_id) REFERENCES %s(id) ON DELETE CASCADE
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`, s.tagTable, s.table))
+	}
+	for _, stmt := range statements {
+		if _, err := s.tx.ExecContext(s.ctx, stmt); err != nil {
+			return fmt.Errorf("error creating mysql table %s: %w", s.table, err)
+		}
+	}
+	return nil
+}
+
+// WriteQuotes upserts a batch of quotes, mysqlBatchRows to a statement, replacing the tags of
+// those already stored
+func (s *mysqlSink) WriteQuotes(quotes []Quote) error {
+	for start := 0; start < len(quotes); start += mysqlBatchRows {
+		if err := s.upsert(quotes[start:min(start+mysqlBatchRows, len(quotes))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsert upserts quotes, and their tags into the join table, with one statement each
+func (s *mysqlSink) upsert(quotes []Quote) error {
+	if len(quotes) == 0 {
+		return nil
+	}
+	columns, row := "id, text, author, year, context, lang", "(?, ?, ?, ?, ?, ?)"
+	updates := "text = VALUES(text), author = VALUES(author), year = VALUES(year), context = VALUES(context), lang = VALUES(lang)"
+	if s.tagTable == "" {
+		columns, row, updates = columns+", tags", "(?, ?, ?, ?, ?, ?, ?)", updates+", tags = VALUES(tags)"
+	}
+
+	var args, ids, links []any
+	for _, quote := range quotes {
+		id := quote.key()
+		ids = append(ids, id)
+		args = append(args, id, quote.Text, nullString(quote.Author), nullInt(quote.Year), nullString(quote.Context), quote.Language)
+		tags := nonEmptyTags(quote.Tags)
+		if s.tagTable == "" {
+			encoded, err := json.Marshal(append([]string{}, tags...))
+			if err != nil {
+				return fmt.Errorf("error marshalling the tags of quote %s: %w", id, err)
+			}
+			args = append(args, string(encoded))
+		}
+		for _, tag := range tags {
+			links = append(links, id, tag)
+		}
+	}
+	rows := strings.Repeat(row+", ", len(quotes)-1) + row
+	upsert := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s ON DUPLICATE KEY UPDATE %s", s.table, columns, rows, updates)
+	if _, err := s.tx.ExecContext(s.ctx, upsert, args...); err != nil {
+		return fmt.Errorf("error upserting quotes %s to %s: %w", ids[0], ids[len(ids)-1], err)
+	}
+	if s.tagTable == "" {
+		return nil
+	}
+
+	placeholders := strings.Repeat("?, ", len(ids)-1) + "?"
+	if _, err := s.tx.ExecContext(s.ctx, fmt.Sprintf("DELETE FROM %s WHERE quote_id IN (%s)", s.tagTable, placeholders), ids...); err != nil {
+		return fmt.Errorf("error tagging quotes %s to %s: %w", ids[0], ids[len(ids)-1], err)
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	pairs := strings.Repeat("(?, ?), ", len(links)/2-1) + "(?, ?)"
+	if _, err := s.tx.ExecContext(s.ctx, fmt.Sprintf("INSERT IGNORE INTO %s (quote_id, tag) VALUES %s", s.tagTable, pairs), links...); err != nil {
+		return fmt.Errorf("error tagging quotes %s to %s: %w", ids[0], ids[len(ids)-1], err)
+	}
+	return nil
+}
+
+// Commit commits the transaction and closes the connection
+func (s *mysqlSink) Commit() error {
+	defer s.close()
+	if err := s.tx.Commit(); err != nil {
+		return fmt.Errorf("error committing mysql transaction: %w", err)
+	}
+	return nil
+}
+
+// Rollback abandons the transaction and closes the connection
+func (s *mysqlSink) Rollback() error {
+	defer s.close()
+	return s.tx.Rollback()
+}
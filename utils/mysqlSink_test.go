@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMySQLTx records the statements executed in it
+type fakeMySQLTx struct {
+	statements []string
+	args       [][]any
+	err        error
+	committed  bool
+	rolledBack bool
+}
+
+func (tx *fakeMySQLTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if tx.err != nil {
+		return nil, tx.err
+	}
+	tx.statements = append(tx.statements, query)
+	tx.args = append(tx.args, args)
+	return nil, nil
+}
+
+func (tx *fakeMySQLTx) Commit() error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakeMySQLTx) Rollback() error {
+	tx.rolledBack = true
+	return nil
+}
+
+func newFakeMySQLSink(tx *fakeMySQLTx, table, tagTable string) *mysqlSink {
+	return &mysqlSink{tx: tx, ctx: context.Background(), close: func() error { return nil }, table: table, tagTable: tagTable}
+}
+
+func TestMySQLTarget(t *testing.T) {
+	t.Setenv("MYSQL_PWD", "from-env")
+	tests := []struct {
+		target   string
+		addr     string
+		database string
+		user     string
+		password string
+		table    string
+		tagTable string
+		wantErr  string
+	}{
+		{target: "//app@localhost/content", addr: "localhost:3306", database: "content", user: "app", password: "from-env", table: "`quotes`"},
+		{target: "//app:secret@db:3307/content?table=site.quotes&tags=join", addr: "db:3307", database: "content", user: "app", password: "secret", table: "`site`.`quotes`", tagTable: "`site`.`quotes_tags`"},
+		{target: "//localhost/content?tags=json&table=my`quotes", addr: "localhost:3306", database: "content", password: "from-env", table: "`my``quotes`"},
+		{target: "//localhost", wantErr: "expected mysql://user@host:3306/database"},
+		{target: "//localhost/content?table=a..b", wantErr: `invalid mysql table "a..b"`},
+		{target: "//localhost/content?tags=array", wantErr: `invalid mysql tags "array"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			config, table, tagTable, err := mysqlTarget(tt.target)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.addr, config.Addr)
+			assert.Equal(t, tt.database, config.DBName)
+			assert.Equal(t, tt.user, config.User)
+			assert.Equal(t, tt.password, config.Passwd)
+			assert.Equal(t, tt.table, table)
+			assert.Equal(t, tt.tagTable, tagTable)
+		})
+	}
+}
+
+func TestMySQLSinkJSON(t *testing.T) {
+	tx := &fakeMySQLTx{}
+	sink := newFakeMySQLSink(tx, "`quotes`", "")
+	require.NoError(t, sink.createTables())
+	require.NoError(t, sink.WriteQuotes([]Quote{
+		{ID: 1, Text: "Know thyself.", Tags: []string{"wisdom", ""}, Language: "en-US", Year: 1900},
+		{ID: 2, Text: "Carpe diem.", Language: "la"},
+	}))
+	require.NoError(t, sink.Commit())
+	assert.True(t, tx.committed)
+
+	require.Len(t, tx.statements, 2)
+	assert.Contains(t, tx.statements[0], "tags    JSON NOT NULL")
+	assert.Equal(t, "INSERT INTO `quotes` (id, text, author, year, context, lang, tags) VALUES (?, ?, ?, ?, ?, ?, ?), (?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE "+
+		"text = VALUES(text), author = VALUES(author), year = VALUES(year), context = VALUES(context), lang = VALUES(lang), tags = VALUES(tags)", tx.statements[1])
+	assert.Equal(t, []any{
+		"1", "Know thyself.", sql.NullString{}, sql.NullInt64{Int64: 1900, Valid: true}, sql.NullString{}, "en-US", `["wisdom"]`,
+		"2", "Carpe diem.", sql.NullString{}, sql.NullInt64{}, sql.NullString{}, "la", `[]`,
+	}, tx.args[1])
+}
+
+func TestMySQLSinkBatches(t *testing.T) {
+	tx := &fakeMySQLTx{}
+	sink := newFakeMySQLSink(tx, "`quotes`", "`quotes_tags`")
+	quotes := make([]Quote, mysqlBatchRows+1)
+	for i := range quotes {
+		quotes[i] = Quote{ID: int64(i + 1), Text: "Know thyself.", Tags: []string{"wisdom"}, Language: "en"}
+	}
+	quotes[mysqlBatchRows].Tags = nil
+	require.NoError(t, sink.WriteQuotes(quotes))
+	require.NoError(t, sink.Rollback())
+	assert.True(t, tx.rolledBack)
+
+	// The first batch upserts, clears and relinks its tags; the last quote has none to link
+	require.Len(t, tx.statements, 5)
+	assert.Len(t, tx.args[0], mysqlBatchRows*6)
+	assert.True(t, strings.HasPrefix(tx.statements[1], "DELETE FROM `quotes_tags` WHERE quote_id IN (?, ?"))
+	assert.True(t, strings.HasPrefix(tx.statements[2], "INSERT IGNORE INTO `quotes_tags` (quote_id, tag) VALUES (?, ?), "))
+	assert.Equal(t, []any{"1", "wisdom"}, tx.args[2][:2])
+	assert.Equal(t, []any{"1001"}, tx.args[4])
+}
+
+func TestMySQLSinkErrors(t *testing.T) {
+	tx := &fakeMySQLTx{err: errors.New("table doesn't exist")}
+	sink := newFakeMySQLSink(tx, "`quotes`", "")
+	assert.ErrorContains(t, sink.createTables(), "error creating mysql table `quotes`: table doesn't exist")
+	assert.ErrorContains(t, sink.WriteQuotes([]Quote{{ID: 7, Text: "Be yourself."}, {ID: 8, Text: "Carpe diem."}}), "error upserting quotes 7 to 8: table doesn't exist")
+
+	_, err := OpenSink("mysql://nobody@127.0.0.1:1/quotes", DefaultOptions())
+	assert.ErrorContains(t, err, "error connecting to mysql")
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsFlushTimeout is how long a batch of published quotes may take to reach the server
+const natsFlushTimeout = 30 * time.Second
+
+// natsConn is the part of nats.Conn used by natsSink
+type natsConn interface {
+	PublishMsg(msg *nats.Msg) error
+	FlushTimeout(timeout time.Duration) error
+	Drain() error
+}
+
+// natsEvent is published on the completion subject when the conversion succeeds or fails
+type natsEvent struct {
+	Event   string `json:"event"` // conversion.succeeded or conversion.failed
+	Subject string `json:"subject"`
+	Quotes  int    `json:"quotes"` // quotes published on Subject
+}
+
+// natsSink publishes every quote on a NATS subject, then an event on the completion subject
+// once the conversion is over, for consumers that react to new quotes instead of polling
+type natsSink struct {
+	conn      natsConn
+	subject   string
+	done      string // subject of the completion event
+	published int
+}
+
+// openNATSSink opens the sink of --to nats://server:4222/subject?done=subject.done. The
+// credentials are those of the URL, user:password@ or token@, or the .creds file named by
+// NATS_CREDS.
+func openNATSSink(target string, opts Options) (QuoteSink, error) {
+	servers, subject, done, err := natsTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	options := []nats.Option{nats.Name("toJson " + generatorInfo().Version)}
+	if creds := os.Getenv("NATS_CREDS"); creds != "" {
+		options = append(options, nats.UserCredentials(creds))
+	}
+	conn, err := nats.Connect(strings.Join(servers, ","), options...)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to NATS %s: %w", strings.Join(servers, ","), err)
+	}
+	return &natsSink{conn: conn, subject: subject, done: done}, nil
+}
+
+// natsTarget reads the servers, the subject of the quotes and that of the completion event of
+// the target of a nats sink, //user:password@server:4222,server2/subject?done=subject.done;
+// the completion subject defaults to the subject followed by .done
+func natsTarget(target string) (servers []string, subject, done string, err error) {
+	location, query, _ := strings.Cut(target, "?")
+	hosts, subject, _ := strings.Cut(strings.TrimPrefix(location, "//"), "/")
+	subject = strings.TrimSuffix(subject, "/")
+	values, queryErr := url.ParseQuery(query)
+	if !strings.HasPrefix(target, "//") || hosts == "" || !validNATSSubject(subject) || queryErr != nil {
+		return nil, "", "", fmt.Errorf("invalid sink %q, expected nats://server:4222/subject", "nats:"+target)
+	}
+	done = values.Get("done")
+	if done == "" {
+		done = subject + ".done"
+	}
+	if !validNATSSubject(done) {
+		return nil, "", "", fmt.Errorf("invalid completion subject %q of sink %q", done, "nats:"+target)
+	}
+	// The credentials given once apply to every server, nats.Connect expects them on each
+	userinfo := ""
+	if i := strings.LastIndex(hosts, "@"); i >= 0 {
+		userinfo, hosts = hosts[:i+1], hosts[i+1:]
+	}
+	for _, host := range strings.Split(hosts, ",") {
+		servers = append(servers, "nats://"+userinfo+host)
+	}
+	return servers, subject, done, nil
+}
+
+// validNATSSubject reports whether subject can be published on: dot-separated tokens without
+// spaces or wildcards
+func validNATSSubject(subject string) bool {
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n*>") {
+		return false
+	}
+	for _, token := range strings.Split(subject, ".") {
+		if token == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteQuotes publishes a batch of quotes, the JSON of each one with its ID in the Quote-Id
+// header, and waits for the server to have received them
+func (s *natsSink) WriteQuotes(quotes []Quote) error {
+	for _, quote := range quotes {
+		data, err := json.Marshal(quote)
+		if err != nil {
+			return fmt.Errorf("error marshalling quote %s: %w", quote.key(), err)
+		}
+		msg := nats.NewMsg(s.subject)
+		msg.Header.Set("Quote-Id", quote.key())
+		msg.Header.Set("Content-Type", "application/json")
+		msg.Data = data
+		if err := s.conn.PublishMsg(msg); err != nil {
+			return fmt.Errorf("error publishing to NATS subject %s: %w", s.subject, err)
+		}
+	}
+	if err := s.conn.FlushTimeout(natsFlushTimeout); err != nil {
+		return fmt.Errorf("error publishing to NATS subject %s: %w", s.subject, err)
+	}
+	s.published += len(quotes)
+	return nil
+}
+
+// Commit publishes the conversion.succeeded event and closes the connection
+func (s *natsSink) Commit() error {
+	return s.finish("conversion.succeeded")
+}
+
+// Rollback publishes the conversion.failed event and closes the connection. The quotes already
+// published can't be taken back, the event tells consumers to disregard them.
+func (s *natsSink) Rollback() error {
+	return s.finish("conversion.failed")
+}
+
+// finish publishes the completion event and drains the connection
+func (s *natsSink) finish(event string) error {
+	data, err := json.Marshal(natsEvent{Event: event, Subject: s.subject, Quotes: s.published})
+	if err == nil {
+		msg := nats.NewMsg(s.done)
+		msg.Header.Set("Content-Type", "application/json")
+		msg.Data = data
+		err = s.conn.PublishMsg(msg)
+	}
+	if err == nil {
+		err = s.conn.FlushTimeout(natsFlushTimeout)
+	}
+	if drainErr := s.conn.Drain(); err == nil {
+		err = drainErr
+	}
+	if err != nil {
+		return fmt.Errorf("error publishing to NATS subject %s: %w", s.done, err)
+	}
+	return nil
+}
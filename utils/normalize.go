@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// textNormalizers lists every --normalize step
+var textNormalizers = map[string]func(string) string{
+	"whitespace":   collapseWhitespace,
+	"ascii":        asciiPunctuation,
+	"smart":        smartPunctuation,
+	"strip-period": stripTrailingPeriod,
+	"trim":         strings.TrimSpace,
+}
+
+// normalizerOrder is the order the steps run in, whatever order they were requested in
+var normalizerOrder = []string{"whitespace", "ascii", "smart", "strip-period", "trim"}
+
+// NormalizeList collects the comma separated --normalize steps applied to quote text
+type NormalizeList []string
+
+// String implements flag.Value
+func (n *NormalizeList) String() string {
+	return strings.Join(*n, ",")
+}
+
+// Set implements flag.Value, accepting only the supported steps
+func (n *NormalizeList) Set(value string) error {
+	for _, step := range strings.Split(value, ",") {
+		step = strings.ToLower(strings.TrimSpace(step))
+		if _, ok := textNormalizers[step]; !ok {
+			names := make([]string, 0, len(textNormalizers))
+			for name := range textNormalizers {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("unknown normalization %q (supported: %s)", step, strings.Join(names, ", "))
+		}
+		*n = append(*n, step)
+	}
+	if n.has("ascii") && n.has("smart") {
+		return fmt.Errorf("the ascii and smart normalizations are mutually exclusive")
+	}
+	return nil
+}
+
+// has reports whether step was requested
+func (n NormalizeList) has(step string) bool {
+	for _, s := range n {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// apply runs the requested steps over text
+func (n NormalizeList) apply(text string) string {
+	for _, step := range normalizerOrder {
+		if n.has(step) {
+			text = textNormalizers[step](text)
+		}
+	}
+	return text
+}
+
+// collapseWhitespace replaces every run of whitespace, line breaks included, with a single space
+func collapseWhitespace(text string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// asciiReplacer maps typographic punctuation to its ASCII equivalent
+var asciiReplacer = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'", "′", "'",
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`, "″", `"`,
+	"–", "-", "—", "--", "‒", "-", "―", "--",
+	"…", "...",
+	" ", " ",
+)
+
+// asciiPunctuation converts smart quotes, dashes and ellipses to ASCII
+func asciiPunctuation(text string) string {
+	return asciiReplacer.Replace(text)
+}
+
+// smartPunctuation converts ASCII quotes to curly quotes, -- to an em dash and ... to an ellipsis.
+// A quote opens at the start of the text or after whitespace or an opening bracket and closes
+// everywhere else, so apostrophes become ’.
+func smartPunctuation(text string) string {
+	text = strings.NewReplacer("---", "—", "--", "—", "...", "…").Replace(text)
+
+	var b strings.Builder
+	prev := ' '
+	for _, r := range text {
+		opening := unicode.IsSpace(prev) || strings.ContainsRune("([{—“‘", prev)
+		switch {
+		case r == '"' && opening:
+			b.WriteRune('“')
+		case r == '"':
+			b.WriteRune('”')
+		case r == '\'' && opening:
+			b.WriteRune('‘')
+		case r == '\'':
+			b.WriteRune('’')
+		default:
+			b.WriteRune(r)
+		}
+		prev = r
+	}
+	return b.String()
+}
+
+// stripTrailingPeriod removes a single full stop ending the text, along with any whitespace
+// after it, but keeps ellipses
+func stripTrailingPeriod(text string) string {
+	trimmed := strings.TrimRightFunc(text, unicode.IsSpace)
+	if strings.HasSuffix(trimmed, ".") && !strings.HasSuffix(trimmed, "..") {
+		return strings.TrimSuffix(trimmed, ".")
+	}
+	return text
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTextNormalizers tests each normalization step on its own
+func TestTextNormalizers(t *testing.T) {
+	tests := []struct {
+		step     string
+		input    string
+		expected string
+	}{
+		{step: "whitespace", input: "  Two\t\tspaces\n and  a line ", expected: " Two spaces and a line "},
+		{step: "ascii", input: "“It’s a–b—c…”", expected: `"It's a-b--c..."`},
+		{step: "smart", input: `"It's (the 'end')"... -- he said`, expected: "“It’s (the ‘end’)”… — he said"},
+		{step: "strip-period", input: "The end. ", expected: "The end"},
+		{step: "strip-period", input: "To be continued...", expected: "To be continued..."},
+		{step: "strip-period", input: "No period", expected: "No period"},
+		{step: "trim", input: "  padded \n", expected: "padded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.step, func(t *testing.T) {
+			assert.Equal(t, tt.expected, textNormalizers[tt.step](tt.input))
+		})
+	}
+}
+
+// TestNormalizeList tests parsing the step list and applying it in a fixed order
+func TestNormalizeList(t *testing.T) {
+	var steps NormalizeList
+	require.NoError(t, steps.Set("trim, strip-period"))
+	require.NoError(t, steps.Set("WHITESPACE"))
+	assert.Equal(t, NormalizeList{"trim", "strip-period", "whitespace"}, steps)
+	assert.Equal(t, "Spaced out", steps.apply("  Spaced \n out.  "))
+
+	assert.Error(t, steps.Set("rot13"))
+
+	var exclusive NormalizeList
+	assert.Error(t, exclusive.Set("ascii,smart"))
+}
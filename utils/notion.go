@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// notionAPI is the public Notion API
+	notionAPI = "https://api.notion.com/v1"
+	// notionVersion is the version of the Notion API the requests are written for
+	notionVersion = "2022-06-28"
+	// notionPageSize is the most rows Notion returns per query
+	notionPageSize = 100
+)
+
+// notionProperty is the value of a property of a Notion page, a row of a database. Only the
+// member named by Type is set.
+type notionProperty struct {
+	Type        string                  `json:"type"`
+	Title       []notionRichText        `json:"title"`
+	RichText    []notionRichText        `json:"rich_text"`
+	Number      *float64                `json:"number"`
+	Select      *notionOption           `json:"select"`
+	Status      *notionOption           `json:"status"`
+	MultiSelect []notionOption          `json:"multi_select"`
+	Date        *struct{ Start string } `json:"date"`
+	People      []struct{ Name string } `json:"people"`
+	URL         *string                 `json:"url"`
+	Email       *string                 `json:"email"`
+	PhoneNumber *string                 `json:"phone_number"`
+	Checkbox    bool                    `json:"checkbox"`
+	Formula     *notionProperty         `json:"formula"`
+	String      *string                 `json:"string"`  // value of a string formula
+	Boolean     *bool                   `json:"boolean"` // value of a boolean formula
+}
+
+type notionRichText struct {
+	PlainText string `json:"plain_text"`
+}
+
+type notionOption struct {
+	Name string `json:"name"`
+}
+
+// text returns the value of the property as the text of a cell; tags of a multi-select are
+// joined with ", "
+func (p notionProperty) text() string {
+	switch p.Type {
+	case "title":
+		return notionPlainText(p.Title)
+	case "rich_text":
+		return notionPlainText(p.RichText)
+	case "number":
+		if p.Number != nil {
+			return strconv.FormatFloat(*p.Number, 'f', -1, 64)
+		}
+	case "select":
+		if p.Select != nil {
+			return p.Select.Name
+		}
+	case "status":
+		if p.Status != nil {
+			return p.Status.Name
+		}
+	case "multi_select":
+		names := make([]string, len(p.MultiSelect))
+		for i, option := range p.MultiSelect {
+			names[i] = option.Name
+		}
+		return strings.Join(names, ", ")
+	case "date":
+		if p.Date != nil {
+			return p.Date.Start
+		}
+	case "people":
+		names := make([]string, len(p.People))
+		for i, person := range p.People {
+			names[i] = person.Name
+		}
+		return strings.Join(names, ", ")
+	case "url", "email", "phone_number", "string":
+		for _, value := range []*string{p.URL, p.Email, p.PhoneNumber, p.String} {
+			if value != nil {
+				return *value
+			}
+		}
+	case "checkbox":
+		return strconv.FormatBool(p.Checkbox)
+	case "boolean":
+		if p.Boolean != nil {
+			return strconv.FormatBool(*p.Boolean)
+		}
+	case "formula":
+		if p.Formula != nil {
+			return p.Formula.text()
+		}
+	}
+	return ""
+}
+
+func notionPlainText(texts []notionRichText) string {
+	var b strings.Builder
+	for _, text := range texts {
+		b.WriteString(text.PlainText)
+	}
+	return b.String()
+}
+
+// importNotion queries every row of the Notion database whose ID is args[0], authenticating with
+// opts.Token or the NOTION_TOKEN environment variable. Each row is a quote and its properties are
+// columns, recognised by name like the header of a sheet, so a Tags multi-select and an Author
+// property are read as such. The title property holds the text unless another property is
+// named like it, such as Quote.
+func importNotion(args []string, opts ImportOptions) (*sliceRows, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("notion needs the ID of a database")
+	}
+	token := opts.Token
+	if token == "" {
+		token = os.Getenv("NOTION_TOKEN")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("notion needs an integration token, set with --token or NOTION_TOKEN")
+	}
+	api := opts.API
+	if api == "" {
+		api = notionAPI
+	}
+	source := newHTTPSource(opts.Interval)
+	source.headers["Authorization"] = "Bearer " + token
+	source.headers["Notion-Version"] = notionVersion
+
+	var pages []map[string]notionProperty
+	body := map[string]any{"page_size": notionPageSize}
+	for {
+		var response struct {
+			Results []struct {
+				Properties map[string]notionProperty `json:"properties"`
+			} `json:"results"`
+			HasMore    bool   `json:"has_more"`
+			NextCursor string `json:"next_cursor"`
+		}
+		url := strings.TrimSuffix(api, "/") + "/databases/" + args[0] + "/query"
+		if err := source.doJSON(http.MethodPost, url, body, &response); err != nil {
+			return nil, err
+		}
+		for _, page := range response.Results {
+			pages = append(pages, page.Properties)
+		}
+		if !response.HasMore || response.NextCursor == "" {
+			break
+		}
+		body["start_cursor"] = response.NextCursor
+	}
+	return notionRows(pages), nil
+}
+
+// notionRows lays out the rows of a Notion database with one column per property, like
+// fieldRows
+func notionRows(pages []map[string]notionProperty) *sliceRows {
+	title := ""
+	records := make([]map[string]string, len(pages))
+	for i, page := range pages {
+		records[i] = make(map[string]string, len(page))
+		for name, property := range page {
+			records[i][name] = property.text()
+			if property.Type == "title" {
+				title = name
+			}
+		}
+	}
+	rows := fieldRows(records)
+
+	// The title is the text when no property is named like one
+	header := rows[0]
+	textNamed := false
+	for _, name := range header {
+		textNamed = textNamed || containsTag(columnFields["text"], strings.ToLower(strings.TrimSpace(name)))
+	}
+	for i, name := range header {
+		if name == title && !textNamed {
+			header[i] = "Quote"
+		}
+	}
+	return &sliceRows{rows: rows}
+}
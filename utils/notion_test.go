@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNotionPropertyText tests reading the values of the property types
+func TestNotionPropertyText(t *testing.T) {
+	tests := map[string]string{
+		`{"type": "title", "title": [{"plain_text": "Know "}, {"plain_text": "thyself."}]}`: "Know thyself.",
+		`{"type": "rich_text", "rich_text": []}`:                                            "",
+		`{"type": "number", "number": 1950}`:                                                "1950",
+		`{"type": "number", "number": null}`:                                                "",
+		`{"type": "select", "select": {"name": "en"}}`:                                      "en",
+		`{"type": "status", "status": {"name": "Published"}}`:                               "Published",
+		`{"type": "multi_select", "multi_select": [{"name": "wisdom"}, {"name": "life"}]}`:  "wisdom, life",
+		`{"type": "date", "date": {"start": "1926-12-04", "end": null}}`:                    "1926-12-04",
+		`{"type": "people", "people": [{"name": "Ada"}]}`:                                   "Ada",
+		`{"type": "url", "url": "https://example.org"}`:                                     "https://example.org",
+		`{"type": "checkbox", "checkbox": true}`:                                            "true",
+		`{"type": "formula", "formula": {"type": "string", "string": "computed"}}`:          "computed",
+		`{"type": "formula", "formula": {"type": "boolean", "boolean": false}}`:             "false",
+		`{"type": "relation", "relation": [{"id": "x"}]}`:                                   "",
+	}
+	for property, want := range tests {
+		var p notionProperty
+		require.NoError(t, json.Unmarshal([]byte(property), &p))
+		assert.Equal(t, want, p.text(), property)
+	}
+}
+
+// TestImportNotion tests querying every page of a database
+func TestImportNotion(t *testing.T) {
+	var cursors []any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/databases/db123/query", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, notionVersion, r.Header.Get("Notion-Version"))
+		var body map[string]any
+		content, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(content, &body))
+		cursors = append(cursors, body["start_cursor"])
+
+		page := `{"properties": {
+			"Name": {"type": "title", "title": [{"plain_text": "Know thyself."}]},
+			"Author": {"type": "rich_text", "rich_text": [{"plain_text": "Socrates"}]},
+			"Tags": {"type": "multi_select", "multi_select": [{"name": "wisdom"}]}}}`
+		if body["start_cursor"] == nil {
+			w.Write([]byte(`{"results": [` + page + `], "has_more": true, "next_cursor": "next"}`))
+			return
+		}
+		w.Write([]byte(`{"results": [{"properties": {
+			"Name": {"type": "title", "title": [{"plain_text": "Carpe diem."}]},
+			"Author": {"type": "rich_text", "rich_text": [{"plain_text": "Horace"}]},
+			"Tags": {"type": "multi_select", "multi_select": []}}}], "has_more": false, "next_cursor": null}`))
+	}))
+	defer server.Close()
+
+	rows, err := importNotion([]string{"db123"}, ImportOptions{API: server.URL, Token: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"Author", "Quote", "Tags"}, {"Socrates", "Know thyself.", "wisdom"}, {"Horace", "Carpe diem.", ""}}, rows.rows)
+	assert.Equal(t, []any{nil, "next"}, cursors)
+
+	t.Setenv("NOTION_TOKEN", "")
+	_, err = importNotion([]string{"db123"}, ImportOptions{API: server.URL})
+	assert.ErrorContains(t, err, "NOTION_TOKEN")
+	_, err = importNotion(nil, ImportOptions{Token: "secret"})
+	assert.Error(t, err)
+}
+
+// TestNotionRows tests that a property named like the text is used over the title
+func TestNotionRows(t *testing.T) {
+	rows := notionRows([]map[string]notionProperty{{
+		"Name":  {Type: "title", Title: []notionRichText{{PlainText: "entry 1"}}},
+		"Quote": {Type: "rich_text", RichText: []notionRichText{{PlainText: "Know thyself."}}},
+	}})
+	assert.Equal(t, [][]string{{"Name", "Quote"}, {"entry 1", "Know thyself."}}, rows.rows)
+}
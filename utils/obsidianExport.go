@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// obsidianFrontMatter is the YAML front matter of an exported Obsidian note, read by Obsidian
+// as its properties
+type obsidianFrontMatter struct {
+	ID      any      `yaml:"id"` // the UUID of the quote when it has one
+	Author  string   `yaml:"author,omitempty"`
+	Year    int      `yaml:"year,omitempty"`
+	Context string   `yaml:"context,omitempty"`
+	Lang    string   `yaml:"lang"`
+	Tags    []string `yaml:"tags"`
+}
+
+// ExportObsidianVault writes one Markdown note per quote into outDir, a folder of an Obsidian
+// vault or a new vault. The note holds the quote as a blockquote, its author as a [[link]] so
+// the quotes of an author are listed among the backlinks of their note, and its tags as
+// #links; the fields are also written as front matter.
+func ExportObsidianVault(data QuotesData, outDir string) error {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("error creating directory %s: %w", outDir, err)
+	}
+
+	for _, quote := range data.Quotes {
+		tags := []string{}
+		for _, tag := range nonEmptyTags(quote.Tags) {
+			if tag = obsidianTag(tag); tag != "" && !containsTag(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		frontMatter := obsidianFrontMatter{
+			ID:      quote.ref(),
+			Author:  quote.Author,
+			Year:    quote.Year,
+			Context: quote.Context,
+			Lang:    quote.Language,
+			Tags:    tags,
+		}
+
+		var body strings.Builder
+		for _, line := range strings.Split(strings.TrimSpace(quote.Text), "\n") {
+			body.WriteString(strings.TrimRight("> "+strings.TrimSpace(line), " ") + "\n")
+		}
+		if attribution := obsidianAttribution(quote); attribution != "" {
+			body.WriteString("\n— " + attribution + "\n")
+		}
+		if len(tags) > 0 {
+			body.WriteString("\n#" + strings.Join(tags, " #") + "\n")
+		}
+
+		filename := filepath.Join(outDir, "quote-"+quote.key()+".md")
+		if err := writeFrontMatterFile(filename, frontMatter, body.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// obsidianAttribution returns the attribution line of a note, as "[[Author]], Context (Year)"
+func obsidianAttribution(quote Quote) string {
+	var parts []string
+	if author := obsidianLinkTarget(quote.Author); author != "" {
+		parts = append(parts, "[["+author+"]]")
+	}
+	if quote.Context != "" {
+		parts = append(parts, quote.Context)
+	}
+	attribution := strings.Join(parts, ", ")
+	if quote.Year != 0 {
+		attribution = strings.TrimSpace(attribution + " (" + strconv.Itoa(quote.Year) + ")")
+	}
+	return attribution
+}
+
+// obsidianLinkTarget removes the characters Obsidian doesn't allow in the name of a linked note
+func obsidianLinkTarget(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]|#^\/:`, r) {
+			return -1
+		}
+		return r
+	}, name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// obsidianTag turns a tag into one Obsidian recognises: letters, digits, "_", "-" and "/",
+// with other characters replaced by "-". A tag of digits only, such as a year, is prefixed
+// with "_" since Obsidian wants a tag to hold something other than a number.
+func obsidianTag(tag string) string {
+	tag = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '/' {
+			return r
+		}
+		return '-'
+	}, strings.TrimSpace(strings.TrimPrefix(tag, "#")))
+	tag = strings.Trim(tag, "-/")
+	if tag == "" {
+		return ""
+	}
+	if strings.IndexFunc(tag, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+		tag = "_" + tag
+	}
+	return tag
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// TestExportObsidianVault tests the front matter and body of the exported notes
+func TestExportObsidianVault(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "vault", "Quotes")
+	data := QuotesData{Quotes: []Quote{
+		{ID: 1, Text: "First line\nsecond line\n", Author: "Albert Einstein", Year: 1926, Context: "Letter to Max Born", Tags: []string{"physics", "famous quotes", "1926"}, Language: "en-US"},
+		{ID: 2, Text: "Untagged", Tags: []string{""}, Language: "en-US"},
+	}}
+
+	require.NoError(t, ExportObsidianVault(data, dir))
+
+	content, err := os.ReadFile(filepath.Join(dir, "quote-1.md"))
+	require.NoError(t, err)
+	parts := strings.SplitN(string(content), "---\n", 3)
+	require.Len(t, parts, 3)
+
+	var frontMatter obsidianFrontMatter
+	require.NoError(t, yaml.Unmarshal([]byte(parts[1]), &frontMatter))
+	assert.Equal(t, obsidianFrontMatter{ID: 1, Author: "Albert Einstein", Year: 1926, Context: "Letter to Max Born", Lang: "en-US", Tags: []string{"physics", "famous-quotes", "_1926"}}, frontMatter)
+	assert.Equal(t, "\n> First line\n> second line\n\n— [[Albert Einstein]], Letter to Max Born (1926)\n\n#physics #famous-quotes #_1926\n", parts[2])
+
+	content, err = os.ReadFile(filepath.Join(dir, "quote-2.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "tags: []\n")
+	assert.True(t, strings.HasSuffix(string(content), "\n> Untagged\n"))
+}
+
+// TestExportObsidianVaultUUIDs tests that quotes keyed by UUID get a note each, named after it
+func TestExportObsidianVaultUUIDs(t *testing.T) {
+	dir := t.TempDir()
+	data := QuotesData{Quotes: []Quote{
+		{UUID: "0190a0e4-0000-7000-8000-000000000001", Text: "First", Language: "en-US"},
+		{UUID: "0190a0e4-0000-7000-8000-000000000002", Text: "Second", Language: "en-US"},
+	}}
+	require.NoError(t, ExportObsidianVault(data, dir))
+
+	content, err := os.ReadFile(filepath.Join(dir, "quote-0190a0e4-0000-7000-8000-000000000002.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "id: 0190a0e4-0000-7000-8000-000000000002\n")
+	assert.Contains(t, string(content), "> Second\n")
+	assert.FileExists(t, filepath.Join(dir, "quote-0190a0e4-0000-7000-8000-000000000001.md"))
+}
+
+// TestObsidianTag tests turning tags into ones Obsidian recognises
+func TestObsidianTag(t *testing.T) {
+	tests := map[string]string{
+		"wisdom":          "wisdom",
+		"#life":           "life",
+		"famous quotes":   "famous-quotes",
+		"science/physics": "science/physics",
+		"don't panic!":    "don-t-panic",
+		"2020":            "_2020",
+		"  ":              "",
+	}
+	for tag, want := range tests {
+		assert.Equal(t, want, obsidianTag(tag), tag)
+	}
+	assert.Equal(t, "ACDC live", obsidianLinkTarget("AC/DC [live]"))
+}
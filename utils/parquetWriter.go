@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"bufio"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetQuote is the Parquet row layout of a Quote; empty optional fields are stored as nulls
+type parquetQuote struct {
+	ID       int64    `parquet:"id"`
+	Text     string   `parquet:"text"`
+	Author   string   `parquet:"author,optional"`
+	Year     int32    `parquet:"year,optional"`
+	Context  string   `parquet:"context,optional"`
+	Tags     []string `parquet:"tags,list"`
+	Language string   `parquet:"lang"`
+}
+
+// parquetEncoder writes quotes as a single Parquet file with snappy-compressed pages
+type parquetEncoder struct {
+	writer *parquet.GenericWriter[parquetQuote]
+}
+
+// open creates the Parquet writer on first use, since it needs the output writer
+func (e *parquetEncoder) open(w *bufio.Writer) {
+	if e.writer == nil {
+		e.writer = parquet.NewGenericWriter[parquetQuote](w, parquet.Compression(&parquet.Snappy))
+	}
+}
+
+func (e *parquetEncoder) writeQuote(w *bufio.Writer, quote Quote, index int) error {
+	e.open(w)
+	_, err := e.writer.Write([]parquetQuote{{
+		ID:       quote.ID,
+		Text:     quote.Text,
+		Author:   quote.Author,
+		Year:     int32(quote.Year),
+		Context:  quote.Context,
+		Tags:     quote.Tags,
+		Language: quote.Language,
+	}})
+	return err
+}
+
+func (e *parquetEncoder) finish(w *bufio.Writer, count int) error {
+	e.open(w)
+	return e.writer.Close()
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParquetEncoder tests that quotes round-trip through the Parquet writer
+func TestParquetEncoder(t *testing.T) {
+	quotes := []Quote{
+		{ID: 1, Text: "First", Tags: []string{"a", "b"}, Language: "en-US"},
+		{ID: 2, Text: "Second", Author: "Someone", Year: 1950, Context: "Book", Tags: []string{"c"}, Language: "ta-IN"},
+	}
+
+	var buf bytes.Buffer
+	s := NewQuoteStreamWriter(&buf, StreamParquet)
+	for _, q := range quotes {
+		require.NoError(t, s.WriteQuote(q))
+	}
+	require.NoError(t, s.Close())
+
+	rows, err := parquet.Read[parquetQuote](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, int64(1), rows[0].ID)
+	assert.Equal(t, []string{"a", "b"}, rows[0].Tags)
+	assert.Equal(t, "", rows[0].Author)
+	assert.Equal(t, "Someone", rows[1].Author)
+	assert.Equal(t, int32(1950), rows[1].Year)
+	assert.Equal(t, "ta-IN", rows[1].Language)
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PIIAction selects what happens to quotes containing emails, phone numbers or URLs
+type PIIAction string
+
+const (
+	PIINone   PIIAction = ""
+	PIIRedact PIIAction = "redact"
+	PIIDrop   PIIAction = "drop"
+	PIIReport PIIAction = "report"
+)
+
+// String implements flag.Value
+func (a *PIIAction) String() string {
+	return string(*a)
+}
+
+// Set implements flag.Value, accepting only the supported actions
+func (a *PIIAction) Set(name string) error {
+	switch action := PIIAction(strings.ToLower(name)); action {
+	case PIIRedact, PIIDrop, PIIReport:
+		*a = action
+		return nil
+	}
+	return fmt.Errorf("unknown PII action %q (supported: redact, drop, report)", name)
+}
+
+// piiPattern detects one kind of personal data, replaced by its placeholder when redacting
+type piiPattern struct {
+	kind        string
+	pattern     *regexp.Regexp
+	placeholder string
+}
+
+// piiPatterns are applied in order, so addresses inside URLs are redacted with the URL
+var piiPatterns = []piiPattern{
+	{kind: "url", pattern: regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]*[^\s<>".,;:!?)\]'"]`), placeholder: "[url]"},
+	{kind: "email", pattern: regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}\b`), placeholder: "[email]"},
+	{kind: "phone", pattern: regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)\s?|\b\d{2,4}[\s.-]?)\d{3,4}[\s.-]?\d{3,4}\b`), placeholder: "[phone]"},
+}
+
+// scrubPII returns the kinds of personal data found in the text, author and context of the quote,
+// replacing them with placeholders when redact is set. Data is detected as if every earlier kind
+// was already redacted, so an address inside a URL is only reported as a URL.
+func scrubPII(quote *Quote, redact bool) []string {
+	var kinds []string
+	fields := []string{quote.Text, quote.Author, quote.Context}
+	for _, p := range piiPatterns {
+		found := false
+		for i, field := range fields {
+			if p.pattern.MatchString(field) {
+				found = true
+				fields[i] = p.pattern.ReplaceAllString(field, p.placeholder)
+			}
+		}
+		if found {
+			kinds = append(kinds, p.kind)
+		}
+	}
+	if redact {
+		quote.Text, quote.Author, quote.Context = fields[0], fields[1], fields[2]
+	}
+	return kinds
+}
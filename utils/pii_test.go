@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScrubPII tests detecting and redacting emails, phone numbers and URLs
+func TestScrubPII(t *testing.T) {
+	tests := []struct {
+		name     string
+		quote    Quote
+		kinds    []string
+		redacted Quote
+	}{
+		{
+			name:     "clean",
+			quote:    Quote{Text: "Between 1950-2020 we met 3 times.", Author: "Seneca"},
+			redacted: Quote{Text: "Between 1950-2020 we met 3 times.", Author: "Seneca"},
+		},
+		{
+			name:     "email",
+			quote:    Quote{Text: "Write to jane.doe@example.com today."},
+			kinds:    []string{"email"},
+			redacted: Quote{Text: "Write to [email] today."},
+		},
+		{
+			name:     "url_and_phone",
+			quote:    Quote{Text: "See https://example.com/a?b=c.", Context: "Call +44 20 7946 0958 or (555) 123-4567"},
+			kinds:    []string{"url", "phone"},
+			redacted: Quote{Text: "See [url].", Context: "Call [phone] or [phone]"},
+		},
+		{
+			name:     "email_in_url",
+			quote:    Quote{Text: "www.example.com/u/me@example.com", Author: "555.123.4567"},
+			kinds:    []string{"url", "phone"},
+			redacted: Quote{Text: "[url]", Author: "[phone]"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote := tt.quote
+			assert.Equal(t, tt.kinds, scrubPII(&quote, false))
+			assert.Equal(t, tt.quote, quote)
+
+			assert.Equal(t, tt.kinds, scrubPII(&quote, true))
+			assert.Equal(t, tt.redacted, quote)
+		})
+	}
+}
+
+// TestPIIActionSet tests parsing the --pii flag
+func TestPIIActionSet(t *testing.T) {
+	var a PIIAction
+	require.NoError(t, a.Set("Redact"))
+	assert.Equal(t, PIIRedact, a)
+	assert.Error(t, a.Set("mask"))
+}
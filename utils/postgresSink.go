@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// postgresTx is the part of pgx.Tx used by postgresSink
+type postgresTx interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// postgresSink upserts quotes into a table of a PostgreSQL database inside a single transaction.
+// Quotes are keyed by their ID, so converting again updates the rows of the quotes in place;
+// rows of quotes no longer in the sheet are left alone.
+type postgresSink struct {
+	tx    postgresTx
+	ctx   context.Context
+	close func(ctx context.Context) error
+	table pgx.Identifier
+	// tagTable is the join table holding the tags of the quotes, nil when they are stored in
+	// the tags array column of table
+	tagTable pgx.Identifier
+}
+
+// openPostgresSink opens the sink of --to postgres://user@host/db?table=quotes&tags=array.
+// The table, which may be qualified by its schema, is created when it doesn't exist; tags=join
+// stores the tags in a <table>_tags join table instead of an array column. The other parameters
+// and the PG* environment variables are read by pgx, so the password can stay out of the URL.
+func openPostgresSink(target string, opts Options) (QuoteSink, error) {
+	connString, table, tagTable, err := postgresTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	ctx := optsContext(opts)
+	conn, err := pgx.Connect(ctx, connString)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to postgres: %w", err)
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		conn.Close(ctx)
+		return nil, fmt.Errorf("error starting postgres transaction: %w", err)
+	}
+
+	s := &postgresSink{tx: tx, ctx: ctx, close: conn.Close, table: table, tagTable: tagTable}
+	if err := s.createTables(); err != nil {
+		s.Rollback()
+		return nil, err
+	}
+	return s, nil
+}
+
+// postgresTarget reads the connection string, the table and the join table of the tags, nil
+// for an array column, from the target of a postgres sink
+func postgresTarget(target string) (string, pgx.Identifier, pgx.Identifier, error) {
+	u, err := url.Parse("postgres:" + target)
+	if err != nil || u.Host == "" {
+		return "", nil, nil, fmt.Errorf("invalid sink %q, expected postgres://user@host/database", "postgres:"+target)
+	}
+	query := u.Query()
+	name := query.Get("table")
+	if name == "" {
+		name = "quotes"
+	}
+	table := pgx.Identifier(strings.Split(name, "."))
+	for _, part := range table {
+		if part == "" || len(table) > 2 {
+			return "", nil, nil, fmt.Errorf("invalid postgres table %q, expected table or schema.table", name)
+		}
+	}
+
+	var tagTable pgx.Identifier
+	switch query.Get("tags") {
+	case "", "array":
+	case "join":
+		tagTable = append(pgx.Identifier{}, table...)
+		tagTable[len(tagTable)-1] += "_tags"
+	default:
+		return "", nil, nil, fmt.Errorf("invalid postgres tags %q, expected array or join", query.Get("tags"))
+	}
+
+	// The parameters of the sink are not connection parameters pgx would understand
+	query.Del("table")
+	query.Del("tags")
+	u.RawQuery = query.Encode()
+	return u.String(), table, tagTable, nil
+}
+
+// createTables creates the table of the quotes and the join table of their tags, if missing
+func (s *postgresSink) createTables() error {
+	table := s.table.Sanitize()
+	tagsColumn := ",\n\t\ttags    TEXT[] NOT NULL DEFAULT '{}'"
+	if s.tagTable != nil {
+		tagsColumn = ""
+	}
+	statements := []string{fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id      TEXT PRIMARY KEY,
+		text    TEXT NOT NULL,
+		author  TEXT,
+		year    INTEGER,
+		context TEXT,
+		lang    TEXT NOT NULL%s
+	)`, table, tagsColumn)}
+	if s.tagTable != nil {
+		statements = append(statements, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		quote_id TEXT NOT NULL REFERENCES %s(id) ON DELETE CASCADE,
+		tag      TEXT NOT NULL,
+		PRIMARY KEY (quote_id, tag)
+	)`, s.tagTable.Sanitize(), table))
+	}
+	for _, stmt := range statements {
+		if _, err := s.tx.Exec(s.ctx, stmt); err != nil {
+			return fmt.Errorf("error creating postgres table %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// WriteQuotes upserts a batch of quotes, replacing the tags of those already stored
+func (s *postgresSink) WriteQuotes(quotes []Quote) error {
+	columns, values, updates := "id, text, author, year, context, lang", "$1, $2, $3, $4, $5, $6",
+		"text = excluded.text, author = excluded.author, year = excluded.year, context = excluded.context, lang = excluded.lang"
+	if s.tagTable == nil {
+		columns, values, updates = columns+", tags", values+", $7", updates+", tags = excluded.tags"
+	}
+	upsert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (id) DO UPDATE SET %s", s.table.Sanitize(), columns, values, updates)
+
+	for _, quote := range quotes {
+		id := quote.key()
+		tags := append([]string{}, nonEmptyTags(quote.Tags)...)
+		args := []any{id, quote.Text, nullString(quote.Author), nullInt(quote.Year), nullString(quote.Context), quote.Language}
+		if s.tagTable == nil {
+			args = append(args, tags)
+		}
+		if _, err := s.tx.Exec(s.ctx, upsert, args...); err != nil {
+			return fmt.Errorf("error upserting quote %s: %w", id, err)
+		}
+		if s.tagTable == nil {
+			continue
+		}
+
+		tagTable := s.tagTable.Sanitize()
+		if _, err := s.tx.Exec(s.ctx, fmt.Sprintf("DELETE FROM %s WHERE quote_id = $1", tagTable), id); err != nil {
+			return fmt.Errorf("error tagging quote %s: %w", id, err)
+		}
+		for _, tag := range tags {
+			if _, err := s.tx.Exec(s.ctx, fmt.Sprintf("INSERT INTO %s (quote_id, tag) VALUES ($1, $2) ON CONFLICT DO NOTHING", tagTable), id, tag); err != nil {
+				return fmt.Errorf("error tagging quote %s: %w", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Commit commits the transaction and closes the connection
+func (s *postgresSink) Commit() error {
+	defer s.close(s.ctx)
+	if err := s.tx.Commit(s.ctx); err != nil {
+		return fmt.Errorf("error committing postgres transaction: %w", err)
+	}
+	return nil
+}
+
+// Rollback abandons the transaction and closes the connection
+func (s *postgresSink) Rollback() error {
+	defer s.close(s.ctx)
+	return s.tx.Rollback(s.ctx)
+}
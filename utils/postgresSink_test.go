@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePostgresTx records the statements executed in it
+type fakePostgresTx struct {
+	statements []string
+	args       [][]any
+	err        error
+	committed  bool
+	rolledBack bool
+}
+
+func (tx *fakePostgresTx) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	if tx.err != nil {
+		return pgconn.CommandTag{}, tx.err
+	}
+	tx.statements = append(tx.statements, sql)
+	tx.args = append(tx.args, arguments)
+	return pgconn.CommandTag{}, nil
+}
+
+func (tx *fakePostgresTx) Commit(ctx context.Context) error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakePostgresTx) Rollback(ctx context.Context) error {
+	tx.rolledBack = true
+	return nil
+}
+
+func newFakePostgresSink(tx *fakePostgresTx, table, tagTable pgx.Identifier) *postgresSink {
+	return &postgresSink{tx: tx, ctx: context.Background(), close: func(context.Context) error { return nil }, table: table, tagTable: tagTable}
+}
+
+func TestPostgresTarget(t *testing.T) {
+	tests := []struct {
+		target     string
+		connString string
+		table      pgx.Identifier
+		tagTable   pgx.Identifier
+		wantErr    string
+	}{
+		{target: "//app@localhost/quotes", connString: "postgres://app@localhost/quotes", table: pgx.Identifier{"quotes"}},
+		{target: "//app@db:5433/app?sslmode=require&table=content.quotes&tags=join", connString: "postgres://app@db:5433/app?sslmode=require", table: pgx.Identifier{"content", "quotes"}, tagTable: pgx.Identifier{"content", "quotes_tags"}},
+		{target: "//localhost/app?tags=array", connString: "postgres://localhost/app", table: pgx.Identifier{"quotes"}},
+		{target: "quotes.db", wantErr: "expected postgres://user@host/database"},
+		{target: "//localhost/app?table=a.b.c", wantErr: `invalid postgres table "a.b.c"`},
+		{target: "//localhost/app?tags=csv", wantErr: `invalid postgres tags "csv"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			connString, table, tagTable, err := postgresTarget(tt.target)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.connString, connString)
+			assert.Equal(t, tt.table, table)
+			assert.Equal(t, tt.tagTable, tagTable)
+		})
+	}
+}
+
+func TestPostgresSinkArray(t *testing.T) {
+	tx := &fakePostgresTx{}
+	sink := newFakePostgresSink(tx, pgx.Identifier{"quotes"}, nil)
+	require.NoError(t, sink.createTables())
+	require.NoError(t, sink.WriteQuotes([]Quote{
+		{ID: 1, Text: "Know thyself.", Tags: []string{"wisdom", ""}, Language: "en-US", Year: 1900},
+		{ID: 2, UUID: "0190a0e4-0000-7000-8000-000000000000", Text: "Carpe diem.", Language: "la"},
+	}))
+	require.NoError(t, sink.Commit())
+	assert.True(t, tx.committed)
+
+	require.Len(t, tx.statements, 3)
+	assert.Contains(t, tx.statements[0], `CREATE TABLE IF NOT EXISTS "quotes"`)
+	assert.Contains(t, tx.statements[0], "tags    TEXT[] NOT NULL")
+	assert.Equal(t, `INSERT INTO "quotes" (id, text, author, year, context, lang, tags) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (id) DO UPDATE SET `+
+		"text = excluded.text, author = excluded.author, year = excluded.year, context = excluded.context, lang = excluded.lang, tags = excluded.tags", tx.statements[1])
+	assert.Equal(t, []any{"1", "Know thyself.", sql.NullString{}, sql.NullInt64{Int64: 1900, Valid: true}, sql.NullString{}, "en-US", []string{"wisdom"}}, tx.args[1])
+	assert.Equal(t, "0190a0e4-0000-7000-8000-000000000000", tx.args[2][0])
+	assert.Equal(t, []string{}, tx.args[2][6], "quotes without tags get an empty array rather than NULL")
+}
+
+func TestPostgresSinkJoin(t *testing.T) {
+	tx := &fakePostgresTx{}
+	sink := newFakePostgresSink(tx, pgx.Identifier{"app", "quotes"}, pgx.Identifier{"app", "quotes_tags"})
+	require.NoError(t, sink.createTables())
+	require.NoError(t, sink.WriteQuotes([]Quote{{ID: 3, Text: "Know thyself.", Tags: []string{"wisdom", "life"}, Language: "en"}}))
+	require.NoError(t, sink.Rollback())
+	assert.True(t, tx.rolledBack)
+
+	require.Len(t, tx.statements, 6)
+	assert.NotContains(t, tx.statements[0], "tags")
+	assert.Contains(t, tx.statements[1], `CREATE TABLE IF NOT EXISTS "app"."quotes_tags"`)
+	assert.Contains(t, tx.statements[1], `REFERENCES "app"."quotes"(id)`)
+	assert.NotContains(t, tx.statements[2], "tags", "the tags are not a column of the quotes")
+	assert.Equal(t, `DELETE FROM "app"."quotes_tags" WHERE quote_id = $1`, tx.statements[3])
+	assert.Equal(t, []any{"3", "wisdom"}, tx.args[4])
+	assert.Equal(t, []any{"3", "life"}, tx.args[5])
+}
+
+func TestPostgresSinkErrors(t *testing.T) {
+	tx := &fakePostgresTx{err: errors.New("relation does not exist")}
+	sink := newFakePostgresSink(tx, pgx.Identifier{"quotes"}, nil)
+	assert.ErrorContains(t, sink.createTables(), `error creating postgres table "quotes": relation does not exist`)
+	assert.ErrorContains(t, sink.WriteQuotes([]Quote{{ID: 7, Text: "Be yourself."}}), "error upserting quote 7: relation does not exist")
+
+	_, err := OpenSink("postgres://nobody@127.0.0.1:1/quotes?connect_timeout=1", DefaultOptions())
+	assert.ErrorContains(t, err, "error connecting to postgres")
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// idPreserver hands out the IDs of a previous conversion to the quotes whose text still matches,
+// so IDs stay stable across spreadsheet edits. Quotes are assigned in output order.
+type idPreserver struct {
+	strategy IDStrategy
+	previous map[string][]Quote // previous quotes by textKey, in their previous order
+	nextID   int64              // next fresh row ID, above every previous one
+	reused   int
+	fresh    int
+}
+
+// textKey normalizes quote text for matching, ignoring case, punctuation and spacing
+func textKey(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}), " ")
+}
+
+// loadIDPreserver reads the quotes of a previous JSON conversion. A missing file is not an
+// error, every quote then gets a fresh ID as on the first conversion.
+func loadIDPreserver(fileName string, strategy IDStrategy) (*idPreserver, error) {
+	p := &idPreserver{strategy: strategy, previous: make(map[string][]Quote), nextID: 1}
+	if _, err := os.Stat(fileName); errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	data, err := ReadQuotesFromJSON(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the IDs to preserve: %w", err)
+	}
+	for _, quote := range data.Quotes {
+		// Only IDs of the current kind can be reused
+		if (strategy == IDUUID) != (quote.UUID != "") {
+			continue
+		}
+		key := textKey(quote.Text)
+		p.previous[key] = append(p.previous[key], quote)
+		p.nextID = max(p.nextID, quote.ID+1)
+	}
+	return p, nil
+}
+
+// assign gives the quote the ID of the first unused previous quote with the same text, or a fresh one
+func (p *idPreserver) assign(quote *Quote) error {
+	key := textKey(quote.Text)
+	if matches := p.previous[key]; len(matches) > 0 {
+		quote.ID, quote.UUID = matches[0].ID, matches[0].UUID
+		p.previous[key] = matches[1:]
+		p.reused++
+		return nil
+	}
+
+	p.fresh++
+	if p.strategy == IDUUID {
+		id, err := newQuoteUUID()
+		if err != nil {
+			return err
+		}
+		quote.UUID = id
+		return nil
+	}
+	quote.ID = p.nextID
+	p.nextID++
+	return nil
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIDPreserverRows tests that edited rows keep their IDs and new ones get fresh IDs
+func TestIDPreserverRows(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "quotes.json")
+	require.NoError(t, WriteJSONToFile(file, QuotesData{Quotes: []Quote{
+		{ID: 1, Text: "Know thyself."},
+		{ID: 2, Text: "Carpe diem"},
+		{ID: 7, Text: "Carpe diem"},
+		{UUID: "01890a5d-ac96-774b-bcce-b302099a8057", Text: "Memento mori"},
+	}}))
+	p, err := loadIDPreserver(file, IDRow)
+	require.NoError(t, err)
+
+	quotes := []Quote{
+		{ID: 1, Text: "A new quote"},
+		{ID: 2, Text: "carpe   diem!"},
+		{ID: 3, Text: "Know thyself"},
+		{ID: 4, Text: "Carpe diem."},
+		{ID: 5, Text: "Carpe diem"},
+		{ID: 6, Text: "Memento mori"},
+	}
+	for i := range quotes {
+		require.NoError(t, p.assign(&quotes[i]))
+	}
+	var ids []int64
+	for _, quote := range quotes {
+		ids = append(ids, quote.ID)
+		assert.Empty(t, quote.UUID)
+	}
+	assert.Equal(t, []int64{8, 2, 1, 7, 9, 10}, ids)
+	assert.Equal(t, 3, p.reused)
+	assert.Equal(t, 3, p.fresh)
+}
+
+// TestIDPreserverUUIDs tests that UUIDs are reused and generated for new quotes
+func TestIDPreserverUUIDs(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "quotes.json")
+	require.NoError(t, WriteJSONToFile(file, QuotesData{Quotes: []Quote{
+		{ID: 1, Text: "Know thyself."},
+		{UUID: "01890a5d-ac96-774b-bcce-b302099a8057", Text: "Memento mori"},
+	}}))
+	p, err := loadIDPreserver(file, IDUUID)
+	require.NoError(t, err)
+
+	old, fresh := Quote{ID: 1, Text: "Memento mori."}, Quote{ID: 2, Text: "Know thyself."}
+	require.NoError(t, p.assign(&old))
+	require.NoError(t, p.assign(&fresh))
+	assert.Equal(t, "01890a5d-ac96-774b-bcce-b302099a8057", old.UUID)
+	assert.NotEmpty(t, fresh.UUID)
+	assert.NotEqual(t, old.UUID, fresh.UUID)
+}
+
+// TestLoadIDPreserverMissingFile tests that the first conversion numbers quotes from 1
+func TestLoadIDPreserverMissingFile(t *testing.T) {
+	p, err := loadIDPreserver(filepath.Join(t.TempDir(), "quotes.json"), IDRow)
+	require.NoError(t, err)
+	quote := Quote{ID: 5, Text: "a"}
+	require.NoError(t, p.assign(&quote))
+	assert.Equal(t, int64(1), quote.ID)
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"time"
+)
+
+// ProfileOptions configure the profiling of a run, for performance issues with huge workbooks
+type ProfileOptions struct {
+	Addr        string // address net/http/pprof is served on while running, e.g. localhost:6060
+	CPUProfile  string // file the CPU profile of the whole run is written to
+	HeapProfile string // file a heap profile is written to when the run ends
+}
+
+// StartProfiling starts the profiling asked for by opts. The returned function stops it and
+// writes the profiles, so it must be called before exiting.
+func StartProfiling(opts ProfileOptions) (func() error, error) {
+	var listener net.Listener
+	if opts.Addr != "" {
+		var err error
+		if listener, err = net.Listen("tcp", opts.Addr); err != nil {
+			return nil, fmt.Errorf("error serving pprof: %w", err)
+		}
+		log.Printf("Serving pprof on http://%s/debug/pprof/", listener.Addr())
+		server := &http.Server{Handler: pprofHandler(), ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := server.Serve(listener); err != nil && !errors.Is(err, net.ErrClosed) {
+				log.Printf("Error serving pprof: %v", err)
+			}
+		}()
+	}
+
+	var cpuFile *os.File
+	if opts.CPUProfile != "" {
+		var err error
+		if cpuFile, err = os.Create(opts.CPUProfile); err != nil {
+			return nil, fmt.Errorf("error creating CPU profile: %w", err)
+		}
+		if err := runtimepprof.StartCPUProfile(cpuFile); err != nil {
+			cpuFile.Close()
+			return nil, fmt.Errorf("error starting CPU profile: %w", err)
+		}
+	}
+
+	return func() error {
+		var errs []error
+		if cpuFile != nil {
+			runtimepprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("error writing CPU profile: %w", err))
+			}
+		}
+		if opts.HeapProfile != "" {
+			errs = append(errs, writeHeapProfile(opts.HeapProfile))
+		}
+		if listener != nil {
+			listener.Close()
+		}
+		return errors.Join(errs...)
+	}, nil
+}
+
+// writeHeapProfile writes the profile of the memory in use to fileName, after a garbage
+// collection so it is up to date
+func writeHeapProfile(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("error creating heap profile: %w", err)
+	}
+	runtime.GC()
+	if err := runtimepprof.WriteHeapProfile(file); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing heap profile: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing heap profile: %w", err)
+	}
+	return nil
+}
+
+// pprofHandler serves the profiles of net/http/pprof under /debug/pprof/, without registering
+// them on http.DefaultServeMux
+func pprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
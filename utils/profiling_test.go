@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartProfiling(t *testing.T) {
+	dir := t.TempDir()
+	opts := ProfileOptions{
+		Addr:        "127.0.0.1:0",
+		CPUProfile:  filepath.Join(dir, "cpu.pprof"),
+		HeapProfile: filepath.Join(dir, "heap.pprof"),
+	}
+	stop, err := StartProfiling(opts)
+	require.NoError(t, err)
+	require.NoError(t, stop())
+
+	for _, fileName := range []string{opts.CPUProfile, opts.HeapProfile} {
+		info, err := os.Stat(fileName)
+		require.NoError(t, err)
+		assert.NotZero(t, info.Size(), fileName)
+	}
+}
+
+func TestStartProfilingErrors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing", "profile.pprof")
+	_, err := StartProfiling(ProfileOptions{CPUProfile: missing})
+	assert.ErrorContains(t, err, "error creating CPU profile")
+
+	_, err = StartProfiling(ProfileOptions{Addr: "not an address"})
+	assert.ErrorContains(t, err, "error serving pprof")
+
+	stop, err := StartProfiling(ProfileOptions{HeapProfile: missing})
+	require.NoError(t, err)
+	assert.ErrorContains(t, stop(), "error creating heap profile")
+}
+
+func TestPprofHandler(t *testing.T) {
+	for target, status := range map[string]int{
+		"/debug/pprof/":              http.StatusOK,
+		"/debug/pprof/heap":          http.StatusOK,
+		"/debug/pprof/cmdline":       http.StatusOK,
+		"/debug/pprof/not-a-profile": http.StatusNotFound,
+	} {
+		t.Run(target, func(t *testing.T) {
+			r := httptest.NewRecorder()
+			pprofHandler().ServeHTTP(r, httptest.NewRequest(http.MethodGet, target, nil))
+			assert.Equal(t, status, r.Code)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// quotableAPI is the public Quotable API
+	quotableAPI = "https://api.quotable.io"
+	// quotablePageSize is the most quotes Quotable returns per page
+	quotablePageSize = 150
+	// zenQuotesAPI is the public ZenQuotes API
+	zenQuotesAPI = "https://zenquotes.io/api"
+)
+
+// importQuotable pages through the quotes of the Quotable API at opts.API, those with any of
+// the tags in args or all of them without args, stopping after opts.Limit quotes when it is set.
+// The tags of Quotable are kept, as famous-quotes for "Famous Quotes", so new categories can be
+// seeded by their tag.
+func importQuotable(args []string, opts ImportOptions) (*sliceRows, error) {
+	api := opts.API
+	if api == "" {
+		api = quotableAPI
+	}
+	source := newHTTPSource(opts.Interval)
+
+	rows := [][]string{quoteColumns}
+	for page := 1; ; page++ {
+		query := url.Values{"page": {strconv.Itoa(page)}, "limit": {strconv.Itoa(quotablePageSize)}}
+		if len(args) > 0 {
+			query.Set("tags", strings.Join(args, "|"))
+		}
+		var response struct {
+			TotalPages int `json:"totalPages"`
+			Results    []struct {
+				Content string   `json:"content"`
+				Author  string   `json:"author"`
+				Tags    []string `json:"tags"`
+			} `json:"results"`
+		}
+		if err := source.getJSON(strings.TrimSuffix(api, "/")+"/quotes?"+query.Encode(), &response); err != nil {
+			return nil, err
+		}
+		for _, quote := range response.Results {
+			tags := make([]string, len(quote.Tags))
+			for i, tag := range quote.Tags {
+				tags[i] = tagSlug(tag)
+			}
+			rows = append(rows, []string{strings.Join(tags, ", "), quote.Content, quote.Author, "", "", ""})
+			if opts.Limit > 0 && len(rows)-1 >= opts.Limit {
+				return &sliceRows{rows: rows}, nil
+			}
+		}
+		if page >= response.TotalPages || len(response.Results) == 0 {
+			return &sliceRows{rows: rows}, nil
+		}
+	}
+}
+
+// importZenQuotes fetches a batch of quotes from the ZenQuotes API at opts.API, keeping
+// opts.Limit of them when it is set. ZenQuotes has no tags or paging, each request returns
+// different quotes; its free tier asks for attribution wherever the quotes are shown.
+func importZenQuotes(args []string, opts ImportOptions) (*sliceRows, error) {
+	if len(args) > 0 {
+		return nil, fmt.Errorf("zenquotes takes no arguments, it has no tags to filter by")
+	}
+	api := opts.API
+	if api == "" {
+		api = zenQuotesAPI
+	}
+	var response []struct {
+		Quote  string `json:"q"`
+		Author string `json:"a"`
+	}
+	if err := newHTTPSource(opts.Interval).getJSON(strings.TrimSuffix(api, "/")+"/quotes", &response); err != nil {
+		return nil, err
+	}
+	// Errors such as the rate limit are answered with a single quote by ZenQuotes itself
+	if len(response) == 1 && response[0].Author == "zenquotes.io" {
+		return nil, fmt.Errorf("error fetching ZenQuotes: %s", response[0].Quote)
+	}
+
+	rows := [][]string{quoteColumns}
+	for _, quote := range response {
+		if opts.Limit > 0 && len(rows)-1 >= opts.Limit {
+			break
+		}
+		rows = append(rows, []string{"", quote.Quote, quote.Author, "", "", ""})
+	}
+	return &sliceRows{rows: rows}, nil
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// quotableServer serves three pages of two quotes like the Quotable API
+func quotableServer(t *testing.T, tags *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/quotes", r.URL.Path)
+		*tags = append(*tags, r.URL.Query().Get("tags"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		var results []map[string]any
+		for i := 1; i <= 2; i++ {
+			results = append(results, map[string]any{
+				"_id":     "x",
+				"content": "Quote " + strconv.Itoa((page-1)*2+i) + ".",
+				"author":  "Author",
+				"tags":    []string{"Famous Quotes", "wisdom"},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"count": 2, "totalCount": 6, "page": page, "totalPages": 3, "results": results})
+	}))
+}
+
+// TestImportQuotable tests paging through the Quotable API
+func TestImportQuotable(t *testing.T) {
+	var tags []string
+	server := quotableServer(t, &tags)
+	defer server.Close()
+
+	rows, err := importQuotable([]string{"wisdom", "love"}, ImportOptions{API: server.URL})
+	require.NoError(t, err)
+	require.Len(t, rows.rows, 7)
+	assert.Equal(t, []string{"famous-quotes, wisdom", "Quote 1.", "Author", "", "", ""}, rows.rows[1])
+	assert.Equal(t, "Quote 6.", rows.rows[6][1])
+	assert.Equal(t, []string{"wisdom|love", "wisdom|love", "wisdom|love"}, tags)
+
+	tags = nil
+	rows, err = importQuotable(nil, ImportOptions{API: server.URL, Limit: 3})
+	require.NoError(t, err)
+	assert.Len(t, rows.rows, 4)
+	assert.Equal(t, []string{"", ""}, tags)
+}
+
+// TestImportZenQuotes tests fetching a batch from the ZenQuotes API
+func TestImportZenQuotes(t *testing.T) {
+	limited := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/quotes", r.URL.Path)
+		if limited {
+			w.Write([]byte(`[{"q": "Too many requests. Obtain an auth key for unlimited access.", "a": "zenquotes.io", "h": ""}]`))
+			return
+		}
+		w.Write([]byte(`[{"q": "Act as if what you do makes a difference.", "a": "William James", "h": ""}, {"q": "Well begun is half done.", "a": "Aristotle", "h": ""}]`))
+	}))
+	defer server.Close()
+
+	rows, err := importZenQuotes(nil, ImportOptions{API: server.URL + "/"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{quoteColumns, {"", "Act as if what you do makes a difference.", "William James", "", "", ""}, {"", "Well begun is half done.", "Aristotle", "", "", ""}}, rows.rows)
+
+	rows, err = importZenQuotes(nil, ImportOptions{API: server.URL, Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, rows.rows, 2)
+
+	limited = true
+	_, err = importZenQuotes(nil, ImportOptions{API: server.URL})
+	assert.ErrorContains(t, err, "Too many requests")
+	_, err = importZenQuotes([]string{"love"}, ImportOptions{API: server.URL})
+	assert.Error(t, err)
+}
@@ -1,33 +1,51 @@
 package utils
 
 import (
+	"context"
+	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/xuri/excelize/v2"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Quote represents the structure for each quote in the JSON output
 type Quote struct {
-	ID       int64    `json:"id"`
-	Text     string   `json:"text"`
-	Author   string   `json:"author,omitempty"`
-	Year     int      `json:"year,omitempty"`
-	Context  string   `json:"context,omitempty"`
-	Tags     []string `json:"tags"`
-	Language string   `json:"lang"`
+	ID                 int64             `json:"id"`
+	UUID               string            `json:"-"` // written as the id instead of ID when set, see MarshalJSON
+	Text               string            `json:"text"`
+	Author             string            `json:"author,omitempty"`
+	Year               int               `json:"year,omitempty"`
+	Context            string            `json:"context,omitempty"`
+	Tags               []string          `json:"tags"`
+	Language           string            `json:"lang"`
+	WordCount          int               `json:"wordCount,omitempty"`
+	ReadingTimeSeconds int               `json:"readingTimeSeconds,omitempty"` // at readingWordsPerMinute
+	Sentiment          *Sentiment        `json:"sentiment,omitempty"`
+	RelatedIDs         []any             `json:"relatedIds,omitempty"`   // IDs of the most similar quotes, see linkRelated
+	Translations       map[string]string `json:"translations,omitempty"` // text translated by --translate-to, by language tag
 }
 
 // Metadata represents additional metadata information
 type Metadata struct {
-	Version     string `json:"version"`
-	LastUpdated string `json:"lastUpdated"`
-	TotalQuotes int    `json:"totalQuotes"`
-	URL         string `json:"url"`
+	Version     string            `json:"version"`
+	LastUpdated string            `json:"lastUpdated"`
+	TotalQuotes int               `json:"totalQuotes"`
+	URL         string            `json:"url"`
+	SHA256      string            `json:"sha256,omitempty"` // digest of the quotes file, set with --checksums
+	Stats       *QuoteStats       `json:"stats,omitempty"`  // length of the quotes, nil when there are none
+	Counts      *QuoteCounts      `json:"counts,omitempty"` // number of quotes per tag, author and language
+	Source      *SourceInfo       `json:"source,omitempty"` // spreadsheet the quotes were converted from
+	Generator   *GeneratorInfo    `json:"generator,omitempty"`
+	Duration    float64           `json:"durationSeconds,omitempty"` // time the conversion took
+	Extra       map[string]string `json:"extra,omitempty"`           // deployment details set with --meta, such as the license
 	Schema      struct {
 		Format   string `json:"format"`
 		Encoding string `json:"encoding"`
@@ -37,12 +55,111 @@ type Metadata struct {
 
 // QuotesData holds the entire JSON structure with quotes and metadata
 type QuotesData struct {
-	Quotes   []Quote  `json:"quotes"`
+	Quotes []Quote `json:"quotes"`
 }
 
-// OpenExcelFile opens the Excel file
+// Options controls how the Excel rows are converted into quotes
+type Options struct {
+	Workers            int                    // number of goroutines processing rows concurrently
+	BatchSize          int                    // number of rows processed and flushed to the output at a time
+	Resume             bool                   // continue from the checkpoint left by an interrupted conversion
+	InputFormat        InputFormat            // how ConvertFile reads its input, detected from the file when empty
+	Format             StreamFormat           // layout of the quotes output file
+	Sinks              SinkList               // additional destinations for the quotes, as scheme:target
+	UploadContentType  string                 // Content-Type of the quotes file uploaded to a bucket by --to, by its extension when empty
+	UploadCacheControl string                 // Cache-Control of the files uploaded to a bucket by --to
+	SFTPKey            string                 // private key of --to sftp://..., the first of ~/.ssh/id_* when empty
+	SFTPKnownHosts     string                 // known_hosts file checked by --to sftp://..., ~/.ssh/known_hosts when empty
+	Strfile            bool                   // write a strfile(8) index next to fortune output
+	ESIndex            string                 // index named in the es-bulk action lines
+	MongoTagsField     string                 // name of the tags array in mongo documents
+	SQLDialect         SQLDialect             // dialect written by the sql format
+	RedisPrefix        string                 // prefix of every key written by the redis format and sink
+	TemplateFile       string                 // text/template rendered by the template format
+	Indent             string                 // indentation of the json format
+	Compact            bool                   // write the json format without any whitespace
+	Output             string                 // quotes output file, "-" for stdout; derived from Format when empty
+	Compress           Compression            // compression of the quotes and metadata files
+	Backups            int                    // number of previous outputs kept as backups before overwriting
+	BackupTime         bool                   // name backups after the time they were taken instead of numbering them
+	Checksums          bool                   // record the SHA-256 of the outputs in checksums.txt and the metadata
+	SignKey            string                 // ed25519 PEM private key used to write a detached .sig of the quotes file
+	Encrypt            RecipientList          // age recipients the quotes file is encrypted to
+	SplitBy            SplitKey               // also write one QuotesData file per group of quotes
+	SplitDir           string                 // directory of the split or chunk files, by-<SplitBy> or chunks when empty
+	ChunkSize          int                    // also page the quotes into numbered files of this many quotes
+	TagIndex           bool                   // also write tags.json mapping every tag to its quote IDs
+	Normalize          NormalizeList          // normalization steps applied to the quote text
+	KeepInvisible      bool                   // skip NFC normalization and the stripping of invisible characters
+	Validation         ValidationRules        // checks every quote has to pass, failing rows are skipped
+	Language           string                 // BCP 47 language tag of the quotes
+	Columns            ColumnList             // field=column overrides of the columns found from the header row
+	KeepAttribution    bool                   // leave "— Author" attributions in the text instead of moving them to Author
+	TextDelimiter      string                 // line separating the quotes of plain-text input, see splitTextEntries
+	AuthorAliases      string                 // authors.yaml mapping canonical author names to their aliases
+	TagPolicy          TagPolicy              // how the tags cell is split, the legacy behaviour when zero
+	TagAliases         string                 // YAML file folding tag variants into canonical tags
+	AllowedTags        string                 // file listing the only tags that are kept
+	BannedTags         string                 // file listing tags that are removed
+	DropFilteredQuotes bool                   // drop quotes whose tags were all removed by AllowedTags or BannedTags
+	Taxonomy           string                 // YAML file nesting tags under their parent tags
+	TagRules           string                 // YAML file mapping tags to keywords that add them to matching quotes
+	Blocklist          string                 // file listing prohibited terms, one per line
+	BlocklistAction    BlocklistAction        // whether quotes with a prohibited term are excluded or only flagged
+	BlocklistReview    string                 // JSON file listing the quotes caught by the blocklist
+	Spellcheck         string                 // dictionary file the quote text and authors are spellchecked against, one word per line
+	SpellcheckIgnore   string                 // file listing the names and terms the spellcheck accepts, one per line
+	SpellcheckReport   string                 // JSON file listing the likely typos found by Spellcheck
+	PII                PIIAction              // whether rows with emails, phone numbers or URLs are redacted, dropped or reported
+	Sentiment          bool                   // add a lexicon-based sentiment label and score to every quote
+	Related            int                    // number of the most similar quotes linked by the relatedIds of every quote, 0 for none
+	TranslateTo        []string               // language tags the text of every quote is translated into
+	Translator         string                 // translation provider of TranslateTo, see TranslatorNames, libretranslate when empty
+	TranslatorURL      string                 // API of the translation provider, its public one when empty
+	IDStrategy         IDStrategy             // how quote IDs are assigned
+	PreserveIDs        string                 // previous quotes.json whose IDs are reused for quotes with the same text
+	Incremental        bool                   // only process rows that changed since the previous incremental run
+	VersionBump        VersionBump            // how Metadata.Version changes from the previous metadata
+	MetadataURL        string                 // URL recorded in the metadata, where the dataset is published
+	MetadataVersion    string                 // fixed Metadata.Version, replacing the bumped one
+	MetadataExtra      MetadataFields         // extra key=value pairs recorded in the metadata
+	MetadataConfig     string                 // YAML file with the url, version and extra fields of the metadata
+	EmbedMetadata      bool                   // write the metadata into the json quotes file instead of quotesMetadata.json
+	KeepExisting       bool                   // merge leaves the quotes matching an existing one untouched instead of updating them
+	Replace            bool                   // merge drops the existing quotes missing from the input, so the dataset mirrors it
+	Progress           func(rows, quotes int) // called after each batch with the rows read and the quotes kept so far
+	Context            context.Context        // parent of the tracing spans of the conversion, context.Background() when nil
+	Webhooks           []string               // URLs notified with a WebhookPayload when ConvertFile finishes
+	WebhookSecret      string                 // key of the HMAC signature of the webhook payloads, unsigned when empty
+	webhook            *webhookReport         // report of the conversion to the webhooks, set by ConvertFile
+	templateMetadata   templateMetadata       // metadata of the dataset for --template, set by convertRows
+}
+
+// DefaultOptions returns the options used when none are supplied
+func DefaultOptions() Options {
+	return Options{
+		Workers:        1,
+		BatchSize:      100,
+		Format:         StreamArray,
+		Indent:         "  ",
+		ESIndex:        "quotes",
+		MongoTagsField: "tags",
+		SQLDialect:     DialectPostgres,
+		IDStrategy:     IDRow,
+		VersionBump:    BumpAuto,
+		Language:       "en-US",
+
+		BlocklistAction: BlocklistExclude,
+		BlocklistReview: blocklistReviewFile,
+
+		SpellcheckReport: spellcheckReportFile,
+	}
+}
+
+// OpenExcelFile opens the Excel file. Cells in the default date format are read with a
+// four-digit year, since excelize would otherwise render them as mm-dd-yy and lose the century.
 func OpenExcelFile(fileName string) (*excelize.File, error) {
-	file, err := excelize.OpenFile(fileName)
+	file, err := excelize.OpenFile(fileName, excelize.Options{ShortDatePattern: "yyyy-mm-dd"})
 	if err != nil {
 		return nil, fmt.Errorf("failed to open Excel file %s: %w", fileName, err)
 	}
@@ -51,9 +168,16 @@ func OpenExcelFile(fileName string) (*excelize.File, error) {
 
 // ReadQuotesFromExcel processes the Excel file and outputs JSON with quotes and metadata
 func ReadQuotesFromExcel(fileNameValue string) error {
+	return ReadQuotesFromExcelWithOptions(fileNameValue, DefaultOptions())
+}
+
+// ReadQuotesFromExcelWithOptions is ReadQuotesFromExcel with configurable options
+func ReadQuotesFromExcelWithOptions(fileNameValue string, opts Options) error {
 	fileName := fileNameValue
 
+	_, span := startSpan(opts, "open input", attribute.String("input.file", fileName))
 	file, err := OpenExcelFile(fileName)
+	endSpan(span, err)
 	if err != nil {
 		log.Printf("Error opening Excel file: %v", err)
 		return err
@@ -64,14 +188,84 @@ func ReadQuotesFromExcel(fileNameValue string) error {
 		}
 	}()
 
-	return ReadExcelFile(file)
+	return ReadExcelFileWithOptions(file, opts)
 }
 
 // ReadExcelFile reads data from the first sheet, processes it in batches, and outputs accumulated JSON
 func ReadExcelFile(file *excelize.File) error {
-	var accumulatedQuotes []Quote
-	batchSize := 100 // Set your desired batch size
+	return ReadExcelFileWithOptions(file, DefaultOptions())
+}
+
+// ReadExcelQuotes processes the first sheet like ReadExcelFileWithOptions, but returns the quotes
+// instead of writing them. IDs are the row numbers and the output options are ignored.
+func ReadExcelQuotes(file *excelize.File, opts Options) ([]Quote, error) {
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("no sheets found in the Excel file")
+	}
+	rows, err := file.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("unable to load cells: %w", err)
+	}
+	return readQuotes(rows, opts)
+}
+
+// readQuotes processes rows, header first, into quotes for ReadExcelQuotes and ReadInputQuotes
+func readQuotes(rows [][]string, opts Options) ([]Quote, error) {
+	var err error
+	if opts.Language != "" {
+		if opts.Language, err = NormalizeLanguageTag(opts.Language); err != nil {
+			return nil, err
+		}
+	}
+
+	processor, err := loadRowProcessor(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, processor.finish()
+	}
+	// The header row tells us where the columns are
+	if processor.columns, err = newColumnMap(rows[0], opts.Columns); err != nil {
+		return nil, err
+	}
+	batchSize := opts.BatchSize
+	if batchSize < 1 {
+		batchSize = DefaultOptions().BatchSize
+	}
+	quotes := []Quote{}
+	for start := 1; start < len(rows); start += batchSize {
+		end := min(start+batchSize, len(rows))
+		quotes = append(quotes, parseBatch(opts, processor, rows[start:end], start, processor.parse)...)
+		if opts.Progress != nil {
+			opts.Progress(end-1, len(quotes))
+		}
+	}
+	return quotes, finishRows(opts, processor)
+}
 
+// parseBatch processes a batch of rows, the first being row first, in a "parse rows" span
+// recording how many became quotes and how many failed validation
+func parseBatch(opts Options, processor *rowProcessor, batch [][]string, first int, parse rowParser) []Quote {
+	_, span := startSpan(opts, "parse rows", attribute.Int("rows.first", first), attribute.Int("rows.count", len(batch)))
+	defer span.End()
+	invalid := processor.invalid.Load()
+	quotes := processRows(batch, first, opts.Workers, parse)
+	span.SetAttributes(attribute.Int("quotes.count", len(quotes)), attribute.Int64("rows.invalid", processor.invalid.Load()-invalid))
+	return quotes
+}
+
+// finishRows finishes processor in a "validate" span, failing strict validation
+func finishRows(opts Options, processor *rowProcessor) error {
+	_, span := startSpan(opts, "validate", attribute.Int64("rows.invalid", processor.invalid.Load()))
+	err := processor.finish()
+	endSpan(span, err)
+	return err
+}
+
+// ReadExcelFileWithOptions is ReadExcelFile with configurable options
+func ReadExcelFileWithOptions(file *excelize.File, opts Options) error {
 	// Get all sheet names
 	sheets := file.GetSheetList()
 	if len(sheets) == 0 {
@@ -81,73 +275,556 @@ func ReadExcelFile(file *excelize.File) error {
 	// Access the first sheet
 	sheetName := sheets[0]
 
-	// Read all rows in the specified sheet
-	rows, err := file.GetRows(sheetName)
+	// Iterate the rows of the sheet instead of loading them all at once
+	rows, err := file.Rows(sheetName)
 	if err != nil {
 		return fmt.Errorf("unable to load cells: %w", err)
 	}
+	defer rows.Close()
+	return convertRows(excelRows{rows}, sheetName, file.Path, opts)
+}
 
-	// Process each row in batches
-	var batch []Quote
-	for i, row := range rows {
-		if i == 0 {
-			// Skip header row if present
+// convertRows converts the rows of an input, header first, into the outputs configured in opts.
+// sourceName identifies the input in checkpoints and sourcePath is its file, if any.
+func convertRows(rows rowReader, sourceName, sourcePath string, opts Options) error {
+	start := time.Now()
+	batchSize := opts.BatchSize
+	if batchSize < 1 {
+		batchSize = DefaultOptions().BatchSize
+	}
+	var err error
+
+	// Pick up from the last checkpoint when resuming an interrupted conversion
+	if opts.Resume && (len(opts.Sinks) > 0 || opts.SplitBy != SplitNone || opts.ChunkSize > 0 || opts.TagIndex || opts.Blocklist != "" || opts.Spellcheck != "") {
+		return fmt.Errorf("--resume cannot be combined with --to, --split-by, --chunk-size, --tag-index, --blocklist or --spellcheck, sinks are written in a single pass")
+	}
+	if opts.Resume && opts.Related > 0 {
+		return fmt.Errorf("--resume cannot be combined with --related, the quotes are only written once all of them are read")
+	}
+	if opts.Resume && (opts.Compress != CompressNone || len(opts.Encrypt) > 0) {
+		return fmt.Errorf("--resume is not supported for compressed or encrypted output")
+	}
+	if opts.Strfile && (opts.Compress != CompressNone || len(opts.Encrypt) > 0) {
+		return fmt.Errorf("--strfile cannot be combined with --compress or --encrypt-recipient, the index needs plain offsets")
+	}
+	if opts.Resume && !opts.Format.Resumable() {
+		return fmt.Errorf("--resume is not supported for the %s format", opts.Format)
+	}
+	if opts.Incremental && (opts.Resume || opts.PreserveIDs != "") {
+		return fmt.Errorf("--incremental cannot be combined with --resume or --preserve-ids")
+	}
+	if opts.Incremental && len(opts.Encrypt) > 0 {
+		// The row fingerprints can't be encrypted, the next run has no key to read them
+		return fmt.Errorf("--incremental cannot be combined with --encrypt-recipient, the row fingerprints hold the quotes in plaintext")
+	}
+	if opts.Resume && opts.PreserveIDs != "" {
+		return fmt.Errorf("--resume cannot be combined with --preserve-ids, the resumed file already holds the IDs")
+	}
+	if opts.IDStrategy == IDUUID && (opts.Format == StreamParquet || opts.Format == StreamAvro || opts.Format == StreamSQL) {
+		return fmt.Errorf("--id-strategy uuid is not supported for the %s format, its id column is an integer", opts.Format)
+	}
+	if opts.IDStrategy == IDUUID {
+		for _, spec := range opts.Sinks {
+			if scheme, _, _ := strings.Cut(spec, ":"); strings.EqualFold(scheme, "sqlite") {
+				return fmt.Errorf("--id-strategy uuid is not supported by the sqlite sink, its id column is an integer")
+			}
+		}
+	}
+	if opts.Strfile && opts.Format != StreamFortune {
+		return fmt.Errorf("--strfile requires --format fortune")
+	}
+	if err := checkEmbedMetadata(opts); err != nil {
+		return err
+	}
+	if opts.Language != "" {
+		if opts.Language, err = NormalizeLanguageTag(opts.Language); err != nil {
+			return err
+		}
+	}
+	toStdout := opts.Output == "-"
+	if toStdout && opts.Resume {
+		return fmt.Errorf("--resume cannot be combined with --out -, stdout cannot be rewound")
+	}
+	if toStdout && opts.Incremental {
+		return fmt.Errorf("--incremental cannot be combined with --out -, the row fingerprints are stored next to the file")
+	}
+	if toStdout && opts.Strfile {
+		return fmt.Errorf("--strfile cannot be combined with --out -, the index needs a file")
+	}
+	var signKey ed25519.PrivateKey
+	if opts.SignKey != "" {
+		if toStdout {
+			return fmt.Errorf("--sign-key cannot be combined with --out -, the signature is written next to the file")
+		}
+		if signKey, err = loadSigningKey(opts.SignKey); err != nil {
+			return err
+		}
+	}
+	if opts.Format == StreamTemplate {
+		// Surface template errors before converting anything
+		if _, err := loadOutputTemplate(opts.TemplateFile); err != nil {
+			return err
+		}
+	}
+	metadataConfig, err := loadMetadataConfig(opts)
+	if err != nil {
+		return err
+	}
+	outputFile := outputFileName(opts)
+	metadataFile := "quotesMetadata.json" + opts.Compress.Extension()
+	checkpointPath := checkpointFile(outputFile)
+	var resume *checkpoint
+	if opts.Resume {
+		if resume, err = loadCheckpoint(checkpointPath); err != nil {
+			return err
+		}
+		if resume == nil {
+			log.Printf("No checkpoint found at %s, starting from the beginning", checkpointPath)
+		} else if resume.Source != sourceName {
+			return fmt.Errorf("checkpoint %s was written for sheet %q, not %q", checkpointPath, resume.Source, sourceName)
+		} else if resume.Format != opts.Format.String() {
+			return fmt.Errorf("checkpoint %s was written for format %q, not %q", checkpointPath, resume.Format, opts.Format)
+		}
+	}
+
+	// Load the previous IDs before the output file is recreated, it's usually the same file
+	var preserver *idPreserver
+	if opts.PreserveIDs != "" {
+		if preserver, err = loadIDPreserver(opts.PreserveIDs, opts.IDStrategy); err != nil {
+			return err
+		}
+	}
+
+	// The previous dataset decides the next version, so read it before it's overwritten too
+	var previousMetadata *Metadata
+	if opts.EmbedMetadata {
+		if previousMetadata, err = readEmbeddedMetadata(outputFile); err != nil {
+			return err
+		}
+	}
+	if previousMetadata == nil {
+		if previousMetadata, err = readPreviousMetadata(metadataFile, opts.Compress); err != nil {
+			return err
+		}
+	}
+	var previousQuotes []Quote
+	diffable := previousMetadata != nil && (opts.VersionBump == BumpAuto || opts.VersionBump == "") &&
+		opts.Format == StreamArray && len(opts.Encrypt) == 0 && !toStdout && resume == nil
+	if diffable {
+		if previousQuotes, err = readQuotesFile(outputFile, opts.Compress); err != nil {
+			log.Printf("Unable to compare with the previous quotes, assuming a patch: %v", err)
+			diffable = false
+		}
+	}
+
+	var cache *rowCache
+	cachePath := rowCacheFile(outputFile)
+	if opts.Incremental {
+		if cache, err = loadRowCache(cachePath); err != nil {
+			return err
+		}
+	}
+
+	// Quotes are streamed to the output file as each batch completes
+	var stream *QuoteStreamWriter
+	var totals quoteTotals
+	batchStart := 1
+	// Templates render the metadata with the quotes, before it's written on its own; they
+	// aren't diffed, so it doesn't depend on the finished output
+	opts.templateMetadata = func(count int) (Metadata, error) {
+		return datasetMetadata(opts, count, totals, sourcePath, previousMetadata, nil, metadataConfig)
+	}
+	if toStdout {
+		stream = NewQuoteStreamWriterWithOptions(os.Stdout, opts)
+	} else if resume != nil {
+		log.Printf("Resuming after row %d with %d quotes already written", resume.Row, resume.Count)
+		stream, err = ResumeQuoteStreamFile(outputFile, opts, resume.Offset, resume.Count)
+		batchStart = resume.Row + 1
+		totals = resume.Stats
+	} else {
+		// Keep the previous outputs around so a bad conversion can be rolled back
+		now := time.Now()
+		for _, previous := range []string{outputFile, metadataFile} {
+			if err := backupFile(previous, opts.Backups, opts.BackupTime, now); err != nil {
+				return err
+			}
+		}
+		stream, err = CreateQuoteStreamFileWithOptions(outputFile, opts)
+	}
+	if err != nil {
+		log.Printf("Error writing JSON to file: %v", err)
+		return err
+	}
+	defer stream.Close()
+
+	// Open the extra sinks; they are rolled back unless the whole conversion succeeds
+	var sinks []QuoteSink
+	var uploaders []uploader
+	committed := false
+	defer func() {
+		if !committed {
+			for _, sink := range sinks {
+				sink.Rollback()
+			}
+		}
+	}()
+	for _, spec := range opts.Sinks {
+		if isUploadSink(spec) {
+			u, err := openUploader(spec, opts)
+			if err != nil {
+				return err
+			}
+			uploaders = append(uploaders, u)
 			continue
 		}
-		if len(row) < 2 {
-			log.Printf("Skipping row %d due to insufficient columns: %v", i, row)
-			continue // Skip rows with insufficient columns
+		sink, err := OpenSink(spec, opts)
+		if err != nil {
+			return err
 		}
+		sinks = append(sinks, sink)
+	}
+	if opts.SplitBy != SplitNone {
+		sink, err := openSplitSink(opts)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, sink)
+	}
+	if opts.ChunkSize > 0 {
+		sink, err := openChunkSink(opts)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, sink)
+	}
+	if opts.TagIndex {
+		sinks = append(sinks, newTagIndexSink(tagIndexFile+envelopeExtension(opts), opts))
+	}
+
+	processor, err := loadRowProcessor(opts)
+	if err != nil {
+		return err
+	}
 
-		// Process tags by removing spaces and splitting by commas
-		rawTags := strings.ReplaceAll(row[0], " ", "") // Remove spaces
-		tags := strings.Split(rawTags, ",")            // Split by commas
+	parse := processor.parse
+
+	translator, err := newQuoteTranslator(opts)
+	if err != nil {
+		return err
+	}
+
+	// write appends the quotes of a batch to the outputs and records a checkpoint
+	var batch [][]string
+	blankRows := 0
+	write := func(quotes []Quote) error {
+		for i := range quotes {
+			parsed := quotes[i].key()
+			// IDs are assigned here rather than by the workers so they increase in output order
+			if preserver != nil {
+				if err := preserver.assign(&quotes[i]); err != nil {
+					return err
+				}
+			} else if opts.IDStrategy == IDUUID && quotes[i].UUID == "" {
+				id, err := newQuoteUUID()
+				if err != nil {
+					return err
+				}
+				quotes[i].UUID = id
+			}
+			if processor.blocklist != nil {
+				processor.blocklist.written(parsed, quotes[i])
+			}
+		}
+		if opts.Related > 0 {
+			linkRelated(quotes, opts.Related)
+		}
+		if translator != nil {
+			if err := translator.translateQuotes(quotes); err != nil {
+				return err
+			}
+		}
+		for i := range quotes {
+			if cache != nil {
+				cache.written(quotes[i])
+			}
+			if err := stream.WriteQuote(quotes[i]); err != nil {
+				return err
+			}
+			totals.add(quotes[i])
+		}
+		for _, sink := range sinks {
+			if err := sink.WriteQuotes(quotes); err != nil {
+				return err
+			}
+		}
+		batchStart += len(batch)
+		batch = batch[:0] // Reset the batch
+		if opts.Progress != nil {
+			opts.Progress(batchStart-1, stream.Count())
+		}
+		if err := stream.Flush(); err != nil {
+			return err
+		}
+		if toStdout || opts.Compress != CompressNone || len(opts.Encrypt) > 0 || !opts.Format.Resumable() {
+			return nil
+		}
+		return saveCheckpoint(checkpointPath, checkpoint{
+			Source: sourceName,
+			Format: opts.Format.String(),
+			Row:    batchStart - 1,
+			Offset: stream.Offset(),
+			Count:  stream.Count(),
+			Stats:  totals,
+		})
+	}
+	// Quotes can only be related once all of them are read, so with --related they are held
+	// back and written in one go
+	var held []Quote
+	// flush processes the pending batch and writes the resulting quotes, each step in its span
+	flush := func() error {
+		quotes := parseBatch(opts, processor, batch, batchStart, parse)
+		if opts.Related > 0 {
+			held = append(held, quotes...)
+			batchStart += len(batch)
+			batch = batch[:0]
+			return nil
+		}
+		_, span := startSpan(opts, "write quotes", attribute.Int("quotes.count", len(quotes)))
+		err := write(quotes)
+		endSpan(span, err)
+		return err
+	}
 
-		// Create a Quote struct with data from the row
-		quote := Quote{
-			ID:       int64(i), // Generate an ID
-			Text:     row[1],   // Column 1 as the quote text
-			Tags:     tags,     // Column 0 as tags
-			Language: "en-US",  // Default language
+	// Process each row in batches
+	for i := 0; rows.Next(); i++ {
+		row, err := rows.Columns()
+		if err != nil {
+			return fmt.Errorf("unable to read row %d: %w", i, err)
+		}
+		if i == 0 {
+			// Skip header row if present, it only tells us where the columns are
+			if processor.columns, err = newColumnMap(row, opts.Columns); err != nil {
+				return err
+			}
+			if cache != nil {
+				fingerprint, err := processingFingerprint(row, opts)
+				if err != nil {
+					return err
+				}
+				cache.start(fingerprint, processor.parse, processor)
+				parse = cache.parseRow
+			}
+			continue
+		}
+		if i < batchStart {
+			// Already converted before the interruption
+			continue
 		}
 
-		// Add quote to the current batch
-		batch = append(batch, quote)
+		// Hold back empty rows until a non-empty one follows, so trailing blank rows are trimmed as GetRows does
+		if len(row) == 0 {
+			blankRows++
+			continue
+		}
+		for ; blankRows > 0; blankRows-- {
+			batch = append(batch, nil)
+		}
+		batch = append(batch, row)
 
-		// If batch size is reached, add the batch to the accumulated list
+		// If batch size is reached, write the batch out
 		if len(batch) >= batchSize {
-			accumulatedQuotes = append(accumulatedQuotes, batch...)
-			batch = nil // Reset the batch
+			if err := flush(); err != nil {
+				log.Printf("Error writing JSON to file: %v", err)
+				return err
+			}
 		}
 	}
+	if err := rows.Error(); err != nil {
+		return fmt.Errorf("unable to load cells: %w", err)
+	}
 
-	// Add any remaining quotes from the last incomplete batch
+	// Write any remaining rows from the last incomplete batch
 	if len(batch) > 0 {
-		accumulatedQuotes = append(accumulatedQuotes, batch...)
+		if err := flush(); err != nil {
+			log.Printf("Error writing JSON to file: %v", err)
+			return err
+		}
+	}
+	if opts.Related > 0 {
+		_, span := startSpan(opts, "write quotes", attribute.Int("quotes.count", len(held)))
+		err := write(held)
+		endSpan(span, err)
+		if err != nil {
+			log.Printf("Error writing JSON to file: %v", err)
+			return err
+		}
+	}
+	// The errors of the outputs are recorded by the span of the whole conversion
+	_, outputSpan := startSpan(opts, "write outputs", attribute.String("output.file", outputFile))
+	defer outputSpan.End()
+	if err := stream.Close(); err != nil {
+		log.Printf("Error writing JSON to file: %v", err)
+		return err
 	}
 
-	// Create metadata for the accumulated quotes
-	metadata := Metadata{
-		Version:     "1.0",
-		LastUpdated: time.Now().Format(time.RFC3339),
-		TotalQuotes: len(accumulatedQuotes),
-		URL:         "path/to/file", // Set URL if available
+	if opts.Strfile {
+		if err := WriteStrfileIndex(outputFile); err != nil {
+			return err
+		}
 	}
-	metadata.Schema.Format = "JSON"
-	metadata.Schema.Encoding = "UTF-8"
-	metadata.Schema.FileType = "text"
 
-	// Combine accumulated quotes and metadata into the final structure
-	quotesData := QuotesData{
-		Quotes:   accumulatedQuotes,
+	if preserver != nil {
+		log.Printf("%d quote IDs preserved from %s, %d new", preserver.reused, opts.PreserveIDs, preserver.fresh)
+	}
+	if err := finishRows(opts, processor); err != nil {
+		return err
 	}
 
-	// Write the accumulated quotes to a JSON file
-	if err := WriteJSONToFile("quotes.json", quotesData); err != nil {
-		log.Printf("Error writing JSON to file: %v", err)
+	committed = true
+	for _, sink := range sinks {
+		if err := sink.Commit(); err != nil {
+			return err
+		}
+	}
+
+	if cache != nil {
+		if err := cache.save(cachePath); err != nil {
+			return err
+		}
+		log.Printf("%d of %d rows unchanged since the last incremental run", cache.reused, len(cache.hashes))
+	}
+
+	// The output is complete, so there is nothing left to resume
+	if !toStdout {
+		if err := os.Remove(checkpointPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("Error removing checkpoint: %v", err)
+		}
+	}
+
+	// Create metadata for the accumulated quotes
+	var diff *QuotesDiff
+	if diffable {
+		current, err := readQuotesFile(outputFile, opts.Compress)
+		if err != nil {
+			return err
+		}
+		changes, err := versionDiff(previousQuotes, current)
+		if err != nil {
+			return err
+		}
+		diff = &changes
+	}
+	metadata, err := datasetMetadata(opts, stream.Count(), totals, sourcePath, previousMetadata, diff, metadataConfig)
+	if err != nil {
 		return err
 	}
+	if previousMetadata != nil && previousMetadata.Version != metadata.Version {
+		log.Printf("Dataset version changed from %s to %s", previousMetadata.Version, metadata.Version)
+	}
+	if opts.Checksums && !toStdout && !opts.EmbedMetadata {
+		if metadata.SHA256, err = fileSHA256(outputFile); err != nil {
+			return err
+		}
+	}
+	metadata.Duration = durationSeconds(start)
+	if opts.EmbedMetadata {
+		// A combined file can't hold its own digest, checksums.txt still has it
+		if err := embedMetadata(outputFile, metadata, opts); err != nil {
+			return err
+		}
+	} else if err := writeMetadataFile(metadataFile, metadata, opts.Compress); err != nil {
+		return err
+	}
+	if signKey != nil {
+		if err := signFile(outputFile, signKey); err != nil {
+			return err
+		}
+	}
+	if opts.Checksums {
+		files := []string{outputFile, metadataFile}
+		if toStdout {
+			files = files[1:]
+		} else if opts.EmbedMetadata {
+			files = files[:1]
+		}
+		if err := writeChecksums(checksumFile, files); err != nil {
+			return err
+		}
+	}
+	files := writtenFiles(outputFile, metadataFile, signKey != nil, opts)
+	if err := uploadOutputs(uploaders, files, outputFile, opts); err != nil {
+		return err
+	}
+	if opts.webhook != nil && !toStdout {
+		if err := opts.webhook.outputs(files, metadata, uploaders); err != nil {
+			return err
+		}
+	}
+
+	// Report on stderr so stdout only ever carries the quotes
+	if toStdout {
+		outputFile = "stdout"
+	}
+	fmt.Fprintf(os.Stderr, "Quotes successfully written to %s\n", outputFile)
+	return nil
+}
+
+// writtenFiles lists the files a conversion to outputFile with opts writes, those delivered by
+// the uploaders; the directories of --split-by and --chunk-size aren't
+func writtenFiles(outputFile, metadataFile string, signed bool, opts Options) []string {
+	files := []string{outputFile}
+	if opts.Strfile {
+		files = append(files, outputFile+".dat")
+	}
+	if signed {
+		files = append(files, outputFile+".sig")
+	}
+	if !opts.EmbedMetadata {
+		files = append(files, metadataFile)
+	}
+	if opts.TagIndex {
+		files = append(files, tagIndexFile+envelopeExtension(opts))
+	}
+	if opts.Checksums {
+		files = append(files, checksumFile)
+	}
+	return files
+}
+
+// datasetMetadata describes the dataset of totalQuotes quotes converted from sourcePath: its
+// stats, its version after previous given the diff of the quotes, and the configured fields
+func datasetMetadata(opts Options, totalQuotes int, totals quoteTotals, sourcePath string, previous *Metadata, diff *QuotesDiff, config metadataConfig) (Metadata, error) {
+	metadata := newMetadata(opts, totalQuotes)
+	metadata.Stats = totals.stats()
+	metadata.Counts = totals.counts()
+	var err error
+	if metadata.Source, err = sourceInfo(sourcePath); err != nil {
+		return metadata, err
+	}
+	if metadata.Version, err = nextVersion(previous, metadata, opts.VersionBump, diff); err != nil {
+		return metadata, err
+	}
+	config.apply(&metadata)
+	return metadata, nil
+}
+
+// newMetadata describes a dataset of totalQuotes quotes converted with opts
+func newMetadata(opts Options, totalQuotes int) Metadata {
+	metadata := Metadata{
+		Version:     initialVersion,
+		LastUpdated: time.Now().Format(time.RFC3339),
+		TotalQuotes: totalQuotes,
+		URL:         defaultMetadataURL,
+		Generator:   generatorInfo(),
+	}
+	metadata.Schema.Format = opts.Format.SchemaName()
+	metadata.Schema.Encoding = opts.Compress.encoding()
+	metadata.Schema.FileType = "text"
+	return metadata
+}
 
+// writeMetadataFile writes the metadata JSON file, compressed with compression
+func writeMetadataFile(filename string, metadata Metadata, compression Compression) error {
 	// converting metadata to json encoding
 	jsonMetadata, err := json.MarshalIndent(metadata, "", " ")
 	if err != nil {
@@ -155,25 +832,371 @@ func ReadExcelFile(file *excelize.File) error {
 	}
 
 	// writing metadata json file
-	if err := os.WriteFile("quotesMetadata.json", jsonMetadata, 0644); err != nil {
+	if err := compression.writeFile(filename, jsonMetadata, 0644); err != nil {
 		return fmt.Errorf("error writing metadata.json %v", err)
 	}
+	return nil
+}
+
+// outputFileName returns the name of the quotes output file for opts
+func outputFileName(opts Options) string {
+	if opts.Output != "" {
+		return opts.Output
+	}
+	extension := opts.Format.Extension()
+	if opts.Format == StreamTemplate {
+		extension = templateExtension(opts.TemplateFile)
+	}
+	return "quotes" + extension + envelopeExtension(opts)
+}
+
+// envelopeExtension returns what opts.Compress and opts.Encrypt add to the name of a quotes
+// file, such as .gz.age
+func envelopeExtension(opts Options) string {
+	extension := opts.Compress.Extension()
+	if len(opts.Encrypt) > 0 {
+		extension += ".age"
+	}
+	return extension
+}
+
+// rowProcessor applies the per-quote processing configured in opts to parseRow. It is safe for
+// concurrent use by the worker pool.
+type rowProcessor struct {
+	opts          Options
+	columns       columnMap     // layout of the sheet, set from the header row
+	aliases       aliasMap      // canonical author names, if configured
+	tagAliases    aliasMap      // canonical tags, if configured
+	tagsRewritten atomic.Int64  // number of tags rewritten by tagAliases
+	tagRules      tagRules      // keyword rules adding tags, if configured
+	tagsAdded     atomic.Int64  // number of tags added by tagRules
+	tagFilter     *tagFilter    // allowed and banned tags, if configured
+	tagsFiltered  atomic.Int64  // number of tags removed by tagFilter
+	quotesDropped atomic.Int64  // number of quotes dropped because tagFilter removed all their tags
+	taxonomy      taxonomy      // parents inherited by tags, if configured
+	invalid       atomic.Int64  // number of rows that failed validation
+	blocklist     *blocklist    // prohibited terms, if configured
+	spellchecker  *spellchecker // dictionary the quotes are spellchecked against, if configured
+	piiFound      atomic.Int64  // number of rows containing emails, phone numbers or URLs
+	reportsMu     sync.Mutex
+	reports       map[int]*rowReport // side reports of every row, saved by the row cache with --incremental
+}
+
+// newRowProcessor returns a row processor for opts reading the default column layout
+func newRowProcessor(opts Options) *rowProcessor {
+	return &rowProcessor{opts: opts, columns: defaultColumns}
+}
+
+// loadRowProcessor returns a row processor with the alias, rule, filter and taxonomy files of opts loaded
+func loadRowProcessor(opts Options) (*rowProcessor, error) {
+	p := newRowProcessor(opts)
+	var err error
+	if opts.AuthorAliases != "" {
+		if p.aliases, err = loadAliases(opts.AuthorAliases, "author"); err != nil {
+			return nil, err
+		}
+	}
+	if opts.TagAliases != "" {
+		if p.tagAliases, err = loadAliases(opts.TagAliases, "tag"); err != nil {
+			return nil, err
+		}
+	}
+	if opts.TagRules != "" {
+		if p.tagRules, err = loadTagRules(opts.TagRules); err != nil {
+			return nil, err
+		}
+	}
+	if opts.AllowedTags != "" || opts.BannedTags != "" {
+		if p.tagFilter, err = newTagFilter(opts.AllowedTags, opts.BannedTags); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Blocklist != "" {
+		if p.blocklist, err = loadBlocklist(opts.Blocklist, opts.BlocklistAction); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Spellcheck != "" {
+		if p.spellchecker, err = loadSpellchecker(opts.Spellcheck, opts.SpellcheckIgnore); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Taxonomy != "" {
+		if p.taxonomy, err = loadTaxonomy(opts.Taxonomy); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
 
-	fmt.Println("JSON data successfully written to quotes_output.json")
+// finish logs what the processing changed, writes the blocklist review and spellcheck report and
+// fails strict validation
+func (p *rowProcessor) finish() error {
+	if rewritten := p.tagsRewritten.Load(); rewritten > 0 {
+		log.Printf("%d tags rewritten by tag aliases", rewritten)
+	}
+	if added := p.tagsAdded.Load(); added > 0 {
+		log.Printf("%d tags added by the tag rules", added)
+	}
+	if filtered := p.tagsFiltered.Load(); filtered > 0 {
+		log.Printf("%d tags removed by the tag filters, %d quotes dropped", filtered, p.quotesDropped.Load())
+	}
+	if found := p.piiFound.Load(); found > 0 {
+		log.Printf("%d rows contained emails, phone numbers or URLs (%s)", found, p.opts.PII)
+	}
+	if p.blocklist != nil {
+		review := p.opts.BlocklistReview + envelopeExtension(p.opts)
+		if err := p.blocklist.writeReview(review, p.opts); err != nil {
+			return err
+		}
+		if blocked := len(p.blocklist.blocked); blocked > 0 {
+			log.Printf("%d quotes caught by the blocklist (%s), see %s", blocked, p.opts.BlocklistAction, review)
+		}
+	}
+	if p.spellchecker != nil {
+		report := p.opts.SpellcheckReport + envelopeExtension(p.opts)
+		if err := p.spellchecker.writeReport(report, p.opts); err != nil {
+			return err
+		}
+		if typos := len(p.spellchecker.typos); typos > 0 {
+			log.Printf("%d likely typos found by the spellcheck, see %s", typos, report)
+		}
+	}
+	if invalid := p.invalid.Load(); invalid > 0 {
+		log.Printf("%d rows failed validation", invalid)
+		if p.opts.Validation.Strict {
+			return fmt.Errorf("%d rows failed validation", invalid)
+		}
+	}
 	return nil
 }
 
+// reportPII counts and logs a row containing the personal data of kinds, and reports whether
+// it should still be written
+func (p *rowProcessor) reportPII(i int, kinds []string) bool {
+	p.piiFound.Add(1)
+	if p.opts.PII == PIIDrop {
+		log.Printf("Skipping row %d, it contains %s", i, strings.Join(kinds, ", "))
+		return false
+	}
+	log.Printf("Row %d contains %s", i, strings.Join(kinds, ", "))
+	return true
+}
+
+// report updates the side report of row i, kept for the row cache with --incremental
+func (p *rowProcessor) report(i int, update func(report *rowReport)) {
+	if !p.opts.Incremental {
+		return
+	}
+	p.reportsMu.Lock()
+	defer p.reportsMu.Unlock()
+	if p.reports == nil {
+		p.reports = make(map[int]*rowReport)
+	}
+	if p.reports[i] == nil {
+		p.reports[i] = &rowReport{}
+	}
+	update(p.reports[i])
+}
+
+// takeReport returns and forgets the side report of row i, nil when there is none
+func (p *rowProcessor) takeReport(i int) *rowReport {
+	p.reportsMu.Lock()
+	defer p.reportsMu.Unlock()
+	report := p.reports[i]
+	delete(p.reports, i)
+	return report
+}
+
+// replayReport records the side report of a row reused by the row cache as if it had been
+// processed again at row i, so the blocklist review and the PII log still cover it
+func (p *rowProcessor) replayReport(i int, row []string, report *rowReport) {
+	if len(report.PII) > 0 {
+		p.report(i, func(r *rowReport) { r.PII = report.PII })
+		p.reportPII(i, report.PII)
+	}
+	if report.Blocked != nil && p.blocklist != nil {
+		entry := *report.Blocked
+		entry.Row = i
+		p.renumber(i, row, &entry.Quote)
+		p.blocklist.add(entry)
+		p.report(i, func(r *rowReport) { r.Blocked = &entry })
+	}
+	if len(report.Typos) > 0 && p.spellchecker != nil {
+		typos := make([]typo, len(report.Typos))
+		for j, entry := range report.Typos {
+			entry.Row = i
+			typos[j] = entry
+		}
+		p.spellchecker.add(typos...)
+		p.report(i, func(r *rowReport) { r.Typos = typos })
+	}
+}
+
+// renumber gives the quote of a row reused by the row cache the ID of row i, its row number
+// unless its id column has one
+func (p *rowProcessor) renumber(i int, row []string, quote *Quote) {
+	quote.ID = int64(i)
+	if value := strings.TrimSpace(p.columns.cell(row, "id")); value != "" {
+		// The value was checked when the row was cached
+		setColumnID(quote, value, p.opts.IDStrategy)
+	}
+}
+
+// parse is the rowParser handed to processRows
+func (p *rowProcessor) parse(i int, row []string) (Quote, bool) {
+	quote, ok := p.columns.parseRow(i, row)
+	if !ok {
+		return quote, false
+	}
+	if p.opts.TagPolicy != (TagPolicy{}) {
+		cell := p.columns.cell(row, "tags")
+		if !p.opts.KeepInvisible {
+			// Clean before splitting so invisible characters can't leave empty or duplicate tags
+			cell = cleanText(cell)
+		}
+		quote.Tags = p.opts.TagPolicy.split(cell)
+	}
+	if lang := strings.TrimSpace(p.columns.cell(row, "lang")); lang != "" {
+		// A language column overrides the default for its row
+		tag, err := NormalizeLanguageTag(lang)
+		if err != nil {
+			log.Printf("Skipping row %d, it failed validation: %v", i, err)
+			p.invalid.Add(1)
+			return Quote{}, false
+		}
+		quote.Language = tag
+	} else if p.opts.Language != "" {
+		quote.Language = p.opts.Language
+	}
+	if value := strings.TrimSpace(p.columns.cell(row, "year")); value != "" {
+		year, err := parseYear(value)
+		if err != nil {
+			log.Printf("Skipping row %d, it failed validation: %v", i, err)
+			p.invalid.Add(1)
+			return Quote{}, false
+		}
+		quote.Year = year
+	}
+	if value := strings.TrimSpace(p.columns.cell(row, "id")); value != "" {
+		if err := setColumnID(&quote, value, p.opts.IDStrategy); err != nil {
+			log.Printf("Skipping row %d, it failed validation: %v", i, err)
+			p.invalid.Add(1)
+			return Quote{}, false
+		}
+	}
+	if !p.opts.KeepInvisible {
+		cleanQuote(&quote)
+	}
+	if !p.opts.KeepAttribution && quote.Author == "" {
+		if text, author, ok := extractAttribution(quote.Text); ok {
+			quote.Text, quote.Author = text, author
+		}
+	}
+	if p.aliases != nil && quote.Author != "" {
+		quote.Author = p.aliases.canonical(quote.Author)
+	}
+	if p.tagAliases != nil {
+		var rewritten int
+		quote.Tags, rewritten = p.tagAliases.canonicalTags(quote.Tags)
+		p.tagsRewritten.Add(int64(rewritten))
+	}
+	if p.tagRules != nil {
+		var added int
+		quote.Tags, added = p.tagRules.apply(quote.Text, quote.Tags)
+		p.tagsAdded.Add(int64(added))
+	}
+	if p.tagFilter != nil {
+		tags, removed := p.tagFilter.filter(quote.Tags)
+		if removed > 0 {
+			p.tagsFiltered.Add(int64(removed))
+			if p.opts.DropFilteredQuotes && len(nonEmptyTags(tags)) == 0 {
+				p.quotesDropped.Add(1)
+				return Quote{}, false
+			}
+		}
+		quote.Tags = tags
+	}
+	if p.taxonomy != nil {
+		quote.Tags = p.taxonomy.expand(quote.Tags)
+	}
+	if len(p.opts.Normalize) > 0 {
+		quote.Text = p.opts.Normalize.apply(quote.Text)
+	}
+	if p.opts.PII != PIINone {
+		if kinds := scrubPII(&quote, p.opts.PII == PIIRedact); len(kinds) > 0 {
+			p.report(i, func(report *rowReport) { report.PII = kinds })
+			if !p.reportPII(i, kinds) {
+				return Quote{}, false
+			}
+		}
+	}
+	if failures := p.opts.Validation.validate(quote); len(failures) > 0 {
+		log.Printf("Skipping row %d, it failed validation: %s", i, strings.Join(failures, "; "))
+		p.invalid.Add(1)
+		return Quote{}, false
+	}
+	if p.blocklist != nil {
+		entry, keep := p.blocklist.check(i, quote)
+		if entry != nil {
+			p.report(i, func(report *rowReport) { report.Blocked = entry })
+		}
+		if !keep {
+			return Quote{}, false
+		}
+	}
+	if p.spellchecker != nil {
+		if typos := p.spellchecker.check(i, quote); len(typos) > 0 {
+			p.report(i, func(report *rowReport) { report.Typos = typos })
+		}
+	}
+	if p.opts.Sentiment {
+		sentiment := analyzeSentiment(quote.Text)
+		quote.Sentiment = &sentiment
+	}
+	countWords(&quote)
+	return quote, true
+}
+
+// parseRow converts a single spreadsheet row in the default column layout into a Quote,
+// reporting false if the row should be skipped
+func parseRow(i int, row []string) (Quote, bool) {
+	return defaultColumns.parseRow(i, row)
+}
+
+// parseRow converts a single spreadsheet row into a Quote, reporting false if the row should be skipped
+func (m columnMap) parseRow(i int, row []string) (Quote, bool) {
+	if len(row) <= m["text"] {
+		log.Printf("Skipping row %d due to insufficient columns: %v", i, row)
+		return Quote{}, false // Skip rows with insufficient columns
+	}
+
+	// Process tags by removing spaces and splitting by commas
+	rawTags := strings.ReplaceAll(m.cell(row, "tags"), " ", "") // Remove spaces
+	tags := strings.Split(rawTags, ",")                         // Split by commas
+
+	// Create a Quote struct with data from the row
+	quote := Quote{
+		ID:       int64(i),               // Generate an ID
+		Text:     m.cell(row, "text"),    // Quote text column
+		Author:   m.cell(row, "author"),  // Author column, if any
+		Context:  m.cell(row, "context"), // Context column, if any
+		Tags:     tags,                   // Tags column
+		Language: "en-US",                // Default language
+	}
+	return quote, true
+}
+
 // WriteJSONToFile saves the JSON data to a specified file
 func WriteJSONToFile(filename string, data QuotesData) error {
-	// Convert data to JSON format with indentation
-	jsonData, err := json.MarshalIndent(data, "", "  ")
-	if err != nil {
-		return fmt.Errorf("error marshalling JSON: %w", err)
-	}
+	return WriteJSONToFileWithOptions(filename, data, DefaultOptions())
+}
 
-	// Write JSON data to file
-	err = os.WriteFile(filename, jsonData, 0644)
-	if err != nil {
+// WriteJSONToFileWithOptions is WriteJSONToFile honouring opts.Indent and opts.Compact
+func WriteJSONToFileWithOptions(filename string, data QuotesData, opts Options) error {
+	opts.Format = StreamArray
+	// Stream the quotes into the file one by one instead of marshalling everything at once
+	if err := WriteQuotesToFileWithOptions(filename, opts, data); err != nil {
 		return fmt.Errorf("error writing JSON to file: %w", err)
 	}
 
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -86,6 +87,13 @@ func TestReadQuotesFromExcel(t *testing.T) {
 	assert.FileExists(t, "quotes.json")
 	assert.FileExists(t, "quotesMetadata.json")
 
+	// The metadata identifies the spreadsheet
+	metadata, err := ReadMetadataFromJSON("quotesMetadata.json")
+	require.NoError(t, err)
+	digest, err := fileSHA256(tmpFile)
+	require.NoError(t, err)
+	assert.Equal(t, &SourceInfo{File: "test.xlsx", SHA256: digest}, metadata.Source)
+
 	// Clean up
 	os.Remove("quotes.json")
 	os.Remove("quotesMetadata.json")
@@ -126,6 +134,146 @@ func TestReadExcelFile(t *testing.T) {
 	os.Remove("quotesMetadata.json")
 }
 
+// TestReadExcelFileBatchSize tests that small batches produce the same output as a single batch
+func TestReadExcelFileBatchSize(t *testing.T) {
+	f, _ := createTestExcelFile(t)
+	defer os.Remove("quotes.json")
+	defer os.Remove("quotesMetadata.json")
+
+	opts := DefaultOptions()
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	expected, err := os.ReadFile("quotes.json")
+	require.NoError(t, err)
+
+	opts.BatchSize = 1
+	opts.Workers = 2
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	actual, err := os.ReadFile("quotes.json")
+	require.NoError(t, err)
+
+	assert.Equal(t, string(expected), string(actual))
+}
+
+// TestReadExcelFileOutput tests writing the quotes to a named file and to stdout
+func TestReadExcelFileOutput(t *testing.T) {
+	f, _ := createTestExcelFile(t)
+	defer os.Remove("quotesMetadata.json")
+
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(t.TempDir(), "out.json")
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	expected, err := os.ReadFile(opts.Output)
+	require.NoError(t, err)
+	assert.NoFileExists(t, "quotes.json")
+
+	// Capture stdout while converting to it
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	opts.Output = "-"
+	err = ReadExcelFileWithOptions(f, opts)
+	os.Stdout = stdout
+	require.NoError(t, err)
+	w.Close()
+	actual, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(actual))
+
+	opts.Resume = true
+	assert.Error(t, ReadExcelFileWithOptions(f, opts))
+}
+
+// TestRowProcessor tests that the configured processing is applied after parsing
+func TestRowProcessor(t *testing.T) {
+	opts := DefaultOptions()
+	require.NoError(t, opts.Normalize.Set("whitespace,trim"))
+	opts.Validation.MaxLength = 20
+	processor := newRowProcessor(opts)
+
+	quote, ok := processor.parse(4, []string{"a, b", "  Too   many\n spaces "})
+	require.True(t, ok)
+	assert.Equal(t, "Too many spaces", quote.Text)
+	assert.Equal(t, int64(4), quote.ID)
+
+	_, ok = processor.parse(5, []string{"a"})
+	assert.False(t, ok)
+	assert.Equal(t, int64(0), processor.invalid.Load())
+
+	_, ok = processor.parse(6, []string{"a", "This quote is far too long"})
+	assert.False(t, ok)
+	assert.Equal(t, int64(1), processor.invalid.Load())
+}
+
+// TestRowProcessorPII tests redacting and dropping rows with personal data
+func TestRowProcessorPII(t *testing.T) {
+	opts := DefaultOptions()
+	opts.PII = PIIRedact
+	processor := newRowProcessor(opts)
+	quote, ok := processor.parse(1, []string{"a", "Mail me at me@example.com"})
+	require.True(t, ok)
+	assert.Equal(t, "Mail me at [email]", quote.Text)
+
+	opts.PII = PIIDrop
+	processor = newRowProcessor(opts)
+	_, ok = processor.parse(1, []string{"a", "Mail me at me@example.com"})
+	assert.False(t, ok)
+	assert.Equal(t, int64(1), processor.piiFound.Load())
+}
+
+// TestReadExcelFileLanguage tests that the language tag is normalized and validated
+func TestReadExcelFileLanguage(t *testing.T) {
+	f, _ := createTestExcelFile(t)
+	defer os.Remove("quotes.json")
+	defer os.Remove("quotesMetadata.json")
+
+	opts := DefaultOptions()
+	opts.Language = "ta-in"
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	data, err := ReadQuotesFromJSON("quotes.json")
+	require.NoError(t, err)
+	assert.Equal(t, "ta-IN", data.Quotes[0].Language)
+
+	opts.Language = "not a tag"
+	assert.Error(t, ReadExcelFileWithOptions(f, opts))
+}
+
+// TestReadExcelFileStrictValidation tests that strict validation fails the conversion
+func TestReadExcelFileStrictValidation(t *testing.T) {
+	f, _ := createTestExcelFile(t)
+	defer os.Remove("quotes.json")
+	defer os.Remove("quotesMetadata.json")
+
+	opts := DefaultOptions()
+	opts.Validation.RequireTags = true
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+
+	opts.Validation.Strict = true
+	assert.Error(t, ReadExcelFileWithOptions(f, opts))
+}
+
+// TestReadExcelFileBlocklist tests that blocklisted quotes are excluded and written for review
+func TestReadExcelFileBlocklist(t *testing.T) {
+	f, _ := createTestExcelFile(t)
+	defer os.Remove("quotes.json")
+	defer os.Remove("quotesMetadata.json")
+
+	dir := t.TempDir()
+	opts := DefaultOptions()
+	opts.Blocklist = filepath.Join(dir, "blocklist.txt")
+	opts.BlocklistReview = filepath.Join(dir, "blocked.json")
+	require.NoError(t, os.WriteFile(opts.Blocklist, []byte("philosophy\n"), 0644))
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+
+	data, err := ReadQuotesFromJSON("quotes.json")
+	require.NoError(t, err)
+	assert.Len(t, data.Quotes, 2)
+
+	review, err := os.ReadFile(opts.BlocklistReview)
+	require.NoError(t, err)
+	assert.Contains(t, string(review), `"Test quote 3"`)
+}
+
 // TestWriteJSONToFile tests JSON file writing functionality
 func TestWriteJSONToFile(t *testing.T) {
 	tests := []struct {
@@ -216,6 +364,11 @@ func TestMetadataGeneration(t *testing.T) {
 	assert.Equal(t, "JSON", metadata.Schema.Format)
 	assert.Equal(t, "UTF-8", metadata.Schema.Encoding)
 	assert.Equal(t, "text", metadata.Schema.FileType)
+	require.NotNil(t, metadata.Counts)
+	assert.Equal(t, 1, metadata.Counts.ByTag["wisdom"])
+	assert.Equal(t, map[string]int{"en-US": 3}, metadata.Counts.ByLanguage)
+	require.NotNil(t, metadata.Generator)
+	assert.NotEmpty(t, metadata.Generator.GoVersion)
 
 	// Verify LastUpdated is a valid RFC3339 timestamp
 	_, err = time.Parse(time.RFC3339, metadata.LastUpdated)
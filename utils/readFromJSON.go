@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ReadQuotesFromJSON loads a quotes.json file produced by a previous conversion
+func ReadQuotesFromJSON(fileName string) (QuotesData, error) {
+	var data QuotesData
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return data, fmt.Errorf("failed to read JSON file %s: %w", fileName, err)
+	}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return data, fmt.Errorf("failed to parse JSON file %s: %w", fileName, err)
+	}
+	return data, nil
+}
+
+// ReadMetadataFromJSON loads a quotesMetadata.json file produced by a previous conversion
+func ReadMetadataFromJSON(fileName string) (Metadata, error) {
+	var metadata Metadata
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return metadata, fmt.Errorf("failed to read metadata file %s: %w", fileName, err)
+	}
+	if err := json.Unmarshal(content, &metadata); err != nil {
+		return metadata, fmt.Errorf("failed to parse metadata file %s: %w", fileName, err)
+	}
+	return metadata, nil
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReadQuotesFromJSON tests loading a written dataset and the error cases
+func TestReadQuotesFromJSON(t *testing.T) {
+	dir := t.TempDir()
+	data := QuotesData{Quotes: []Quote{{ID: 1, Text: "Test quote", Tags: []string{"test"}, Language: "en-US"}}}
+
+	file := filepath.Join(dir, "quotes.json")
+	require.NoError(t, WriteJSONToFile(file, data))
+	loaded, err := ReadQuotesFromJSON(file)
+	require.NoError(t, err)
+	assert.Equal(t, data, loaded)
+
+	_, err = ReadQuotesFromJSON(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte("{"), 0644))
+	_, err = ReadQuotesFromJSON(invalid)
+	assert.Error(t, err)
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readwiseBook is a book of a Readwise JSON export, as written by its export API
+type readwiseBook struct {
+	Title      string `json:"title"`
+	Author     string `json:"author"`
+	Highlights []struct {
+		Text      string        `json:"text"`
+		Tags      []readwiseTag `json:"tags"`
+		IsDiscard bool          `json:"is_discard"`
+	} `json:"highlights"`
+}
+
+type readwiseTag struct {
+	Name string `json:"name"`
+}
+
+// readwiseHighlight is a highlight of a Readwise CSV or JSON export
+type readwiseHighlight struct {
+	text, title, author string
+	tags                []string
+}
+
+// isReadwiseCSV reports whether header, lower-cased, is that of a Readwise CSV export
+func isReadwiseCSV(header []string) bool {
+	return containsTag(header, "highlight") && containsTag(header, "book title")
+}
+
+// isReadwiseJSON reports whether fileName holds a Readwise JSON export: an object whose first
+// key is one of those of the export API response, or a list of books with highlights
+func isReadwiseJSON(fileName string) bool {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	token, err := decoder.Token()
+	if err != nil {
+		return false
+	}
+	switch token {
+	case json.Delim('{'):
+		key, err := decoder.Token()
+		return err == nil && (key == "count" || key == "nextPageCursor" || key == "results")
+	case json.Delim('['):
+		var first map[string]json.RawMessage
+		if !decoder.More() || decoder.Decode(&first) != nil {
+			return false
+		}
+		_, ok := first["highlights"]
+		return ok
+	}
+	return false
+}
+
+// readReadwiseJSON loads the highlights of a Readwise JSON export, either the export API
+// response with its results or the list of books itself. Discarded highlights are skipped.
+func readReadwiseJSON(content []byte) ([]readwiseHighlight, error) {
+	var books []readwiseBook
+	if strings.HasPrefix(strings.TrimSpace(string(content)), "[") {
+		if err := json.Unmarshal(content, &books); err != nil {
+			return nil, err
+		}
+	} else {
+		var response struct {
+			Results []readwiseBook `json:"results"`
+		}
+		if err := json.Unmarshal(content, &response); err != nil {
+			return nil, err
+		}
+		books = response.Results
+	}
+
+	var highlights []readwiseHighlight
+	for _, book := range books {
+		for _, h := range book.Highlights {
+			if h.IsDiscard {
+				continue
+			}
+			highlight := readwiseHighlight{text: strings.TrimSpace(h.Text), title: book.Title, author: book.Author}
+			for _, tag := range h.Tags {
+				highlight.tags = append(highlight.tags, tag.Name)
+			}
+			highlights = append(highlights, highlight)
+		}
+	}
+	return highlights, nil
+}
+
+// readReadwiseCSV loads the highlights of a Readwise CSV export, with its Highlight, Book
+// Title, Book Author and Tags columns; the others, such as notes and locations, are ignored
+func readReadwiseCSV(records [][]string) []readwiseHighlight {
+	if len(records) == 0 {
+		return nil
+	}
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	cell := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var highlights []readwiseHighlight
+	for _, record := range records[1:] {
+		highlight := readwiseHighlight{text: cell(record, "highlight"), title: cell(record, "book title"), author: cell(record, "book author")}
+		if tags := cell(record, "tags"); tags != "" {
+			highlight.tags = []string{tags}
+		}
+		highlights = append(highlights, highlight)
+	}
+	return highlights
+}
+
+// readReadwiseRows reads a Readwise CSV or JSON export as rows, with the book title as the
+// context. A highlight saved more than once, in the same book or another, is read once: the
+// first with the same text ignoring case, punctuation and spacing is kept.
+func readReadwiseRows(fileName string, opts Options) (*sliceRows, error) {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Readwise export %s: %w", fileName, err)
+	}
+	var highlights []readwiseHighlight
+	if trimmed := strings.TrimSpace(string(content)); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		if highlights, err = readReadwiseJSON(content); err != nil {
+			return nil, fmt.Errorf("failed to parse Readwise export %s: %w", fileName, err)
+		}
+	} else {
+		records, err := readCSV(fileName)
+		if err != nil {
+			return nil, err
+		}
+		highlights = readReadwiseCSV(records)
+	}
+
+	rows := [][]string{quoteColumns}
+	seen := make(map[string]bool)
+	for _, highlight := range highlights {
+		key := textKey(highlight.text)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		rows = append(rows, []string{strings.Join(highlight.tags, ", "), highlight.text, highlight.author, "", highlight.title, ""})
+	}
+	return &sliceRows{rows: rows}, nil
+}
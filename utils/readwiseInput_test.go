@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readwiseCSVSample is a Readwise CSV export, with a highlight saved twice
+const readwiseCSVSample = `Highlight,Book Title,Book Author,Amazon Book ID,Note,Color,Tags,Location Type,Location,Highlighted at,Document tags
+"Waste no more time arguing what a good man should be. Be one.",Meditations,Marcus Aurelius,B000FC1JAI,,yellow,"stoicism, action",location,120,2023-01-02 10:00:00+00:00,
+The obstacle is the way.,The Obstacle Is the Way,Ryan Holiday,,,blue,,location,30,2023-01-03 10:00:00+00:00,
+"Waste no more time arguing what a good man should be; be one!",Meditations (Hays),Marcus Aurelius,,,,,location,98,2023-02-01 10:00:00+00:00,
+`
+
+// readwiseJSONSample is a Readwise export API response, with a discarded highlight
+const readwiseJSONSample = `{"count": 1, "nextPageCursor": null, "results": [{
+  "user_book_id": 12, "title": "Walden", "author": "Henry David Thoreau", "source": "kindle",
+  "highlights": [
+    {"id": 1, "text": "Simplify, simplify.", "tags": [{"id": 3, "name": "simplicity"}, {"id": 4, "name": "life"}], "is_discard": false},
+    {"id": 2, "text": "Discarded highlight.", "tags": [], "is_discard": true}
+  ]
+}]}`
+
+// TestReadReadwiseRows tests reading the CSV and JSON exports
+func TestReadReadwiseRows(t *testing.T) {
+	dir := t.TempDir()
+	csvFile := filepath.Join(dir, "readwise-data.csv")
+	require.NoError(t, os.WriteFile(csvFile, []byte(readwiseCSVSample), 0644))
+	jsonFile := filepath.Join(dir, "readwise.json")
+	require.NoError(t, os.WriteFile(jsonFile, []byte(readwiseJSONSample), 0644))
+	listFile := filepath.Join(dir, "books.json")
+	require.NoError(t, os.WriteFile(listFile, []byte(`[{"title": "Walden", "author": "Henry David Thoreau", "highlights": [{"text": "Simplify, simplify."}]}]`), 0644))
+
+	assert.Equal(t, InputFormat("readwise"), detectInputFormat(csvFile))
+	assert.Equal(t, InputFormat("readwise"), detectInputFormat(jsonFile))
+	assert.Equal(t, InputFormat("readwise"), detectInputFormat(listFile))
+
+	rows, err := readReadwiseRows(csvFile, DefaultOptions())
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		quoteColumns,
+		{"stoicism, action", "Waste no more time arguing what a good man should be. Be one.", "Marcus Aurelius", "", "Meditations", ""},
+		{"", "The obstacle is the way.", "Ryan Holiday", "", "The Obstacle Is the Way", ""},
+	}, rows.rows)
+
+	rows, err = readReadwiseRows(jsonFile, DefaultOptions())
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{quoteColumns, {"simplicity, life", "Simplify, simplify.", "Henry David Thoreau", "", "Walden", ""}}, rows.rows)
+
+	rows, err = readReadwiseRows(listFile, DefaultOptions())
+	require.NoError(t, err)
+	assert.Len(t, rows.rows, 2)
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte(`{"results": 3}`), 0644))
+	_, err = readReadwiseRows(invalid, DefaultOptions())
+	assert.Error(t, err)
+	_, err = readReadwiseRows(filepath.Join(dir, "missing.csv"), DefaultOptions())
+	assert.Error(t, err)
+}
+
+// TestIsReadwiseJSON tests telling a Readwise export from a quotes.json
+func TestIsReadwiseJSON(t *testing.T) {
+	dir := t.TempDir()
+	quotes := filepath.Join(dir, "quotes.json")
+	require.NoError(t, os.WriteFile(quotes, []byte(`{"quotes": [{"id": 1, "text": "a"}]}`), 0644))
+	list := filepath.Join(dir, "list.json")
+	require.NoError(t, os.WriteFile(list, []byte(`[{"text": "a"}]`), 0644))
+
+	assert.False(t, isReadwiseJSON(quotes))
+	assert.False(t, isReadwiseJSON(list))
+	assert.False(t, isReadwiseJSON(filepath.Join(dir, "missing.json")))
+	assert.Equal(t, InputFormat("json"), detectInputFormat(quotes))
+}
+
+// TestMergeReadwiseKeepExisting tests importing highlights without touching existing quotes
+func TestMergeReadwiseKeepExisting(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "readwise.csv")
+	require.NoError(t, os.WriteFile(input, []byte(readwiseCSVSample), 0644))
+	into := filepath.Join(dir, "quotes.json")
+	require.NoError(t, WriteJSONToFile(into, QuotesData{Quotes: []Quote{{ID: 4, Text: "The obstacle is the way", Author: "Ryan Holiday", Tags: []string{"curated"}, Language: "en-US"}}}))
+
+	opts := DefaultOptions()
+	opts.KeepExisting = true
+	result, err := MergeFile(input, into, filepath.Join(dir, "quotesMetadata.json"), MergeByText, opts)
+	require.NoError(t, err)
+	assert.Equal(t, MergeResult{Added: 1, Unchanged: 1}, result)
+
+	data, err := ReadQuotesFromJSON(into)
+	require.NoError(t, err)
+	require.Len(t, data.Quotes, 2)
+	assert.Equal(t, []string{"curated"}, data.Quotes[0].Tags)
+	assert.Equal(t, "Meditations", data.Quotes[1].Context)
+	assert.Equal(t, int64(5), data.Quotes[1].ID)
+}
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisSink loads the quotes into Redis with the keys of --format redis, plus the sorted set
+// <prefix>quotes:index scoring each ID by its position so ZRANGE can pick a quote at random. The
+// hashes are written as the quotes are converted; the sets are built under <prefix>staging: and
+// renamed over the live ones in a transaction once the conversion succeeds, which also removes
+// the quotes and tags a previous conversion left.
+type redisSink struct {
+	client *redis.Client
+	ctx    context.Context
+	prefix string
+	staged map[string]bool // live names of the staged sets
+	count  int
+}
+
+// openRedisSink opens the sink of --to redis://:password@host:6379/0, rediss:// for TLS, with
+// the keys prefixed by --redis-prefix
+func openRedisSink(scheme string) func(target string, opts Options) (QuoteSink, error) {
+	return func(target string, opts Options) (QuoteSink, error) {
+		options, err := redis.ParseURL(scheme + ":" + target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sink %q, expected redis://host:6379/0: %w", scheme+":"+target, err)
+		}
+		s := &redisSink{client: redis.NewClient(options), ctx: optsContext(opts), prefix: opts.RedisPrefix, staged: make(map[string]bool)}
+		// Sets staged by an interrupted conversion would be renamed along
+		leftovers, err := s.keys(redisGlobEscape(s.prefix) + "staging:*")
+		if err == nil && len(leftovers) > 0 {
+			err = s.client.Del(s.ctx, leftovers...).Err()
+		}
+		if err != nil {
+			s.client.Close()
+			return nil, fmt.Errorf("error connecting to redis %s: %w", options.Addr, err)
+		}
+		return s, nil
+	}
+}
+
+// keys returns the keys matching pattern, scanning rather than blocking the server with KEYS
+func (s *redisSink) keys(pattern string) ([]string, error) {
+	var keys []string
+	iter := s.client.Scan(s.ctx, 0, pattern, 1000).Iterator()
+	for iter.Next(s.ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+// WriteQuotes writes the hashes of a batch of quotes and stages their IDs, in one round trip
+func (s *redisSink) WriteQuotes(quotes []Quote) error {
+	if len(quotes) == 0 {
+		return nil
+	}
+	pipe := s.client.Pipeline()
+	for _, quote := range quotes {
+		commands := redisCommands(quote, s.prefix)
+		// Fields a quote no longer has must not outlive the previous conversion
+		pipe.Del(s.ctx, commands[0][1])
+		for _, command := range commands {
+			args := make([]any, len(command))
+			for i, arg := range command {
+				args[i] = arg
+			}
+			if command[0] == "SADD" {
+				s.staged[command[1]] = true
+				args[1] = s.staging(command[1])
+			}
+			pipe.Do(s.ctx, args...)
+		}
+		index := s.prefix + "quotes:index"
+		s.staged[index] = true
+		pipe.ZAdd(s.ctx, s.staging(index), redis.Z{Score: float64(s.count), Member: quote.key()})
+		s.count++
+	}
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return fmt.Errorf("error loading quotes %s to %s into redis: %w", quotes[0].key(), quotes[len(quotes)-1].key(), err)
+	}
+	return nil
+}
+
+// staging returns the name a set is built under until the conversion succeeds
+func (s *redisSink) staging(key string) string {
+	return s.prefix + "staging:" + strings.TrimPrefix(key, s.prefix)
+}
+
+// Commit renames the staged sets over the live ones in a transaction, deleting the hashes of
+// the quotes and the sets of the tags the conversion no longer has
+func (s *redisSink) Commit() error {
+	defer s.client.Close()
+	quotes := s.prefix + "quotes"
+	removed, err := s.client.SDiff(s.ctx, quotes, s.staging(quotes)).Result()
+	if err != nil {
+		return fmt.Errorf("error committing redis quotes: %w", err)
+	}
+	tags, err := s.keys(redisGlobEscape(s.prefix) + "tag:*")
+	if err != nil {
+		return fmt.Errorf("error committing redis quotes: %w", err)
+	}
+
+	_, err = s.client.TxPipelined(s.ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range removed {
+			pipe.Del(s.ctx, s.prefix+"quote:"+id)
+		}
+		for _, tag := range tags {
+			if !s.staged[tag] {
+				pipe.Del(s.ctx, tag)
+			}
+		}
+		if s.count == 0 {
+			pipe.Del(s.ctx, quotes, s.prefix+"quotes:index")
+		}
+		for key := range s.staged {
+			pipe.Rename(s.ctx, s.staging(key), key)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error committing redis quotes: %w", err)
+	}
+	return nil
+}
+
+// Rollback deletes the staged sets; the hashes already written stay
+func (s *redisSink) Rollback() error {
+	defer s.client.Close()
+	if len(s.staged) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(s.staged))
+	for key := range s.staged {
+		keys = append(keys, s.staging(key))
+	}
+	return s.client.Del(s.ctx, keys...).Err()
+}
+
+// redisGlobEscape escapes the characters of s that a SCAN pattern would treat as a glob
+func redisGlobEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`).Replace(s)
+}
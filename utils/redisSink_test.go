@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestRedisSink(t *testing.T, server *miniredis.Miniredis) QuoteSink {
+	opts := DefaultOptions()
+	opts.RedisPrefix = "site:"
+	sink, err := OpenSink("redis://"+server.Addr()+"/0", opts)
+	require.NoError(t, err)
+	return sink
+}
+
+// TestRedisSink tests that a conversion replaces the quotes and sets of the previous one
+func TestRedisSink(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	sink := openTestRedisSink(t, server)
+	require.NoError(t, sink.WriteQuotes([]Quote{
+		{ID: 1, Text: "Know thyself.", Author: "Socrates", Tags: []string{"wisdom"}, Language: "en"},
+		{ID: 2, Text: "Carpe diem.", Tags: []string{"life", "latin"}, Language: "la"},
+	}))
+	require.NoError(t, sink.Commit())
+
+	members, err := server.SMembers("site:quotes")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, members)
+	assert.Equal(t, "Socrates", server.HGet("site:quote:1", "author"))
+	ranked, err := server.ZMembers("site:quotes:index")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, ranked)
+	score, err := server.ZScore("site:quotes:index", "2")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, score)
+
+	// The second conversion drops quote 1, the latin tag and the author of quote 2's old version
+	sink = openTestRedisSink(t, server)
+	require.NoError(t, sink.WriteQuotes([]Quote{
+		{ID: 2, Text: "Carpe diem.", Tags: []string{"life"}, Language: "la"},
+		{ID: 3, Text: "Be yourself.", Author: "Oscar Wilde", Tags: []string{"life"}, Language: "en"},
+	}))
+	require.NoError(t, sink.Commit())
+
+	members, err = server.SMembers("site:quotes")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2", "3"}, members)
+	assert.False(t, server.Exists("site:quote:1"))
+	assert.False(t, server.Exists("site:tag:latin"))
+	assert.False(t, server.Exists("site:tag:wisdom"))
+	life, err := server.SMembers("site:tag:life")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2", "3"}, life)
+	assert.Equal(t, []string{"site:quote:2", "site:quote:3", "site:quotes", "site:quotes:index", "site:tag:life"}, server.Keys())
+}
+
+// TestRedisSinkRollback tests that a failed conversion leaves the live sets alone
+func TestRedisSinkRollback(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.SAdd("site:quotes", "1")
+	server.SAdd("site:tag:wisdom", "1")
+	// Left by an interrupted conversion
+	server.SAdd("site:staging:tag:stale", "9")
+
+	sink := openTestRedisSink(t, server)
+	assert.False(t, server.Exists("site:staging:tag:stale"))
+	require.NoError(t, sink.WriteQuotes([]Quote{{ID: 2, Text: "Carpe diem.", Tags: []string{"life"}, Language: "la"}}))
+	require.NoError(t, sink.Rollback())
+
+	assert.Equal(t, []string{"site:quote:2", "site:quotes", "site:tag:wisdom"}, server.Keys())
+	members, err := server.SMembers("site:quotes")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, members)
+}
+
+func TestRedisSinkErrors(t *testing.T) {
+	_, err := OpenSink("redis://localhost:6379/not-a-db", DefaultOptions())
+	assert.ErrorContains(t, err, `invalid sink "redis://localhost:6379/not-a-db"`)
+
+	_, err = OpenSink("redis://127.0.0.1:1/0", DefaultOptions())
+	assert.ErrorContains(t, err, "error connecting to redis 127.0.0.1:1")
+
+	server := miniredis.RunT(t)
+	sink := openTestRedisSink(t, server)
+	server.SetError("READONLY You can't write against a read only replica")
+	assert.ErrorContains(t, sink.WriteQuotes([]Quote{{ID: 1, Text: "Know thyself."}, {ID: 2, Text: "Carpe diem."}}), "error loading quotes 1 to 2 into redis")
+}
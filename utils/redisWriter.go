@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// redisCommands returns the commands storing a quote: a hash at <prefix>quote:<id>, the ID added
+// to the set <prefix>tag:<tag> for each tag, and the ID added to the set <prefix>quotes
+func redisCommands(quote Quote, prefix string) [][]string {
+	id := quote.key()
+	tags := nonEmptyTags(quote.Tags)
+
+	hset := []string{"HSET", prefix + "quote:" + id, "id", id, "text", quote.Text, "lang", quote.Language, "tags", strings.Join(tags, ",")}
+	if quote.Author != "" {
+		hset = append(hset, "author", quote.Author)
+	}
+	if quote.Year != 0 {
+		hset = append(hset, "year", strconv.Itoa(quote.Year))
+	}
+	if quote.Context != "" {
+		hset = append(hset, "context", quote.Context)
+	}
+
+	commands := [][]string{hset}
+	for _, tag := range tags {
+		commands = append(commands, []string{"SADD", prefix + "tag:" + tag, id})
+	}
+	return append(commands, []string{"SADD", prefix + "quotes", id})
+}
+
+// writeRESP writes a command in the Redis serialization protocol
+func writeRESP(w *bufio.Writer, args []string) error {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return nil
+}
+
+// redisEncoder writes raw protocol commands for `redis-cli --pipe`
+type redisEncoder struct {
+	prefix string
+}
+
+func (e redisEncoder) writeQuote(w *bufio.Writer, quote Quote, index int) error {
+	for _, command := range redisCommands(quote, e.prefix) {
+		writeRESP(w, command)
+	}
+	return nil
+}
+
+func (redisEncoder) finish(w *bufio.Writer, count int) error {
+	return nil
+}
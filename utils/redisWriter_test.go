@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRedisCommands tests the hash and set layout of a quote
+func TestRedisCommands(t *testing.T) {
+	commands := redisCommands(Quote{ID: 3, Text: "Text", Author: "Someone", Year: 1950, Tags: []string{"a", "", "b"}, Language: "en-US"}, "app:")
+	assert.Equal(t, [][]string{
+		{"HSET", "app:quote:3", "id", "3", "text", "Text", "lang", "en-US", "tags", "a,b", "author", "Someone", "year", "1950"},
+		{"SADD", "app:tag:a", "3"},
+		{"SADD", "app:tag:b", "3"},
+		{"SADD", "app:quotes", "3"},
+	}, commands)
+}
+
+// TestRedisEncoder tests the protocol framing, including multi-byte and multi-line values
+func TestRedisEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewQuoteStreamWriter(&buf, StreamRedis)
+	require.NoError(t, s.WriteQuote(Quote{ID: 1, Text: "é\r\nx", Tags: []string{""}, Language: "en-US"}))
+	require.NoError(t, s.Close())
+
+	assert.Equal(t, "*10\r\n$4\r\nHSET\r\n$7\r\nquote:1\r\n$2\r\nid\r\n$1\r\n1\r\n$4\r\ntext\r\n$5\r\né\r\nx\r\n"+
+		"$4\r\nlang\r\n$5\r\nen-US\r\n$4\r\ntags\r\n$0\r\n\r\n"+
+		"*3\r\n$4\r\nSADD\r\n$6\r\nquotes\r\n$1\r\n1\r\n", buf.String())
+}
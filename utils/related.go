@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// relatedMinScore is the least cosine similarity of two quotes for one to be related to the
+// other, below it they only share common words
+const relatedMinScore = 0.1
+
+// relatedStopWords are left out of the similarity of quotes, they say nothing of their subject
+var relatedStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "but": true, "not": true, "you": true, "your": true,
+	"all": true, "any": true, "can": true, "had": true, "her": true, "his": true, "him": true, "has": true,
+	"have": true, "was": true, "were": true, "one": true, "our": true, "out": true, "who": true, "what": true,
+	"when": true, "where": true, "which": true, "why": true, "how": true, "that": true, "this": true,
+	"these": true, "those": true, "their": true, "them": true, "they": true, "there": true, "then": true,
+	"than": true, "with": true, "without": true, "from": true, "into": true, "onto": true, "about": true,
+	"will": true, "would": true, "should": true, "could": true, "shall": true, "may": true, "might": true,
+	"must": true, "been": true, "being": true, "its": true, "it's": true, "does": true, "did": true,
+	"doing": true, "done": true, "also": true, "only": true, "just": true, "very": true, "more": true,
+	"most": true, "some": true, "such": true, "own": true, "same": true, "other": true, "each": true,
+	"every": true, "over": true, "under": true, "again": true, "once": true, "here": true, "she": true,
+	"yourself": true, "myself": true, "himself": true, "herself": true, "itself": true, "ourselves": true,
+	"themselves": true, "because": true, "while": true, "until": true, "upon": true, "nor": true, "too": true,
+	"don't": true, "isn't": true, "let": true, "yet": true, "ever": true, "never": true, "always": true,
+}
+
+// relatedTerms returns the distinct terms a quote is compared by: the words of its text, less
+// stop words and those shorter than three letters, and its tags, prefixed by #
+func relatedTerms(quote Quote) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	add := func(term string) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	words := strings.FieldsFunc(strings.ToLower(quote.Text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\'' && r != '’'
+	})
+	for _, word := range words {
+		word = strings.ReplaceAll(strings.Trim(word, "'’"), "’", "'")
+		if len([]rune(word)) >= 3 && !relatedStopWords[word] {
+			add(word)
+		}
+	}
+	for _, tag := range nonEmptyTags(quote.Tags) {
+		add("#" + strings.ToLower(tag))
+	}
+	return terms
+}
+
+// linkRelated sets the RelatedIDs of every quote to the IDs of the n quotes most similar to
+// it, the most similar first. Quotes are compared by the cosine of their terms weighted by
+// inverse document frequency, so rare words and tags count the most; a quote has fewer related
+// quotes when not enough of them reach relatedMinScore.
+func linkRelated(quotes []Quote, n int) {
+	if n <= 0 {
+		return
+	}
+	terms := make([][]string, len(quotes))
+	postings := make(map[string][]int)
+	for i, quote := range quotes {
+		terms[i] = relatedTerms(quote)
+		for _, term := range terms[i] {
+			postings[term] = append(postings[term], i)
+		}
+	}
+	weights := make(map[string]float64, len(postings))
+	for term, quoteIndexes := range postings {
+		weights[term] = math.Log(float64(len(quotes)) / float64(len(quoteIndexes)))
+	}
+	norms := make([]float64, len(quotes))
+	for i := range quotes {
+		for _, term := range terms[i] {
+			norms[i] += weights[term] * weights[term]
+		}
+		norms[i] = math.Sqrt(norms[i])
+	}
+
+	type candidate struct {
+		index int
+		score float64
+	}
+	for i := range quotes {
+		quotes[i].RelatedIDs = nil
+		if norms[i] == 0 {
+			continue
+		}
+		dots := make(map[int]float64)
+		for _, term := range terms[i] {
+			for _, j := range postings[term] {
+				if j != i {
+					dots[j] += weights[term] * weights[term]
+				}
+			}
+		}
+		var candidates []candidate
+		for j, dot := range dots {
+			if norms[j] == 0 {
+				continue
+			}
+			if score := dot / (norms[i] * norms[j]); score >= relatedMinScore {
+				candidates = append(candidates, candidate{index: j, score: score})
+			}
+		}
+		sort.Slice(candidates, func(a, b int) bool {
+			if candidates[a].score != candidates[b].score {
+				return candidates[a].score > candidates[b].score
+			}
+			return candidates[a].index < candidates[b].index
+		})
+		for _, c := range candidates[:min(n, len(candidates))] {
+			quotes[i].RelatedIDs = append(quotes[i].RelatedIDs, quotes[c.index].ref())
+		}
+	}
+}
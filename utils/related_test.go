@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelatedTerms(t *testing.T) {
+	quote := Quote{Text: "The courage to be is the courage to accept yourself, it’s Courage!", Tags: []string{"Courage", ""}}
+	assert.Equal(t, []string{"courage", "accept", "#courage"}, relatedTerms(quote))
+}
+
+func TestLinkRelated(t *testing.T) {
+	quotes := []Quote{
+		{ID: 1, Text: "Courage is resistance to fear, mastery of fear.", Tags: []string{"courage"}},
+		{ID: 2, Text: "Fear is the path to the dark side.", Tags: []string{"fear"}},
+		{ID: 3, Text: "Courage is grace under pressure.", Tags: []string{"courage"}},
+		{ID: 4, Text: "Bake the bread slowly.", Tags: []string{"cooking"}},
+		{UUID: "0190a0e4-0000-7000-8000-000000000000", Text: "Mastery of fear is courage.", Tags: []string{"courage"}},
+	}
+	linkRelated(quotes, 2)
+
+	assert.Equal(t, []any{"0190a0e4-0000-7000-8000-000000000000", int64(3)}, quotes[0].RelatedIDs)
+	assert.Nil(t, quotes[1].RelatedIDs, "sharing the common word fear alone is not enough")
+	assert.Equal(t, []any{"0190a0e4-0000-7000-8000-000000000000", int64(1)}, quotes[2].RelatedIDs)
+	assert.Nil(t, quotes[3].RelatedIDs, "a quote sharing nothing has no related quotes")
+
+	linkRelated(quotes, 1)
+	assert.Equal(t, []any{"0190a0e4-0000-7000-8000-000000000000"}, quotes[0].RelatedIDs, "previous links are replaced")
+}
+
+// TestConvertRelated tests that --related links the quotes of a conversion across batches
+func TestConvertRelated(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	dir := t.TempDir()
+	input := filepath.Join(dir, "quotes.csv")
+	require.NoError(t, os.WriteFile(input, []byte("Tags,Quote\n"+
+		"courage,Courage is resistance to fear.\n"+
+		"cooking,Bake the bread slowly.\n"+
+		"courage,Fear is conquered by courage.\n"), 0644))
+
+	opts := DefaultOptions()
+	opts.BatchSize = 1
+	opts.Related = 3
+	opts.Output = filepath.Join(dir, "quotes.json")
+	require.NoError(t, ConvertFile(input, opts))
+
+	data, err := ReadQuotesFromJSON(opts.Output)
+	require.NoError(t, err)
+	require.Len(t, data.Quotes, 3)
+	assert.Equal(t, []any{float64(3)}, data.Quotes[0].RelatedIDs)
+	assert.Nil(t, data.Quotes[1].RelatedIDs)
+	assert.Equal(t, []any{float64(1)}, data.Quotes[2].RelatedIDs)
+
+	opts.Resume = true
+	assert.ErrorContains(t, ConvertFile(input, opts), "--resume cannot be combined with --related")
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// s3PutObject is the part of the S3 client used by s3Uploader
+type s3PutObject interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// s3Uploader uploads the output files into an S3 bucket, under a prefix
+type s3Uploader struct {
+	client       s3PutObject
+	bucket       string
+	prefix       string
+	cacheControl string
+}
+
+// openS3Uploader opens the uploader of --to s3://bucket/path/. The credentials and region are
+// those of the aws CLI: the AWS_* environment variables, the shared config and credentials files
+// with AWS_PROFILE, or the role of the instance or task.
+func openS3Uploader(target string, opts Options) (uploader, error) {
+	bucket, prefix := uploadBucketPath(target)
+	cfg, err := config.LoadDefaultConfig(optsContext(opts))
+	if err != nil {
+		return nil, fmt.Errorf("error loading the AWS configuration: %w", err)
+	}
+	return &s3Uploader{client: s3.NewFromConfig(cfg), bucket: bucket, prefix: prefix, cacheControl: opts.UploadCacheControl}, nil
+}
+
+func (u *s3Uploader) upload(ctx context.Context, files []uploadFile) error {
+	for _, file := range files {
+		if err := u.put(ctx, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *s3Uploader) location(fileName string) string {
+	return "s3://" + u.bucket + "/" + u.prefix + filepath.Base(fileName)
+}
+
+// put uploads one file, replacing the object of the same name
+func (u *s3Uploader) put(ctx context.Context, file uploadFile) error {
+	body, err := os.Open(file.path)
+	if err != nil {
+		return fmt.Errorf("error opening %s for upload: %w", file.path, err)
+	}
+	defer body.Close()
+	key := u.prefix + filepath.Base(file.path)
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(u.bucket),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(file.contentType),
+	}
+	if u.cacheControl != "" {
+		input.CacheControl = aws.String(u.cacheControl)
+	}
+	if _, err := u.client.PutObject(ctx, input); err != nil {
+		return fmt.Errorf("error uploading %s to s3://%s/%s: %w", file.path, u.bucket, key, err)
+	}
+	return nil
+}
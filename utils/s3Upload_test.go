@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 records the objects put into it, by path
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]*http.Request
+	bodies  map[string]string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	s3 := &fakeS3{objects: map[string]*http.Request{}, bodies: map[string]string{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "unexpected "+r.Method, http.StatusMethodNotAllowed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		s3.mu.Lock()
+		defer s3.mu.Unlock()
+		s3.objects[r.URL.Path] = r
+		s3.bodies[r.URL.Path] = string(body)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("AWS_ENDPOINT_URL_S3", server.URL)
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	return s3, server
+}
+
+func TestConvertFileS3Upload(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	defer os.Remove(checksumFile)
+	s3, _ := newFakeS3(t)
+	input := filepath.Join(t.TempDir(), "quotes.csv")
+	require.NoError(t, os.WriteFile(input, []byte("Tags,Quote,Author\nwisdom,Know thyself.,Socrates\n"), 0644))
+
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(t.TempDir(), "quotes.json")
+	opts.Sinks = SinkList{"s3://quotes/site/v1"}
+	opts.Checksums = true
+	opts.UploadCacheControl = "public, max-age=300"
+	require.NoError(t, ConvertFile(input, opts))
+
+	require.Len(t, s3.objects, 3)
+	quotes := s3.objects["/quotes/site/v1/quotes.json"]
+	require.NotNil(t, quotes, "objects: %v", s3.bodies)
+	assert.Equal(t, "application/json", quotes.Header.Get("Content-Type"))
+	assert.Equal(t, "public, max-age=300", quotes.Header.Get("Cache-Control"))
+	assert.Contains(t, s3.bodies["/quotes/site/v1/quotes.json"], "Know thyself.")
+	assert.Contains(t, s3.bodies["/quotes/site/v1/quotesMetadata.json"], `"totalQuotes": 1`)
+	assert.Equal(t, "text/plain; charset=utf-8", s3.objects["/quotes/site/v1/checksums.txt"].Header.Get("Content-Type"))
+}
+
+func TestS3UploaderErrors(t *testing.T) {
+	_, server := newFakeS3(t)
+	server.Close()
+	t.Setenv("AWS_MAX_ATTEMPTS", "1")
+	file := filepath.Join(t.TempDir(), "quotes.json")
+	require.NoError(t, os.WriteFile(file, []byte("[]"), 0644))
+
+	u, err := openS3Uploader("//quotes", DefaultOptions())
+	require.NoError(t, err)
+	err = u.upload(context.Background(), []uploadFile{{path: file, contentType: "application/json"}})
+	assert.ErrorContains(t, err, "error uploading "+file+" to s3://quotes/quotes.json")
+
+	err = u.upload(context.Background(), []uploadFile{{path: filepath.Join(t.TempDir(), "missing.json")}})
+	assert.ErrorContains(t, err, "error opening")
+}
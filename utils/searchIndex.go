@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// searchSchema creates the tables of a search index: the quotes as written to JSON, their tags
+// for filtering, and the FTS5 table of their searchable fields with the same rowids. The porter
+// tokenizer lets "persevere" find "perseverance".
+var searchSchema = []string{
+	`CREATE TABLE quotes (
+		rowid    INTEGER PRIMARY KEY,
+		document TEXT NOT NULL
+	)`,
+	`CREATE TABLE quote_tags (
+		quote INTEGER NOT NULL REFERENCES quotes(rowid),
+		tag   TEXT NOT NULL
+	)`,
+	`CREATE INDEX quote_tags_tag ON quote_tags (tag, quote)`,
+	`CREATE VIRTUAL TABLE quotes_fts USING fts5(text, author, context, tags, tokenize = 'porter unicode61 remove_diacritics 2')`,
+}
+
+// searchSink builds the SQLite full-text search index queried by SearchQuotes. The index is
+// written to a temporary file renamed over the previous one when the conversion succeeds, so
+// searches never see a half-built index.
+type searchSink struct {
+	db        *sql.DB
+	tx        *sql.Tx
+	fileName  string
+	temporary string
+	rowid     int64
+}
+
+// openSearchSink starts building the index of --to search:quotes.search.db
+func openSearchSink(target string, opts Options) (QuoteSink, error) {
+	temporary := target + ".tmp"
+	if err := os.Remove(temporary); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error creating search index %s: %w", target, err)
+	}
+	db, err := sql.Open("sqlite", temporary)
+	if err != nil {
+		return nil, fmt.Errorf("error creating search index %s: %w", target, err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating search index %s: %w", target, err)
+	}
+
+	s := &searchSink{db: db, tx: tx, fileName: target, temporary: temporary}
+	for _, stmt := range searchSchema {
+		if _, err := tx.Exec(stmt); err != nil {
+			s.Rollback()
+			return nil, fmt.Errorf("error creating search index %s: %w", target, err)
+		}
+	}
+	return s, nil
+}
+
+// WriteQuotes adds a batch of quotes to the index
+func (s *searchSink) WriteQuotes(quotes []Quote) error {
+	for _, quote := range quotes {
+		s.rowid++
+		document, err := json.Marshal(quote)
+		if err != nil {
+			return fmt.Errorf("error indexing quote %s: %w", quote.key(), err)
+		}
+		tags := nonEmptyTags(quote.Tags)
+		if _, err := s.tx.Exec("INSERT INTO quotes (rowid, document) VALUES (?, ?)", s.rowid, string(document)); err != nil {
+			return fmt.Errorf("error indexing quote %s: %w", quote.key(), err)
+		}
+		if _, err := s.tx.Exec("INSERT INTO quotes_fts (rowid, text, author, context, tags) VALUES (?, ?, ?, ?, ?)",
+			s.rowid, quote.Text, quote.Author, quote.Context, strings.Join(tags, " ")); err != nil {
+			return fmt.Errorf("error indexing quote %s: %w", quote.key(), err)
+		}
+		for _, tag := range tags {
+			if _, err := s.tx.Exec("INSERT INTO quote_tags (quote, tag) VALUES (?, ?)", s.rowid, strings.ToLower(tag)); err != nil {
+				return fmt.Errorf("error indexing quote %s: %w", quote.key(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// Commit finishes the index and moves it over the previous one
+func (s *searchSink) Commit() error {
+	if err := s.tx.Commit(); err != nil {
+		s.db.Close()
+		os.Remove(s.temporary)
+		return fmt.Errorf("error committing search index %s: %w", s.fileName, err)
+	}
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("error committing search index %s: %w", s.fileName, err)
+	}
+	if err := os.Rename(s.temporary, s.fileName); err != nil {
+		return fmt.Errorf("error committing search index %s: %w", s.fileName, err)
+	}
+	return nil
+}
+
+// Rollback abandons the index, leaving the previous one in place
+func (s *searchSink) Rollback() error {
+	defer os.Remove(s.temporary)
+	defer s.db.Close()
+	return s.tx.Rollback()
+}
+
+// SearchOptions narrows the quotes found by SearchQuotes
+type SearchOptions struct {
+	Tags  []string // tags every quote found must have, ignoring case
+	Limit int      // most quotes returned, 0 for all
+}
+
+// SearchQuotes returns the quotes of the search index in fileName matching every word of query
+// in their text, author, context or tags, the best matches first; the text weighs the most.
+// Words are matched by their stem and without diacritics, an empty query matches every quote.
+func SearchQuotes(fileName, query string, opts SearchOptions) ([]Quote, error) {
+	if _, err := os.Stat(fileName); err != nil {
+		return nil, fmt.Errorf("failed to open search index %s: %w", fileName, err)
+	}
+	db, err := sql.Open("sqlite", "file:"+fileName+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open search index %s: %w", fileName, err)
+	}
+	defer db.Close()
+
+	var conditions []string
+	var args []any
+	from, order := "quotes q", "q.rowid"
+	if match := searchMatch(query); match != "" {
+		from, order = "quotes_fts f JOIN quotes q ON q.rowid = f.rowid", "bm25(quotes_fts, 10.0, 5.0, 2.0, 1.0), q.rowid"
+		conditions = append(conditions, "quotes_fts MATCH ?")
+		args = append(args, match)
+	}
+	for _, tag := range opts.Tags {
+		conditions = append(conditions, "q.rowid IN (SELECT quote FROM quote_tags WHERE tag = ?)")
+		args = append(args, strings.ToLower(tag))
+	}
+	stmt := "SELECT q.document FROM " + from
+	if len(conditions) > 0 {
+		stmt += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	stmt += " ORDER BY " + order
+	if opts.Limit > 0 {
+		stmt += " LIMIT ?"
+		args = append(args, opts.Limit)
+	}
+
+	rows, err := db.Query(stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s: %w", fileName, err)
+	}
+	defer rows.Close()
+	quotes := []Quote{}
+	for rows.Next() {
+		var document string
+		if err := rows.Scan(&document); err != nil {
+			return nil, fmt.Errorf("failed to search %s: %w", fileName, err)
+		}
+		var quote Quote
+		if err := json.Unmarshal([]byte(document), &quote); err != nil {
+			return nil, fmt.Errorf("failed to search %s: %w", fileName, err)
+		}
+		quotes = append(quotes, quote)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search %s: %w", fileName, err)
+	}
+	return quotes, nil
+}
+
+// searchMatch turns the words of a query into an FTS5 query matching all of them, each quoted
+// so punctuation and operators such as OR and NOT are searched for as plain words
+func searchMatch(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for i, word := range words {
+		words[i] = `"` + word + `"`
+	}
+	return strings.Join(words, " ")
+}
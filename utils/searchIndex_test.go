@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildTestSearchIndex(t *testing.T, fileName string, quotes []Quote) {
+	sink, err := OpenSink("search:"+fileName, DefaultOptions())
+	require.NoError(t, err)
+	require.NoError(t, sink.WriteQuotes(quotes))
+	require.NoError(t, sink.Commit())
+}
+
+// quoteTexts returns the texts of quotes, in order
+func quoteTexts(quotes []Quote) []string {
+	texts := make([]string, len(quotes))
+	for i, quote := range quotes {
+		texts[i] = quote.Text
+	}
+	return texts
+}
+
+func TestSearchQuotes(t *testing.T) {
+	index := filepath.Join(t.TempDir(), "quotes.search.db")
+	buildTestSearchIndex(t, index, []Quote{
+		{ID: 1, Text: "Perseverance is not a long race.", Author: "Walter Elliot", Tags: []string{"Motivation"}, Language: "en"},
+		{ID: 2, Text: "It does not matter how slowly you go.", Author: "Confucius", Context: "On perseverance", Tags: []string{"motivation", "wisdom"}, Language: "en"},
+		{ID: 3, Text: "Persevere, and preserve yourselves for better days.", Author: "Virgil", Tags: []string{"latin"}, Language: "en"},
+		{UUID: "0190a0e4-0000-7000-8000-000000000000", Text: "Naïve café talk.", Language: "fr"},
+	})
+
+	tests := []struct {
+		name  string
+		query string
+		opts  SearchOptions
+		want  []string
+	}{
+		{name: "stemmed, text before context", query: "perseverance", want: []string{
+			"Perseverance is not a long race.", "Persevere, and preserve yourselves for better days.", "It does not matter how slowly you go.",
+		}},
+		{name: "tag", query: "perseverance", opts: SearchOptions{Tags: []string{"motivation"}}, want: []string{
+			"Perseverance is not a long race.", "It does not matter how slowly you go.",
+		}},
+		{name: "every tag", query: "", opts: SearchOptions{Tags: []string{"MOTIVATION", "wisdom"}}, want: []string{"It does not matter how slowly you go."}},
+		{name: "every word", query: "slowly confucius", want: []string{"It does not matter how slowly you go."}},
+		{name: "operators are words", query: `not OR "race`, want: []string{}},
+		{name: "diacritics", query: "naive cafe", want: []string{"Naïve café talk."}},
+		{name: "limit", query: "perseverance", opts: SearchOptions{Limit: 1}, want: []string{"Perseverance is not a long race."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quotes, err := SearchQuotes(index, tt.query, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, quoteTexts(quotes))
+		})
+	}
+
+	quotes, err := SearchQuotes(index, "cafe", SearchOptions{})
+	require.NoError(t, err)
+	require.Len(t, quotes, 1)
+	assert.Equal(t, "0190a0e4-0000-7000-8000-000000000000", quotes[0].UUID)
+	assert.Equal(t, "fr", quotes[0].Language)
+}
+
+// TestSearchSinkReplace tests that a conversion replaces the index only when it succeeds
+func TestSearchSinkReplace(t *testing.T) {
+	index := filepath.Join(t.TempDir(), "quotes.search.db")
+	buildTestSearchIndex(t, index, []Quote{{ID: 1, Text: "Know thyself.", Language: "en"}})
+
+	sink, err := OpenSink("search:"+index, DefaultOptions())
+	require.NoError(t, err)
+	require.NoError(t, sink.WriteQuotes([]Quote{{ID: 1, Text: "Carpe diem.", Language: "la"}}))
+	require.NoError(t, sink.Rollback())
+	_, err = os.Stat(index + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+	quotes, err := SearchQuotes(index, "", SearchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Know thyself."}, quoteTexts(quotes))
+
+	buildTestSearchIndex(t, index, []Quote{{ID: 1, Text: "Carpe diem.", Language: "la"}})
+	quotes, err = SearchQuotes(index, "", SearchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Carpe diem."}, quoteTexts(quotes))
+}
+
+func TestSearchQuotesMissingIndex(t *testing.T) {
+	_, err := SearchQuotes(filepath.Join(t.TempDir(), "missing.db"), "wisdom", SearchOptions{})
+	assert.ErrorContains(t, err, "failed to open search index")
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Sentiment is the lexicon-based sentiment of a quote
+type Sentiment struct {
+	Label string  `json:"label"` // positive, neutral or negative
+	Score float64 `json:"score"` // from -1 (most negative) to 1 (most positive)
+}
+
+// sentimentLexicon holds the valence of common words, in the style of the AFINN word list
+var sentimentLexicon = map[string]float64{
+	"love": 3, "loved": 3, "loves": 3, "loving": 3, "joy": 3, "joyful": 3, "happy": 3, "happiness": 3,
+	"wonderful": 4, "amazing": 4, "beautiful": 3, "beauty": 3, "brilliant": 4, "excellent": 3, "glorious": 3,
+	"good": 2, "great": 3, "best": 3, "better": 2, "kind": 2, "kindness": 2, "gentle": 2, "peace": 2,
+	"peaceful": 2, "calm": 2, "hope": 2, "hopeful": 2, "faith": 1, "trust": 1, "courage": 2, "brave": 2,
+	"strong": 2, "strength": 2, "free": 1, "freedom": 2, "smile": 2, "laugh": 1, "laughter": 2, "friend": 1,
+	"friends": 1, "friendship": 2, "grateful": 3, "gratitude": 3, "thankful": 2, "bless": 2, "blessed": 3,
+	"success": 2, "successful": 3, "win": 4, "wins": 4, "dream": 1, "dreams": 1, "inspire": 2,
+	"inspiration": 2, "inspiring": 3, "wise": 2, "wisdom": 2, "light": 1, "shine": 2, "grow": 1, "growth": 2,
+	"heal": 2, "healing": 2, "believe": 2, "succeed": 3, "achieve": 2, "enjoy": 2, "delight": 3,
+	"fun": 4, "warm": 1, "care": 2, "compassion": 2, "honest": 2, "truth": 2, "true": 2, "pure": 1,
+	"positive": 2, "optimism": 2, "triumph": 4, "passion": 1, "alive": 1, "precious": 2, "treasure": 2,
+	"bad": -3, "worse": -3, "worst": -3, "hate": -3, "hatred": -3, "sad": -2, "sadness": -2, "sorrow": -2,
+	"fear": -2, "afraid": -2, "scared": -2, "angry": -3, "anger": -3, "pain": -2, "painful": -2, "hurt": -2,
+	"cry": -1, "tears": -2, "lonely": -2, "alone": -2, "lost": -3, "lose": -3, "loss": -3, "fail": -2,
+	"failure": -2, "failed": -2, "death": -2, "die": -3, "dead": -3, "kill": -3, "war": -2, "evil": -3,
+	"cruel": -3, "suffer": -2, "suffering": -2, "despair": -3, "misery": -3, "miserable": -3, "weak": -2,
+	"weakness": -2, "wrong": -2, "lie": -2, "lies": -2, "betray": -3, "broken": -1, "dark": -1,
+	"darkness": -1, "regret": -2, "guilt": -3, "shame": -2, "worry": -3, "anxiety": -2, "doubt": -1,
+	"enemy": -2, "enemies": -2, "grief": -2, "destroy": -3, "terrible": -3, "horrible": -3, "awful": -3,
+	"ugly": -3, "stupid": -2, "foolish": -2, "fool": -2, "poor": -2, "sick": -2, "difficult": -1, "hard": -1,
+	"struggle": -2, "problem": -2, "problems": -2, "trouble": -2, "empty": -1, "cold": -1, "bitter": -2,
+	"jealous": -2, "envy": -1, "greed": -3, "violence": -3, "hell": -4, "tragedy": -2, "boring": -3,
+}
+
+// sentimentNegators flip the valence of the words following them
+var sentimentNegators = map[string]bool{
+	"not": true, "no": true, "never": true, "nothing": true, "nobody": true, "none": true, "neither": true,
+	"nor": true, "cannot": true, "without": true,
+}
+
+// sentimentNegationWindow is the number of words after a negator whose valence is flipped
+const sentimentNegationWindow = 3
+
+// analyzeSentiment scores text against sentimentLexicon. The summed valence is normalized to
+// (-1, 1) the way VADER does, and scores within 0.05 of zero are neutral.
+func analyzeSentiment(text string) Sentiment {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\'' && r != '’'
+	})
+
+	sum, negated := 0.0, 0
+	for _, word := range words {
+		word = strings.Trim(word, "'’")
+		if sentimentNegators[word] || strings.HasSuffix(word, "n't") || strings.HasSuffix(word, "n’t") {
+			negated = sentimentNegationWindow
+			continue
+		}
+		valence := sentimentLexicon[word]
+		if negated > 0 {
+			valence *= -0.5
+			negated--
+		}
+		sum += valence
+	}
+
+	score := math.Round(sum/math.Sqrt(sum*sum+15)*1000) / 1000
+	label := "neutral"
+	switch {
+	case score >= 0.05:
+		label = "positive"
+	case score <= -0.05:
+		label = "negative"
+	}
+	return Sentiment{Label: label, Score: score}
+}
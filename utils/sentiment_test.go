@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAnalyzeSentiment tests the labels and scores of the lexicon-based sentiment
+func TestAnalyzeSentiment(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		label string
+		score float64
+	}{
+		{name: "positive", text: "Love and hope win.", label: "positive", score: 0.919},
+		{name: "negative", text: "Fear is the path to pain.", label: "negative", score: -0.718},
+		{name: "neutral", text: "The table is made of wood.", label: "neutral", score: 0},
+		{name: "negated", text: "Don't be afraid.", label: "positive", score: 0.25},
+		{name: "negation_window", text: "I do not doubt you.", label: "positive", score: 0.128},
+		{name: "empty", text: "", label: "neutral", score: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sentiment := analyzeSentiment(tt.text)
+			assert.Equal(t, tt.label, sentiment.Label)
+			assert.InDelta(t, tt.score, sentiment.Score, 0.0005)
+		})
+	}
+}
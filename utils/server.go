@@ -0,0 +1,345 @@
+package utils
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const (
+	// serverPageSize is the number of quotes per page of /quotes when no limit is asked for
+	serverPageSize = 20
+	// serverMaxPageSize is the most quotes a page of /quotes holds
+	serverMaxPageSize = 100
+	// serverMaxUpload is the largest file the server converts
+	serverMaxUpload = 64 << 20
+)
+
+// Server serves a quotes dataset over HTTP as JSON: GET /quotes.json is the whole dataset, GET
+// /quotes lists the quotes, filtered by
+// tag, author and lang and split in pages, GET /quotes/{id} returns one of them, GET /random
+// and GET /daily pick one and POST /graphql answers GraphQL queries over them. POST /conversions,
+// when enabled, converts an uploaded file, streaming its progress, and GET /metrics has the
+// Prometheus metrics of the requests and conversions.
+type Server struct {
+	quotes      []Quote
+	dataset     []byte // the quotes as served by /quotes.json
+	etag        string // quoted digest of dataset, the entity tag of every cacheable response
+	opts        ServerOptions
+	mux         *http.ServeMux
+	now         func() time.Time     // clock of /daily, replaced in tests
+	limiter     *rateLimiter         // nil without a rate limit
+	conversions *conversions         // nil unless uploads are converted
+	registry    *prometheus.Registry // metrics served at /metrics
+	requests    *requestMetrics
+	converted   *ConversionMetrics
+	traced      http.Handler // serve wrapped in a tracing span
+}
+
+// ServerOptions configures a Server
+type ServerOptions struct {
+	CacheControl  string        // Cache-Control header of the cacheable responses, none when empty
+	ModTime       time.Time     // when the dataset last changed, sent as Last-Modified; the start time when zero
+	APIKeys       []string      // keys of which requests need one, open to all when empty
+	RateLimit     float64       // requests per second per client IP, unlimited when zero
+	RateBurst     int           // requests a client may send at once, one second of RateLimit when zero
+	TrustProxy    bool          // read the client IP from X-Forwarded-For, as set by a reverse proxy
+	Conversions   int           // conversions of uploads run at once, none are accepted when zero
+	ConversionTTL time.Duration // how long the result of a finished conversion is kept, serverConversionTTL when zero
+}
+
+// quotesPage is the response of /quotes
+type quotesPage struct {
+	Quotes []Quote `json:"quotes"`
+	Total  int     `json:"total"` // number of quotes matching the filters, across pages
+	Page   int     `json:"page"`
+	Limit  int     `json:"limit"`
+}
+
+// NewServer returns a Server for the quotes of data
+func NewServer(data QuotesData, opts ServerOptions) *Server {
+	dataset, _ := json.Marshal(QuotesData{Quotes: data.Quotes})
+	digest := sha256.Sum256(dataset)
+	if opts.ModTime.IsZero() {
+		opts.ModTime = time.Now()
+	}
+	s := &Server{
+		quotes:  data.Quotes,
+		dataset: dataset,
+		etag:    `"` + hex.EncodeToString(digest[:16]) + `"`,
+		opts:    opts,
+		mux:     http.NewServeMux(),
+		now:     time.Now,
+		limiter: newRateLimiter(opts.RateLimit, opts.RateBurst),
+	}
+	s.registry = prometheus.NewRegistry()
+	s.registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	s.requests = newRequestMetrics(s.registry)
+	s.converted = NewConversionMetrics(s.registry)
+	s.traced = otelhttp.NewHandler(http.HandlerFunc(s.serve), "serve", otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		return s.route(r)
+	}))
+	s.mux.Handle("GET /metrics", promhttp.HandlerFor(s.registry, promhttp.HandlerOpts{}))
+	s.mux.HandleFunc("GET /quotes.json", s.handleDataset)
+	s.mux.HandleFunc("GET /quotes", s.cacheable(s.handleQuotes))
+	s.mux.HandleFunc("GET /quotes/{id}", s.cacheable(s.handleQuote))
+	s.mux.HandleFunc("GET /random", s.handleRandom)
+	s.mux.HandleFunc("GET /daily", s.handleDaily)
+	s.mux.Handle("POST /graphql", newGraphQLHandler(s))
+	if opts.Conversions > 0 {
+		s.conversions = newConversions(opts.Conversions, cmp.Or(opts.ConversionTTL, serverConversionTTL), func() time.Time { return s.now() })
+		s.mux.HandleFunc("POST /conversions", s.handleStartConversion)
+		s.mux.HandleFunc("GET /conversions/{id}", s.handleConversionResult)
+		s.mux.HandleFunc("GET /conversions/{id}/events", s.handleConversionEvents)
+	}
+	return s
+}
+
+// ServeHTTP implements http.Handler, tracing the requests in a span named after their route
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.traced.ServeHTTP(w, r)
+}
+
+// route returns the pattern of the route r matches, such as "GET /quotes/{id}"
+func (s *Server) route(r *http.Request) string {
+	_, route := s.mux.Handler(r)
+	return cmp.Or(route, "unmatched")
+}
+
+// serve answers r, refusing the requests over the rate limit or without a valid API key
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	route := s.route(r)
+	recorder := &statusRecorder{ResponseWriter: w}
+	defer func() {
+		s.requests.observe(route, r.Method, cmp.Or(recorder.status, http.StatusOK), time.Since(start))
+	}()
+
+	if status, message, wait := s.guard(clientIP(r, s.opts.TrustProxy), requestAPIKey(r)); status != 0 {
+		writeRefusal(recorder, status, message, wait)
+		return
+	}
+	s.mux.ServeHTTP(recorder, r)
+}
+
+// handleDataset serves the whole dataset as a quotes.json file, honouring conditional and range
+// requests
+func (s *Server) handleDataset(w http.ResponseWriter, r *http.Request) {
+	s.setCacheHeaders(w)
+	w.Header().Set("Content-Type", "application/json")
+	http.ServeContent(w, r, "quotes.json", s.opts.ModTime, bytes.NewReader(s.dataset))
+}
+
+// cacheable wraps a handler whose response only depends on the request and the dataset, so the
+// ETag of the dataset identifies it: a request whose If-None-Match or If-Modified-Since shows
+// it is still current is answered 304 Not Modified without running the handler
+func (s *Server) cacheable(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.setCacheHeaders(w)
+		if s.notModified(r) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// setCacheHeaders sets the ETag, Last-Modified and Cache-Control headers of a cacheable response
+func (s *Server) setCacheHeaders(w http.ResponseWriter) {
+	w.Header().Set("ETag", s.etag)
+	w.Header().Set("Last-Modified", s.opts.ModTime.UTC().Format(http.TimeFormat))
+	if s.opts.CacheControl != "" {
+		w.Header().Set("Cache-Control", s.opts.CacheControl)
+	}
+}
+
+// notModified reports whether the client already has the current dataset: If-None-Match lists
+// its ETag, or, without If-None-Match, If-Modified-Since isn't before its modification time
+func (s *Server) notModified(r *http.Request) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == s.etag {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !s.opts.ModTime.Truncate(time.Second).After(since)
+}
+
+// handleQuotes lists the quotes having the tag, by the author and in the language given by the
+// query, matched ignoring case; lang=en matches en-US too. page (from 1) and limit pick the
+// page returned.
+func (s *Server) handleQuotes(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, err := queryInt(query.Get("page"), 1)
+	if err != nil {
+		page = 0 // reported as out of range
+	}
+	limit, err := queryInt(query.Get("limit"), serverPageSize)
+	if err != nil {
+		limit = 0
+	}
+	if err := checkPage(page, limit); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	matching := filterQuotes(s.quotes, quoteFilter{Tag: query.Get("tag"), Author: query.Get("author"), Lang: query.Get("lang")})
+	writeJSON(w, http.StatusOK, quotesPage{Quotes: pageOf(matching, page, limit), Total: len(matching), Page: page, Limit: limit})
+}
+
+// handleQuote returns the quote with the ID in the path, numeric or a UUID
+func (s *Server) handleQuote(w http.ResponseWriter, r *http.Request) {
+	quote, ok := s.findQuote(r.PathValue("id"))
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, fmt.Sprintf("no quote with ID %s", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, quote)
+}
+
+// findQuote returns the quote with the ID, numeric or a UUID
+func (s *Server) findQuote(id string) (Quote, bool) {
+	for _, quote := range s.quotes {
+		if strings.EqualFold(quote.key(), id) {
+			return quote, true
+		}
+	}
+	return Quote{}, false
+}
+
+// handleRandom returns a random quote, one having the tag of the query when it is given
+func (s *Server) handleRandom(w http.ResponseWriter, r *http.Request) {
+	matching := filterQuotes(s.quotes, quoteFilter{Tag: r.URL.Query().Get("tag")})
+	if len(matching) == 0 {
+		writeJSONError(w, http.StatusNotFound, "no quote matches")
+		return
+	}
+	writeJSON(w, http.StatusOK, matching[rand.IntN(len(matching))])
+}
+
+// handleDaily returns the quote of the day, today in UTC or the date of the query as
+// YYYY-MM-DD. The quote is picked from a hash of the date, so every server and every request
+// agrees on it for as long as the dataset doesn't change.
+func (s *Server) handleDaily(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = s.now().UTC().Format(time.DateOnly)
+	} else if _, err := time.Parse(time.DateOnly, date); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "date must be formatted as YYYY-MM-DD")
+		return
+	}
+	if len(s.quotes) == 0 {
+		writeJSONError(w, http.StatusNotFound, "no quote matches")
+		return
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(date))
+	writeJSON(w, http.StatusOK, s.quotes[hash.Sum64()%uint64(len(s.quotes))])
+}
+
+// quoteFilter selects the quotes served, each field being ignored when empty
+type quoteFilter struct {
+	Tag    string // a tag of the quote, ignoring case and repeated whitespace
+	Author string // the author, matched like the tag
+	Lang   string // the language, see matchesLanguage
+	Text   string // part of the text, ignoring case
+}
+
+// filterQuotes returns the quotes matching every field of filter
+func filterQuotes(quotes []Quote, filter quoteFilter) []Quote {
+	text := strings.ToLower(filter.Text)
+	matching := []Quote{}
+	for _, quote := range quotes {
+		if filter.Tag != "" && !hasTag(quote, filter.Tag) {
+			continue
+		}
+		if filter.Author != "" && aliasKey(quote.Author) != aliasKey(filter.Author) {
+			continue
+		}
+		if filter.Lang != "" && !matchesLanguage(quote.Language, filter.Lang) {
+			continue
+		}
+		if text != "" && !strings.Contains(strings.ToLower(quote.Text), text) {
+			continue
+		}
+		matching = append(matching, quote)
+	}
+	return matching
+}
+
+// hasTag reports whether the quote has tag, ignoring case and repeated whitespace
+func hasTag(quote Quote, tag string) bool {
+	for _, t := range quote.Tags {
+		if aliasKey(t) == aliasKey(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesLanguage reports whether the language tag lang is wanted, the same tag or its primary
+// language: en matches en and en-US but en-US doesn't match en-GB
+func matchesLanguage(lang, wanted string) bool {
+	lang, wanted = strings.ToLower(lang), strings.ToLower(wanted)
+	return lang == wanted || strings.HasPrefix(lang, wanted+"-")
+}
+
+// checkPage returns an error when page, from 1, or limit is out of range
+func checkPage(page, limit int) error {
+	if page < 1 {
+		return fmt.Errorf("page must be a positive number")
+	}
+	if limit < 1 || limit > serverMaxPageSize {
+		return fmt.Errorf("limit must be between 1 and %d", serverMaxPageSize)
+	}
+	return nil
+}
+
+// pageOf returns the quotes of a page, from 1, of limit quotes
+func pageOf(quotes []Quote, page, limit int) []Quote {
+	// Pages past the end are empty, checked first so (page-1)*limit can't overflow
+	if page > len(quotes)/limit+1 {
+		return quotes[len(quotes):]
+	}
+	start := min((page-1)*limit, len(quotes))
+	end := min(start+limit, len(quotes))
+	return quotes[start:end]
+}
+
+// queryInt parses a number of the query, fallback when it is missing
+func queryInt(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// writeJSON writes v as the JSON response with status
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError writes an error response as {"error": message}
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,217 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// rateLimiterIdle is how long a client is remembered by the rate limiter after its last request
+const rateLimiterIdle = 10 * time.Minute
+
+// LoadAPIKeys reads a file holding one API key per line; blank lines and lines starting with #
+// are ignored
+func LoadAPIKeys(fileName string) ([]string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys %s: %w", fileName, err)
+	}
+	defer file.Close()
+
+	var keys []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			keys = append(keys, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read API keys %s: %w", fileName, err)
+	}
+	return keys, nil
+}
+
+// authorized reports whether key is one of the API keys of the server, or whether the server
+// has none. Keys are compared in constant time so they can't be guessed from timings.
+func (s *Server) authorized(key string) bool {
+	if len(s.opts.APIKeys) == 0 {
+		return true
+	}
+	valid := false
+	for _, k := range s.opts.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// requestAPIKey returns the API key of a request, sent as "Authorization: Bearer <key>" or
+// in an X-API-Key header
+func requestAPIKey(r *http.Request) string {
+	if key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(key)
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// clientIP returns the address a request comes from; behind a trusted proxy, the last address
+// of its X-Forwarded-For header, the one the proxy added. Those before it are written by the
+// client, which could pick a new one for each request.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if forwarded := r.Header.Values("X-Forwarded-For"); trustProxy && len(forwarded) > 0 {
+		addresses := strings.Split(forwarded[len(forwarded)-1], ",")
+		if last := strings.TrimSpace(addresses[len(addresses)-1]); last != "" {
+			return last
+		}
+	}
+	return remoteHost(r.RemoteAddr)
+}
+
+// remoteHost returns the host of a host:port address
+func remoteHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// guard checks the rate limit of a client and its API key, returning the status and message
+// of the refusal or zero when the request may go on. wait is how long to wait before retrying.
+func (s *Server) guard(client, key string) (status int, message string, wait time.Duration) {
+	if s.limiter != nil {
+		if ok, wait := s.limiter.allow(client); !ok {
+			return http.StatusTooManyRequests, "too many requests, slow down", wait
+		}
+	}
+	if !s.authorized(key) {
+		return http.StatusUnauthorized, "a valid API key is required", 0
+	}
+	return 0, "", 0
+}
+
+// rateLimiter limits the requests of every client to rate per second, with bursts of up to burst
+// requests, as a token bucket per client
+type rateLimiter struct {
+	rate, burst float64
+	now         func() time.Time
+
+	mu        sync.Mutex
+	clients   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns a rateLimiter, or nil when rate isn't positive. burst defaults to one
+// second of requests, and at least one.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = max(1, int(math.Ceil(rate)))
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), now: time.Now, clients: map[string]*tokenBucket{}}
+}
+
+// allow takes a token from the bucket of client, returning false and how long until one is back
+// when it is empty
+func (l *rateLimiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if now.Sub(l.lastSweep) > rateLimiterIdle {
+		for name, bucket := range l.clients {
+			if now.Sub(bucket.last) > rateLimiterIdle {
+				delete(l.clients, name)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	bucket, ok := l.clients[client]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.clients[client] = bucket
+	}
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// writeRefusal answers a request refused by guard
+func writeRefusal(w http.ResponseWriter, status int, message string, wait time.Duration) {
+	switch status {
+	case http.StatusTooManyRequests:
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	case http.StatusUnauthorized:
+		w.Header().Set("WWW-Authenticate", `Bearer realm="quotes"`)
+	}
+	writeJSONError(w, status, message)
+}
+
+// grpcGuard checks the rate limit and API key of a gRPC call like guard, the key being sent in
+// the authorization metadata as "Bearer <key>" or in x-api-key
+func (s *Server) grpcGuard(ctx context.Context) error {
+	client := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		client = remoteHost(p.Addr.String())
+	}
+	key := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			key, _ = strings.CutPrefix(values[0], "Bearer ")
+		} else if values := md.Get("x-api-key"); len(values) > 0 {
+			key = values[0]
+		}
+	}
+	switch code, message, _ := s.guard(client, key); code {
+	case http.StatusTooManyRequests:
+		return status.Error(codes.ResourceExhausted, message)
+	case http.StatusUnauthorized:
+		return status.Error(codes.Unauthenticated, message)
+	}
+	return nil
+}
+
+// grpcGuardInterceptors return the interceptors applying grpcGuard to every call
+func (s *Server) grpcGuardInterceptors() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := s.grpcGuard(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := s.grpcGuard(stream.Context()); err != nil {
+				return err
+			}
+			return handler(srv, stream)
+		}),
+	}
+}
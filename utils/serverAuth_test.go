@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"toJson/quotepb"
+)
+
+// TestRateLimiter tests the token bucket of every client
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(2, 3)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		ok, _ := limiter.allow("a")
+		assert.True(t, ok)
+	}
+	ok, wait := limiter.allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+	ok, _ = limiter.allow("b")
+	assert.True(t, ok, "clients have buckets of their own")
+
+	now = now.Add(time.Second)
+	for i := 0; i < 2; i++ {
+		ok, _ := limiter.allow("a")
+		assert.True(t, ok)
+	}
+	ok, _ = limiter.allow("a")
+	assert.False(t, ok)
+
+	now = now.Add(rateLimiterIdle + time.Second)
+	limiter.allow("c")
+	assert.NotContains(t, limiter.clients, "a", "idle clients are forgotten")
+
+	assert.Nil(t, newRateLimiter(0, 0))
+	assert.Equal(t, 5.0, newRateLimiter(4.5, 0).burst)
+}
+
+// TestServerAPIKeys tests that requests need one of the API keys
+func TestServerAPIKeys(t *testing.T) {
+	server := NewServer(QuotesData{Quotes: []Quote{{ID: 1, Text: "Know thyself."}}}, ServerOptions{APIKeys: []string{"k1", "k2"}})
+	tests := []struct {
+		headers map[string]string
+		status  int
+	}{
+		{nil, http.StatusUnauthorized},
+		{map[string]string{"Authorization": "Bearer k2"}, http.StatusOK},
+		{map[string]string{"X-API-Key": "k1"}, http.StatusOK},
+		{map[string]string{"Authorization": "Bearer k3"}, http.StatusUnauthorized},
+		{map[string]string{"Authorization": "Basic k1"}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/quotes/1", nil)
+		for name, value := range tt.headers {
+			r.Header.Set(name, value)
+		}
+		server.ServeHTTP(recorder, r)
+		assert.Equal(t, tt.status, recorder.Code, tt.headers)
+		if tt.status == http.StatusUnauthorized {
+			assert.Equal(t, `Bearer realm="quotes"`, recorder.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+// TestServerRateLimit tests that clients over the rate limit are refused with 429
+func TestServerRateLimit(t *testing.T) {
+	server := NewServer(QuotesData{}, ServerOptions{RateLimit: 1, RateBurst: 2, TrustProxy: true})
+	get := func(remote, forwarded string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/quotes", nil)
+		r.RemoteAddr = remote
+		if forwarded != "" {
+			r.Header.Set("X-Forwarded-For", forwarded)
+		}
+		server.ServeHTTP(recorder, r)
+		return recorder
+	}
+	assert.Equal(t, http.StatusOK, get("192.0.2.1:1000", "").Code)
+	assert.Equal(t, http.StatusOK, get("192.0.2.1:1001", "").Code)
+	refused := get("192.0.2.1:1002", "")
+	assert.Equal(t, http.StatusTooManyRequests, refused.Code)
+	assert.Equal(t, "1", refused.Header().Get("Retry-After"))
+
+	// Behind the proxy, clients are told apart by X-Forwarded-For
+	assert.Equal(t, http.StatusOK, get("192.0.2.1:1003", "192.0.2.1, 198.51.100.7").Code)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Forwarded-For", "198.51.100.7")
+	assert.Equal(t, "198.51.100.7", clientIP(r, true))
+	r.Header.Set("X-Forwarded-For", "spoofed, 198.51.100.7")
+	assert.Equal(t, "198.51.100.7", clientIP(r, true), "the addresses before the proxy's are the client's own")
+	r.Header.Add("X-Forwarded-For", "203.0.113.9")
+	assert.Equal(t, "203.0.113.9", clientIP(r, true), "the proxy may add a header of its own")
+	assert.Equal(t, "192.0.2.1", clientIP(r, false), "X-Forwarded-For is ignored without a trusted proxy")
+}
+
+// TestServerGRPCGuard tests that gRPC calls need an API key too
+func TestServerGRPCGuard(t *testing.T) {
+	client := grpcClientOf(t, NewServer(QuotesData{Quotes: []Quote{{ID: 1, Text: "Know thyself."}}}, ServerOptions{APIKeys: []string{"k1"}}))
+	_, err := client.GetQuote(context.Background(), &quotepb.GetQuoteRequest{Id: "1"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer k1")
+	quote, err := client.GetQuote(ctx, &quotepb.GetQuoteRequest{Id: "1"})
+	require.NoError(t, err)
+	assert.Equal(t, "Know thyself.", quote.Text)
+
+	stream, err := client.Convert(context.Background())
+	require.NoError(t, err)
+	_, err = stream.CloseAndRecv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+// TestLoadAPIKeys tests reading a file of API keys
+func TestLoadAPIKeys(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "keys.txt")
+	require.NoError(t, os.WriteFile(fileName, []byte("# widget\nk1\n\n  k2  \n"), 0600))
+	keys, err := LoadAPIKeys(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"k1", "k2"}, keys)
+
+	_, err = LoadAPIKeys(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testServer() *Server {
+	return NewServer(QuotesData{Quotes: []Quote{
+		{ID: 1, Text: "Know thyself.", Author: "Socrates", Tags: []string{"wisdom"}, Language: "en-US"},
+		{ID: 2, Text: "Carpe diem.", Author: "Horace", Tags: []string{"life", "Wisdom"}, Language: "la"},
+		{ID: 3, Text: "Be yourself.", Author: "Oscar Wilde", Tags: []string{"life"}, Language: "en-GB"},
+	}}, ServerOptions{Conversions: 2})
+}
+
+// serve sends a GET request to the server and decodes its JSON response into v
+func serve(t *testing.T, handler http.Handler, target string, v any) int {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), v))
+	return recorder.Code
+}
+
+// TestServerQuotes tests filtering and paging /quotes
+func TestServerQuotes(t *testing.T) {
+	server := testServer()
+	tests := []struct {
+		target string
+		ids    []int64
+		total  int
+	}{
+		{"/quotes", []int64{1, 2, 3}, 3},
+		{"/quotes?tag=wisdom", []int64{1, 2}, 2},
+		{"/quotes?author=oscar%20wilde", []int64{3}, 1},
+		{"/quotes?lang=en", []int64{1, 3}, 2},
+		{"/quotes?lang=en-us", []int64{1}, 1},
+		{"/quotes?tag=life&lang=la", []int64{2}, 1},
+		{"/quotes?limit=2&page=2", []int64{3}, 3},
+		{"/quotes?limit=2&page=5", []int64{}, 3},
+		{"/quotes?limit=100&page=100000000000000001", []int64{}, 3},
+		{"/quotes?tag=none", []int64{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			var page struct {
+				Quotes []struct {
+					ID int64 `json:"id"`
+				} `json:"quotes"`
+				Total int `json:"total"`
+			}
+			assert.Equal(t, http.StatusOK, serve(t, server, tt.target, &page))
+			ids := []int64{}
+			for _, quote := range page.Quotes {
+				ids = append(ids, quote.ID)
+			}
+			assert.Equal(t, tt.ids, ids)
+			assert.Equal(t, tt.total, page.Total)
+		})
+	}
+
+	var response map[string]string
+	assert.Equal(t, http.StatusBadRequest, serve(t, server, "/quotes?page=0", &response))
+	assert.Equal(t, http.StatusBadRequest, serve(t, server, "/quotes?limit=1000", &response))
+	assert.Contains(t, response["error"], "limit")
+}
+
+// TestServerQuote tests looking up /quotes/{id}
+func TestServerQuote(t *testing.T) {
+	server := testServer()
+	var quote Quote
+	assert.Equal(t, http.StatusOK, serve(t, server, "/quotes/2", &quote))
+	assert.Equal(t, "Carpe diem.", quote.Text)
+
+	var response map[string]string
+	assert.Equal(t, http.StatusNotFound, serve(t, server, "/quotes/9", &response))
+	assert.Equal(t, "no quote with ID 9", response["error"])
+
+	uuid := "0b9c6e2a-4d1f-4c5e-9a3b-2f7d8e1c6a50"
+	server = NewServer(QuotesData{Quotes: []Quote{{UUID: uuid, Text: "Know thyself."}}}, ServerOptions{})
+	assert.Equal(t, http.StatusOK, serve(t, server, "/quotes/"+uuid, &quote))
+	assert.Equal(t, "Know thyself.", quote.Text)
+}
+
+// TestServerRandom tests that /random picks among the quotes with the tag
+func TestServerRandom(t *testing.T) {
+	server := testServer()
+	for i := 0; i < 20; i++ {
+		var quote Quote
+		assert.Equal(t, http.StatusOK, serve(t, server, "/random?tag=life", &quote))
+		assert.Contains(t, []int64{2, 3}, quote.ID)
+	}
+	var response map[string]string
+	assert.Equal(t, http.StatusNotFound, serve(t, server, "/random?tag=none", &response))
+}
+
+// TestServerDaily tests that /daily picks the same quote all day
+func TestServerDaily(t *testing.T) {
+	server := testServer()
+	server.now = func() time.Time { return time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC) }
+	var today, again, dated Quote
+	assert.Equal(t, http.StatusOK, serve(t, server, "/daily", &today))
+	assert.Equal(t, http.StatusOK, serve(t, server, "/daily", &again))
+	assert.Equal(t, http.StatusOK, serve(t, server, "/daily?date=2024-03-01", &dated))
+	assert.Equal(t, today.ID, again.ID)
+	assert.Equal(t, today.ID, dated.ID)
+
+	seen := map[int64]bool{}
+	for day := 1; day <= 31; day++ {
+		var quote Quote
+		serve(t, server, "/daily?date="+time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC).Format(time.DateOnly), &quote)
+		seen[quote.ID] = true
+	}
+	assert.Greater(t, len(seen), 1)
+
+	var response map[string]string
+	assert.Equal(t, http.StatusBadRequest, serve(t, server, "/daily?date=tomorrow", &response))
+	assert.Equal(t, http.StatusNotFound, serve(t, NewServer(QuotesData{}, ServerOptions{}), "/daily", &response))
+}
+
+// TestServerConditionalRequests tests the ETag, Last-Modified and Cache-Control of the dataset
+func TestServerConditionalRequests(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	data := QuotesData{Quotes: []Quote{{ID: 1, Text: "Know thyself.", Tags: []string{"wisdom"}, Language: "en-US"}}}
+	server := NewServer(data, ServerOptions{CacheControl: "public, max-age=60", ModTime: modified})
+	get := func(target string, headers map[string]string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		for name, value := range headers {
+			r.Header.Set(name, value)
+		}
+		server.ServeHTTP(recorder, r)
+		return recorder
+	}
+
+	first := get("/quotes.json", nil)
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	assert.Equal(t, "public, max-age=60", first.Header().Get("Cache-Control"))
+	assert.Equal(t, "Fri, 01 Mar 2024 12:00:00 GMT", first.Header().Get("Last-Modified"))
+	var served QuotesData
+	require.NoError(t, json.Unmarshal(first.Body.Bytes(), &served))
+	assert.Equal(t, "Know thyself.", served.Quotes[0].Text)
+
+	tests := []struct {
+		target  string
+		headers map[string]string
+		status  int
+	}{
+		{"/quotes.json", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"/quotes.json", map[string]string{"If-None-Match": `"other", W/` + etag}, http.StatusNotModified},
+		{"/quotes.json", map[string]string{"If-None-Match": `"other"`}, http.StatusOK},
+		{"/quotes.json", map[string]string{"If-Modified-Since": "Fri, 01 Mar 2024 12:00:00 GMT"}, http.StatusNotModified},
+		{"/quotes.json", map[string]string{"If-Modified-Since": "Thu, 29 Feb 2024 12:00:00 GMT"}, http.StatusOK},
+		{"/quotes?tag=wisdom", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"/quotes/1", map[string]string{"If-None-Match": `"other"`}, http.StatusOK},
+		{"/quotes/1", map[string]string{"If-Modified-Since": "Sat, 02 Mar 2024 00:00:00 GMT"}, http.StatusNotModified},
+	}
+	for _, tt := range tests {
+		recorder := get(tt.target, tt.headers)
+		assert.Equal(t, tt.status, recorder.Code, "%s %v", tt.target, tt.headers)
+		assert.Equal(t, etag, recorder.Header().Get("ETag"))
+		if tt.status == http.StatusNotModified {
+			assert.Empty(t, recorder.Body.String())
+		}
+	}
+
+	// Another dataset has another ETag, and /random is never cached
+	other := NewServer(QuotesData{Quotes: []Quote{{ID: 1, Text: "Carpe diem."}}}, ServerOptions{})
+	recorder := httptest.NewRecorder()
+	other.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/quotes.json", nil))
+	assert.NotEqual(t, etag, recorder.Header().Get("ETag"))
+	assert.Empty(t, get("/random", nil).Header().Get("ETag"))
+}
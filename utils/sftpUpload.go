@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sftpDefaultKeys are the private keys tried, in ~/.ssh, when --sftp-key isn't set
+var sftpDefaultKeys = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// sftpUploader uploads the output files into a directory of an SFTP server
+type sftpUploader struct {
+	address string // host:port of the server
+	config  *ssh.ClientConfig
+	dir     string // remote directory, relative to the login directory unless absolute
+}
+
+// openSFTPUploader opens the uploader of --to sftp://user@host:port/path/, authenticating with
+// the private key opts.SFTPKey, or the first of ~/.ssh/id_ed25519, id_ecdsa and id_rsa, whose
+// passphrase, if any, is SFTP_KEY_PASSPHRASE. The server must be listed in opts.SFTPKnownHosts,
+// ~/.ssh/known_hosts by default. A path starting with /~/ is relative to the login directory.
+func openSFTPUploader(target string, opts Options) (uploader, error) {
+	location, err := url.Parse("sftp:" + target)
+	if err != nil || location.Hostname() == "" {
+		return nil, fmt.Errorf("invalid sink %q, expected sftp://user@host/path/", "sftp:"+target)
+	}
+	if _, ok := location.User.Password(); ok {
+		return nil, fmt.Errorf("sftp authenticates with a key, remove the password from sftp://%s", location.Host)
+	}
+	login := location.User.Username()
+	if login == "" {
+		current, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("sftp needs a user name, as sftp://user@%s: %w", location.Host, err)
+		}
+		login = current.Username
+	}
+	port := location.Port()
+	if port == "" {
+		port = "22"
+	}
+	dir := location.Path
+	if dir == "/~" || strings.HasPrefix(dir, "/~/") {
+		dir = strings.TrimPrefix(strings.TrimPrefix(dir, "/~"), "/")
+	}
+
+	signer, err := loadSFTPKey(opts.SFTPKey)
+	if err != nil {
+		return nil, err
+	}
+	knownHosts := opts.SFTPKnownHosts
+	if knownHosts == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("sftp needs --sftp-known-hosts: %w", err)
+		}
+		knownHosts = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeys, err := knownhosts.New(knownHosts)
+	if err != nil {
+		return nil, fmt.Errorf("error reading known hosts %s: %w", knownHosts, err)
+	}
+	return &sftpUploader{
+		address: net.JoinHostPort(location.Hostname(), port),
+		config: &ssh.ClientConfig{
+			User:            login,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: hostKeys,
+			Timeout:         30 * time.Second,
+		},
+		dir: dir,
+	}, nil
+}
+
+// loadSFTPKey reads the private key fileName, or the first default key found in ~/.ssh
+func loadSFTPKey(fileName string) (ssh.Signer, error) {
+	if fileName == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("sftp needs --sftp-key: %w", err)
+		}
+		for _, name := range sftpDefaultKeys {
+			candidate := filepath.Join(home, ".ssh", name)
+			if _, err := os.Stat(candidate); err == nil {
+				fileName = candidate
+				break
+			}
+		}
+		if fileName == "" {
+			return nil, fmt.Errorf("sftp needs a private key, set with --sftp-key or found in ~/.ssh")
+		}
+	}
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading SFTP key %s: %w", fileName, err)
+	}
+	var signer ssh.Signer
+	if passphrase := os.Getenv("SFTP_KEY_PASSPHRASE"); passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(content, []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SFTP key %s: %w", fileName, err)
+	}
+	return signer, nil
+}
+
+// upload copies the files into the remote directory, creating it if needed. Each is written
+// under a temporary name and renamed once complete, so whatever picks up the files from the
+// directory never sees one half-written.
+func (u *sftpUploader) upload(ctx context.Context, files []uploadFile) error {
+	conn, err := (&net.Dialer{Timeout: u.config.Timeout}).DialContext(ctx, "tcp", u.address)
+	if err != nil {
+		return fmt.Errorf("error connecting to %s: %w", u.address, err)
+	}
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, u.address, u.config)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("error connecting to %s: %w", u.address, err)
+	}
+	client, err := sftp.NewClient(ssh.NewClient(sshConn, channels, requests))
+	if err != nil {
+		sshConn.Close()
+		return fmt.Errorf("error starting SFTP on %s: %w", u.address, err)
+	}
+	defer sshConn.Close()
+	defer client.Close()
+
+	if u.dir != "" {
+		if err := client.MkdirAll(u.dir); err != nil {
+			return fmt.Errorf("error creating %s on %s: %w", u.dir, u.address, err)
+		}
+	}
+	for _, file := range files {
+		if err := u.put(client, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *sftpUploader) location(fileName string) string {
+	name := path.Join(u.dir, filepath.Base(fileName))
+	if !path.IsAbs(name) {
+		name = "/~/" + name
+	}
+	return "sftp://" + u.config.User + "@" + u.address + name
+}
+
+// put uploads one file, replacing the remote file of the same name
+func (u *sftpUploader) put(client *sftp.Client, file uploadFile) error {
+	body, err := os.Open(file.path)
+	if err != nil {
+		return fmt.Errorf("error opening %s for upload: %w", file.path, err)
+	}
+	defer body.Close()
+	name := path.Join(u.dir, filepath.Base(file.path))
+	partial := path.Join(u.dir, "."+filepath.Base(file.path)+".part")
+	remote, err := client.Create(partial)
+	if err != nil {
+		return fmt.Errorf("error uploading %s to %s:%s: %w", file.path, u.address, name, err)
+	}
+	if _, err := io.Copy(remote, body); err != nil {
+		remote.Close()
+		client.Remove(partial)
+		return fmt.Errorf("error uploading %s to %s:%s: %w", file.path, u.address, name, err)
+	}
+	if err := remote.Close(); err != nil {
+		client.Remove(partial)
+		return fmt.Errorf("error uploading %s to %s:%s: %w", file.path, u.address, name, err)
+	}
+	if _, ok := client.HasExtension("posix-rename@openssh.com"); ok {
+		err = client.PosixRename(partial, name)
+	} else {
+		// A plain SFTP rename refuses to replace a file
+		client.Remove(name)
+		err = client.Rename(partial, name)
+	}
+	if err != nil {
+		return fmt.Errorf("error renaming %s to %s on %s: %w", partial, name, u.address, err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sftpTestServer is an SFTP server on the local file system, accepting the key it was made with
+type sftpTestServer struct {
+	address    string
+	key        string // private key file of the client
+	knownHosts string // known_hosts file listing the server
+}
+
+func newSFTPTestServer(t *testing.T) sftpTestServer {
+	dir := t.TempDir()
+	_, hostPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostKey, err := ssh.NewSignerFromKey(hostPrivate)
+	require.NoError(t, err)
+	clientPublic, clientPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	authorized, err := ssh.NewPublicKey(clientPublic)
+	require.NoError(t, err)
+
+	config := &ssh.ServerConfig{PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+		if conn.User() == "quotes" && string(key.Marshal()) == string(authorized.Marshal()) {
+			return nil, nil
+		}
+		return nil, assert.AnError
+	}}
+	config.AddHostKey(hostKey)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSFTP(conn, config)
+		}
+	}()
+
+	server := sftpTestServer{address: listener.Addr().String(), key: filepath.Join(dir, "id_ed25519"), knownHosts: filepath.Join(dir, "known_hosts")}
+	block, err := ssh.MarshalPrivateKey(clientPrivate, "")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(server.key, pem.EncodeToMemory(block), 0600))
+	line := knownhosts.Line([]string{knownhosts.Normalize(server.address)}, hostKey.PublicKey())
+	require.NoError(t, os.WriteFile(server.knownHosts, []byte(line+"\n"), 0644))
+	return server
+}
+
+// serveSFTP serves the sftp subsystem of the sessions of conn
+func serveSFTP(conn net.Conn, config *ssh.ServerConfig) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for request := range requests {
+				request.Reply(request.Type == "subsystem" && string(request.Payload[4:]) == "sftp", nil)
+			}
+		}()
+		server, err := sftp.NewServer(channel)
+		if err != nil {
+			return
+		}
+		server.Serve()
+		server.Close()
+	}
+}
+
+func (s sftpTestServer) options() Options {
+	opts := DefaultOptions()
+	opts.SFTPKey = s.key
+	opts.SFTPKnownHosts = s.knownHosts
+	return opts
+}
+
+func TestConvertFileSFTPUpload(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	server := newSFTPTestServer(t)
+	input := filepath.Join(t.TempDir(), "quotes.csv")
+	require.NoError(t, os.WriteFile(input, []byte("Tags,Quote,Author\nwisdom,Know thyself.,Socrates\n"), 0644))
+	drop := filepath.Join(t.TempDir(), "cms", "drop")
+	require.NoError(t, os.MkdirAll(drop, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(drop, "quotes.json"), []byte("[]"), 0644))
+
+	opts := server.options()
+	opts.Output = filepath.Join(t.TempDir(), "quotes.json")
+	opts.Sinks = SinkList{"sftp://quotes@" + server.address + drop + "/incoming/"}
+	require.NoError(t, ConvertFile(input, opts))
+
+	entries, err := os.ReadDir(filepath.Join(drop, "incoming"))
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"quotes.json", "quotesMetadata.json"}, names, "no partial files are left")
+	content, err := os.ReadFile(filepath.Join(drop, "incoming", "quotes.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Know thyself.")
+
+	// An existing file is replaced
+	opts.Sinks = SinkList{"sftp://quotes@" + server.address + drop}
+	require.NoError(t, ConvertFile(input, opts))
+	content, err = os.ReadFile(filepath.Join(drop, "quotes.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Know thyself.")
+}
+
+func TestSFTPUploaderErrors(t *testing.T) {
+	server := newSFTPTestServer(t)
+	file := filepath.Join(t.TempDir(), "quotes.json")
+	require.NoError(t, os.WriteFile(file, []byte("[]"), 0644))
+	upload := func(target string, opts Options) error {
+		u, err := openSFTPUploader(target, opts)
+		if err != nil {
+			return err
+		}
+		return u.upload(context.Background(), []uploadFile{{path: file}})
+	}
+
+	assert.ErrorContains(t, upload("//someone@"+server.address+"/tmp", server.options()), "unable to authenticate")
+
+	opts := server.options()
+	opts.SFTPKnownHosts = filepath.Join(t.TempDir(), "known_hosts")
+	require.NoError(t, os.WriteFile(opts.SFTPKnownHosts, nil, 0644))
+	assert.ErrorContains(t, upload("//quotes@"+server.address+"/tmp", opts), "key is unknown")
+
+	assert.ErrorContains(t, upload("//quotes:secret@"+server.address+"/tmp", server.options()), "authenticates with a key")
+	assert.ErrorContains(t, upload("///tmp", server.options()), "expected sftp://user@host/path/")
+
+	opts = server.options()
+	opts.SFTPKey = filepath.Join(t.TempDir(), "missing")
+	assert.ErrorContains(t, upload("//quotes@"+server.address+"/tmp", opts), "error reading SFTP key")
+
+	_, err := openUploader("sftp:host", DefaultOptions())
+	assert.ErrorContains(t, err, "expected sftp://user@host/path/")
+}
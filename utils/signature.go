@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// loadSigningKey reads an ed25519 private key from a PKCS#8 PEM file, as written by
+// openssl genpkey -algorithm ed25519
+func loadSigningKey(fileName string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key %s: %w", fileName, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("signing key %s is not a PEM encoded PRIVATE KEY", fileName)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", fileName, err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is a %T, not an ed25519 key", fileName, key)
+	}
+	return edKey, nil
+}
+
+// signFile writes the raw 64 byte ed25519 signature of a file's contents to filename.sig, which
+// can be checked with openssl pkeyutl -verify -rawin
+func signFile(filename string, key ed25519.PrivateKey) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read %s for signing: %w", filename, err)
+	}
+	if err := os.WriteFile(filename+".sig", ed25519.Sign(key, data), 0644); err != nil {
+		return fmt.Errorf("failed to write signature for %s: %w", filename, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyFile writes a PKCS#8 PEM private key into dir
+func writeKeyFile(t *testing.T, dir string, key any) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	file := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+	return file
+}
+
+// TestSignFile tests that signatures verify against the public key
+func TestSignFile(t *testing.T) {
+	dir := t.TempDir()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	key, err := loadSigningKey(writeKeyFile(t, dir, private))
+	require.NoError(t, err)
+
+	file := filepath.Join(dir, "quotes.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"quotes": []}`), 0644))
+	require.NoError(t, signFile(file, key))
+
+	signature, err := os.ReadFile(file + ".sig")
+	require.NoError(t, err)
+	assert.Len(t, signature, ed25519.SignatureSize)
+	assert.True(t, ed25519.Verify(public, []byte(`{"quotes": []}`), signature))
+	assert.False(t, ed25519.Verify(public, []byte(`{"quotes": [1]}`), signature))
+}
+
+// TestLoadSigningKeyErrors tests rejected key files
+func TestLoadSigningKeyErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := loadSigningKey(filepath.Join(dir, "missing.pem"))
+	assert.Error(t, err)
+
+	notPEM := filepath.Join(dir, "key.txt")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a key"), 0600))
+	_, err = loadSigningKey(notPEM)
+	assert.Error(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, err = loadSigningKey(writeKeyFile(t, dir, ecKey))
+	assert.Error(t, err)
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// QuoteSink receives the converted quotes alongside the output file, one batch at a time
+type QuoteSink interface {
+	// WriteQuotes stores a batch of quotes
+	WriteQuotes(quotes []Quote) error
+	// Commit makes everything written so far permanent and releases the sink
+	Commit() error
+	// Rollback discards everything written so far and releases the sink
+	Rollback() error
+}
+
+// sinkOpeners maps a --to scheme to the function opening that kind of sink
+var sinkOpeners = map[string]func(target string, opts Options) (QuoteSink, error){
+	"sqlite":        openSQLiteSink,
+	"search":        openSearchSink,
+	"postgres":      openPostgresSink,
+	"postgresql":    openPostgresSink,
+	"mysql":         openMySQLSink,
+	"mariadb":       openMySQLSink,
+	"mongodb":       openMongoSink("mongodb"),
+	"mongodb+srv":   openMongoSink("mongodb+srv"),
+	"redis":         openRedisSink("redis"),
+	"rediss":        openRedisSink("rediss"),
+	"elasticsearch": openElasticsearchSink,
+	"opensearch":    openElasticsearchSink,
+	"kafka":         openKafkaSink,
+	"nats":          openNATSSink,
+}
+
+// OpenSink opens the sink described by a --to value of the form scheme:target
+func OpenSink(spec string, opts Options) (QuoteSink, error) {
+	scheme, target, ok := strings.Cut(spec, ":")
+	if !ok || target == "" {
+		return nil, fmt.Errorf("invalid sink %q, expected scheme:target", spec)
+	}
+	open, ok := sinkOpeners[strings.ToLower(scheme)]
+	if !ok {
+		return nil, fmt.Errorf("unknown sink %q (supported: %s)", scheme, strings.Join(SinkSchemes(), ", "))
+	}
+	return open(target, opts)
+}
+
+// SinkSchemes returns the --to schemes of all supported sinks and uploaders
+func SinkSchemes() []string {
+	schemes := make([]string, 0, len(sinkOpeners)+len(uploaderOpeners))
+	for scheme := range sinkOpeners {
+		schemes = append(schemes, scheme)
+	}
+	for scheme := range uploaderOpeners {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// SinkList collects repeated --to flags
+type SinkList []string
+
+// String implements flag.Value
+func (s *SinkList) String() string {
+	return strings.Join(*s, ",")
+}
+
+// Set implements flag.Value
+func (s *SinkList) Set(spec string) error {
+	*s = append(*s, spec)
+	return nil
+}
@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// spellcheckReportFile lists the likely typos found by the spellcheck when no other name is given
+const spellcheckReportFile = "typos.json"
+
+// spellcheckSuggestions is the most corrections suggested for a misspelled word
+const spellcheckSuggestions = 3
+
+// typo is an entry of the spellcheck report
+type typo struct {
+	Row         int      `json:"row"`
+	Field       string   `json:"field"` // text or author
+	Word        string   `json:"word"`
+	Suggestions []string `json:"suggestions"` // dictionary words one edit away, most alike first
+}
+
+// spellchecker reports the words of the quote text and author names missing from a dictionary
+// and an ignore list. check is called concurrently by the worker pool.
+type spellchecker struct {
+	words    map[string]bool // dictionary and ignored words, lower case
+	alphabet []rune          // letters of the dictionary, tried by the suggestions
+	mu       sync.Mutex
+	typos    []typo
+	suggest  map[string][]string // suggestions by word, so a repeated typo is looked up once
+}
+
+// loadSpellchecker reads a dictionary holding one word per line, such as /usr/share/dict/words
+// or a hunspell .dic file whose affix flags are ignored, and an optional ignore list of the
+// project's names and terms
+func loadSpellchecker(dictionary, ignore string) (*spellchecker, error) {
+	words, err := loadList(dictionary, "dictionary")
+	if err != nil {
+		return nil, err
+	}
+	if ignore != "" {
+		ignored, err := loadList(ignore, "spellcheck ignore")
+		if err != nil {
+			return nil, err
+		}
+		for word := range ignored {
+			words[word] = true
+		}
+	}
+
+	s := &spellchecker{words: make(map[string]bool, len(words)), suggest: make(map[string][]string)}
+	letters := make(map[rune]bool)
+	for word := range words {
+		word, _, _ = strings.Cut(word, "/")
+		word = strings.ReplaceAll(word, "’", "'")
+		s.words[word] = true
+		for _, r := range word {
+			if unicode.IsLetter(r) && !letters[r] {
+				letters[r] = true
+				s.alphabet = append(s.alphabet, r)
+			}
+		}
+	}
+	sort.Slice(s.alphabet, func(i, j int) bool { return s.alphabet[i] < s.alphabet[j] })
+	return s, nil
+}
+
+// known reports whether a word taken from a quote is spelled correctly: in the dictionary,
+// ignoring case, with or without a possessive 's
+func (s *spellchecker) known(word string) bool {
+	lower := strings.ToLower(word)
+	base, possessive := strings.CutSuffix(lower, "'s")
+	return s.words[lower] || (possessive && s.words[base])
+}
+
+// spellcheckWords splits a field into the words worth checking: runs of letters and
+// apostrophes, less single letters and all capital acronyms such as NASA or NASA's
+func spellcheckWords(field string) []string {
+	fields := strings.FieldsFunc(strings.ReplaceAll(field, "’", "'"), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	words := fields[:0]
+	for _, word := range fields {
+		word = strings.Trim(word, "'")
+		if acronym := strings.TrimSuffix(word, "'s"); len([]rune(word)) < 2 || strings.ToUpper(acronym) == acronym {
+			continue
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
+// check records and returns the words of the text and author of the quote of row i that the
+// dictionary doesn't know, once per field
+func (s *spellchecker) check(i int, quote Quote) []typo {
+	var found []typo
+	for _, field := range []struct{ name, value string }{{"text", quote.Text}, {"author", quote.Author}} {
+		seen := make(map[string]bool)
+		for _, word := range spellcheckWords(field.value) {
+			if seen[word] || s.known(word) {
+				continue
+			}
+			seen[word] = true
+			found = append(found, typo{Row: i, Field: field.name, Word: word, Suggestions: s.suggestions(word)})
+		}
+	}
+	s.add(found...)
+	return found
+}
+
+// add records typos found in a row
+func (s *spellchecker) add(typos ...typo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.typos = append(s.typos, typos...)
+}
+
+// suggestions returns the dictionary words one deletion, transposition, replacement or
+// insertion away from word, ordered by how many of their letters are in the same place
+func (s *spellchecker) suggestions(word string) []string {
+	lower := strings.ToLower(word)
+	s.mu.Lock()
+	cached, ok := s.suggest[lower]
+	s.mu.Unlock()
+	if ok {
+		return cached
+	}
+
+	runes := []rune(lower)
+	seen := make(map[string]bool)
+	found := []string{}
+	try := func(candidate []rune) {
+		if word := string(candidate); !seen[word] && s.words[word] {
+			seen[word] = true
+			found = append(found, word)
+		}
+	}
+	edit := make([]rune, 0, len(runes)+1)
+	for i := range runes {
+		try(append(append(edit[:0], runes[:i]...), runes[i+1:]...))
+		if i+1 < len(runes) {
+			edit = append(edit[:0], runes...)
+			edit[i], edit[i+1] = edit[i+1], edit[i]
+			try(edit)
+		}
+	}
+	for i := 0; i <= len(runes); i++ {
+		for _, r := range s.alphabet {
+			if i < len(runes) && r != runes[i] {
+				edit = append(edit[:0], runes...)
+				edit[i] = r
+				try(edit)
+			}
+			try(append(append(append(edit[:0], runes[:i]...), r), runes[i:]...))
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		a, b := samePlace(runes, found[i]), samePlace(runes, found[j])
+		if a != b {
+			return a > b
+		}
+		return found[i] < found[j]
+	})
+	found = found[:min(len(found), spellcheckSuggestions)]
+	s.mu.Lock()
+	s.suggest[lower] = found
+	s.mu.Unlock()
+	return found
+}
+
+// samePlace counts the letters of candidate equal to those of word at the same position, so a
+// transposition or a replacement ranks above an insertion shifting every following letter
+func samePlace(word []rune, candidate string) int {
+	count := 0
+	for i, r := range []rune(candidate) {
+		if i < len(word) && word[i] == r {
+			count++
+		}
+	}
+	return count
+}
+
+// writeReport writes the likely typos in row order to fileName, compressed and encrypted like
+// the quotes file
+func (s *spellchecker) writeReport(fileName string, opts Options) error {
+	sort.SliceStable(s.typos, func(i, j int) bool { return s.typos[i].Row < s.typos[j].Row })
+	data, err := json.MarshalIndent(struct {
+		Typos []typo `json:"typos"`
+	}{Typos: append([]typo{}, s.typos...)}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode spellcheck report: %w", err)
+	}
+	if err := writeEnvelopeFile(fileName, append(data, '\n'), opts); err != nil {
+		return fmt.Errorf("failed to write spellcheck report %s: %w", fileName, err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSpellchecker writes a dictionary and an ignore list and loads them
+func writeSpellchecker(t *testing.T, dictionary, ignore string) *spellchecker {
+	t.Helper()
+	dir := t.TempDir()
+	dictionaryFile, ignoreFile := filepath.Join(dir, "words.dic"), filepath.Join(dir, "ignore.txt")
+	require.NoError(t, os.WriteFile(dictionaryFile, []byte(dictionary), 0644))
+	require.NoError(t, os.WriteFile(ignoreFile, []byte(ignore), 0644))
+	s, err := loadSpellchecker(dictionaryFile, ignoreFile)
+	require.NoError(t, err)
+	return s
+}
+
+func TestSpellcheckWords(t *testing.T) {
+	assert.Equal(t, []string{"Don't", "panic", "it's", "only", "the", "end"},
+		spellcheckWords("Don’t panic, 'it's only' NASA's the end-3 a"))
+}
+
+// TestSpellcheckCheck tests that unknown words are recorded with their row and suggestions
+func TestSpellcheckCheck(t *testing.T) {
+	s := writeSpellchecker(t, "12\nlove/MS\nis\nin\npatient\nkind\nthe\nbelieve\nbelief\nhope\nPaul\n", "# names\nCorinthians\n")
+
+	s.check(4, Quote{Text: "Hope is kind, hope is patient.", Author: "Paul"})
+	s.check(3, Quote{Text: "Love is patinet, love is kidn, kidn!", Author: "Saint Paul"})
+	s.check(2, Quote{Text: "Beleive in Corinthians' love.", Author: "St. Pual"})
+
+	file := filepath.Join(t.TempDir(), "typos.json")
+	require.NoError(t, s.writeReport(file, DefaultOptions()))
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"typos": [
+		{"row": 2, "field": "text", "word": "Beleive", "suggestions": ["believe"]},
+		{"row": 2, "field": "author", "word": "St", "suggestions": []},
+		{"row": 2, "field": "author", "word": "Pual", "suggestions": ["paul"]},
+		{"row": 3, "field": "text", "word": "patinet", "suggestions": ["patient"]},
+		{"row": 3, "field": "text", "word": "kidn", "suggestions": ["kind"]},
+		{"row": 3, "field": "author", "word": "Saint", "suggestions": []}
+	]}`, string(data))
+
+	_, err = loadSpellchecker(filepath.Join(t.TempDir(), "missing.dic"), "")
+	assert.Error(t, err)
+}
+
+// TestConvertSpellcheck tests that --spellcheck reports the typos of a conversion without
+// changing the quotes
+func TestConvertSpellcheck(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	dir := t.TempDir()
+	input := filepath.Join(dir, "quotes.csv")
+	require.NoError(t, os.WriteFile(input, []byte("Tags,Quote\nlove,Love is patinet.\nhope,Hope is kind.\n"), 0644))
+	dictionary := filepath.Join(dir, "words.txt")
+	require.NoError(t, os.WriteFile(dictionary, []byte("love\nis\npatient\nhope\nkind\n"), 0644))
+
+	opts := DefaultOptions()
+	opts.Spellcheck = dictionary
+	opts.SpellcheckReport = filepath.Join(dir, "typos.json")
+	opts.Output = filepath.Join(dir, "quotes.json")
+	require.NoError(t, ConvertFile(input, opts))
+
+	data, err := ReadQuotesFromJSON(opts.Output)
+	require.NoError(t, err)
+	require.Len(t, data.Quotes, 2)
+	assert.Equal(t, "Love is patinet.", data.Quotes[0].Text)
+	report, err := os.ReadFile(opts.SpellcheckReport)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"typos": [{"row": 1, "field": "text", "word": "patinet", "suggestions": ["patient"]}]}`, string(report))
+}
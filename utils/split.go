@@ -0,0 +1,268 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SplitKey selects the field --split-by groups quotes on
+type SplitKey string
+
+const (
+	SplitNone   SplitKey = ""
+	SplitTag    SplitKey = "tag"
+	SplitLang   SplitKey = "lang"
+	SplitAuthor SplitKey = "author"
+)
+
+// splitInfo describes how quotes are grouped for a SplitKey
+type splitInfo struct {
+	// keys returns the groups a quote belongs to
+	keys func(quote Quote) []string
+	// fileName names the file of a group, given the names already in use
+	fileName func(key string, used map[string]bool) string
+}
+
+// splitKeys lists every supported --split-by value
+var splitKeys = map[SplitKey]splitInfo{
+	SplitTag: {
+		keys: func(quote Quote) []string {
+			return nonEmptyTags(quote.Tags)
+		},
+		fileName: func(key string, used map[string]bool) string {
+			if key == "" {
+				key = "untagged"
+			}
+			return uniqueSlug(key, used) + ".json"
+		},
+	},
+	SplitLang: {
+		keys: func(quote Quote) []string {
+			if quote.Language == "" {
+				return nil
+			}
+			return []string{quote.Language}
+		},
+		fileName: func(key string, used map[string]bool) string {
+			if key == "" {
+				key = "und" // BCP 47 for an undetermined language
+			}
+			// Keep the tag's case, quotes.en-US.json, but nothing that is unsafe in a file name
+			key = strings.Map(func(r rune) rune {
+				if r < 128 && (r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+					return r
+				}
+				return '-'
+			}, key)
+			name := "quotes." + key
+			for i := 2; used[name]; i++ {
+				name = fmt.Sprintf("quotes.%s-%d", key, i)
+			}
+			used[name] = true
+			return name + ".json"
+		},
+	},
+	SplitAuthor: {
+		keys: func(quote Quote) []string {
+			if quote.Author == "" {
+				return nil
+			}
+			return []string{quote.Author}
+		},
+		fileName: func(key string, used map[string]bool) string {
+			if key == "" {
+				key = "unknown"
+			}
+			return uniqueSlug(key, used) + ".json"
+		},
+	},
+}
+
+// String implements flag.Value
+func (k *SplitKey) String() string {
+	return string(*k)
+}
+
+// Set implements flag.Value, accepting only the supported keys
+func (k *SplitKey) Set(name string) error {
+	key := SplitKey(strings.ToLower(name))
+	if _, ok := splitKeys[key]; !ok {
+		names := make([]string, 0, len(splitKeys))
+		for key := range splitKeys {
+			names = append(names, string(key))
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown split key %q (supported: %s)", name, strings.Join(names, ", "))
+	}
+	*k = key
+	return nil
+}
+
+// splitIndexFile lists the files written by --split-by and how many quotes each holds
+const splitIndexFile = "index.json"
+
+// splitIndex is the structure of splitIndexFile
+type splitIndex struct {
+	SplitBy SplitKey          `json:"splitBy"`
+	Files   []splitIndexEntry `json:"files"`
+}
+
+type splitIndexEntry struct {
+	Key   string `json:"key"`
+	File  string `json:"file"`
+	Count int    `json:"count"`
+}
+
+// splitMaxWriters is how many split files are streamed at once. The quotes of the groups past
+// it are kept in memory and written one file at a time on Commit, so splitting by author or
+// tag doesn't run out of file descriptors.
+var splitMaxWriters = 64
+
+// splitSink writes every group of quotes to its own QuotesData file in a directory. Quotes
+// without a key (such as untagged quotes) are grouped under the empty key.
+type splitSink struct {
+	split    splitInfo
+	opts     Options
+	index    splitIndex
+	dir      string
+	writers  map[string]*QuoteStreamWriter
+	buffered map[string][]Quote // groups past splitMaxWriters, written on Commit
+	files    map[string]string
+	used     map[string]bool
+}
+
+// openSplitSink prepares the directory for opts.SplitBy, defaulting to by-<key>
+func openSplitSink(opts Options) (QuoteSink, error) {
+	dir := opts.SplitDir
+	if dir == "" {
+		dir = "by-" + string(opts.SplitBy)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create split directory %s: %w", dir, err)
+	}
+
+	// The split files are JSON regardless of the main output format, compressed and encrypted
+	// like it
+	streamOpts := DefaultOptions()
+	streamOpts.Indent = opts.Indent
+	streamOpts.Compact = opts.Compact
+	streamOpts.Compress = opts.Compress
+	streamOpts.Encrypt = opts.Encrypt
+	return &splitSink{
+		split:    splitKeys[opts.SplitBy],
+		opts:     streamOpts,
+		index:    splitIndex{SplitBy: opts.SplitBy},
+		dir:      dir,
+		writers:  make(map[string]*QuoteStreamWriter),
+		buffered: make(map[string][]Quote),
+		files:    make(map[string]string),
+		used:     make(map[string]bool),
+	}, nil
+}
+
+func (s *splitSink) WriteQuotes(quotes []Quote) error {
+	for _, quote := range quotes {
+		keys := s.split.keys(quote)
+		if len(keys) == 0 {
+			keys = []string{""}
+		}
+		for _, key := range keys {
+			writer, err := s.writer(key)
+			if err != nil {
+				return err
+			}
+			if writer == nil {
+				s.buffered[key] = append(s.buffered[key], quote)
+				continue
+			}
+			if err := writer.WriteQuote(quote); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writer returns the stream of a group, creating its file on first use. It returns nil for
+// the groups past splitMaxWriters, whose quotes are buffered instead.
+func (s *splitSink) writer(key string) (*QuoteStreamWriter, error) {
+	if writer, ok := s.writers[key]; ok {
+		return writer, nil
+	}
+	if _, ok := s.files[key]; !ok {
+		s.files[key] = s.split.fileName(key, s.used) + envelopeExtension(s.opts)
+	}
+	if len(s.writers) >= splitMaxWriters {
+		return nil, nil
+	}
+	writer, err := CreateQuoteStreamFileWithOptions(filepath.Join(s.dir, s.files[key]), s.opts)
+	if err != nil {
+		return nil, err
+	}
+	s.writers[key] = writer
+	return writer, nil
+}
+
+func (s *splitSink) Commit() error {
+	keys := make([]string, 0, len(s.files))
+	for key := range s.files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	s.index.Files = make([]splitIndexEntry, 0, len(keys))
+	for _, key := range keys {
+		writer, ok := s.writers[key]
+		if !ok {
+			var err error
+			if writer, err = s.writeBuffered(key); err != nil {
+				return err
+			}
+		}
+		if err := writer.Close(); err != nil {
+			return err
+		}
+		s.index.Files = append(s.index.Files, splitIndexEntry{Key: key, File: s.files[key], Count: writer.Count()})
+	}
+
+	data, err := json.MarshalIndent(s.index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling split index: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, splitIndexFile), data, 0644); err != nil {
+		return fmt.Errorf("error writing split index: %w", err)
+	}
+	return nil
+}
+
+// writeBuffered writes the buffered quotes of a group to its file, returning its stream for
+// Commit to close
+func (s *splitSink) writeBuffered(key string) (*QuoteStreamWriter, error) {
+	writer, err := CreateQuoteStreamFileWithOptions(filepath.Join(s.dir, s.files[key]), s.opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, quote := range s.buffered[key] {
+		if err := writer.WriteQuote(quote); err != nil {
+			writer.Close()
+			return nil, err
+		}
+	}
+	delete(s.buffered, key)
+	return writer, nil
+}
+
+func (s *splitSink) Rollback() error {
+	for _, writer := range s.writers {
+		writer.Close()
+	}
+	for _, file := range s.files {
+		os.Remove(filepath.Join(s.dir, file))
+	}
+	return nil
+}
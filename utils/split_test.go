@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readSplitFile reads a QuotesData file written by a split sink
+func readSplitFile(t *testing.T, file string) QuotesData {
+	t.Helper()
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	var quotes QuotesData
+	require.NoError(t, json.Unmarshal(data, &quotes))
+	return quotes
+}
+
+// TestSplitSinkByTag tests per-tag files and the index listing them
+func TestSplitSinkByTag(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "by-tag")
+	opts := DefaultOptions()
+	opts.SplitBy = SplitTag
+	opts.SplitDir = dir
+
+	sink, err := openSplitSink(opts)
+	require.NoError(t, err)
+	require.NoError(t, sink.WriteQuotes([]Quote{
+		{ID: 1, Text: "One", Tags: []string{"Wisdom", "Life"}},
+		{ID: 2, Text: "Two", Tags: []string{""}},
+	}))
+	require.NoError(t, sink.WriteQuotes([]Quote{{ID: 3, Text: "Three", Tags: []string{"Wisdom"}}}))
+	require.NoError(t, sink.Commit())
+
+	wisdom := readSplitFile(t, filepath.Join(dir, "wisdom.json"))
+	require.Len(t, wisdom.Quotes, 2)
+	assert.Equal(t, int64(3), wisdom.Quotes[1].ID)
+	assert.Len(t, readSplitFile(t, filepath.Join(dir, "life.json")).Quotes, 1)
+	assert.Len(t, readSplitFile(t, filepath.Join(dir, "untagged.json")).Quotes, 1)
+
+	data, err := os.ReadFile(filepath.Join(dir, splitIndexFile))
+	require.NoError(t, err)
+	var index splitIndex
+	require.NoError(t, json.Unmarshal(data, &index))
+	assert.Equal(t, splitIndex{SplitBy: SplitTag, Files: []splitIndexEntry{
+		{Key: "", File: "untagged.json", Count: 1},
+		{Key: "Life", File: "life.json", Count: 1},
+		{Key: "Wisdom", File: "wisdom.json", Count: 2},
+	}}, index)
+}
+
+// TestSplitSinkByLang tests per-language files named after the language tag
+func TestSplitSinkByLang(t *testing.T) {
+	dir := t.TempDir()
+	opts := DefaultOptions()
+	opts.SplitBy = SplitLang
+	opts.SplitDir = dir
+
+	sink, err := openSplitSink(opts)
+	require.NoError(t, err)
+	require.NoError(t, sink.WriteQuotes([]Quote{
+		{ID: 1, Text: "One", Language: "en-US"},
+		{ID: 2, Text: "இரண்டு", Language: "ta-IN"},
+		{ID: 3, Text: "Three", Language: "en-US"},
+		{ID: 4, Text: "Four"},
+	}))
+	require.NoError(t, sink.Commit())
+
+	assert.Len(t, readSplitFile(t, filepath.Join(dir, "quotes.en-US.json")).Quotes, 2)
+	assert.Equal(t, "இரண்டு", readSplitFile(t, filepath.Join(dir, "quotes.ta-IN.json")).Quotes[0].Text)
+	assert.Len(t, readSplitFile(t, filepath.Join(dir, "quotes.und.json")).Quotes, 1)
+}
+
+// TestSplitSinkByAuthor tests slugified author files, including authors sharing a slug
+func TestSplitSinkByAuthor(t *testing.T) {
+	dir := t.TempDir()
+	opts := DefaultOptions()
+	opts.SplitBy = SplitAuthor
+	opts.SplitDir = dir
+
+	sink, err := openSplitSink(opts)
+	require.NoError(t, err)
+	require.NoError(t, sink.WriteQuotes([]Quote{
+		{ID: 1, Text: "One", Author: "Marcus Aurelius"},
+		{ID: 2, Text: "Two", Author: "marcus aurelius"},
+		{ID: 3, Text: "Three"},
+	}))
+	require.NoError(t, sink.Commit())
+
+	assert.Equal(t, int64(1), readSplitFile(t, filepath.Join(dir, "marcus-aurelius.json")).Quotes[0].ID)
+	assert.Equal(t, int64(2), readSplitFile(t, filepath.Join(dir, "marcus-aurelius-2.json")).Quotes[0].ID)
+	assert.Len(t, readSplitFile(t, filepath.Join(dir, "unknown.json")).Quotes, 1)
+	assert.FileExists(t, filepath.Join(dir, splitIndexFile))
+}
+
+// TestSplitSinkEncrypted tests that the split files are compressed and encrypted like the
+// quotes file
+func TestSplitSinkEncrypted(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	dir := t.TempDir()
+	opts := DefaultOptions()
+	opts.SplitBy = SplitTag
+	opts.SplitDir = dir
+	opts.Compress = CompressGzip
+	opts.Encrypt = RecipientList{identity.Recipient()}
+
+	sink, err := openSplitSink(opts)
+	require.NoError(t, err)
+	require.NoError(t, sink.WriteQuotes([]Quote{{ID: 1, Text: "Secret", Tags: []string{"wisdom"}}}))
+	require.NoError(t, sink.Commit())
+
+	var quotes QuotesData
+	require.NoError(t, json.Unmarshal(decryptFile(t, identity, CompressGzip, filepath.Join(dir, "wisdom.json.gz.age")), &quotes))
+	require.Len(t, quotes.Quotes, 1)
+	assert.Equal(t, "Secret", quotes.Quotes[0].Text)
+	index, err := os.ReadFile(filepath.Join(dir, splitIndexFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(index), `"file": "wisdom.json.gz.age"`)
+}
+
+// TestSplitSinkManyGroups tests that groups past splitMaxWriters are buffered and written on Commit
+func TestSplitSinkManyGroups(t *testing.T) {
+	splitMaxWriters = 2
+	t.Cleanup(func() { splitMaxWriters = 64 })
+	dir := t.TempDir()
+	opts := DefaultOptions()
+	opts.SplitBy = SplitAuthor
+	opts.SplitDir = dir
+
+	sink, err := openSplitSink(opts)
+	require.NoError(t, err)
+	authors := []string{"Seneca", "Epictetus", "Marcus Aurelius", "Zeno", "Seneca", "Zeno"}
+	for i, author := range authors {
+		require.NoError(t, sink.WriteQuotes([]Quote{{ID: int64(i + 1), Text: "Quote", Author: author}}))
+	}
+	assert.Len(t, sink.(*splitSink).writers, 2, "no more files are open than the limit")
+	require.NoError(t, sink.Commit())
+
+	zeno := readSplitFile(t, filepath.Join(dir, "zeno.json"))
+	require.Len(t, zeno.Quotes, 2)
+	assert.Equal(t, []int64{4, 6}, []int64{zeno.Quotes[0].ID, zeno.Quotes[1].ID})
+	assert.Len(t, readSplitFile(t, filepath.Join(dir, "seneca.json")).Quotes, 2)
+	assert.Len(t, readSplitFile(t, filepath.Join(dir, "marcus-aurelius.json")).Quotes, 1)
+
+	data, err := os.ReadFile(filepath.Join(dir, splitIndexFile))
+	require.NoError(t, err)
+	var index splitIndex
+	require.NoError(t, json.Unmarshal(data, &index))
+	assert.Equal(t, []splitIndexEntry{
+		{Key: "Epictetus", File: "epictetus.json", Count: 1},
+		{Key: "Marcus Aurelius", File: "marcus-aurelius.json", Count: 1},
+		{Key: "Seneca", File: "seneca.json", Count: 2},
+		{Key: "Zeno", File: "zeno.json", Count: 2},
+	}, index.Files)
+}
+
+// TestSplitSinkRollback tests that a failed conversion leaves no split files behind
+func TestSplitSinkRollback(t *testing.T) {
+	dir := t.TempDir()
+	opts := DefaultOptions()
+	opts.SplitBy = SplitTag
+	opts.SplitDir = dir
+
+	sink, err := openSplitSink(opts)
+	require.NoError(t, err)
+	require.NoError(t, sink.WriteQuotes([]Quote{{ID: 1, Text: "One", Tags: []string{"a"}}}))
+	require.NoError(t, sink.Rollback())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+// TestSplitKeySet tests parsing of --split-by values
+func TestSplitKeySet(t *testing.T) {
+	var key SplitKey
+	require.NoError(t, key.Set("TAG"))
+	assert.Equal(t, SplitTag, key)
+	assert.Error(t, key.Set("colour"))
+}
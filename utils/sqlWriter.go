@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// SQLDialect selects the flavour of SQL written by --format sql
+type SQLDialect string
+
+const (
+	DialectPostgres SQLDialect = "postgres"
+	DialectMySQL    SQLDialect = "mysql"
+	DialectSQLite   SQLDialect = "sqlite"
+)
+
+// String implements flag.Value
+func (d *SQLDialect) String() string {
+	return string(*d)
+}
+
+// Set implements flag.Value, accepting only the supported dialects
+func (d *SQLDialect) Set(name string) error {
+	switch dialect := SQLDialect(strings.ToLower(name)); dialect {
+	case DialectPostgres, DialectMySQL, DialectSQLite:
+		*d = dialect
+		return nil
+	}
+	return fmt.Errorf("unknown SQL dialect %q (supported: postgres, mysql, sqlite)", name)
+}
+
+// sqlEncoder writes a SQL script recreating the quotes, tags and quote_tags tables used by the
+// sqlite sink. Tag IDs are assigned while writing, which is why the format is not resumable.
+type sqlEncoder struct {
+	dialect SQLDialect
+	tagIDs  map[string]int
+}
+
+// newSQLEncoder returns a sql encoder for opts.SQLDialect
+func newSQLEncoder(opts Options) quoteEncoder {
+	return &sqlEncoder{dialect: opts.SQLDialect, tagIDs: make(map[string]int)}
+}
+
+// quote returns a string literal escaped for the dialect
+func (e *sqlEncoder) quote(s string) string {
+	s = strings.ReplaceAll(s, "\x00", "")
+	if e.dialect == DialectMySQL {
+		// MySQL treats backslash as an escape character in string literals by default
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// nullable quotes s, or returns NULL for empty strings
+func (e *sqlEncoder) nullable(s string) string {
+	if s == "" {
+		return "NULL"
+	}
+	return e.quote(s)
+}
+
+// ident quotes a table or column identifier
+func (e *sqlEncoder) ident(name string) string {
+	if e.dialect == DialectMySQL {
+		return "`" + name + "`"
+	}
+	return `"` + name + `"`
+}
+
+// header drops and recreates the tables inside a transaction
+func (e *sqlEncoder) header(w *bufio.Writer) {
+	begin, suffix := "BEGIN;", ""
+	switch e.dialect {
+	case DialectMySQL:
+		begin, suffix = "START TRANSACTION;", " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+	case DialectSQLite:
+		begin = "BEGIN TRANSACTION;"
+	}
+
+	w.WriteString(begin + "\n\n")
+	for _, table := range []string{"quote_tags", "tags", "quotes"} {
+		fmt.Fprintf(w, "DROP TABLE IF EXISTS %s;\n", e.ident(table))
+	}
+	fmt.Fprintf(w, "\nCREATE TABLE %s (\n  %s BIGINT PRIMARY KEY,\n  %s TEXT NOT NULL,\n  %s TEXT,\n  %s INTEGER,\n  %s TEXT,\n  %s VARCHAR(35) NOT NULL\n)%s;\n",
+		e.ident("quotes"), e.ident("id"), e.ident("text"), e.ident("author"), e.ident("year"), e.ident("context"), e.ident("lang"), suffix)
+	fmt.Fprintf(w, "\nCREATE TABLE %s (\n  %s BIGINT PRIMARY KEY,\n  %s VARCHAR(255) NOT NULL UNIQUE\n)%s;\n",
+		e.ident("tags"), e.ident("id"), e.ident("name"), suffix)
+	fmt.Fprintf(w, "\nCREATE TABLE %s (\n  %s BIGINT NOT NULL REFERENCES %s(%s),\n  %s BIGINT NOT NULL REFERENCES %s(%s),\n  PRIMARY KEY (%s, %s)\n)%s;\n\n",
+		e.ident("quote_tags"), e.ident("quote_id"), e.ident("quotes"), e.ident("id"), e.ident("tag_id"), e.ident("tags"), e.ident("id"),
+		e.ident("quote_id"), e.ident("tag_id"), suffix)
+}
+
+func (e *sqlEncoder) writeQuote(w *bufio.Writer, quote Quote, index int) error {
+	if index == 0 {
+		e.header(w)
+	}
+
+	year := "NULL"
+	if quote.Year != 0 {
+		year = fmt.Sprint(quote.Year)
+	}
+	fmt.Fprintf(w, "INSERT INTO %s (%s, %s, %s, %s, %s, %s) VALUES (%d, %s, %s, %s, %s, %s);\n",
+		e.ident("quotes"), e.ident("id"), e.ident("text"), e.ident("author"), e.ident("year"), e.ident("context"), e.ident("lang"),
+		quote.ID, e.quote(quote.Text), e.nullable(quote.Author), year, e.nullable(quote.Context), e.quote(quote.Language))
+
+	linked := make(map[int]bool)
+	for _, tag := range nonEmptyTags(quote.Tags) {
+		tagID, ok := e.tagIDs[tag]
+		if !ok {
+			tagID = len(e.tagIDs) + 1
+			e.tagIDs[tag] = tagID
+			fmt.Fprintf(w, "INSERT INTO %s (%s, %s) VALUES (%d, %s);\n", e.ident("tags"), e.ident("id"), e.ident("name"), tagID, e.quote(tag))
+		}
+		if linked[tagID] {
+			continue
+		}
+		linked[tagID] = true
+		fmt.Fprintf(w, "INSERT INTO %s (%s, %s) VALUES (%d, %d);\n", e.ident("quote_tags"), e.ident("quote_id"), e.ident("tag_id"), quote.ID, tagID)
+	}
+	return nil
+}
+
+func (e *sqlEncoder) finish(w *bufio.Writer, count int) error {
+	if count == 0 {
+		e.header(w)
+	}
+	_, err := w.WriteString("\nCOMMIT;\n")
+	return err
+}
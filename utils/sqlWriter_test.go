@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sqlTestQuotes contains quoting edge cases
+var sqlTestQuotes = []Quote{
+	{ID: 1, Text: `It's a "test" \ with backslash`, Author: "O'Brien", Tags: []string{"a", "b", "a"}, Language: "en-US"},
+	{ID: 2, Text: "Second", Year: 1950, Tags: []string{"b"}, Language: "en-US"},
+}
+
+// encodeSQL renders the test quotes in the given dialect
+func encodeSQL(t *testing.T, dialect SQLDialect) string {
+	opts := DefaultOptions()
+	opts.Format = StreamSQL
+	opts.SQLDialect = dialect
+
+	var buf bytes.Buffer
+	s := NewQuoteStreamWriterWithOptions(&buf, opts)
+	for _, q := range sqlTestQuotes {
+		require.NoError(t, s.WriteQuote(q))
+	}
+	require.NoError(t, s.Close())
+	return buf.String()
+}
+
+// TestSQLEncoderSQLite tests that the sqlite dump loads and reproduces the data
+func TestSQLEncoderSQLite(t *testing.T) {
+	script := encodeSQL(t, DialectSQLite)
+
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(script)
+	require.NoError(t, err)
+
+	var text, author string
+	require.NoError(t, db.QueryRow(`SELECT text, author FROM quotes WHERE id = 1`).Scan(&text, &author))
+	assert.Equal(t, sqlTestQuotes[0].Text, text)
+	assert.Equal(t, "O'Brien", author)
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM tags`).Scan(&count))
+	assert.Equal(t, 2, count)
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM quote_tags`).Scan(&count))
+	assert.Equal(t, 3, count)
+
+	var year sql.NullInt64
+	require.NoError(t, db.QueryRow(`SELECT year FROM quotes WHERE id = 1`).Scan(&year))
+	assert.False(t, year.Valid)
+}
+
+// TestSQLEncoderDialects tests dialect specific quoting
+func TestSQLEncoderDialects(t *testing.T) {
+	postgres := encodeSQL(t, DialectPostgres)
+	assert.Contains(t, postgres, `'It''s a "test" \ with backslash'`)
+	assert.Contains(t, postgres, `INSERT INTO "quotes"`)
+	assert.Contains(t, postgres, "BEGIN;")
+
+	mysql := encodeSQL(t, DialectMySQL)
+	assert.Contains(t, mysql, `'It''s a "test" \\ with backslash'`)
+	assert.Contains(t, mysql, "INSERT INTO `quotes`")
+	assert.Contains(t, mysql, "START TRANSACTION;")
+	assert.Contains(t, mysql, "ENGINE=InnoDB")
+
+	var d SQLDialect
+	assert.NoError(t, d.Set("MySQL"))
+	assert.Equal(t, DialectMySQL, d)
+	assert.Error(t, d.Set("oracle"))
+}
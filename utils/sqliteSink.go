@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema creates the tables filled by the sqlite sink
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS quotes (
+		id      INTEGER PRIMARY KEY,
+		text    TEXT NOT NULL,
+		author  TEXT,
+		year    INTEGER,
+		context TEXT,
+		lang    TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS tags (
+		id   INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE
+	)`,
+	`CREATE TABLE IF NOT EXISTS quote_tags (
+		quote_id INTEGER NOT NULL REFERENCES quotes(id),
+		tag_id   INTEGER NOT NULL REFERENCES tags(id),
+		PRIMARY KEY (quote_id, tag_id)
+	)`,
+}
+
+// sqliteSink writes quotes into a SQLite database inside a single transaction,
+// replacing whatever a previous conversion stored there
+type sqliteSink struct {
+	db     *sql.DB
+	tx     *sql.Tx
+	tagIDs map[string]int64
+}
+
+// openSQLiteSink opens (or creates) the database file and starts the transaction
+func openSQLiteSink(target string, opts Options) (QuoteSink, error) {
+	db, err := sql.Open("sqlite", target)
+	if err != nil {
+		return nil, fmt.Errorf("error opening sqlite database %s: %w", target, err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error starting sqlite transaction: %w", err)
+	}
+
+	s := &sqliteSink{db: db, tx: tx, tagIDs: make(map[string]int64)}
+	statements := append(sqliteSchema, "DELETE FROM quote_tags", "DELETE FROM quotes", "DELETE FROM tags")
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			s.Rollback()
+			return nil, fmt.Errorf("error preparing sqlite database %s: %w", target, err)
+		}
+	}
+	return s, nil
+}
+
+// WriteQuotes inserts a batch of quotes and links them to their tags
+func (s *sqliteSink) WriteQuotes(quotes []Quote) error {
+	for _, quote := range quotes {
+		_, err := s.tx.Exec("INSERT INTO quotes (id, text, author, year, context, lang) VALUES (?, ?, ?, ?, ?, ?)",
+			quote.ID, quote.Text, nullString(quote.Author), nullInt(quote.Year), nullString(quote.Context), quote.Language)
+		if err != nil {
+			return fmt.Errorf("error inserting quote %d: %w", quote.ID, err)
+		}
+
+		for _, tag := range quote.Tags {
+			if tag == "" {
+				continue
+			}
+			tagID, err := s.tagID(tag)
+			if err != nil {
+				return err
+			}
+			if _, err := s.tx.Exec("INSERT OR IGNORE INTO quote_tags (quote_id, tag_id) VALUES (?, ?)", quote.ID, tagID); err != nil {
+				return fmt.Errorf("error tagging quote %d: %w", quote.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// tagID returns the row ID for a tag, inserting it on first use
+func (s *sqliteSink) tagID(name string) (int64, error) {
+	if id, ok := s.tagIDs[name]; ok {
+		return id, nil
+	}
+	result, err := s.tx.Exec("INSERT INTO tags (name) VALUES (?)", name)
+	if err != nil {
+		return 0, fmt.Errorf("error inserting tag %q: %w", name, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("error inserting tag %q: %w", name, err)
+	}
+	s.tagIDs[name] = id
+	return id, nil
+}
+
+// Commit commits the transaction and closes the database
+func (s *sqliteSink) Commit() error {
+	defer s.db.Close()
+	if err := s.tx.Commit(); err != nil {
+		return fmt.Errorf("error committing sqlite transaction: %w", err)
+	}
+	return nil
+}
+
+// Rollback abandons the transaction and closes the database
+func (s *sqliteSink) Rollback() error {
+	defer s.db.Close()
+	return s.tx.Rollback()
+}
+
+// nullString stores empty strings as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullInt stores zero as NULL
+func nullInt(i int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(i), Valid: i != 0}
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSQLiteSink tests that a conversion fills the quotes, tags and join tables
+func TestSQLiteSink(t *testing.T) {
+	f, _ := createTestExcelFile(t)
+	defer os.Remove("quotes.json")
+	defer os.Remove("quotesMetadata.json")
+
+	dbFile := filepath.Join(t.TempDir(), "quotes.db")
+	opts := DefaultOptions()
+	opts.Sinks = SinkList{"sqlite:" + dbFile}
+
+	// Converting twice must replace rather than duplicate the rows
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+
+	db, err := sql.Open("sqlite", dbFile)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM quotes").Scan(&count))
+	assert.Equal(t, 3, count)
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM tags").Scan(&count))
+	assert.Equal(t, 5, count)
+
+	rows, err := db.Query(`SELECT t.name FROM quote_tags qt JOIN tags t ON t.id = qt.tag_id WHERE qt.quote_id = 3 ORDER BY t.name`)
+	require.NoError(t, err)
+	defer rows.Close()
+	var tags []string
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		tags = append(tags, name)
+	}
+	assert.Equal(t, []string{"life", "philosophy", "wisdom"}, tags)
+}
+
+// TestOpenSinkErrors tests malformed and unknown --to values
+// TestSQLiteSinkUUIDs tests that UUID datasets are refused rather than stored by row index
+func TestSQLiteSinkUUIDs(t *testing.T) {
+	f, _ := createTestExcelFile(t)
+	opts := DefaultOptions()
+	opts.IDStrategy = IDUUID
+	opts.Sinks = SinkList{"SQLite:" + filepath.Join(t.TempDir(), "quotes.db")}
+	assert.ErrorContains(t, ReadExcelFileWithOptions(f, opts), "--id-strategy uuid is not supported by the sqlite sink")
+}
+
+func TestOpenSinkErrors(t *testing.T) {
+	_, err := OpenSink("quotes.db", DefaultOptions())
+	assert.Error(t, err)
+
+	_, err = OpenSink("ftp:quotes.db", DefaultOptions())
+	assert.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// readingWordsPerMinute is the average silent reading speed of adults
+const readingWordsPerMinute = 230
+
+// QuoteStats summarizes the length of the quotes in the metadata
+type QuoteStats struct {
+	AverageLength           float64 `json:"averageLength"` // characters
+	MaxLength               int     `json:"maxLength"`
+	AverageWordCount        float64 `json:"averageWordCount"`
+	MaxWordCount            int     `json:"maxWordCount"`
+	TotalReadingTimeSeconds int     `json:"totalReadingTimeSeconds"`
+}
+
+// quoteTotals accumulates QuoteStats and QuoteCounts while quotes are written. It is saved in
+// checkpoints so a resumed conversion still describes the quotes written before the interruption.
+type quoteTotals struct {
+	Count          int            `json:"count"`
+	Length         int            `json:"length"`
+	MaxLength      int            `json:"maxLength"`
+	Words          int            `json:"words"`
+	MaxWords       int            `json:"maxWords"`
+	ReadingSeconds int            `json:"readingSeconds"`
+	Tags           map[string]int `json:"tags,omitempty"`
+	Authors        map[string]int `json:"authors,omitempty"`
+	Languages      map[string]int `json:"languages,omitempty"`
+}
+
+// countWords sets the word count and reading time of the quote
+func countWords(quote *Quote) {
+	quote.WordCount = len(strings.Fields(quote.Text))
+	quote.ReadingTimeSeconds = int(math.Ceil(float64(quote.WordCount) * 60 / readingWordsPerMinute))
+}
+
+// add includes a written quote in the totals
+func (t *quoteTotals) add(quote Quote) {
+	length := utf8.RuneCountInString(quote.Text)
+	t.Count++
+	t.Length += length
+	t.MaxLength = max(t.MaxLength, length)
+	t.Words += quote.WordCount
+	t.MaxWords = max(t.MaxWords, quote.WordCount)
+	t.ReadingSeconds += quote.ReadingTimeSeconds
+
+	if t.Tags == nil {
+		t.Tags, t.Authors, t.Languages = map[string]int{}, map[string]int{}, map[string]int{}
+	}
+	for _, tag := range quote.Tags {
+		if tag != "" {
+			t.Tags[tag]++
+		}
+	}
+	if quote.Author != "" {
+		t.Authors[quote.Author]++
+	}
+	if quote.Language != "" {
+		t.Languages[quote.Language]++
+	}
+}
+
+// stats returns the summary of the totals, or nil when no quote was written
+func (t quoteTotals) stats() *QuoteStats {
+	if t.Count == 0 {
+		return nil
+	}
+	average := func(total int) float64 {
+		return math.Round(float64(total)/float64(t.Count)*10) / 10
+	}
+	return &QuoteStats{
+		AverageLength:           average(t.Length),
+		MaxLength:               t.MaxLength,
+		AverageWordCount:        average(t.Words),
+		MaxWordCount:            t.MaxWords,
+		TotalReadingTimeSeconds: t.ReadingSeconds,
+	}
+}
+
+// counts returns the breakdowns of the totals, or nil when no quote was written
+func (t quoteTotals) counts() *QuoteCounts {
+	if t.Count == 0 {
+		return nil
+	}
+	return &QuoteCounts{ByTag: t.Tags, ByAuthor: t.Authors, ByLanguage: t.Languages}
+}
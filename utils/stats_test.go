@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCountWords tests the word count and reading time of single quotes
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		text    string
+		words   int
+		seconds int
+	}{
+		{text: "", words: 0, seconds: 0},
+		{text: "Be.", words: 1, seconds: 1},
+		{text: "  The  unexamined life\nis not worth living. ", words: 7, seconds: 2},
+		{text: "one two three four five six seven eight nine ten eleven twelve", words: 12, seconds: 4},
+	}
+	for _, tt := range tests {
+		quote := Quote{Text: tt.text}
+		countWords(&quote)
+		assert.Equal(t, tt.words, quote.WordCount, tt.text)
+		assert.Equal(t, tt.seconds, quote.ReadingTimeSeconds, tt.text)
+	}
+}
+
+// TestQuoteTotals tests the aggregate stats written to the metadata
+func TestQuoteTotals(t *testing.T) {
+	var totals quoteTotals
+	assert.Nil(t, totals.stats())
+
+	for _, text := range []string{"Be.", "Know thyself, friend.", "Ça va?"} {
+		quote := Quote{Text: text}
+		countWords(&quote)
+		totals.add(quote)
+	}
+	assert.Equal(t, &QuoteStats{
+		AverageLength:           10,
+		MaxLength:               21,
+		AverageWordCount:        2,
+		MaxWordCount:            3,
+		TotalReadingTimeSeconds: 3,
+	}, totals.stats())
+}
+
+// TestQuoteTotalsCounts tests the per tag, author and language breakdowns
+func TestQuoteTotalsCounts(t *testing.T) {
+	var totals quoteTotals
+	assert.Nil(t, totals.counts())
+
+	totals.add(Quote{Text: "a", Tags: []string{"life", "wisdom"}, Author: "Seneca", Language: "en-US"})
+	totals.add(Quote{Text: "b", Tags: []string{"life"}, Language: "la"})
+	totals.add(Quote{Text: "c", Tags: []string{""}, Author: "Seneca", Language: "en-US"})
+	assert.Equal(t, &QuoteCounts{
+		ByTag:      map[string]int{"life": 2, "wisdom": 1},
+		ByAuthor:   map[string]int{"Seneca": 2},
+		ByLanguage: map[string]int{"en-US": 2, "la": 1},
+	}, totals.counts())
+}
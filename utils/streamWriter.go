@@ -0,0 +1,379 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// StreamFormat selects how QuoteStreamWriter lays out the quotes
+type StreamFormat int
+
+const (
+	// StreamArray writes {"quotes": [...]} with the same layout as a fully marshalled QuotesData
+	StreamArray StreamFormat = iota
+	// StreamNDJSON writes one compact quote object per line
+	StreamNDJSON
+	// StreamXML writes a <quotes> document, see xmlWriter.go for the element structure
+	StreamXML
+	// StreamParquet writes a Parquet file, see parquetWriter.go for the column layout
+	StreamParquet
+	// StreamMsgpack writes the JSON structure encoded as MessagePack
+	StreamMsgpack
+	// StreamCBOR writes the JSON structure encoded as CBOR
+	StreamCBOR
+	// StreamAvro writes an Avro object container file, see avroWriter.go for the schema
+	StreamAvro
+	// StreamMarkdown writes blockquotes grouped by tag
+	StreamMarkdown
+	// StreamFortune writes % separated quotes for fortune(6)
+	StreamFortune
+	// StreamESBulk writes action and document line pairs for the Elasticsearch _bulk API
+	StreamESBulk
+	// StreamMongo writes Extended JSON documents for mongoimport
+	StreamMongo
+	// StreamSQL writes CREATE TABLE and INSERT statements for the configured dialect
+	StreamSQL
+	// StreamRedis writes Redis protocol commands for redis-cli --pipe
+	StreamRedis
+	// StreamTemplate renders a user supplied text/template, see templateWriter.go
+	StreamTemplate
+)
+
+// quoteEncoder lays out quotes for a single output format
+type quoteEncoder interface {
+	// writeQuote appends a quote to the output; index is the number of quotes written before it
+	writeQuote(w *bufio.Writer, quote Quote, index int) error
+	// finish terminates a document holding count quotes
+	finish(w *bufio.Writer, count int) error
+}
+
+// formatInfo describes a StreamFormat
+type formatInfo struct {
+	name       string // --format value
+	extension  string // output file extension
+	schema     string // value recorded in Metadata.Schema.Format
+	resumable  bool   // whether a partial file can be truncated at a checkpoint and appended to
+	newEncoder func(opts Options) quoteEncoder
+}
+
+// streamFormats lists every supported output format
+var streamFormats = map[StreamFormat]formatInfo{
+	StreamArray:    {name: "json", extension: ".json", schema: "JSON", resumable: true, newEncoder: newJSONArrayEncoder},
+	StreamNDJSON:   {name: "ndjson", extension: ".ndjson", schema: "NDJSON", resumable: true, newEncoder: func(Options) quoteEncoder { return ndjsonEncoder{} }},
+	StreamXML:      {name: "xml", extension: ".xml", schema: "XML", resumable: true, newEncoder: func(Options) quoteEncoder { return xmlEncoder{} }},
+	StreamParquet:  {name: "parquet", extension: ".parquet", schema: "Parquet", newEncoder: func(Options) quoteEncoder { return &parquetEncoder{} }},
+	StreamMsgpack:  {name: "msgpack", extension: ".msgpack", schema: "MessagePack", newEncoder: func(Options) quoteEncoder { return &msgpackEncoder{} }},
+	StreamCBOR:     {name: "cbor", extension: ".cbor", schema: "CBOR", newEncoder: func(Options) quoteEncoder { return &cborEncoder{} }},
+	StreamAvro:     {name: "avro", extension: ".avro", schema: "Avro", newEncoder: func(Options) quoteEncoder { return &avroEncoder{} }},
+	StreamMarkdown: {name: "markdown", extension: ".md", schema: "Markdown", newEncoder: func(Options) quoteEncoder { return &markdownEncoder{} }},
+	StreamFortune:  {name: "fortune", extension: ".fortune", schema: "fortune", resumable: true, newEncoder: func(Options) quoteEncoder { return fortuneEncoder{} }},
+	StreamESBulk:   {name: "es-bulk", extension: ".bulk.ndjson", schema: "Elasticsearch bulk", resumable: true, newEncoder: func(opts Options) quoteEncoder { return esBulkEncoder{index: opts.ESIndex} }},
+	StreamMongo:    {name: "mongo", extension: ".mongo.ndjson", schema: "MongoDB Extended JSON", resumable: true, newEncoder: func(opts Options) quoteEncoder { return mongoEncoder{tagsField: opts.MongoTagsField} }},
+	StreamSQL:      {name: "sql", extension: ".sql", schema: "SQL", newEncoder: newSQLEncoder},
+	StreamRedis:    {name: "redis", extension: ".redis", schema: "Redis protocol", resumable: true, newEncoder: func(opts Options) quoteEncoder { return redisEncoder{prefix: opts.RedisPrefix} }},
+	StreamTemplate: {name: "template", extension: ".txt", schema: "template", newEncoder: func(opts Options) quoteEncoder { return &templateEncoder{opts: opts} }},
+}
+
+// ParseStreamFormat maps a --format value to its StreamFormat
+func ParseStreamFormat(name string) (StreamFormat, error) {
+	name = strings.ToLower(name)
+	if name == "jsonl" {
+		return StreamNDJSON, nil
+	}
+	for format, info := range streamFormats {
+		if info.name == name {
+			return format, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown output format %q (supported: %s)", name, strings.Join(StreamFormatNames(), ", "))
+}
+
+// StreamFormatNames returns the --format values of all supported formats
+func StreamFormatNames() []string {
+	names := make([]string, 0, len(streamFormats))
+	for _, info := range streamFormats {
+		names = append(names, info.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String returns the --format name of the format
+func (f StreamFormat) String() string {
+	return streamFormats[f].name
+}
+
+// Set implements flag.Value so the format can be bound to a --format flag
+func (f *StreamFormat) Set(name string) error {
+	format, err := ParseStreamFormat(name)
+	if err != nil {
+		return err
+	}
+	*f = format
+	return nil
+}
+
+// Extension returns the file extension used for outputs in this format
+func (f StreamFormat) Extension() string {
+	return streamFormats[f].extension
+}
+
+// SchemaName returns the value recorded in Metadata.Schema.Format for this format
+func (f StreamFormat) SchemaName() string {
+	return streamFormats[f].schema
+}
+
+// Resumable reports whether an interrupted output in this format can be resumed from a checkpoint
+func (f StreamFormat) Resumable() bool {
+	return streamFormats[f].resumable
+}
+
+// QuoteStreamWriter encodes quotes one at a time so the full dataset never has to be held in memory
+type QuoteStreamWriter struct {
+	w       *bufio.Writer
+	out     *countingWriter
+	closers []io.Closer // closed in order once the document is terminated
+	enc     quoteEncoder
+	count   int
+	closed  bool
+}
+
+// NewQuoteStreamWriter returns a stream writer encoding quotes into w
+func NewQuoteStreamWriter(w io.Writer, format StreamFormat) *QuoteStreamWriter {
+	opts := DefaultOptions()
+	opts.Format = format
+	return NewQuoteStreamWriterWithOptions(w, opts)
+}
+
+// NewQuoteStreamWriterWithOptions returns a stream writer encoding quotes into w in opts.Format,
+// compressed with opts.Compress and then encrypted to opts.Encrypt
+func NewQuoteStreamWriterWithOptions(w io.Writer, opts Options) *QuoteStreamWriter {
+	s := &QuoteStreamWriter{enc: streamFormats[opts.Format].newEncoder(opts)}
+	// Each layer wraps the previous one, so it has to be closed before it
+	if len(opts.Encrypt) > 0 {
+		aw := opts.Encrypt.newWriter(w)
+		s.closers = append([]io.Closer{aw}, s.closers...)
+		w = aw
+	}
+	if opts.Compress != CompressNone {
+		zw := opts.Compress.newWriter(w)
+		s.closers = append([]io.Closer{zw}, s.closers...)
+		w = zw
+	}
+	s.out = &countingWriter{w: w}
+	s.w = bufio.NewWriter(s.out)
+	return s
+}
+
+// CreateQuoteStreamFile creates (or truncates) filename and returns a stream writer for it
+func CreateQuoteStreamFile(filename string, format StreamFormat) (*QuoteStreamWriter, error) {
+	opts := DefaultOptions()
+	opts.Format = format
+	return CreateQuoteStreamFileWithOptions(filename, opts)
+}
+
+// CreateQuoteStreamFileWithOptions is CreateQuoteStreamFile with configurable options
+func CreateQuoteStreamFileWithOptions(filename string, opts Options) (*QuoteStreamWriter, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error creating output file %s: %w", filename, err)
+	}
+	s := NewQuoteStreamWriterWithOptions(file, opts)
+	s.closers = append(s.closers, file)
+	return s, nil
+}
+
+// ResumeQuoteStreamFile reopens a partially written output, discarding anything past offset,
+// and continues the stream as if count quotes had already been written
+func ResumeQuoteStreamFile(filename string, opts Options, offset int64, count int) (*QuoteStreamWriter, error) {
+	if opts.Compress != CompressNone || len(opts.Encrypt) > 0 {
+		return nil, fmt.Errorf("compressed or encrypted output %s cannot be resumed", filename)
+	}
+	file, err := os.OpenFile(filename, os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening output file %s: %w", filename, err)
+	}
+	if err := file.Truncate(offset); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error truncating output file %s: %w", filename, err)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error seeking output file %s: %w", filename, err)
+	}
+	s := NewQuoteStreamWriterWithOptions(file, opts)
+	s.out.n = offset
+	s.closers = append(s.closers, file)
+	s.count = count
+	return s, nil
+}
+
+// WriteQuotesToFile writes a complete QuotesData to filename in the given format
+func WriteQuotesToFile(filename string, format StreamFormat, data QuotesData) error {
+	opts := DefaultOptions()
+	opts.Format = format
+	return WriteQuotesToFileWithOptions(filename, opts, data)
+}
+
+// WriteQuotesToFileWithOptions writes a complete QuotesData to filename in opts.Format
+func WriteQuotesToFileWithOptions(filename string, opts Options, data QuotesData) error {
+	stream, err := CreateQuoteStreamFileWithOptions(filename, opts)
+	if err != nil {
+		return err
+	}
+	for _, quote := range data.Quotes {
+		if err := stream.WriteQuote(quote); err != nil {
+			stream.Close()
+			return err
+		}
+	}
+	return stream.Close()
+}
+
+// WriteQuote encodes a single quote to the stream
+func (s *QuoteStreamWriter) WriteQuote(quote Quote) error {
+	if err := s.enc.writeQuote(s.w, quote, s.count); err != nil {
+		return fmt.Errorf("error writing quote %d: %w", quote.ID, err)
+	}
+	s.count++
+	return nil
+}
+
+// Count returns the number of quotes written so far
+func (s *QuoteStreamWriter) Count() int {
+	return s.count
+}
+
+// Offset returns the number of bytes flushed to the underlying writer
+func (s *QuoteStreamWriter) Offset() int64 {
+	return s.out.n
+}
+
+// Flush pushes any buffered data to the underlying writer
+func (s *QuoteStreamWriter) Flush() error {
+	if err := s.w.Flush(); err != nil {
+		return fmt.Errorf("error flushing output: %w", err)
+	}
+	return nil
+}
+
+// Close terminates the document, flushes it and closes the compressor and underlying file if the
+// writer owns them
+func (s *QuoteStreamWriter) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	err := s.enc.finish(s.w, s.count)
+	if err == nil {
+		err = s.Flush()
+	}
+	for _, closer := range s.closers {
+		if closeErr := closer.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("error closing output: %w", closeErr)
+		}
+	}
+	return err
+}
+
+// jsonArrayEncoder writes {"quotes": [...]} laid out like json.MarshalIndent(data, "", indent),
+// or like json.Marshal(data) when compact
+type jsonArrayEncoder struct {
+	indent  string
+	compact bool
+}
+
+func newJSONArrayEncoder(opts Options) quoteEncoder {
+	return jsonArrayEncoder{indent: opts.Indent, compact: opts.Compact}
+}
+
+func (e jsonArrayEncoder) writeQuote(w *bufio.Writer, quote Quote, index int) error {
+	if e.compact {
+		data, err := json.Marshal(quote)
+		if err != nil {
+			return err
+		}
+		if index == 0 {
+			w.WriteString(`{"quotes":[`)
+		} else {
+			w.WriteByte(',')
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	data, err := json.MarshalIndent(quote, e.indent+e.indent, e.indent)
+	if err != nil {
+		return err
+	}
+	if index == 0 {
+		w.WriteString("{\n" + e.indent + "\"quotes\": [\n" + e.indent + e.indent)
+	} else {
+		w.WriteString(",\n" + e.indent + e.indent)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (e jsonArrayEncoder) finish(w *bufio.Writer, count int) error {
+	var err error
+	switch {
+	case e.compact && count == 0:
+		_, err = w.WriteString(`{"quotes":[]}`)
+	case e.compact:
+		_, err = w.WriteString("]}")
+	case count == 0:
+		_, err = w.WriteString("{\n" + e.indent + "\"quotes\": []\n}")
+	default:
+		_, err = w.WriteString("\n" + e.indent + "]\n}")
+	}
+	return err
+}
+
+// ParseIndent interprets an --indent value, accepting escapes such as "\t", and checks that
+// it only holds JSON whitespace
+func ParseIndent(value string) (string, error) {
+	indent, err := strconv.Unquote(`"` + strings.ReplaceAll(value, `"`, `\"`) + `"`)
+	if err != nil {
+		return "", fmt.Errorf("invalid indent %q: %w", value, err)
+	}
+	if strings.Trim(indent, " \t") != "" {
+		return "", fmt.Errorf("invalid indent %q: only spaces and tabs are allowed", value)
+	}
+	return indent, nil
+}
+
+// ndjsonEncoder writes one compact quote object per line
+type ndjsonEncoder struct{}
+
+func (ndjsonEncoder) writeQuote(w *bufio.Writer, quote Quote, index int) error {
+	data, err := json.Marshal(quote)
+	if err != nil {
+		return err
+	}
+	w.Write(data)
+	return w.WriteByte('\n')
+}
+
+func (ndjsonEncoder) finish(w *bufio.Writer, count int) error {
+	return nil
+}
+
+// countingWriter tracks how many bytes have reached the wrapped writer
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
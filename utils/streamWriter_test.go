@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestQuoteStreamWriter tests both stream layouts against the marshalled equivalents
+func TestQuoteStreamWriter(t *testing.T) {
+	quotes := []Quote{
+		{ID: 1, Text: "First", Tags: []string{"a", "b"}, Language: "en-US"},
+		{ID: 2, Text: "Second", Author: "Someone", Tags: []string{""}, Language: "en-US"},
+	}
+
+	t.Run("array_matches_marshal_indent", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := NewQuoteStreamWriter(&buf, StreamArray)
+		for _, q := range quotes {
+			require.NoError(t, s.WriteQuote(q))
+		}
+		require.NoError(t, s.Close())
+
+		expected, err := json.MarshalIndent(QuotesData{Quotes: quotes}, "", "  ")
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), buf.String())
+		assert.Equal(t, 2, s.Count())
+	})
+
+	t.Run("array_tab_indent", func(t *testing.T) {
+		opts := DefaultOptions()
+		opts.Indent = "\t"
+		var buf bytes.Buffer
+		s := NewQuoteStreamWriterWithOptions(&buf, opts)
+		for _, q := range quotes {
+			require.NoError(t, s.WriteQuote(q))
+		}
+		require.NoError(t, s.Close())
+
+		expected, err := json.MarshalIndent(QuotesData{Quotes: quotes}, "", "\t")
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), buf.String())
+	})
+
+	t.Run("array_compact", func(t *testing.T) {
+		opts := DefaultOptions()
+		opts.Compact = true
+		var buf bytes.Buffer
+		s := NewQuoteStreamWriterWithOptions(&buf, opts)
+		for _, q := range quotes {
+			require.NoError(t, s.WriteQuote(q))
+		}
+		require.NoError(t, s.Close())
+
+		expected, err := json.Marshal(QuotesData{Quotes: quotes})
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), buf.String())
+
+		buf.Reset()
+		require.NoError(t, NewQuoteStreamWriterWithOptions(&buf, opts).Close())
+		assert.Equal(t, `{"quotes":[]}`, buf.String())
+	})
+
+	t.Run("array_empty", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := NewQuoteStreamWriter(&buf, StreamArray)
+		require.NoError(t, s.Close())
+
+		var data QuotesData
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &data))
+		assert.Empty(t, data.Quotes)
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := NewQuoteStreamWriter(&buf, StreamNDJSON)
+		for _, q := range quotes {
+			require.NoError(t, s.WriteQuote(q))
+		}
+		require.NoError(t, s.Close())
+
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		require.Len(t, lines, 2)
+		for i, line := range lines {
+			var q Quote
+			require.NoError(t, json.Unmarshal([]byte(line), &q))
+			assert.Equal(t, quotes[i], q)
+		}
+	})
+}
+
+// TestParseStreamFormat tests the --format names
+func TestParseStreamFormat(t *testing.T) {
+	for name, want := range map[string]StreamFormat{"json": StreamArray, "NDJSON": StreamNDJSON, "jsonl": StreamNDJSON} {
+		got, err := ParseStreamFormat(name)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := ParseStreamFormat("yaml")
+	assert.Error(t, err)
+}
+
+// TestParseIndent tests escaped and invalid --indent values
+func TestParseIndent(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+		wantErr  bool
+	}{
+		{value: "\\t", expected: "\t"},
+		{value: "    ", expected: "    "},
+		{value: "", expected: ""},
+		{value: "--", wantErr: true},
+		{value: "\\q", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		indent, err := ParseIndent(tt.value)
+		if tt.wantErr {
+			assert.Error(t, err, tt.value)
+			continue
+		}
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.expected, indent)
+	}
+}
+
+// TestStreamFormatsRegistered tests that every format constant has an entry that parses back to it
+func TestStreamFormatsRegistered(t *testing.T) {
+	for format := StreamArray; format <= StreamTemplate; format++ {
+		info, ok := streamFormats[format]
+		require.True(t, ok, "format %d is not registered", format)
+		parsed, err := ParseStreamFormat(info.name)
+		assert.NoError(t, err)
+		assert.Equal(t, format, parsed)
+	}
+}
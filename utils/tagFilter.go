@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// tagFilter removes tags outside an approved vocabulary or on a banned list. Tags are matched
+// like aliases, ignoring case and repeated whitespace.
+type tagFilter struct {
+	allowed map[string]bool // vocabulary of approved tags, nil to allow every tag
+	banned  map[string]bool // tags that are always removed
+}
+
+// loadList reads a file holding one tag or term per line; blank lines and lines starting with # are
+// ignored. kind names the list in error messages.
+func loadList(fileName, kind string) (map[string]bool, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s list %s: %w", kind, fileName, err)
+	}
+	defer file.Close()
+
+	tags := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tags[aliasKey(line)] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s list %s: %w", kind, fileName, err)
+	}
+	return tags, nil
+}
+
+// newTagFilter loads the allowed and banned tag lists; either file name may be empty
+func newTagFilter(allowedFile, bannedFile string) (*tagFilter, error) {
+	filter := &tagFilter{}
+	var err error
+	if allowedFile != "" {
+		if filter.allowed, err = loadList(allowedFile, "tag"); err != nil {
+			return nil, err
+		}
+	}
+	if bannedFile != "" {
+		if filter.banned, err = loadList(bannedFile, "tag"); err != nil {
+			return nil, err
+		}
+	}
+	return filter, nil
+}
+
+// filter returns the tags that pass, leaving empty tags alone, and the number removed
+func (f *tagFilter) filter(tags []string) ([]string, int) {
+	kept := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		key := aliasKey(tag)
+		if key != "" && (f.banned[key] || (f.allowed != nil && !f.allowed[key])) {
+			continue
+		}
+		kept = append(kept, tag)
+	}
+	return kept, len(tags) - len(kept)
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTagFilter tests the allowed vocabulary and banned list, alone and together
+func TestTagFilter(t *testing.T) {
+	dir := t.TempDir()
+	allowed := filepath.Join(dir, "allowed.txt")
+	banned := filepath.Join(dir, "banned.txt")
+	require.NoError(t, os.WriteFile(allowed, []byte("# approved vocabulary\nLife\nlove\n\nwisdom\n"), 0644))
+	require.NoError(t, os.WriteFile(banned, []byte("wisdom\nspam\n"), 0644))
+
+	tags := []string{"life", "Love", "Wisdom", "spam", "misc", ""}
+	tests := []struct {
+		name     string
+		allowed  string
+		banned   string
+		expected []string
+	}{
+		{name: "none", expected: tags},
+		{name: "allowed", allowed: allowed, expected: []string{"life", "Love", "Wisdom", ""}},
+		{name: "banned", banned: banned, expected: []string{"life", "Love", "misc", ""}},
+		{name: "both", allowed: allowed, banned: banned, expected: []string{"life", "Love", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newTagFilter(tt.allowed, tt.banned)
+			require.NoError(t, err)
+			kept, removed := filter.filter(tags)
+			assert.Equal(t, tt.expected, kept)
+			assert.Equal(t, len(tags)-len(tt.expected), removed)
+		})
+	}
+
+	_, err := newTagFilter(filepath.Join(dir, "missing.txt"), "")
+	assert.Error(t, err)
+}
+
+// TestRowProcessorTagFilter tests dropping quotes whose tags were all filtered out
+func TestRowProcessorTagFilter(t *testing.T) {
+	opts := DefaultOptions()
+	opts.DropFilteredQuotes = true
+	processor := newRowProcessor(opts)
+	processor.tagFilter = &tagFilter{banned: map[string]bool{"spam": true}}
+
+	quote, ok := processor.parse(1, []string{"spam,life", "Kept"})
+	require.True(t, ok)
+	assert.Equal(t, []string{"life"}, quote.Tags)
+
+	_, ok = processor.parse(2, []string{"spam", "Dropped"})
+	assert.False(t, ok)
+
+	_, ok = processor.parse(3, []string{"", "Untagged quotes are kept"})
+	assert.True(t, ok)
+
+	assert.Equal(t, int64(2), processor.tagsFiltered.Load())
+	assert.Equal(t, int64(1), processor.quotesDropped.Load())
+	assert.Equal(t, int64(0), processor.invalid.Load())
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// tagIndexFile maps every tag to the quotes carrying it, written by --tag-index
+const tagIndexFile = "tags.json"
+
+// tagIndexEntry lists the quotes carrying a tag
+type tagIndexEntry struct {
+	Count int   `json:"count"`
+	IDs   []any `json:"ids"` // numbers, or strings with --id-strategy uuid
+}
+
+// tagIndexSink collects the quote IDs of every tag and writes them to a file on commit
+type tagIndexSink struct {
+	filename string
+	opts     Options // compression and encryption of the file
+	tags     map[string]*tagIndexEntry
+}
+
+// newTagIndexSink returns a sink writing the tag index to filename, compressed and encrypted
+// like the quotes file
+func newTagIndexSink(filename string, opts Options) QuoteSink {
+	return &tagIndexSink{filename: filename, opts: opts, tags: make(map[string]*tagIndexEntry)}
+}
+
+func (s *tagIndexSink) WriteQuotes(quotes []Quote) error {
+	for _, quote := range quotes {
+		for _, tag := range nonEmptyTags(quote.Tags) {
+			entry, ok := s.tags[tag]
+			if !ok {
+				entry = &tagIndexEntry{}
+				s.tags[tag] = entry
+			}
+			entry.Count++
+			entry.IDs = append(entry.IDs, quote.ref())
+		}
+	}
+	return nil
+}
+
+func (s *tagIndexSink) Commit() error {
+	// encoding/json writes the tags in sorted order
+	data, err := json.MarshalIndent(s.tags, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling tag index: %w", err)
+	}
+	if err := writeEnvelopeFile(s.filename, data, s.opts); err != nil {
+		return fmt.Errorf("error writing tag index %s: %w", s.filename, err)
+	}
+	return nil
+}
+
+func (s *tagIndexSink) Rollback() error {
+	return nil
+}
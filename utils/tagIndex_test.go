@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTagIndexSink tests the tag to quote ID mapping across batches
+func TestTagIndexSink(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tags.json")
+	sink := newTagIndexSink(file, DefaultOptions())
+	require.NoError(t, sink.WriteQuotes([]Quote{
+		{ID: 1, Tags: []string{"Wisdom", "Life"}},
+		{ID: 2, Tags: []string{""}},
+	}))
+	require.NoError(t, sink.WriteQuotes([]Quote{{ID: 3, Tags: []string{"Wisdom"}}}))
+	require.NoError(t, sink.Commit())
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"Life": {"count": 1, "ids": [1]},
+		"Wisdom": {"count": 2, "ids": [1, 3]}
+	}`, string(data))
+}
+
+// TestTagIndexSinkEncrypted tests that the tag index is encrypted like the quotes file
+func TestTagIndexSinkEncrypted(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	opts := DefaultOptions()
+	opts.Encrypt = RecipientList{identity.Recipient()}
+	file := filepath.Join(t.TempDir(), "tags.json.age")
+	sink := newTagIndexSink(file, opts)
+	require.NoError(t, sink.WriteQuotes([]Quote{{ID: 1, Tags: []string{"Wisdom"}}}))
+	require.NoError(t, sink.Commit())
+
+	assert.JSONEq(t, `{"Wisdom": {"count": 1, "ids": [1]}}`, string(decryptFile(t, identity, CompressNone, file)))
+}
+
+// TestTagIndexSinkEmpty tests that a dataset without tags produces an empty object
+func TestTagIndexSinkEmpty(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tags.json")
+	sink := newTagIndexSink(file, DefaultOptions())
+	require.NoError(t, sink.WriteQuotes([]Quote{{ID: 1, Tags: []string{""}}}))
+	require.NoError(t, sink.Commit())
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(data))
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TagPolicy controls how the tags cell is split into tags. The zero value is the legacy
+// behaviour: every space is removed and the cell is split on commas, keeping empty tags.
+type TagPolicy struct {
+	Separators string // characters separating tags, "," when empty
+	Trim       bool   // trim each tag instead of removing every space
+	Lowercase  bool   // lowercase every tag
+	DropEmpty  bool   // drop empty tags, so an empty cell gives [] rather than [""]
+	Dedupe     bool   // keep only the first occurrence of a tag
+}
+
+// tagPolicyPresets are the named --tag-policy values
+var tagPolicyPresets = map[string]TagPolicy{
+	"legacy": {},
+	"clean":  {Separators: ",;|", Trim: true, Lowercase: true, DropEmpty: true, Dedupe: true},
+}
+
+// String implements flag.Value
+func (p *TagPolicy) String() string {
+	for name, preset := range tagPolicyPresets {
+		if *p == preset {
+			return name
+		}
+	}
+	var steps []string
+	for _, step := range []struct {
+		name string
+		on   bool
+	}{{"trim", p.Trim}, {"lowercase", p.Lowercase}, {"drop-empty", p.DropEmpty}, {"dedupe", p.Dedupe}} {
+		if step.on {
+			steps = append(steps, step.name)
+		}
+	}
+	return strings.Join(steps, ",")
+}
+
+// Set implements flag.Value, accepting a preset name or a comma separated list of steps. The
+// separators are kept so they can be set independently with --tag-separators.
+func (p *TagPolicy) Set(value string) error {
+	if preset, ok := tagPolicyPresets[strings.ToLower(value)]; ok {
+		separators := p.Separators
+		*p = preset
+		if separators != "" {
+			p.Separators = separators
+		}
+		return nil
+	}
+	for _, step := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(step)) {
+		case "trim":
+			p.Trim = true
+		case "lowercase":
+			p.Lowercase = true
+		case "drop-empty":
+			p.DropEmpty = true
+		case "dedupe":
+			p.Dedupe = true
+		default:
+			return fmt.Errorf("unknown tag policy %q (supported: legacy, clean, or a list of trim, lowercase, drop-empty, dedupe)", step)
+		}
+	}
+	return nil
+}
+
+// split turns a tags cell into tags according to the policy
+func (p TagPolicy) split(cell string) []string {
+	separators := p.Separators
+	if separators == "" {
+		separators = ","
+	}
+	if !p.Trim {
+		cell = strings.ReplaceAll(cell, " ", "")
+	}
+	parts := splitAny(cell, separators)
+
+	tags := make([]string, 0, len(parts))
+	seen := make(map[string]bool)
+	for _, tag := range parts {
+		if p.Trim {
+			tag = strings.TrimSpace(tag)
+		}
+		if p.Lowercase {
+			tag = strings.ToLower(tag)
+		}
+		if p.DropEmpty && tag == "" {
+			continue
+		}
+		if p.Dedupe {
+			if seen[tag] {
+				continue
+			}
+			seen[tag] = true
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// splitAny is strings.Split on any of the separator characters
+func splitAny(s, separators string) []string {
+	var parts []string
+	start := 0
+	for i, r := range s {
+		if strings.ContainsRune(separators, r) {
+			parts = append(parts, s[start:i])
+			start = i + len(string(r))
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTagPolicySplit tests the legacy behaviour and each step of the pipeline
+func TestTagPolicySplit(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   TagPolicy
+		cell     string
+		expected []string
+	}{
+		{name: "legacy", policy: TagPolicy{}, cell: "wisdom, self love,", expected: []string{"wisdom", "selflove", ""}},
+		{name: "legacy_empty", policy: TagPolicy{}, cell: "", expected: []string{""}},
+		{name: "trim", policy: TagPolicy{Trim: true}, cell: " self love , life", expected: []string{"self love", "life"}},
+		{name: "lowercase", policy: TagPolicy{Lowercase: true}, cell: "Life,LOVE", expected: []string{"life", "love"}},
+		{name: "drop_empty", policy: TagPolicy{DropEmpty: true}, cell: ",a,,b,", expected: []string{"a", "b"}},
+		{name: "drop_empty_cell", policy: TagPolicy{DropEmpty: true}, cell: "", expected: []string{}},
+		{name: "dedupe", policy: TagPolicy{Dedupe: true}, cell: "a,b,a", expected: []string{"a", "b"}},
+		{name: "separators", policy: TagPolicy{Separators: ";|"}, cell: "a;b|c,d", expected: []string{"a", "b", "c,d"}},
+		{name: "clean", policy: tagPolicyPresets["clean"], cell: " Life | love; LIFE,, Self Love ", expected: []string{"life", "love", "self love"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.split(tt.cell))
+		})
+	}
+}
+
+// TestTagPolicySet tests presets and step lists
+func TestTagPolicySet(t *testing.T) {
+	var policy TagPolicy
+	require.NoError(t, policy.Set("clean"))
+	assert.Equal(t, tagPolicyPresets["clean"], policy)
+	assert.Equal(t, "clean", policy.String())
+
+	policy = TagPolicy{Separators: "/"}
+	require.NoError(t, policy.Set("trim,dedupe"))
+	assert.Equal(t, TagPolicy{Separators: "/", Trim: true, Dedupe: true}, policy)
+	assert.Equal(t, "trim,dedupe", policy.String())
+
+	assert.Error(t, policy.Set("shout"))
+}
+
+// TestRowProcessorTagPolicy tests that the policy replaces the legacy tag splitting
+func TestRowProcessorTagPolicy(t *testing.T) {
+	opts := DefaultOptions()
+	opts.TagPolicy = tagPolicyPresets["clean"]
+	processor := newRowProcessor(opts)
+
+	quote, ok := processor.parse(1, []string{"Life;\u200b;life | Self Love", "Text"})
+	require.True(t, ok)
+	assert.Equal(t, []string{"life", "self love"}, quote.Tags)
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// tagRule adds tag to quotes whose text matches one of its keywords
+type tagRule struct {
+	tag     string
+	pattern *regexp.Regexp
+}
+
+// tagRules are applied in tag order so the added tags are deterministic
+type tagRules []tagRule
+
+// loadTagRules reads a YAML file mapping every tag to the keywords that earn it, such as:
+//
+//	love:
+//	  - love
+//	  - beloved
+//	courage:
+//	  - brave*
+//	  - fear
+//
+// Keywords match whole words or phrases ignoring case, and a trailing * matches any word ending.
+func loadTagRules(fileName string) (tagRules, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tag rules %s: %w", fileName, err)
+	}
+	var keywords map[string][]string
+	if err := yaml.Unmarshal(data, &keywords); err != nil {
+		return nil, fmt.Errorf("failed to parse tag rules %s: %w", fileName, err)
+	}
+
+	tags := make([]string, 0, len(keywords))
+	for tag := range keywords {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	rules := make(tagRules, 0, len(tags))
+	for _, tag := range tags {
+		pattern, err := keywordPattern(keywords[tag])
+		if err != nil {
+			return nil, fmt.Errorf("tag rules %s: tag %q: %w", fileName, tag, err)
+		}
+		rules = append(rules, tagRule{tag: tag, pattern: pattern})
+	}
+	return rules, nil
+}
+
+// keywordPattern matches any of the keywords as a whole word or phrase ignoring case, where a
+// trailing * matches any word ending
+func keywordPattern(keywords []string) (*regexp.Regexp, error) {
+	alternatives := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		words := strings.Fields(strings.TrimSuffix(keyword, "*"))
+		if len(words) == 0 {
+			return nil, fmt.Errorf("empty keyword")
+		}
+		for i, word := range words {
+			words[i] = regexp.QuoteMeta(word)
+		}
+		alternative := strings.Join(words, `\s+`)
+		if strings.HasSuffix(keyword, "*") {
+			alternative += `\w*`
+		}
+		alternatives = append(alternatives, alternative)
+	}
+	if len(alternatives) == 0 {
+		return nil, fmt.Errorf("no keywords")
+	}
+	return regexp.Compile(`(?i)\b(?:` + strings.Join(alternatives, "|") + `)\b`)
+}
+
+// apply appends the tags of every rule matching text that aren't already present, and returns
+// the number of tags added. Quotes without any explicit tag lose their empty placeholder tags.
+func (r tagRules) apply(text string, tags []string) ([]string, int) {
+	present := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		present[aliasKey(tag)] = true
+	}
+
+	added := 0
+	for _, rule := range r {
+		if present[aliasKey(rule.tag)] || !rule.pattern.MatchString(text) {
+			continue
+		}
+		if added == 0 && len(nonEmptyTags(tags)) == 0 {
+			tags = nil
+		}
+		present[aliasKey(rule.tag)] = true
+		tags = append(tags, rule.tag)
+		added++
+	}
+	return tags, added
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTagRules writes a tag rules file and loads it
+func writeTagRules(t *testing.T, content string) (tagRules, error) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	return loadTagRules(file)
+}
+
+// TestTagRulesApply tests that matching keywords add their tags after the explicit ones
+func TestTagRulesApply(t *testing.T) {
+	rules, err := writeTagRules(t, `
+love: [love, beloved]
+courage: ["brave*", "fear"]
+time: ["the present moment"]
+`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		text     string
+		tags     []string
+		expected []string
+		added    int
+	}{
+		{name: "keyword", text: "All you need is Love.", tags: []string{"music"}, expected: []string{"music", "love"}, added: 1},
+		{name: "whole_words_only", text: "Gloves off.", tags: []string{"sport"}, expected: []string{"sport"}},
+		{name: "prefix_and_sorted", text: "Be braver than your fear of love.", tags: []string{"life"}, expected: []string{"life", "courage", "love"}, added: 2},
+		{name: "phrase", text: "Live in the  present\nmoment.", tags: []string{}, expected: []string{"time"}, added: 1},
+		{name: "already_tagged", text: "My beloved.", tags: []string{"Love"}, expected: []string{"Love"}},
+		{name: "replaces_empty_placeholder", text: "Love wins.", tags: []string{""}, expected: []string{"love"}, added: 1},
+		{name: "no_match_keeps_placeholder", text: "Nothing here.", tags: []string{""}, expected: []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, added := rules.apply(tt.text, tt.tags)
+			assert.Equal(t, tt.expected, tags)
+			assert.Equal(t, tt.added, added)
+		})
+	}
+}
+
+// TestLoadTagRulesErrors tests malformed rules files
+func TestLoadTagRulesErrors(t *testing.T) {
+	_, err := writeTagRules(t, "love: love\n")
+	assert.Error(t, err)
+
+	_, err = writeTagRules(t, "love: []\n")
+	assert.Error(t, err)
+
+	_, err = writeTagRules(t, "love: [\"*\"]\n")
+	assert.Error(t, err)
+
+	_, err = loadTagRules(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// taxonomy maps the normalized name of a tag to its parent tag
+type taxonomy map[string]string
+
+// loadTaxonomy reads a YAML file nesting tags under their parents:
+//
+//	philosophy:
+//	  stoicism:
+//	  existentialism:
+//	spirituality:
+//	  meditation:
+func loadTaxonomy(fileName string) (taxonomy, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read taxonomy %s: %w", fileName, err)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse taxonomy %s: %w", fileName, err)
+	}
+
+	t := taxonomy{}
+	var walk func(node *yaml.Node, parent string) error
+	walk = func(node *yaml.Node, parent string) error {
+		switch {
+		case node.Kind == yaml.ScalarNode && node.Tag == "!!null":
+			return nil
+		case node.Kind != yaml.MappingNode:
+			return fmt.Errorf("taxonomy %s line %d: expected tags nested in a mapping", fileName, node.Line)
+		}
+		for i := 0; i < len(node.Content); i += 2 {
+			name := node.Content[i].Value
+			key := aliasKey(name)
+			if existing, ok := t[key]; ok {
+				return fmt.Errorf("taxonomy %s line %d: tag %q appears under both %q and %q", fileName, node.Content[i].Line, name, existing, parent)
+			}
+			t[key] = parent
+			if err := walk(node.Content[i+1], name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(root.Content) == 0 {
+		return t, nil
+	}
+	if err := walk(root.Content[0], ""); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// expand replaces path tags such as philosophy/stoicism with their last segment and appends
+// every ancestor, from the path and from the taxonomy, that the quote doesn't already carry
+func (t taxonomy) expand(tags []string) []string {
+	expanded := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	add := func(tag string) {
+		if key := aliasKey(tag); !seen[key] {
+			seen[key] = true
+			expanded = append(expanded, tag)
+		}
+	}
+
+	var ancestors []string
+	for _, tag := range tags {
+		segments := strings.Split(tag, "/")
+		add(strings.TrimSpace(segments[len(segments)-1]))
+		for i := len(segments) - 1; i >= 0; i-- {
+			segment := strings.TrimSpace(segments[i])
+			if i < len(segments)-1 {
+				ancestors = append(ancestors, segment)
+			}
+			// Follow the taxonomy upwards, guarding against cycles
+			for parent, depth := t[aliasKey(segment)], 0; parent != "" && depth < len(t); parent, depth = t[aliasKey(parent)], depth+1 {
+				ancestors = append(ancestors, parent)
+			}
+		}
+	}
+	for _, ancestor := range ancestors {
+		add(ancestor)
+	}
+	return expanded
+}
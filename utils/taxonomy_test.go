@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTaxonomy writes a taxonomy file and loads it
+func writeTaxonomy(t *testing.T, content string) (taxonomy, error) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "taxonomy.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	return loadTaxonomy(file)
+}
+
+// TestTaxonomyExpand tests that quotes inherit the parents of their tags
+func TestTaxonomyExpand(t *testing.T) {
+	tax, err := writeTaxonomy(t, `
+philosophy:
+  stoicism:
+    seneca:
+  existentialism:
+spirituality:
+  meditation:
+`)
+	require.NoError(t, err)
+	assert.Equal(t, taxonomy{"philosophy": "", "stoicism": "philosophy", "seneca": "stoicism", "existentialism": "philosophy", "spirituality": "", "meditation": "spirituality"}, tax)
+
+	tests := []struct {
+		name     string
+		tags     []string
+		expected []string
+	}{
+		{name: "leaf", tags: []string{"Seneca"}, expected: []string{"Seneca", "stoicism", "philosophy"}},
+		{name: "path", tags: []string{"philosophy/stoicism"}, expected: []string{"stoicism", "philosophy"}},
+		{name: "path_outside_taxonomy", tags: []string{"art/poetry"}, expected: []string{"poetry", "art"}},
+		{name: "parent_already_present", tags: []string{"philosophy", "existentialism", "meditation"}, expected: []string{"philosophy", "existentialism", "meditation", "spirituality"}},
+		{name: "unknown_and_empty", tags: []string{"life", ""}, expected: []string{"life", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tax.expand(tt.tags))
+		})
+	}
+}
+
+// TestLoadTaxonomyErrors tests malformed and ambiguous taxonomies
+func TestLoadTaxonomyErrors(t *testing.T) {
+	_, err := writeTaxonomy(t, "- a\n- b\n")
+	assert.Error(t, err)
+
+	_, err = writeTaxonomy(t, "philosophy:\n  ethics:\nreligion:\n  Ethics:\n")
+	assert.Error(t, err)
+
+	_, err = loadTaxonomy(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is what --template templates are executed with: the quotes as .Quotes and the
+// dataset metadata as .Metadata
+type TemplateData struct {
+	QuotesData
+	Metadata Metadata
+}
+
+// templateMetadata returns the metadata of a dataset of count quotes, as it will be written
+type templateMetadata func(count int) (Metadata, error)
+
+// templateFuncs are the helper functions available inside templates
+var templateFuncs = template.FuncMap{
+	"join":        strings.Join,
+	"upper":       strings.ToUpper,
+	"lower":       strings.ToLower,
+	"replace":     strings.ReplaceAll,
+	"tags":        nonEmptyTags,
+	"attribution": quoteAttribution,
+	"year":        formatYear,
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// loadOutputTemplate parses a template file with the helper functions available
+func loadOutputTemplate(fileName string) (*template.Template, error) {
+	if fileName == "" {
+		return nil, fmt.Errorf("the template format needs a --template file")
+	}
+	tmpl, err := template.New(filepath.Base(fileName)).Funcs(templateFuncs).ParseFiles(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", fileName, err)
+	}
+	return tmpl, nil
+}
+
+// templateExtension derives the output extension from the template name, so latex.tex.tmpl
+// produces quotes.tex; templates without an inner extension produce .txt
+func templateExtension(fileName string) string {
+	ext := filepath.Ext(strings.TrimSuffix(filepath.Base(fileName), ".tmpl"))
+	if ext == "" {
+		return ".txt"
+	}
+	return ext
+}
+
+// templateEncoder renders the whole dataset through a template once all quotes are known
+type templateEncoder struct {
+	opts   Options
+	quotes []Quote
+}
+
+func (e *templateEncoder) writeQuote(w *bufio.Writer, quote Quote, index int) error {
+	e.quotes = append(e.quotes, quote)
+	return nil
+}
+
+func (e *templateEncoder) finish(w *bufio.Writer, count int) error {
+	tmpl, err := loadOutputTemplate(e.opts.TemplateFile)
+	if err != nil {
+		return err
+	}
+	data := TemplateData{QuotesData: QuotesData{Quotes: e.quotes}}
+	if e.opts.templateMetadata != nil {
+		if data.Metadata, err = e.opts.templateMetadata(count); err != nil {
+			return err
+		}
+	} else {
+		var totals quoteTotals
+		for _, quote := range e.quotes {
+			totals.add(quote)
+		}
+		data.Metadata = newMetadata(e.opts, count)
+		data.Metadata.Stats = totals.stats()
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to execute template %s: %w", e.opts.TemplateFile, err)
+	}
+	return nil
+}
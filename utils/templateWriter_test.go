@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTemplateEncoder tests that templates see the quotes, metadata and helpers
+func TestTemplateEncoder(t *testing.T) {
+	tmplFile := filepath.Join(t.TempDir(), "quotes.org.tmpl")
+	require.NoError(t, os.WriteFile(tmplFile, []byte(
+		`#+TITLE: {{.Metadata.TotalQuotes}} quotes
+{{range .Quotes}}* {{.Text}}{{with tags .Tags}} :{{join . ":"}}:{{end}}
+{{end}}`), 0644))
+
+	opts := DefaultOptions()
+	opts.Format = StreamTemplate
+	opts.TemplateFile = tmplFile
+
+	var buf bytes.Buffer
+	s := NewQuoteStreamWriterWithOptions(&buf, opts)
+	require.NoError(t, s.WriteQuote(Quote{ID: 1, Text: "First", Tags: []string{"a", "b"}}))
+	require.NoError(t, s.WriteQuote(Quote{ID: 2, Text: "Second", Tags: []string{""}}))
+	require.NoError(t, s.Close())
+
+	assert.Equal(t, "#+TITLE: 2 quotes\n* First :a:b:\n* Second\n", buf.String())
+	assert.Equal(t, "quotes.org", outputFileName(opts))
+}
+
+// TestConvertTemplateMetadata tests that templates see the metadata written with the dataset
+func TestConvertTemplateMetadata(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	dir := t.TempDir()
+	input := filepath.Join(dir, "quotes.csv")
+	require.NoError(t, os.WriteFile(input, []byte("Tags,Quote\nlove,Love is patient.\n"), 0644))
+	tmplFile := filepath.Join(dir, "quotes.txt.tmpl")
+	require.NoError(t, os.WriteFile(tmplFile, []byte(
+		`{{.Metadata.URL}} {{.Metadata.Version}} {{.Metadata.Source.File}} {{.Metadata.Extra.team}}`), 0644))
+
+	opts := DefaultOptions()
+	opts.Format = StreamTemplate
+	opts.TemplateFile = tmplFile
+	opts.Output = filepath.Join(dir, "quotes.txt")
+	opts.MetadataURL = "https://example.com/quotes"
+	opts.MetadataExtra = MetadataFields{"team": "docs"}
+	require.NoError(t, ConvertFile(input, opts))
+	data, err := os.ReadFile(opts.Output)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/quotes 1.0 quotes.csv docs", string(data))
+
+	require.NoError(t, ConvertFile(input, opts))
+	data, err = os.ReadFile(opts.Output)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/quotes 1.0.1 quotes.csv docs", string(data), "the version is bumped like the metadata file's")
+}
+
+// TestLoadOutputTemplateErrors tests missing and invalid templates
+func TestLoadOutputTemplateErrors(t *testing.T) {
+	_, err := loadOutputTemplate("")
+	assert.Error(t, err)
+
+	bad := filepath.Join(t.TempDir(), "bad.tmpl")
+	require.NoError(t, os.WriteFile(bad, []byte("{{.Quotes"), 0644))
+	_, err = loadOutputTemplate(bad)
+	assert.Error(t, err)
+}
+
+// TestTemplateExtension tests output extensions derived from template names
+func TestTemplateExtension(t *testing.T) {
+	assert.Equal(t, ".tex", templateExtension("templates/latex.tex.tmpl"))
+	assert.Equal(t, ".org", templateExtension("quotes.org"))
+	assert.Equal(t, ".txt", templateExtension("custom.tmpl"))
+}
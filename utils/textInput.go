@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// textDelimiterBlank is the TextDelimiter separating quotes by blank lines
+const textDelimiterBlank = "blank"
+
+// splitTextEntries splits plain text into the lines of each quote. With delimiter "blank" quotes
+// are separated by blank lines; any other delimiter is a line, such as "%", on its own between
+// quotes, and blank lines inside a quote are kept. An empty delimiter picks "%" when the text has
+// a line holding only "%", as fortune files do, and blank lines otherwise.
+func splitTextEntries(content string, delimiter string) [][]string {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if delimiter == "" {
+		delimiter = textDelimiterBlank
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "%" {
+				delimiter = "%"
+				break
+			}
+		}
+	}
+
+	var entries [][]string
+	var entry []string
+	flush := func() {
+		// Drop the blank lines around the quote
+		for len(entry) > 0 && entry[len(entry)-1] == "" {
+			entry = entry[:len(entry)-1]
+		}
+		if len(entry) > 0 {
+			entries = append(entries, entry)
+		}
+		entry = nil
+	}
+	delimiter = strings.TrimSpace(delimiter)
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case delimiter == textDelimiterBlank && trimmed == "":
+			flush()
+		case delimiter != textDelimiterBlank && strings.TrimRight(line, " \t") == delimiter:
+			// An indented delimiter is text, which is how fortune writes a % in a quote
+			flush()
+		case trimmed == "" && len(entry) == 0:
+		default:
+			entry = append(entry, trimmed)
+		}
+	}
+	flush()
+	return entries
+}
+
+// textRows lays out the quotes of plain text as rows under quoteColumns, header first. A last
+// line starting with a dash, "— Author" or fortune's "-- Author, Context (Year)", is the
+// attribution unless keepAttribution is set.
+func textRows(entries [][]string, keepAttribution bool) *sliceRows {
+	rows := [][]string{quoteColumns}
+	for _, lines := range entries {
+		var author, context, year string
+		if last := lines[len(lines)-1]; len(lines) > 1 && !keepAttribution && startsWithDash(last) {
+			author, context, year = parseAttributionLine(last)
+			lines = lines[:len(lines)-1]
+		}
+		rows = append(rows, []string{"", strings.TrimSpace(strings.Join(lines, "\n")), author, year, context, ""})
+	}
+	return &sliceRows{rows: rows}
+}
+
+// readTextRows reads a plain-text file of quotes separated by opts.TextDelimiter as rows
+func readTextRows(fileName string, opts Options) (*sliceRows, error) {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read text file %s: %w", fileName, err)
+	}
+	return textRows(splitTextEntries(string(content), opts.TextDelimiter), opts.KeepAttribution), nil
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSplitTextEntries tests the delimiters of plain-text input
+func TestSplitTextEntries(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		delimiter string
+		want      [][]string
+	}{
+		{
+			name:    "blank lines",
+			content: "\nKnow thyself.\n— Socrates\n\n\n  Carpe diem.  \r\n",
+			want:    [][]string{{"Know thyself.", "— Socrates"}, {"Carpe diem."}},
+		},
+		{
+			name:    "percent detected",
+			content: "First line\n\nsecond paragraph\n%\nCarpe diem.\n%\n",
+			want:    [][]string{{"First line", "", "second paragraph"}, {"Carpe diem."}},
+		},
+		{
+			name:      "blank forced",
+			content:   "a\n%\nb\n\nc",
+			delimiter: "blank",
+			want:      [][]string{{"a", "%", "b"}, {"c"}},
+		},
+		{
+			name:      "custom delimiter",
+			content:   "a\n---\n\nb\n  ---\n",
+			delimiter: "---",
+			want:      [][]string{{"a"}, {"b", "---"}},
+		},
+		{
+			name:    "empty",
+			content: "\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, splitTextEntries(tt.content, tt.delimiter))
+		})
+	}
+}
+
+// TestTextRows tests reading attribution lines
+func TestTextRows(t *testing.T) {
+	entries := [][]string{{"Know thyself.", "— Socrates (c. 400 BCE)"}, {"— a quote starting with a dash"}, {"Be yourself.", "Oscar Wilde"}}
+	assert.Equal(t, [][]string{
+		quoteColumns,
+		{"", "Know thyself.", "Socrates", "c. 400 BCE", "", ""},
+		{"", "— a quote starting with a dash", "", "", "", ""},
+		{"", "Be yourself.\nOscar Wilde", "", "", "", ""},
+	}, textRows(entries, false).rows)
+	assert.Equal(t, []string{"", "Know thyself.\n— Socrates (c. 400 BCE)", "", "", "", ""}, textRows(entries, true).rows[1])
+}
+
+// TestTextRowsFortuneRoundTrip tests reading the output of the fortune format
+func TestTextRowsFortuneRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	require.NoError(t, fortuneEncoder{}.writeQuote(w, Quote{Text: "Know thyself.", Author: "Socrates", Context: "Delphi", Year: 1950}, 0))
+	require.NoError(t, fortuneEncoder{}.writeQuote(w, Quote{Text: "100\n%\nsure"}, 1))
+	require.NoError(t, w.Flush())
+
+	assert.Equal(t, [][]string{
+		quoteColumns,
+		{"", "Know thyself.", "Socrates", "1950", "Delphi", ""},
+		{"", "100\n%\nsure", "", "", "", ""},
+	}, textRows(splitTextEntries(buf.String(), ""), false).rows)
+}
+
+// TestConvertTextFile tests converting a plain-text file through the pipeline
+func TestConvertTextFile(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	input := filepath.Join(t.TempDir(), "legacy.txt")
+	require.NoError(t, os.WriteFile(input, []byte("Know thyself.\n— Socrates\n\n\"Carpe diem.\" — Horace\n\nAnonymous wisdom.\n"), 0644))
+
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(t.TempDir(), "out.json")
+	require.NoError(t, ConvertFile(input, opts))
+	data, err := ReadQuotesFromJSON(opts.Output)
+	require.NoError(t, err)
+	require.Len(t, data.Quotes, 3)
+	assert.Equal(t, "Socrates", data.Quotes[0].Author)
+	assert.Equal(t, "Carpe diem.", data.Quotes[1].Text)
+	assert.Equal(t, "Horace", data.Quotes[1].Author)
+	assert.Equal(t, "", data.Quotes[2].Author)
+	assert.Equal(t, int64(3), data.Quotes[2].ID)
+
+	opts.Columns = ColumnList{"text=B"}
+	assert.Error(t, ConvertFile(input, opts))
+	assert.Error(t, ConvertFile(filepath.Join(t.TempDir(), "missing.txt"), DefaultOptions()))
+}
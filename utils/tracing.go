@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/contrib/exporters/autoexport"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer records the spans of conversions and of the server. It is a no-op until SetupTracing
+// installs an exporter.
+var tracer = otel.Tracer("toJson/utils")
+
+// tracingVariables are the environment variables of which any turns tracing on
+var tracingVariables = []string{"OTEL_TRACES_EXPORTER", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"}
+
+// SetupTracing exports the spans of conversions and of the server when the standard
+// OpenTelemetry environment variables ask for it: OTEL_TRACES_EXPORTER (otlp, console or none),
+// or an OTEL_EXPORTER_OTLP_ENDPOINT, with OTEL_EXPORTER_OTLP_PROTOCOL, OTEL_SERVICE_NAME and
+// OTEL_RESOURCE_ATTRIBUTES read as usual. Without them nothing is recorded. The returned
+// function flushes the spans still buffered and must be called before exiting.
+func SetupTracing(ctx context.Context) (func(), error) {
+	enabled := false
+	for _, name := range tracingVariables {
+		enabled = enabled || os.Getenv(name) != ""
+	}
+	if !enabled {
+		return func() {}, nil
+	}
+	exporter, err := autoexport.NewSpanExporter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error creating the trace exporter: %w", err)
+	}
+	if autoexport.IsNoneSpanExporter(exporter) {
+		return func() {}, nil
+	}
+	// The variables come last so OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES win
+	resource, err := sdkresource.New(ctx,
+		sdkresource.WithAttributes(semconv.ServiceName("toJson"), semconv.ServiceVersion(generatorInfo().Version)),
+		sdkresource.WithTelemetrySDK(),
+		sdkresource.WithFromEnv(),
+	)
+	if err != nil && !errors.Is(err, sdkresource.ErrPartialResource) {
+		return nil, fmt.Errorf("error reading the trace resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(resource))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			log.Printf("Error exporting traces: %v", err)
+		}
+	}, nil
+}
+
+// optsContext is the context of the conversion run with opts, the parent of its spans
+func optsContext(opts Options) context.Context {
+	if opts.Context != nil {
+		return opts.Context
+	}
+	return context.Background()
+}
+
+// startSpan starts the span name as a child of the context of opts, returning opts with the
+// span as its context so the spans of the steps below it nest
+func startSpan(opts Options, name string, attributes ...attribute.KeyValue) (Options, trace.Span) {
+	ctx, span := tracer.Start(optsContext(opts), name, trace.WithAttributes(attributes...))
+	opts.Context = ctx
+	return opts, span
+}
+
+// endSpan ends span, recording err as its status
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	recordSpansOnce sync.Once
+	spanRecorder    = tracetest.NewSpanRecorder()
+)
+
+// recordSpans records the spans of tracer; the global provider can only be installed once, so
+// tests tell their spans apart by the trace started for them
+func recordSpans(t *testing.T) context.Context {
+	recordSpansOnce.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	ctx, span := otel.Tracer("test").Start(context.Background(), t.Name())
+	t.Cleanup(func() { span.End() })
+	return ctx
+}
+
+// tracedSpans returns the ended spans of the trace of ctx, by name
+func tracedSpans(ctx context.Context) map[string][]sdktrace.ReadOnlySpan {
+	traceID := trace.SpanContextFromContext(ctx).TraceID()
+	spans := make(map[string][]sdktrace.ReadOnlySpan)
+	for _, span := range spanRecorder.Ended() {
+		if span.SpanContext().TraceID() == traceID {
+			spans[span.Name()] = append(spans[span.Name()], span)
+		}
+	}
+	return spans
+}
+
+func TestSetupTracingDisabled(t *testing.T) {
+	for _, name := range tracingVariables {
+		t.Setenv(name, "")
+	}
+	flush, err := SetupTracing(context.Background())
+	require.NoError(t, err)
+	flush()
+
+	t.Setenv("OTEL_TRACES_EXPORTER", "none")
+	flush, err = SetupTracing(context.Background())
+	require.NoError(t, err)
+	flush()
+
+	t.Setenv("OTEL_TRACES_EXPORTER", "carrier-pigeon")
+	_, err = SetupTracing(context.Background())
+	assert.ErrorContains(t, err, "error creating the trace exporter")
+}
+
+func TestConvertFileSpans(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	ctx := recordSpans(t)
+	input := filepath.Join(t.TempDir(), "quotes.csv")
+	require.NoError(t, os.WriteFile(input, []byte("Tags,Quote,Author\nwisdom,Know thyself.,Socrates\n,,\nlife,Be yourself.,Oscar Wilde\nlife,Carpe diem.,Horace\n"), 0644))
+
+	opts := DefaultOptions()
+	opts.Context = ctx
+	opts.BatchSize = 2
+	opts.Output = filepath.Join(t.TempDir(), "out.json")
+	require.NoError(t, ConvertFile(input, opts))
+
+	spans := tracedSpans(ctx)
+	require.Len(t, spans["convert"], 1)
+	convert := spans["convert"][0]
+	assert.Equal(t, trace.SpanContextFromContext(ctx).SpanID(), convert.Parent().SpanID())
+	assert.Contains(t, convert.Attributes(), attribute.String("input.format", "csv"))
+	for _, name := range []string{"open input", "parse rows", "write quotes", "validate", "write outputs"} {
+		require.NotEmpty(t, spans[name], name)
+		assert.Equal(t, convert.SpanContext().SpanID(), spans[name][0].Parent().SpanID(), name)
+	}
+	assert.Len(t, spans["parse rows"], 2, "one span per batch")
+	assert.Len(t, spans["write quotes"], 2)
+}
+
+func TestConvertFileSpanError(t *testing.T) {
+	ctx := recordSpans(t)
+	opts := DefaultOptions()
+	opts.Context = ctx
+	require.Error(t, ConvertFile(filepath.Join(t.TempDir(), "missing.csv"), opts))
+
+	spans := tracedSpans(ctx)
+	require.Len(t, spans["convert"], 1)
+	assert.Equal(t, codes.Error, spans["convert"][0].Status().Code)
+	require.Len(t, spans["open input"], 1)
+	assert.Equal(t, codes.Error, spans["open input"][0].Status().Code)
+}
+
+func TestServerSpans(t *testing.T) {
+	ctx := recordSpans(t)
+	r := httptest.NewRequest(http.MethodGet, "/quotes/1", nil)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(r.Header))
+	testServer().ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := tracedSpans(ctx)
+	require.Len(t, spans["GET /quotes/{id}"], 1, "the span is named after the route and continues the trace of the caller")
+	assert.Equal(t, trace.SpanKindServer, spans["GET /quotes/{id}"][0].SpanKind())
+}
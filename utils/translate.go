@@ -0,0 +1,208 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// translateChunk is the most texts sent to a translation API in one request, within the
+// limits of all the providers
+const translateChunk = 50
+
+// translationProvider translates texts from one language to another, by their primary language
+// subtags such as en and ta
+type translationProvider struct {
+	// translate returns the translations of texts, in the same order
+	translate func(source *httpSource, api, key string, texts []string, from, to string) ([]string, error)
+	api       string // public API, used unless --translator-url is set
+	keyEnv    string // environment variable holding the API key
+	needsKey  bool
+}
+
+// translators are the translation providers of --translator, by name
+var translators = map[string]translationProvider{
+	"libretranslate": {translate: translateLibre, api: "https://libretranslate.com", keyEnv: "LIBRETRANSLATE_API_KEY"},
+	"google":         {translate: translateGoogle, api: "https://translation.googleapis.com", keyEnv: "GOOGLE_TRANSLATE_API_KEY", needsKey: true},
+	"deepl":          {translate: translateDeepL, api: "https://api.deepl.com", keyEnv: "DEEPL_AUTH_KEY", needsKey: true},
+}
+
+// TranslatorNames lists the translation providers of --translator, sorted
+func TranslatorNames() []string {
+	names := make([]string, 0, len(translators))
+	for name := range translators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// quoteTranslator fills the Translations of quotes into the languages of --translate-to
+type quoteTranslator struct {
+	provider translationProvider
+	source   *httpSource
+	api      string
+	key      string
+	targets  []string // BCP 47 tags, the keys of Translations
+}
+
+// newQuoteTranslator returns the translator of opts.TranslateTo, nil when there is nothing to
+// translate to. The API key is read from the environment variable of the provider so it never
+// shows in the command line.
+func newQuoteTranslator(opts Options) (*quoteTranslator, error) {
+	if len(opts.TranslateTo) == 0 {
+		return nil, nil
+	}
+	name := strings.ToLower(opts.Translator)
+	if name == "" {
+		name = "libretranslate"
+	}
+	provider, ok := translators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown translator %q (supported: %s)", opts.Translator, strings.Join(TranslatorNames(), ", "))
+	}
+	key := os.Getenv(provider.keyEnv)
+	if provider.needsKey && key == "" {
+		return nil, fmt.Errorf("the %s translator needs an API key in %s", name, provider.keyEnv)
+	}
+	api := provider.api
+	switch {
+	case opts.TranslatorURL != "":
+		api = opts.TranslatorURL
+	case name == "deepl" && strings.HasSuffix(key, ":fx"):
+		// Keys of the free plan only work with its own API
+		api = "https://api-free.deepl.com"
+	}
+
+	t := &quoteTranslator{provider: provider, source: newHTTPSource(0), api: strings.TrimSuffix(api, "/"), key: key}
+	for _, target := range opts.TranslateTo {
+		tag, err := NormalizeLanguageTag(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --translate-to language: %w", err)
+		}
+		t.targets = append(t.targets, tag)
+	}
+	return t, nil
+}
+
+// primaryLanguage returns the primary subtag of a BCP 47 tag, en for en-US, which is what the
+// translation APIs expect
+func primaryLanguage(tag string) string {
+	primary, _, _ := strings.Cut(tag, "-")
+	return strings.ToLower(primary)
+}
+
+// translateQuotes translates the text of a batch of quotes into every target language but
+// their own, grouping the quotes by language so each request translates many of them. Quotes
+// reused by --incremental keep the translations of their unchanged text, and only those of
+// languages no longer targeted are dropped.
+func (t *quoteTranslator) translateQuotes(quotes []Quote) error {
+	byLanguage := make(map[string][]int)
+	var languages []string
+	for i, quote := range quotes {
+		for target := range quote.Translations {
+			if !slices.Contains(t.targets, target) {
+				delete(quotes[i].Translations, target)
+			}
+		}
+		from := primaryLanguage(quote.Language)
+		if _, ok := byLanguage[from]; !ok {
+			languages = append(languages, from)
+		}
+		byLanguage[from] = append(byLanguage[from], i)
+	}
+
+	for _, from := range languages {
+		for _, target := range t.targets {
+			to := primaryLanguage(target)
+			if to == from {
+				continue
+			}
+			var indexes []int
+			for _, index := range byLanguage[from] {
+				if _, ok := quotes[index].Translations[target]; !ok {
+					indexes = append(indexes, index)
+				}
+			}
+			for start := 0; start < len(indexes); start += translateChunk {
+				chunk := indexes[start:min(start+translateChunk, len(indexes))]
+				texts := make([]string, len(chunk))
+				for i, index := range chunk {
+					texts[i] = quotes[index].Text
+				}
+				translated, err := t.provider.translate(t.source, t.api, t.key, texts, from, to)
+				if err == nil && len(translated) != len(texts) {
+					err = fmt.Errorf("%d translations returned for %d texts", len(translated), len(texts))
+				}
+				if err != nil {
+					return fmt.Errorf("error translating quotes %s to %s into %s: %w",
+						quotes[chunk[0]].key(), quotes[chunk[len(chunk)-1]].key(), target, err)
+				}
+				for i, index := range chunk {
+					if quotes[index].Translations == nil {
+						quotes[index].Translations = make(map[string]string, len(t.targets))
+					}
+					quotes[index].Translations[target] = translated[i]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// translateLibre translates with the /translate endpoint of a LibreTranslate server, which
+// may be self-hosted without a key
+func translateLibre(source *httpSource, api, key string, texts []string, from, to string) ([]string, error) {
+	request := map[string]any{"q": texts, "source": from, "target": to, "format": "text"}
+	if key != "" {
+		request["api_key"] = key
+	}
+	var response struct {
+		TranslatedText []string `json:"translatedText"`
+	}
+	err := source.doJSON("POST", api+"/translate", request, &response)
+	return response.TranslatedText, err
+}
+
+// translateGoogle translates with the v2 API of Google Cloud Translation; the key goes in a
+// header so it can't end up in an error message with the URL
+func translateGoogle(source *httpSource, api, key string, texts []string, from, to string) ([]string, error) {
+	source.headers["X-Goog-Api-Key"] = key
+	request := map[string]any{"q": texts, "source": from, "target": to, "format": "text"}
+	var response struct {
+		Data struct {
+			Translations []struct {
+				TranslatedText string `json:"translatedText"`
+			} `json:"translations"`
+		} `json:"data"`
+	}
+	if err := source.doJSON("POST", api+"/language/translate/v2", request, &response); err != nil {
+		return nil, err
+	}
+	translated := make([]string, len(response.Data.Translations))
+	for i, translation := range response.Data.Translations {
+		translated[i] = translation.TranslatedText
+	}
+	return translated, nil
+}
+
+// translateDeepL translates with the v2 API of DeepL, which takes upper case languages
+func translateDeepL(source *httpSource, api, key string, texts []string, from, to string) ([]string, error) {
+	source.headers["Authorization"] = "DeepL-Auth-Key " + key
+	request := map[string]any{"text": texts, "source_lang": strings.ToUpper(from), "target_lang": strings.ToUpper(to)}
+	var response struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	if err := source.doJSON("POST", api+"/v2/translate", request, &response); err != nil {
+		return nil, err
+	}
+	translated := make([]string, len(response.Translations))
+	for i, translation := range response.Translations {
+		translated[i] = translation.Text
+	}
+	return translated, nil
+}
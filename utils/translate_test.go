@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTranslator serves the translation APIs, translating a text by prefixing it with the
+// target language
+func fakeTranslator(t *testing.T, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		var request struct {
+			Q          []string `json:"q"`
+			Text       []string `json:"text"`
+			Source     string   `json:"source"`
+			Target     string   `json:"target"`
+			SourceLang string   `json:"source_lang"`
+			TargetLang string   `json:"target_lang"`
+			APIKey     string   `json:"api_key"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+		var response any
+		switch r.URL.Path {
+		case "/translate":
+			translated := make([]string, len(request.Q))
+			for i, text := range request.Q {
+				translated[i] = request.Target + ":" + text
+			}
+			response = map[string]any{"translatedText": translated}
+		case "/language/translate/v2":
+			assert.Equal(t, "google-key", r.Header.Get("X-Goog-Api-Key"))
+			var translations []map[string]string
+			for _, text := range request.Q {
+				translations = append(translations, map[string]string{"translatedText": request.Target + ":" + text})
+			}
+			response = map[string]any{"data": map[string]any{"translations": translations}}
+		case "/v2/translate":
+			assert.Equal(t, "DeepL-Auth-Key deepl-key:fx", r.Header.Get("Authorization"))
+			assert.Equal(t, strings.ToUpper(request.SourceLang), request.SourceLang)
+			var translations []map[string]string
+			for _, text := range request.Text {
+				translations = append(translations, map[string]string{"text": strings.ToLower(request.TargetLang) + ":" + text})
+			}
+			response = map[string]any{"translations": translations}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func TestTranslateQuotes(t *testing.T) {
+	requests := 0
+	server := fakeTranslator(t, &requests)
+	defer server.Close()
+	t.Setenv("GOOGLE_TRANSLATE_API_KEY", "google-key")
+	t.Setenv("DEEPL_AUTH_KEY", "deepl-key:fx")
+
+	for _, name := range TranslatorNames() {
+		t.Run(name, func(t *testing.T) {
+			requests = 0
+			opts := DefaultOptions()
+			opts.TranslateTo = []string{"ta", "FR", "en-GB"}
+			opts.Translator = name
+			opts.TranslatorURL = server.URL + "/"
+			translator, err := newQuoteTranslator(opts)
+			require.NoError(t, err)
+
+			quotes := []Quote{
+				{ID: 1, Text: "Be yourself.", Language: "en-US"},
+				{ID: 2, Text: "Rien ne se perd.", Language: "fr"},
+				{ID: 3, Text: "Know thyself.", Language: "en-US"},
+			}
+			require.NoError(t, translator.translateQuotes(quotes))
+			assert.Equal(t, map[string]string{"ta": "ta:Be yourself.", "fr": "fr:Be yourself."}, quotes[0].Translations,
+				"a quote isn't translated into its own language")
+			assert.Equal(t, map[string]string{"ta": "ta:Rien ne se perd.", "en-GB": "en:Rien ne se perd."}, quotes[1].Translations)
+			assert.Equal(t, map[string]string{"ta": "ta:Know thyself.", "fr": "fr:Know thyself."}, quotes[2].Translations)
+			assert.Equal(t, 4, requests, "one request per source and target language")
+		})
+	}
+}
+
+func TestNewQuoteTranslator(t *testing.T) {
+	t.Setenv("GOOGLE_TRANSLATE_API_KEY", "")
+
+	opts := DefaultOptions()
+	translator, err := newQuoteTranslator(opts)
+	require.NoError(t, err)
+	assert.Nil(t, translator, "nothing to translate to")
+
+	tests := []struct {
+		name       string
+		translator string
+		languages  []string
+		err        string
+	}{
+		{"unknown translator", "babelfish", []string{"fr"}, `unknown translator "babelfish"`},
+		{"missing key", "google", []string{"fr"}, "needs an API key in GOOGLE_TRANSLATE_API_KEY"},
+		{"invalid language", "libretranslate", []string{"not a language"}, "invalid --translate-to language"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts.Translator = tt.translator
+			opts.TranslateTo = tt.languages
+			_, err := newQuoteTranslator(opts)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestDeepLAPI(t *testing.T) {
+	opts := DefaultOptions()
+	opts.TranslateTo = []string{"de"}
+	opts.Translator = "deepl"
+	for key, api := range map[string]string{"free:fx": "https://api-free.deepl.com", "pro": "https://api.deepl.com"} {
+		t.Setenv("DEEPL_AUTH_KEY", key)
+		translator, err := newQuoteTranslator(opts)
+		require.NoError(t, err)
+		assert.Equal(t, api, translator.api, key)
+	}
+}
+
+// TestConvertTranslated tests that --translate-to writes the translations of every quote
+func TestConvertTranslated(t *testing.T) {
+	requests := 0
+	server := fakeTranslator(t, &requests)
+	defer server.Close()
+	defer os.Remove("quotesMetadata.json")
+	dir := t.TempDir()
+	input := filepath.Join(dir, "quotes.csv")
+	require.NoError(t, os.WriteFile(input, []byte("Tags,Quote\nlove,Love is patient.\nhope,Hope floats.\n"), 0644))
+
+	opts := DefaultOptions()
+	opts.TranslateTo = []string{"hi"}
+	opts.TranslatorURL = server.URL
+	opts.Output = filepath.Join(dir, "quotes.json")
+	require.NoError(t, ConvertFile(input, opts))
+
+	data, err := ReadQuotesFromJSON(opts.Output)
+	require.NoError(t, err)
+	require.Len(t, data.Quotes, 2)
+	assert.Equal(t, map[string]string{"hi": "hi:Love is patient."}, data.Quotes[0].Translations)
+	assert.Equal(t, map[string]string{"hi": "hi:Hope floats."}, data.Quotes[1].Translations)
+
+	opts.TranslatorURL = server.URL + "/missing"
+	assert.ErrorContains(t, ConvertFile(input, opts), "error translating quotes 1 to 2 into hi")
+}
+
+// TestConvertTranslatedIncremental tests that the quotes reused by --incremental aren't translated again
+func TestConvertTranslatedIncremental(t *testing.T) {
+	requests := 0
+	server := fakeTranslator(t, &requests)
+	defer server.Close()
+	defer os.Remove("quotesMetadata.json")
+	dir := t.TempDir()
+	input := filepath.Join(dir, "quotes.csv")
+	require.NoError(t, os.WriteFile(input, []byte("Tags,Quote\nlove,Love is patient.\nhope,Hope floats.\n"), 0644))
+
+	opts := DefaultOptions()
+	opts.TranslateTo = []string{"hi"}
+	opts.TranslatorURL = server.URL
+	opts.Output = filepath.Join(dir, "quotes.json")
+	opts.Incremental = true
+	require.NoError(t, ConvertFile(input, opts))
+	assert.Equal(t, 1, requests)
+
+	requests = 0
+	require.NoError(t, os.WriteFile(input, []byte("Tags,Quote\nlove,Love is patient.\nhope,Hope sinks.\n"), 0644))
+	require.NoError(t, ConvertFile(input, opts))
+	assert.Equal(t, 1, requests, "only the edited quote is translated")
+	data, err := ReadQuotesFromJSON(opts.Output)
+	require.NoError(t, err)
+	require.Len(t, data.Quotes, 2)
+	assert.Equal(t, map[string]string{"hi": "hi:Love is patient."}, data.Quotes[0].Translations)
+	assert.Equal(t, map[string]string{"hi": "hi:Hope sinks."}, data.Quotes[1].Translations)
+
+	requests = 0
+	opts.TranslateTo = []string{"ta"}
+	require.NoError(t, ConvertFile(input, opts))
+	assert.Equal(t, 1, requests, "the reused quotes are translated into the new language")
+	data, err = ReadQuotesFromJSON(opts.Output)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"ta": "ta:Love is patient."}, data.Quotes[0].Translations,
+		"translations into languages no longer targeted are dropped")
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// cleanText strips invisible characters and applies NFC normalization. Control characters other
+// than line breaks and tabs are dropped, as are format characters such as zero-width spaces,
+// word joiners and byte order marks; zero-width (non-)joiners are kept because Indic scripts and
+// emoji sequences depend on them.
+func cleanText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return r
+		case r == '\u200c' || r == '\u200d':
+			return r
+		case unicode.Is(unicode.Cc, r) || unicode.Is(unicode.Cf, r):
+			return -1
+		}
+		return r
+	}, s)
+	return norm.NFC.String(s)
+}
+
+// cleanQuote applies cleanText to the text, author, context and tags of a quote
+func cleanQuote(quote *Quote) {
+	quote.Text = cleanText(quote.Text)
+	quote.Author = cleanText(quote.Author)
+	quote.Context = cleanText(quote.Context)
+	for i, tag := range quote.Tags {
+		quote.Tags[i] = cleanText(tag)
+	}
+}
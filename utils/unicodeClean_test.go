@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCleanText tests invisible character stripping and NFC normalization
+func TestCleanText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "zero_width_space", input: "zero\u200bwidth", expected: "zerowidth"},
+		{name: "word_joiner_and_bom", input: "\ufeffword\u2060joiner", expected: "wordjoiner"},
+		{name: "control_characters", input: "bell\a and\r\n tab\t", expected: "bell and\n tab\t"},
+		{name: "nfc", input: "Cafe\u0301", expected: "Café"},
+		{name: "tamil_unchanged", input: "அன்பு", expected: "அன்பு"},
+		{name: "zwj_kept", input: "👨\u200d👩", expected: "👨\u200d👩"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, cleanText(tt.input))
+		})
+	}
+}
+
+// TestCleanQuote tests that every text field of a quote is cleaned
+func TestCleanQuote(t *testing.T) {
+	quote := Quote{Text: "a\u200b", Author: "b\u00ad", Context: "c\x00", Tags: []string{"d\ufeff", ""}}
+	cleanQuote(&quote)
+	assert.Equal(t, Quote{Text: "a", Author: "b", Context: "c", Tags: []string{"d", ""}}, quote)
+}
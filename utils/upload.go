@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// uploadFile is an output file of a conversion to upload
+type uploadFile struct {
+	path        string // the local file, uploaded under its base name
+	contentType string
+}
+
+// uploader delivers the output files of a finished conversion somewhere else, such as a bucket.
+// Unlike a QuoteSink it gets the files once they are complete, metadata and checksums included.
+type uploader interface {
+	upload(ctx context.Context, files []uploadFile) error
+	// location is the URL fileName is uploaded to
+	location(fileName string) string
+}
+
+// uploaderOpeners maps a --to scheme to the function opening that kind of uploader; the target
+// is what follows the colon, e.g. //bucket/path/ for s3://bucket/path/
+var uploaderOpeners = map[string]func(target string, opts Options) (uploader, error){
+	"s3":     openS3Uploader,
+	"gs":     openGCSUploader,
+	"azblob": openAzureUploader,
+	"sftp":   openSFTPUploader,
+}
+
+// isUploadSink reports whether the --to value spec names an uploader rather than a QuoteSink
+func isUploadSink(spec string) bool {
+	scheme, _, _ := strings.Cut(spec, ":")
+	_, ok := uploaderOpeners[strings.ToLower(scheme)]
+	return ok
+}
+
+// openUploader opens the uploader described by a --to value of the form scheme://target
+func openUploader(spec string, opts Options) (uploader, error) {
+	scheme, target, _ := strings.Cut(spec, ":")
+	if opts.Output == "-" {
+		return nil, fmt.Errorf("--to %s cannot be combined with --out -, it uploads the output files", spec)
+	}
+	scheme = strings.ToLower(scheme)
+	if !strings.HasPrefix(target, "//") || len(target) == 2 {
+		location := "bucket/path/"
+		if scheme == "sftp" {
+			location = "user@host/path/"
+		}
+		return nil, fmt.Errorf("invalid sink %q, expected %s://%s", spec, scheme, location)
+	}
+	return uploaderOpeners[scheme](target, opts)
+}
+
+// uploadBucketPath splits the target of a bucket uploader, //bucket/path/, into the bucket and
+// the prefix of the uploaded names, ending with a slash unless empty
+func uploadBucketPath(target string) (bucket, prefix string) {
+	bucket, prefix, _ = strings.Cut(strings.TrimPrefix(target, "//"), "/")
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return bucket, prefix
+}
+
+// uploadContentTypes are the content types of the extensions of the outputs, which the MIME
+// tables of the system may lack or disagree on
+var uploadContentTypes = map[string]string{
+	".json":   "application/json",
+	".ndjson": "application/x-ndjson",
+	".txt":    "text/plain; charset=utf-8",
+	".csv":    "text/csv; charset=utf-8",
+	".gz":     "application/gzip",
+	".zst":    "application/zstd",
+	".sig":    "application/octet-stream",
+	".dat":    "application/octet-stream",
+	".age":    "application/octet-stream",
+}
+
+// uploadContentType is the content type of an uploaded file: opts.UploadContentType for the
+// quotes file when set, otherwise the type of its extension
+func uploadContentType(fileName, outputFile string, opts Options) string {
+	if fileName == outputFile && opts.UploadContentType != "" {
+		return opts.UploadContentType
+	}
+	extension := strings.ToLower(filepath.Ext(fileName))
+	if contentType, ok := uploadContentTypes[extension]; ok {
+		return contentType
+	}
+	if contentType := mime.TypeByExtension(extension); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// uploadOutputs uploads the files written by a conversion, the quotes file first, with every
+// uploader in turn
+func uploadOutputs(uploaders []uploader, files []string, outputFile string, opts Options) error {
+	if len(uploaders) == 0 {
+		return nil
+	}
+	uploads := make([]uploadFile, len(files))
+	for i, file := range files {
+		uploads[i] = uploadFile{path: file, contentType: uploadContentType(file, outputFile, opts)}
+	}
+	for _, u := range uploaders {
+		opts, span := startSpan(opts, "upload", attribute.String("upload.uploader", fmt.Sprintf("%T", u)), attribute.Int("upload.files", len(files)))
+		err := u.upload(optsContext(opts), uploads)
+		endSpan(span, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestOpenUploader(t *testing.T) {
+	assert.True(t, isUploadSink("s3://bucket/path/"))
+	assert.True(t, isUploadSink("S3://bucket"))
+	assert.True(t, isUploadSink("gs://bucket/path/"))
+	assert.True(t, isUploadSink("azblob://container/path/"))
+	assert.True(t, isUploadSink("sftp://user@host/path/"))
+	assert.False(t, isUploadSink("sqlite:quotes.db"))
+	assert.Contains(t, SinkSchemes(), "s3")
+
+	opts := DefaultOptions()
+	_, err := openUploader("s3:bucket", opts)
+	assert.ErrorContains(t, err, "expected s3://bucket/path/")
+	_, err = openUploader("s3://", opts)
+	assert.ErrorContains(t, err, "expected s3://bucket/path/")
+
+	opts.Output = "-"
+	_, err = openUploader("s3://bucket", opts)
+	assert.ErrorContains(t, err, "cannot be combined with --out -")
+}
+
+func TestUploadBucketPath(t *testing.T) {
+	tests := []struct {
+		target, bucket, prefix string
+	}{
+		{"//quotes", "quotes", ""},
+		{"//quotes/", "quotes", ""},
+		{"//quotes/site/v1", "quotes", "site/v1/"},
+		{"//quotes/site/v1/", "quotes", "site/v1/"},
+	}
+	for _, tt := range tests {
+		bucket, prefix := uploadBucketPath(tt.target)
+		assert.Equal(t, tt.bucket, bucket, tt.target)
+		assert.Equal(t, tt.prefix, prefix, tt.target)
+	}
+}
+
+func TestUploadContentType(t *testing.T) {
+	opts := DefaultOptions()
+	assert.Equal(t, "application/json", uploadContentType("quotes.json", "quotes.json", opts))
+	assert.Equal(t, "application/octet-stream", uploadContentType("quotes.json.sig", "quotes.json", opts))
+	assert.Equal(t, "text/plain; charset=utf-8", uploadContentType("checksums.txt", "quotes.json", opts))
+	assert.Equal(t, "application/gzip", uploadContentType("quotes.json.gz", "quotes.json.gz", opts))
+	assert.Equal(t, "application/octet-stream", uploadContentType("quotes.unknown-extension", "quotes.json", opts))
+
+	opts.UploadContentType = "application/vnd.quotes+json"
+	assert.Equal(t, "application/vnd.quotes+json", uploadContentType("quotes.json", "quotes.json", opts))
+	assert.Equal(t, "application/json", uploadContentType("quotesMetadata.json", "quotes.json", opts), "only the quotes file")
+}
+
+func TestWrittenFiles(t *testing.T) {
+	opts := DefaultOptions()
+	assert.Equal(t, []string{"quotes.json", "quotesMetadata.json"}, writtenFiles("quotes.json", "quotesMetadata.json", false, opts))
+
+	opts.Strfile, opts.TagIndex, opts.Checksums, opts.EmbedMetadata = true, true, true, true
+	assert.Equal(t, []string{"quotes.txt", "quotes.txt.dat", "quotes.txt.sig", tagIndexFile, checksumFile}, writtenFiles("quotes.txt", "quotesMetadata.json", true, opts))
+}
+
+func TestUploaderLocation(t *testing.T) {
+	s3 := &s3Uploader{bucket: "quotes", prefix: "site/"}
+	assert.Equal(t, "s3://quotes/site/quotes.json", s3.location("/tmp/out/quotes.json"))
+	gcs := &gcsUploader{bucket: "quotes"}
+	assert.Equal(t, "gs://quotes/quotes.json", gcs.location("quotes.json"))
+
+	sftp := &sftpUploader{address: "cms:22", config: &ssh.ClientConfig{User: "quotes"}, dir: "/drop"}
+	assert.Equal(t, "sftp://quotes@cms:22/drop/quotes.json", sftp.location("quotes.json"))
+	sftp.dir = "drop"
+	assert.Equal(t, "sftp://quotes@cms:22/~/drop/quotes.json", sftp.location("quotes.json"))
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationRules are the checks every quote has to pass; the zero value checks nothing
+type ValidationRules struct {
+	MinLength       int    // minimum number of characters in the text
+	MaxLength       int    // maximum number of characters in the text
+	RequireAuthor   bool   // the author must not be empty
+	RequireTags     bool   // at least one tag must not be empty
+	DisallowedChars string // characters that must not appear in the text
+	Strict          bool   // fail the conversion if any row fails validation
+}
+
+// validate returns the rules quote breaks, in a readable form
+func (r ValidationRules) validate(quote Quote) []string {
+	var failures []string
+	length := utf8.RuneCountInString(quote.Text)
+	if r.MinLength > 0 && length < r.MinLength {
+		failures = append(failures, fmt.Sprintf("text is %d characters, shorter than %d", length, r.MinLength))
+	}
+	if r.MaxLength > 0 && length > r.MaxLength {
+		failures = append(failures, fmt.Sprintf("text is %d characters, longer than %d", length, r.MaxLength))
+	}
+	if r.RequireAuthor && strings.TrimSpace(quote.Author) == "" {
+		failures = append(failures, "author is missing")
+	}
+	if r.RequireTags && len(nonEmptyTags(quote.Tags)) == 0 {
+		failures = append(failures, "tags are missing")
+	}
+	if r.DisallowedChars != "" {
+		if i := strings.IndexAny(quote.Text, r.DisallowedChars); i >= 0 {
+			c, _ := utf8.DecodeRuneInString(quote.Text[i:])
+			failures = append(failures, fmt.Sprintf("text contains disallowed character %q", c))
+		}
+	}
+	return failures
+}
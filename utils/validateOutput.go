@@ -0,0 +1,301 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// OutputProblem is something wrong with a quotes or metadata file found by ValidateOutput
+type OutputProblem struct {
+	File    string
+	Path    string // location of the offending value, such as quotes[3].id; empty for the whole file
+	Message string
+}
+
+func (p OutputProblem) String() string {
+	if p.Path == "" {
+		return p.File + ": " + p.Message
+	}
+	return p.File + ": " + p.Path + ": " + p.Message
+}
+
+// ValidateOutput checks a json format quotes file written by a conversion: its structure
+// against the JSON Schema, that IDs are unique, texts non-empty and language tags valid, and
+// that the metadata agrees with the quotes. The metadata is taken from the quotes file when it
+// was written with --embed-metadata and from metadataFile otherwise; an empty metadataFile
+// skips those checks. The error is only set when a file can't be read at all.
+func ValidateOutput(quotesFile, metadataFile string) ([]OutputProblem, error) {
+	content, err := os.ReadFile(quotesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON file %s: %w", quotesFile, err)
+	}
+	var problems []OutputProblem
+	report := func(file string) func(path, format string, args ...any) {
+		return func(path, format string, args ...any) {
+			problems = append(problems, OutputProblem{File: file, Path: path, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+	quotesProblem := report(quotesFile)
+
+	document, err := decodeSchemaValue(content)
+	if err != nil {
+		quotesProblem("", "invalid JSON: %v", err)
+		return problems, nil
+	}
+	root := "quotes"
+	if object, ok := document.(map[string]any); ok && object["metadata"] != nil {
+		root = "combined"
+	}
+	if err := validateAgainstSchema(root, document, quotesProblem); err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Metadata *Metadata `json:"metadata"`
+		Quotes   []Quote   `json:"quotes"`
+	}
+	if err := json.Unmarshal(content, &data); err != nil {
+		// The schema problems already say what's wrong
+		return problems, nil
+	}
+
+	ids := make(map[string]int)
+	for i, quote := range data.Quotes {
+		path := fmt.Sprintf("quotes[%d]", i)
+		if first, ok := ids[quote.key()]; ok {
+			quotesProblem(path+".id", "duplicate ID %s, also used by quotes[%d]", quote.key(), first)
+		} else {
+			ids[quote.key()] = i
+		}
+		if strings.TrimSpace(quote.Text) == "" {
+			quotesProblem(path+".text", "text is empty")
+		}
+		if _, err := NormalizeLanguageTag(quote.Language); err != nil {
+			quotesProblem(path+".lang", "invalid language tag %q", quote.Language)
+		}
+	}
+
+	metadata, metadataProblem := data.Metadata, quotesProblem
+	if metadata == nil && metadataFile != "" {
+		metadataContent, err := os.ReadFile(metadataFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read metadata file %s: %w", metadataFile, err)
+		}
+		metadataProblem = report(metadataFile)
+		value, err := decodeSchemaValue(metadataContent)
+		if err != nil {
+			metadataProblem("", "invalid JSON: %v", err)
+			return problems, nil
+		}
+		if err := validateAgainstSchema("metadata", value, metadataProblem); err != nil {
+			return nil, err
+		}
+		metadata = &Metadata{}
+		if err := json.Unmarshal(metadataContent, metadata); err != nil {
+			return problems, nil
+		}
+		if metadata.SHA256 != "" {
+			digest, err := fileSHA256(quotesFile)
+			if err != nil {
+				return nil, err
+			}
+			if digest != metadata.SHA256 {
+				metadataProblem("sha256", "digest %s doesn't match %s, whose digest is %s", metadata.SHA256, quotesFile, digest)
+			}
+		}
+	}
+	if metadata != nil {
+		path := "totalQuotes"
+		if data.Metadata != nil {
+			path = "metadata.totalQuotes"
+		}
+		if metadata.TotalQuotes != len(data.Quotes) {
+			metadataProblem(path, "totalQuotes is %d but %s holds %d quotes", metadata.TotalQuotes, quotesFile, len(data.Quotes))
+		}
+	}
+	return problems, nil
+}
+
+// decodeSchemaValue decodes a JSON document into maps and slices, keeping numbers as
+// json.Number so integers can be told from other numbers
+func decodeSchemaValue(content []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after the top-level value")
+	}
+	return value, nil
+}
+
+// validateAgainstSchema checks value against the JSONSchema document for root
+func validateAgainstSchema(root string, value any, problem func(path, format string, args ...any)) error {
+	data, err := JSONSchema(root)
+	if err != nil {
+		return err
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return err
+	}
+	defs, _ := schema["$defs"].(map[string]any)
+	validator := schemaValidator{defs: defs}
+	for _, failure := range validator.check(schema, value, "") {
+		problem(failure.path, "%s", failure.message)
+	}
+	return nil
+}
+
+// schemaFailure is a value breaking a schema
+type schemaFailure struct {
+	path    string
+	message string
+}
+
+// schemaValidator checks values against the subset of JSON Schema written by JSONSchema
+type schemaValidator struct {
+	defs map[string]any
+}
+
+// check returns how value, found at path, breaks schema
+func (v schemaValidator) check(schema map[string]any, value any, path string) []schemaFailure {
+	fail := func(format string, args ...any) []schemaFailure {
+		return []schemaFailure{{path: path, message: fmt.Sprintf(format, args...)}}
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		def, ok := v.defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+		if !ok {
+			return fail("schema refers to unknown definition %s", ref)
+		}
+		return v.check(def, value, path)
+	}
+	if options, ok := schema["oneOf"].([]any); ok {
+		matches := 0
+		for _, option := range options {
+			if option, ok := option.(map[string]any); ok && len(v.check(option, value, path)) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return fail("%s matches %d of the %d allowed forms", describeJSONValue(value), matches, len(options))
+		}
+		return nil
+	}
+
+	kind, _ := schema["type"].(string)
+	if kind != "" && !jsonValueHasType(value, kind) {
+		return fail("expected %s, found %s", kind, describeJSONValue(value))
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			found = found || allowed == value
+		}
+		if !found {
+			return fail("%s is not one of %v", describeJSONValue(value), enum)
+		}
+	}
+	if number, ok := value.(json.Number); ok {
+		n, _ := number.Float64()
+		if minimum, ok := schema["minimum"].(float64); ok && n < minimum {
+			return fail("%s is below the minimum %v", number, minimum)
+		}
+		if maximum, ok := schema["maximum"].(float64); ok && n > maximum {
+			return fail("%s is above the maximum %v", number, maximum)
+		}
+	}
+	if schema["format"] == "uuid" {
+		if _, err := uuid.Parse(value.(string)); err != nil {
+			return fail("%q is not a UUID", value)
+		}
+	}
+
+	var failures []schemaFailure
+	switch value := value.(type) {
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range value {
+				failures = append(failures, v.check(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case map[string]any:
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := value[name.(string)]; !ok {
+				failures = append(failures, schemaFailure{path: path, message: fmt.Sprintf("missing required field %q", name)})
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		additional, _ := schema["additionalProperties"].(map[string]any)
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := properties[name].(map[string]any)
+			if !ok {
+				property = additional
+			}
+			if property != nil {
+				failures = append(failures, v.check(property, value[name], joinJSONPath(path, name))...)
+			}
+		}
+	}
+	return failures
+}
+
+// jsonValueHasType reports whether a value decoded by decodeSchemaValue is of the JSON Schema type kind
+func jsonValueHasType(value any, kind string) bool {
+	switch value := value.(type) {
+	case string:
+		return kind == "string"
+	case bool:
+		return kind == "boolean"
+	case json.Number:
+		if kind == "number" {
+			return true
+		}
+		_, err := value.Int64()
+		return kind == "integer" && err == nil
+	case []any:
+		return kind == "array"
+	case map[string]any:
+		return kind == "object"
+	}
+	return false
+}
+
+// describeJSONValue names the JSON type of value for problem messages
+func describeJSONValue(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("string %q", value)
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number " + value.String()
+	case []any:
+		return "array"
+	}
+	return "object"
+}
+
+// joinJSONPath appends a field name to a path such as quotes[3]
+func joinJSONPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
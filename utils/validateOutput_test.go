@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// problemStrings formats problems for comparison
+func problemStrings(problems []OutputProblem) []string {
+	lines := make([]string, len(problems))
+	for i, problem := range problems {
+		lines[i] = problem.String()
+	}
+	return lines
+}
+
+// TestValidateOutput tests validating a conversion and its metadata
+func TestValidateOutput(t *testing.T) {
+	f, _ := createTestExcelFile(t)
+	defer os.Remove("quotes.json")
+	defer os.Remove("quotesMetadata.json")
+	opts := DefaultOptions()
+	opts.Checksums = true
+	defer os.Remove(checksumFile)
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+
+	problems, err := ValidateOutput("quotes.json", "quotesMetadata.json")
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	// Tampering with the quotes breaks the digest
+	require.NoError(t, WriteJSONToFile("quotes.json", QuotesData{Quotes: []Quote{{ID: 1, Text: "a", Tags: []string{""}, Language: "en-US"}}}))
+	problems, err = ValidateOutput("quotes.json", "quotesMetadata.json")
+	require.NoError(t, err)
+	require.Len(t, problems, 2)
+	assert.Equal(t, "sha256", problems[0].Path)
+	assert.Equal(t, "quotesMetadata.json: totalQuotes: totalQuotes is 3 but quotes.json holds 1 quotes", problems[1].String())
+
+	_, err = ValidateOutput("quotes.json", "missing.json")
+	assert.Error(t, err)
+	_, err = ValidateOutput("missing.json", "")
+	assert.Error(t, err)
+}
+
+// TestValidateOutputProblems tests the problems found in broken quotes
+func TestValidateOutputProblems(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "quotes.json")
+	require.NoError(t, os.WriteFile(name, []byte(`{"quotes": [
+		{"id": 1, "text": "Fine.", "tags": ["a"], "lang": "en-US"},
+		{"id": 1, "text": "  ", "tags": ["a"], "lang": "not a tag"},
+		{"id": 2.5, "text": "Half.", "tags": null, "lang": "en"},
+		{"id": "nope", "text": "Bad id.", "tags": [], "lang": "en", "sentiment": {"label": "happy", "score": 3}},
+		{"text": 7, "tags": [1]}
+	]}`), 0644))
+	problems, err := ValidateOutput(name, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		name + `: quotes[2].id: number 2.5 matches 0 of the 2 allowed forms`,
+		name + `: quotes[2].tags: expected array, found null`,
+		name + `: quotes[3].id: string "nope" matches 0 of the 2 allowed forms`,
+		name + `: quotes[3].sentiment.label: string "happy" is not one of [positive neutral negative]`,
+		name + `: quotes[3].sentiment.score: 3 is above the maximum 1`,
+		name + `: quotes[4]: missing required field "id"`,
+		name + `: quotes[4]: missing required field "lang"`,
+		name + `: quotes[4].tags[0]: expected string, found number 1`,
+		name + `: quotes[4].text: expected string, found number 7`,
+	}, problemStrings(problems))
+
+	// Once the structure is right, the contents are checked
+	require.NoError(t, os.WriteFile(name, []byte(`{"quotes": [
+		{"id": 1, "text": "Fine.", "tags": ["a"], "lang": "en-US"},
+		{"id": 1, "text": "  ", "tags": ["a"], "lang": "not a tag"}
+	]}`), 0644))
+	problems, err = ValidateOutput(name, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		name + `: quotes[1].id: duplicate ID 1, also used by quotes[0]`,
+		name + `: quotes[1].text: text is empty`,
+		name + `: quotes[1].lang: invalid language tag "not a tag"`,
+	}, problemStrings(problems))
+
+	require.NoError(t, os.WriteFile(name, []byte(`{"quotes": [`), 0644))
+	problems, err = ValidateOutput(name, "")
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	assert.Contains(t, problems[0].Message, "invalid JSON")
+}
+
+// TestValidateOutputEmbedded tests that embedded metadata is checked instead of the metadata file
+func TestValidateOutputEmbedded(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "quotes.json")
+	opts := DefaultOptions()
+	require.NoError(t, WriteJSONToFileWithOptions(name, QuotesData{Quotes: []Quote{{ID: 1, Text: "a", Tags: []string{""}, Language: "en-US"}}}, opts))
+	require.NoError(t, embedMetadata(name, newMetadata(opts, 2), opts))
+
+	problems, err := ValidateOutput(name, "ignored.json")
+	require.NoError(t, err)
+	assert.Equal(t, []string{name + ": metadata.totalQuotes: totalQuotes is 2 but " + name + " holds 1 quotes"}, problemStrings(problems))
+}
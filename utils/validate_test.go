@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidationRules tests each rule on its own and the zero value accepting everything
+func TestValidationRules(t *testing.T) {
+	quote := Quote{Text: "Short <b>quote</b>", Tags: []string{""}}
+
+	tests := []struct {
+		name     string
+		rules    ValidationRules
+		expected []string
+	}{
+		{name: "none", rules: ValidationRules{}},
+		{name: "min_length", rules: ValidationRules{MinLength: 20}, expected: []string{"text is 18 characters, shorter than 20"}},
+		{name: "max_length", rules: ValidationRules{MaxLength: 10}, expected: []string{"text is 18 characters, longer than 10"}},
+		{name: "within_length", rules: ValidationRules{MinLength: 18, MaxLength: 18}},
+		{name: "require_author", rules: ValidationRules{RequireAuthor: true}, expected: []string{"author is missing"}},
+		{name: "require_tags", rules: ValidationRules{RequireTags: true}, expected: []string{"tags are missing"}},
+		{name: "disallowed_chars", rules: ValidationRules{DisallowedChars: "<>"}, expected: []string{`text contains disallowed character '<'`}},
+		{name: "several", rules: ValidationRules{RequireAuthor: true, RequireTags: true}, expected: []string{"author is missing", "tags are missing"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.rules.validate(quote))
+		})
+	}
+}
+
+// TestValidationRulesUnicodeLength tests that lengths count characters rather than bytes
+func TestValidationRulesUnicodeLength(t *testing.T) {
+	rules := ValidationRules{MaxLength: 5}
+	assert.Empty(t, rules.validate(Quote{Text: "அன்பு"}))
+}
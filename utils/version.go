@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// initialVersion is the Metadata.Version of a dataset converted for the first time
+const initialVersion = "1.0"
+
+// VersionBump selects how Metadata.Version changes from the previous metadata
+type VersionBump string
+
+const (
+	// BumpAuto picks the bump from what changed since the previous dataset
+	BumpAuto VersionBump = "auto"
+	// BumpNone keeps the previous version
+	BumpNone  VersionBump = "none"
+	BumpPatch VersionBump = "patch"
+	BumpMinor VersionBump = "minor"
+	BumpMajor VersionBump = "major"
+)
+
+// String implements flag.Value
+func (b *VersionBump) String() string {
+	return string(*b)
+}
+
+// Set implements flag.Value, accepting only the supported bumps
+func (b *VersionBump) Set(name string) error {
+	switch bump := VersionBump(strings.ToLower(name)); bump {
+	case BumpAuto, BumpNone, BumpPatch, BumpMinor, BumpMajor:
+		*b = bump
+		return nil
+	}
+	return fmt.Errorf("unknown version bump %q (supported: auto, none, patch, minor, major)", name)
+}
+
+// parseVersion splits a MAJOR[.MINOR[.PATCH]] version, with an optional leading v, into its parts
+func parseVersion(version string) ([3]int, error) {
+	var parts [3]int
+	fields := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(fields) > len(parts) {
+		return parts, fmt.Errorf("invalid version %q, expected MAJOR.MINOR.PATCH", version)
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, fmt.Errorf("invalid version %q, expected MAJOR.MINOR.PATCH", version)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
+
+// bumpVersion returns previous bumped by bump. Bumped versions are always written in full, so
+// "1.0" becomes "1.0.1" after a patch. BumpAuto must have been resolved by the caller.
+func bumpVersion(previous string, bump VersionBump) (string, error) {
+	parts, err := parseVersion(previous)
+	if err != nil {
+		return "", err
+	}
+	switch bump {
+	case BumpNone:
+		return previous, nil
+	case BumpPatch:
+		parts[2]++
+	case BumpMinor:
+		parts = [3]int{parts[0], parts[1] + 1, 0}
+	case BumpMajor:
+		parts = [3]int{parts[0] + 1, 0, 0}
+	default:
+		return "", fmt.Errorf("unknown version bump %q", bump)
+	}
+	return fmt.Sprintf("%d.%d.%d", parts[0], parts[1], parts[2]), nil
+}
+
+// changeBump picks the bump for the changes in diff: removing quotes breaks consumers that
+// reference them, adding quotes is a new feature and editing them is a fix
+func changeBump(diff QuotesDiff) VersionBump {
+	switch {
+	case len(diff.Removed) > 0:
+		return BumpMajor
+	case len(diff.Added) > 0:
+		return BumpMinor
+	case len(diff.Modified) > 0:
+		return BumpPatch
+	}
+	return BumpNone
+}
+
+// versionDiff compares two datasets for the next version. Quotes are matched by text, so a
+// moved quote isn't a change, then an added quote with the ID of a removed one is an edit of
+// it, such as a fixed typo, rather than a removal.
+func versionDiff(old, new []Quote) (QuotesDiff, error) {
+	diff, err := DiffQuotes(old, new, MergeByText)
+	if err != nil {
+		return diff, err
+	}
+	removed := make(map[string]int, len(diff.Removed))
+	for i, quote := range diff.Removed {
+		removed[quote.key()] = i
+	}
+	paired := make(map[int]bool)
+	added := []Quote{}
+	for _, quote := range diff.Added {
+		i, ok := removed[quote.key()]
+		if !ok || paired[i] {
+			added = append(added, quote)
+			continue
+		}
+		paired[i] = true
+		fields, err := changedFields(diff.Removed[i], quote)
+		if err != nil {
+			return diff, err
+		}
+		diff.Modified = append(diff.Modified, QuoteChange{Old: diff.Removed[i], New: quote, Fields: fields})
+	}
+	remaining := []Quote{}
+	for i, quote := range diff.Removed {
+		if !paired[i] {
+			remaining = append(remaining, quote)
+		}
+	}
+	diff.Added, diff.Removed = added, remaining
+	return diff, nil
+}
+
+// nextVersion returns the version following previous, the metadata of the last conversion or
+// nil if there was none. Under BumpAuto, which an empty bump also means, a change of schema is
+// a major bump and otherwise diff decides; a nil diff means the changes are unknown and only
+// warrant a patch.
+func nextVersion(previous *Metadata, current Metadata, bump VersionBump, diff *QuotesDiff) (string, error) {
+	if previous == nil {
+		return initialVersion, nil
+	}
+	if bump == BumpAuto || bump == "" {
+		switch {
+		case previous.Schema != current.Schema:
+			bump = BumpMajor
+		case diff != nil:
+			bump = changeBump(*diff)
+		default:
+			bump = BumpPatch
+		}
+	}
+	return bumpVersion(previous.Version, bump)
+}
+
+// readPreviousMetadata loads the metadata file left by the last conversion, compressed with
+// compression, or returns nil if there is none
+func readPreviousMetadata(filename string, compression Compression) (*Metadata, error) {
+	content, err := compression.readFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata file %s: %w", filename, err)
+	}
+	var metadata Metadata
+	if err := json.Unmarshal(content, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata file %s: %w", filename, err)
+	}
+	if metadata.Version == "" {
+		metadata.Version = initialVersion
+	}
+	return &metadata, nil
+}
+
+// readQuotesFile loads the quotes of a json format output file compressed with compression
+func readQuotesFile(filename string, compression Compression) ([]Quote, error) {
+	content, err := compression.readFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON file %s: %w", filename, err)
+	}
+	var data QuotesData
+	if err := json.Unmarshal(content, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON file %s: %w", filename, err)
+	}
+	return data.Quotes, nil
+}
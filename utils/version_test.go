@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+// TestVersionBumpSet tests parsing of --bump values
+func TestVersionBumpSet(t *testing.T) {
+	var b VersionBump
+	require.NoError(t, b.Set("MINOR"))
+	assert.Equal(t, BumpMinor, b)
+	assert.Error(t, b.Set("huge"))
+}
+
+// TestBumpVersion tests bumping versions of one to three parts
+func TestBumpVersion(t *testing.T) {
+	tests := []struct {
+		previous string
+		bump     VersionBump
+		want     string
+	}{
+		{"1.0", BumpPatch, "1.0.1"},
+		{"1.0", BumpMinor, "1.1.0"},
+		{"1.0", BumpMajor, "2.0.0"},
+		{"1.0", BumpNone, "1.0"},
+		{"v2.3.4", BumpPatch, "2.3.5"},
+		{"2.3.4", BumpMinor, "2.4.0"},
+		{"3", BumpMajor, "4.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.previous+"-"+string(tt.bump), func(t *testing.T) {
+			version, err := bumpVersion(tt.previous, tt.bump)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, version)
+		})
+	}
+
+	for _, invalid := range []string{"", "1.x", "1.2.3.4", "1.-1"} {
+		_, err := bumpVersion(invalid, BumpPatch)
+		assert.Error(t, err, invalid)
+	}
+}
+
+// TestNextVersion tests how BumpAuto picks the bump
+func TestNextVersion(t *testing.T) {
+	previous := newMetadata(DefaultOptions(), 2)
+	previous.Version = "1.2.3"
+	current := newMetadata(DefaultOptions(), 2)
+
+	version, err := nextVersion(nil, current, BumpAuto, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "1.0", version)
+
+	tests := []struct {
+		name string
+		diff *QuotesDiff
+		want string
+	}{
+		{"unknown", nil, "1.2.4"},
+		{"unchanged", &QuotesDiff{}, "1.2.3"},
+		{"modified", &QuotesDiff{Modified: []QuoteChange{{}}}, "1.2.4"},
+		{"added", &QuotesDiff{Added: []Quote{{}}, Modified: []QuoteChange{{}}}, "1.3.0"},
+		{"removed", &QuotesDiff{Added: []Quote{{}}, Removed: []Quote{{}}}, "2.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := nextVersion(&previous, current, BumpAuto, tt.diff)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, version)
+		})
+	}
+
+	// A different schema breaks consumers whatever the quotes
+	opts := DefaultOptions()
+	opts.Format = StreamNDJSON
+	version, err = nextVersion(&previous, newMetadata(opts, 2), BumpAuto, &QuotesDiff{})
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", version)
+
+	version, err = nextVersion(&previous, current, BumpMinor, &QuotesDiff{})
+	require.NoError(t, err)
+	assert.Equal(t, "1.3.0", version)
+}
+
+// TestReadExcelFileVersion tests that repeated conversions bump the metadata version
+func TestReadExcelFileVersion(t *testing.T) {
+	f, _ := createTestExcelFile(t)
+	os.Remove("quotesMetadata.json")
+	defer os.Remove("quotes.json")
+	defer os.Remove("quotesMetadata.json")
+
+	version := func() string {
+		metadata, err := ReadMetadataFromJSON("quotesMetadata.json")
+		require.NoError(t, err)
+		return metadata.Version
+	}
+	opts := DefaultOptions()
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	assert.Equal(t, "1.0", version())
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	assert.Equal(t, "1.0", version())
+
+	require.NoError(t, f.SetCellValue("Sheet1", "B5", "Test quote 4"))
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	assert.Equal(t, "1.1.0", version())
+
+	require.NoError(t, f.SetCellValue("Sheet1", "A5", "new"))
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	assert.Equal(t, "1.1.1", version())
+
+	// The quote keeps its ID, so fixing its text is an edit rather than a removal
+	require.NoError(t, f.SetCellValue("Sheet1", "B5", "Test quote four"))
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	assert.Equal(t, "1.1.2", version())
+
+	require.NoError(t, f.RemoveRow("Sheet1", 5))
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	assert.Equal(t, "2.0.0", version())
+
+	opts.VersionBump = BumpMinor
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	assert.Equal(t, "2.1.0", version())
+
+	// A fixed version replaces the bump
+	opts.MetadataVersion = "5.0.0"
+	require.NoError(t, ReadExcelFileWithOptions(f, opts))
+	assert.Equal(t, "5.0.0", version())
+}
+
+// TestMergeFileVersion tests that merging only updated quotes is a patch
+func TestMergeFileVersion(t *testing.T) {
+	dir := t.TempDir()
+	into := filepath.Join(dir, "quotes.json")
+	metadataFile := filepath.Join(dir, "quotesMetadata.json")
+	excelFile := filepath.Join(dir, "new.xlsx")
+	f := excelize.NewFile()
+	require.NoError(t, f.SetSheetRow("Sheet1", "A1", &[]string{"Tags", "Quote"}))
+	require.NoError(t, f.SetSheetRow("Sheet1", "A2", &[]string{"a", "Same quote"}))
+	require.NoError(t, f.SaveAs(excelFile))
+	require.NoError(t, f.Close())
+
+	require.NoError(t, WriteJSONToFile(into, QuotesData{Quotes: []Quote{{ID: 1, Text: "Same quote", Tags: []string{"b"}, Language: "en-US"}}}))
+	require.NoError(t, writeMetadataFile(metadataFile, Metadata{Version: "1.0"}, CompressNone))
+	_, err := MergeFile(excelFile, into, metadataFile, MergeByText, DefaultOptions())
+	require.NoError(t, err)
+	metadata, err := ReadMetadataFromJSON(metadataFile)
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.1", metadata.Version)
+}
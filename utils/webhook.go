@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// WebhookPayload is the JSON body POSTed to the --webhook URLs when a conversion finishes
+type WebhookPayload struct {
+	Event           string        `json:"event"` // conversion.succeeded or conversion.failed
+	Input           string        `json:"input"`
+	Rows            int           `json:"rows"`    // rows read, header excluded
+	Quotes          int           `json:"quotes"`  // quotes written
+	Skipped         int           `json:"skipped"` // rows skipped, blank or failing validation
+	Version         string        `json:"version,omitempty"`
+	URL             string        `json:"url,omitempty"` // where the dataset is published, from the metadata
+	Files           []WebhookFile `json:"files,omitempty"`
+	Error           string        `json:"error,omitempty"`
+	StartedAt       string        `json:"startedAt"`
+	FinishedAt      string        `json:"finishedAt"`
+	DurationSeconds float64       `json:"durationSeconds"`
+}
+
+// WebhookFile is an output file of a conversion, with where --to uploaded it
+type WebhookFile struct {
+	Name   string   `json:"name"`
+	SHA256 string   `json:"sha256"`
+	URLs   []string `json:"urls,omitempty"`
+}
+
+// webhookReport collects the WebhookPayload of a conversion while it runs
+type webhookReport struct {
+	payload WebhookPayload
+	start   time.Time
+}
+
+// trackWebhook starts the report of the conversion of fileName run with opts, chaining its
+// Progress to count the rows and quotes
+func trackWebhook(fileName string, opts *Options) *webhookReport {
+	report := &webhookReport{payload: WebhookPayload{Input: fileName}, start: time.Now()}
+	report.payload.StartedAt = report.start.Format(time.RFC3339)
+	progress := opts.Progress
+	opts.Progress = func(rows, quotes int) {
+		report.payload.Rows, report.payload.Quotes = rows, quotes
+		if progress != nil {
+			progress(rows, quotes)
+		}
+	}
+	opts.webhook = report
+	return report
+}
+
+// outputs records the files written by a successful conversion, the metadata they were
+// published with and the uploaders that delivered them
+func (r *webhookReport) outputs(files []string, metadata Metadata, uploaders []uploader) error {
+	r.payload.Version = metadata.Version
+	r.payload.URL = metadata.URL
+	for _, file := range files {
+		sum, err := fileSHA256(file)
+		if err != nil {
+			return err
+		}
+		output := WebhookFile{Name: filepath.Base(file), SHA256: sum}
+		for _, u := range uploaders {
+			output.URLs = append(output.URLs, u.location(file))
+		}
+		r.payload.Files = append(r.payload.Files, output)
+	}
+	return nil
+}
+
+// notify POSTs the payload of the finished conversion, which failed with err if not nil, to
+// every URL of opts.Webhooks, returning the errors of those that failed
+func (r *webhookReport) notify(err error, opts Options) error {
+	r.payload.Event = "conversion.succeeded"
+	if err != nil {
+		r.payload.Event = "conversion.failed"
+		r.payload.Error = err.Error()
+	}
+	r.payload.Skipped = max(0, r.payload.Rows-r.payload.Quotes)
+	r.payload.FinishedAt = time.Now().Format(time.RFC3339)
+	r.payload.DurationSeconds = durationSeconds(r.start)
+	body, marshalErr := json.Marshal(r.payload)
+	if marshalErr != nil {
+		return fmt.Errorf("error marshalling webhook payload: %w", marshalErr)
+	}
+	// A failing webhook doesn't keep the others from being notified
+	var errs []error
+	for _, url := range opts.Webhooks {
+		errs = append(errs, postWebhook(optsContext(opts), url, body, opts.WebhookSecret))
+	}
+	return errors.Join(errs...)
+}
+
+// postWebhook POSTs body to url, retrying network errors, 429 Too Many Requests and server
+// errors. With a secret, the X-Signature-256 header carries the HMAC-SHA256 of the body as
+// sha256=<hex>, for the receiver to check the notification comes from us.
+func postWebhook(ctx context.Context, url string, body []byte, secret string) error {
+	client := &http.Client{Timeout: 30 * time.Second}
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("invalid webhook %s: %w", url, err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "toJson/"+generatorInfo().Version+" (webhook)")
+		if secret != "" {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write(body)
+			req.Header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		}
+		resp, err := client.Do(req)
+		retry := err != nil
+		if err == nil {
+			content, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+			resp.Body.Close()
+			if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+				return nil
+			}
+			retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+			err = fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(content)))
+		}
+		if !retry || attempt >= httpRetries {
+			return fmt.Errorf("error notifying webhook %s: %w", url, err)
+		}
+		time.Sleep(time.Duration(1<<attempt) * webhookRetryDelay)
+	}
+}
+
+// webhookRetryDelay is the delay before the first retry of a webhook, doubled for each next one
+var webhookRetryDelay = time.Second
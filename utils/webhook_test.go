@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookReceiver records the payloads POSTed to it and their signatures
+func webhookReceiver(t *testing.T) (*httptest.Server, *[]WebhookPayload, *[]string) {
+	var payloads []WebhookPayload
+	var signatures []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WebhookPayload
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		payloads = append(payloads, payload)
+		signatures = append(signatures, r.Header.Get("X-Signature-256"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &payloads, &signatures
+}
+
+func TestConvertFileWebhook(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	defer os.Remove(checksumFile)
+	server, payloads, signatures := webhookReceiver(t)
+	input := filepath.Join(t.TempDir(), "quotes.csv")
+	require.NoError(t, os.WriteFile(input, []byte("Tags,Quote,Author\nwisdom,Know thyself.,Socrates\nwisdom\nlife,Be yourself.,Oscar Wilde\n"), 0644))
+
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(t.TempDir(), "quotes.json")
+	opts.Checksums = true
+	opts.Webhooks = []string{server.URL}
+	require.NoError(t, ConvertFile(input, opts))
+
+	require.Len(t, *payloads, 1)
+	payload := (*payloads)[0]
+	assert.Equal(t, "conversion.succeeded", payload.Event)
+	assert.Equal(t, input, payload.Input)
+	assert.Equal(t, 3, payload.Rows)
+	assert.Equal(t, 2, payload.Quotes)
+	assert.Equal(t, 1, payload.Skipped)
+	assert.Equal(t, initialVersion, payload.Version)
+	assert.Empty(t, payload.Error)
+	assert.Empty(t, (*signatures)[0], "unsigned without a secret")
+	require.Len(t, payload.Files, 3)
+	assert.Equal(t, []string{"quotes.json", "quotesMetadata.json", checksumFile}, []string{payload.Files[0].Name, payload.Files[1].Name, payload.Files[2].Name})
+	sum, err := fileSHA256(opts.Output)
+	require.NoError(t, err)
+	assert.Equal(t, sum, payload.Files[0].SHA256)
+	_, err = time.Parse(time.RFC3339, payload.FinishedAt)
+	assert.NoError(t, err)
+}
+
+func TestConvertFileWebhookFailure(t *testing.T) {
+	server, payloads, signatures := webhookReceiver(t)
+	opts := DefaultOptions()
+	opts.Webhooks = []string{server.URL, server.URL}
+	opts.WebhookSecret = "shared secret"
+	err := ConvertFile(filepath.Join(t.TempDir(), "missing.csv"), opts)
+	require.Error(t, err)
+
+	require.Len(t, *payloads, 2, "every webhook is notified")
+	assert.Equal(t, "conversion.failed", (*payloads)[0].Event)
+	assert.Equal(t, err.Error(), (*payloads)[0].Error)
+	assert.Empty(t, (*payloads)[0].Files)
+	assert.Regexp(t, "^sha256=[0-9a-f]{64}$", (*signatures)[0])
+}
+
+func TestPostWebhook(t *testing.T) {
+	webhookRetryDelay = time.Millisecond
+	t.Cleanup(func() { webhookRetryDelay = time.Second })
+
+	var attempts atomic.Int32
+	var signature string
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		signature = r.Header.Get("X-Signature-256")
+		received, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+	body := []byte(`{"event":"conversion.succeeded"}`)
+	require.NoError(t, postWebhook(context.Background(), server.URL, body, "key"))
+	assert.Equal(t, int32(3), attempts.Load())
+	assert.Equal(t, body, received)
+	mac := hmac.New(sha256.New, []byte("key"))
+	mac.Write(body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), signature)
+
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		http.Error(w, "no such hook", http.StatusNotFound)
+	}))
+	defer rejecting.Close()
+	attempts.Store(0)
+	assert.ErrorContains(t, postWebhook(context.Background(), rejecting.URL, body, ""), "404 Not Found: no such hook")
+	assert.Equal(t, int32(1), attempts.Load(), "client errors aren't retried")
+}
+
+func TestConvertFileWebhookError(t *testing.T) {
+	defer os.Remove("quotesMetadata.json")
+	webhookRetryDelay = time.Millisecond
+	t.Cleanup(func() { webhookRetryDelay = time.Second })
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusBadGateway)
+	}))
+	defer server.Close()
+	input := filepath.Join(t.TempDir(), "quotes.csv")
+	require.NoError(t, os.WriteFile(input, []byte("Quote\nKnow thyself.\n"), 0644))
+
+	opts := DefaultOptions()
+	opts.Output = filepath.Join(t.TempDir(), "quotes.json")
+	opts.Webhooks = []string{server.URL}
+	assert.ErrorContains(t, ConvertFile(input, opts), "error notifying webhook")
+
+	// A failing webhook doesn't keep the next ones from being notified
+	receiver, payloads, _ := webhookReceiver(t)
+	opts.Webhooks = []string{server.URL, receiver.URL, server.URL + "/other"}
+	err := ConvertFile(input, opts)
+	assert.ErrorContains(t, err, "error notifying webhook "+server.URL+":")
+	assert.ErrorContains(t, err, "error notifying webhook "+server.URL+"/other")
+	assert.Len(t, *payloads, 1)
+}
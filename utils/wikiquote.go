@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// wikiquoteAPI is the MediaWiki API of the English Wikiquote
+const wikiquoteAPI = "https://en.wikiquote.org/w/api.php"
+
+// wikiquoteSkippedSections are the sections of a Wikiquote page, by lower-cased level two
+// heading, that don't hold quotes by the page's subject or whose attribution is doubtful
+var wikiquoteSkippedSections = []string{
+	"disputed", "misattributed", "unsourced", "see also", "external links", "references",
+	"sources", "further reading", "bibliography", "notes",
+}
+
+var (
+	wikiHeadingPattern  = regexp.MustCompile(`^(=+)\s*(.*?)\s*=+$`)
+	wikiRefPattern      = regexp.MustCompile(`(?s)<ref[^>]*/>|<ref[^>]*>.*?</ref>`)
+	wikiTemplatePattern = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+	wikiLinkPattern     = regexp.MustCompile(`\[\[(?:[^|\]]*\|)?([^\]]*)\]\]`)
+	wikiURLPattern      = regexp.MustCompile(`\[https?://[^\s\]]*\s*([^\]]*)\]`)
+	wikiTagPattern      = regexp.MustCompile(`<[^>]+>`)
+	wikiYearPattern     = regexp.MustCompile(`\b(1[0-9]{3}|20[0-9]{2})\b`)
+)
+
+// importWikiquote fetches the quotes of the Wikiquote pages named by args, such as "Albert
+// Einstein", through the MediaWiki API at opts.API. Each quote is attributed to the subject of
+// its page and tagged with it, as albert-einstein; its source line is the context and the year
+// is read from the source or the section it is listed under.
+func importWikiquote(args []string, opts ImportOptions) (*sliceRows, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf(`wikiquote needs the title of a page, e.g. "Albert Einstein"`)
+	}
+	api := opts.API
+	if api == "" {
+		api = wikiquoteAPI
+	}
+	source := newHTTPSource(opts.Interval)
+
+	rows := [][]string{quoteColumns}
+	for _, page := range args {
+		query := url.Values{
+			"action":        {"parse"},
+			"page":          {page},
+			"prop":          {"wikitext"},
+			"redirects":     {"1"},
+			"format":        {"json"},
+			"formatversion": {"2"},
+		}
+		var response struct {
+			Parse struct {
+				Title    string `json:"title"`
+				Wikitext string `json:"wikitext"`
+			} `json:"parse"`
+			Error *struct {
+				Code string `json:"code"`
+				Info string `json:"info"`
+			} `json:"error"`
+		}
+		if err := source.getJSON(api+"?"+query.Encode(), &response); err != nil {
+			return nil, err
+		}
+		if response.Error != nil {
+			return nil, fmt.Errorf("error fetching Wikiquote page %q: %s", page, response.Error.Info)
+		}
+		rows = append(rows, wikiquoteRows(response.Parse.Title, response.Parse.Wikitext)...)
+	}
+	return &sliceRows{rows: rows}, nil
+}
+
+// wikiquoteRows reads the quotes of the wikitext of a Wikiquote page about author. Quotes are
+// the top-level "* " items; the "** " item below one cites where it comes from.
+func wikiquoteRows(author, wikitext string) [][]string {
+	var rows [][]string
+	skipped := false
+	sectionYear := ""
+	tag := tagSlug(author)
+	var current []string
+	for _, line := range strings.Split(wikitext, "\n") {
+		line = strings.TrimSpace(line)
+		if match := wikiHeadingPattern.FindStringSubmatch(line); match != nil {
+			current = nil
+			name := strings.ToLower(cleanWikitext(match[2]))
+			if len(match[1]) == 2 {
+				skipped = strings.HasPrefix(name, "quotes about") || strings.HasPrefix(name, "about ")
+				for _, section := range wikiquoteSkippedSections {
+					skipped = skipped || name == section
+				}
+			}
+			sectionYear = ""
+			if _, err := parseYear(name); err == nil {
+				sectionYear = name
+			}
+			continue
+		}
+		if skipped {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "**") && !strings.HasPrefix(line, "***"):
+			if current != nil && current[4] == "" {
+				current[4] = cleanWikitext(strings.TrimLeft(line, "*"))
+				if year := wikiYearPattern.FindString(current[4]); year != "" {
+					current[3] = year
+				}
+			}
+		case strings.HasPrefix(line, "*") && !strings.HasPrefix(line, "**"):
+			text := cleanWikitext(strings.TrimPrefix(line, "*"))
+			if text == "" {
+				current = nil
+				continue
+			}
+			current = []string{tag, text, author, sectionYear, "", ""}
+			rows = append(rows, current)
+		}
+	}
+	return rows
+}
+
+// cleanWikitext turns wikitext into plain text: references, templates and markup are removed,
+// links are replaced by their text and entities decoded
+func cleanWikitext(s string) string {
+	s = wikiRefPattern.ReplaceAllString(s, "")
+	for {
+		stripped := wikiTemplatePattern.ReplaceAllString(s, "")
+		if stripped == s {
+			break
+		}
+		s = stripped
+	}
+	s = wikiLinkPattern.ReplaceAllString(s, "$1")
+	s = wikiURLPattern.ReplaceAllString(s, "$1")
+	s = strings.NewReplacer("'''", "", "''", "", "<br>", " ", "<br/>", " ", "<br />", " ").Replace(s)
+	s = wikiTagPattern.ReplaceAllString(s, "")
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
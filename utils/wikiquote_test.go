@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// einsteinWikitext is an excerpt of a Wikiquote page in the usual layout
+const einsteinWikitext = `'''[[w:Albert Einstein|Albert Einstein]]''' (1879–1955) was a physicist.
+{{Wikipedia}}
+== Quotes ==
+=== 1920s ===
+* I am convinced that ''He'' does not throw dice.<ref>Letter</ref>
+** Letter to [[Max Born]] (4 December 1926)
+*** Commentary on the letter
+* Imagination is more important than knowledge.
+** "What Life Means to Einstein", ''The Saturday Evening Post'' {{cite|p=17}}
+=== 1933 ===
+* Quote listed &quot;under&quot; a year section.
+== Disputed ==
+* Insanity is doing the same thing over and over.
+== Quotes about Einstein ==
+* Einstein was a genius.
+== External links ==
+* [https://en.wikipedia.org/wiki/Albert_Einstein Wikipedia article]
+`
+
+// TestWikiquoteRows tests reading the quotes of a page's wikitext
+func TestWikiquoteRows(t *testing.T) {
+	assert.Equal(t, [][]string{
+		{"albert-einstein", "I am convinced that He does not throw dice.", "Albert Einstein", "1926", "Letter to Max Born (4 December 1926)", ""},
+		{"albert-einstein", "Imagination is more important than knowledge.", "Albert Einstein", "", `"What Life Means to Einstein", The Saturday Evening Post`, ""},
+		{"albert-einstein", `Quote listed "under" a year section.`, "Albert Einstein", "1933", "", ""},
+	}, wikiquoteRows("Albert Einstein", einsteinWikitext))
+}
+
+// TestCleanWikitext tests removing wiki markup
+func TestCleanWikitext(t *testing.T) {
+	assert.Equal(t, "Bold link and text, see article", cleanWikitext(`'''Bold''' [[Page|link]] and {{nested {{template}} }}[[text]],<br/>see [https://example.org article]<ref name="a"/>`))
+}
+
+// TestImportWikiquote tests fetching pages from the API and merging them
+func TestImportWikiquote(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		assert.Equal(t, "parse", query.Get("action"))
+		assert.Equal(t, "wikitext", query.Get("prop"))
+		pages = append(pages, query.Get("page"))
+		if query.Get("page") == "Nobody" {
+			json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{"code": "missingtitle", "info": "The page you specified doesn't exist."}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"parse": map[string]string{"title": "Albert Einstein", "wikitext": einsteinWikitext}})
+	}))
+	defer server.Close()
+
+	importOpts := ImportOptions{API: server.URL}
+	rows, err := importWikiquote([]string{"albert einstein"}, importOpts)
+	require.NoError(t, err)
+	assert.Len(t, rows.rows, 4)
+	assert.Equal(t, []string{"albert einstein"}, pages)
+
+	_, err = importWikiquote([]string{"Nobody"}, importOpts)
+	assert.ErrorContains(t, err, `error fetching Wikiquote page "Nobody": The page you specified doesn't exist.`)
+	_, err = importWikiquote(nil, importOpts)
+	assert.Error(t, err)
+
+	dir := t.TempDir()
+	into := dir + "/quotes.json"
+	result, err := ImportQuotes("Wikiquote", []string{"Albert Einstein"}, into, dir+"/quotesMetadata.json", MergeByText, DefaultOptions(), importOpts)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Added)
+	data, err := ReadQuotesFromJSON(into)
+	require.NoError(t, err)
+	require.Len(t, data.Quotes, 3)
+	assert.Equal(t, []string{"albert-einstein"}, data.Quotes[0].Tags)
+	assert.Equal(t, 1926, data.Quotes[0].Year)
+	assert.Equal(t, "Letter to Max Born (4 December 1926)", data.Quotes[0].Context)
+}
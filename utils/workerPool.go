@@ -0,0 +1,54 @@
+package utils
+
+import "sync"
+
+// rowResult holds the outcome of parsing a single row
+type rowResult struct {
+	quote Quote
+	ok    bool
+}
+
+// rowParser converts a spreadsheet row into a Quote, reporting false if the row should be skipped
+type rowParser func(i int, row []string) (Quote, bool)
+
+// processRows parses rows with a pool of workers and returns the quotes in row order.
+// firstIndex is the spreadsheet index of rows[0], used for IDs and log messages.
+func processRows(rows [][]string, firstIndex int, workers int, parse rowParser) []Quote {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]rowResult, len(rows))
+	if workers == 1 {
+		for i, row := range rows {
+			results[i].quote, results[i].ok = parse(firstIndex+i, row)
+		}
+	} else {
+		// Each worker writes only to its own slots, so results needs no locking
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					results[i].quote, results[i].ok = parse(firstIndex+i, rows[i])
+				}
+			}()
+		}
+		for i := range rows {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+	}
+
+	// Collect the parsed quotes, dropping skipped rows
+	quotes := make([]Quote, 0, len(rows))
+	for _, result := range results {
+		if result.ok {
+			quotes = append(quotes, result.quote)
+		}
+	}
+	return quotes
+}
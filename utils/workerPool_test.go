@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProcessRows tests that the worker pool preserves row order and skips short rows
+func TestProcessRows(t *testing.T) {
+	var rows [][]string
+	for i := 0; i < 250; i++ {
+		if i%50 == 7 {
+			rows = append(rows, []string{"short"})
+			continue
+		}
+		rows = append(rows, []string{"tag", fmt.Sprintf("Quote %d", i)})
+	}
+
+	sequential := processRows(rows, 1, 1, parseRow)
+
+	for _, workers := range []int{0, 2, 8} {
+		t.Run(fmt.Sprintf("workers_%d", workers), func(t *testing.T) {
+			quotes := processRows(rows, 1, workers, parseRow)
+			assert.Equal(t, sequential, quotes)
+		})
+	}
+
+	assert.Len(t, sequential, 245)
+	assert.Equal(t, int64(1), sequential[0].ID)
+	assert.Equal(t, "Quote 0", sequential[0].Text)
+	assert.Equal(t, int64(9), sequential[7].ID) // row 8 was skipped
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ExportExcelFile writes the quotes to a new spreadsheet fileName, one row per quote under
+// quoteColumns and an ID column, so the dataset can be edited in Excel and converted again
+// without renumbering it. Rows are streamed, so large datasets don't have to be held in memory
+// twice.
+func ExportExcelFile(data QuotesData, fileName string) error {
+	file := excelize.NewFile()
+	defer file.Close()
+	sheet := file.GetSheetName(0)
+
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		return fmt.Errorf("error creating sheet %s: %w", sheet, err)
+	}
+	// Keep the header in view and give the text room
+	if err := stream.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+	for column, width := range []float64{24, 80, 24, 8, 24, 10, 38} {
+		if err := stream.SetColWidth(column+1, column+1, width); err != nil {
+			return err
+		}
+	}
+	header := make([]any, 0, len(quoteColumns)+1)
+	for _, name := range quoteColumns {
+		header = append(header, name)
+	}
+	header = append(header, "ID")
+	if err := stream.SetRow("A1", header); err != nil {
+		return fmt.Errorf("error writing header: %w", err)
+	}
+
+	for i, quote := range data.Quotes {
+		cells := quoteCells(quote)
+		row := make([]any, 0, len(cells)+1)
+		for _, value := range cells {
+			row = append(row, value)
+		}
+		row = append(row, quote.ref())
+		// Years are written as numbers, so Excel sorts and filters them as such
+		if quote.Year != 0 {
+			row[3] = quote.Year
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err := stream.SetRow(cell, row); err != nil {
+			return fmt.Errorf("error writing row %d: %w", i+2, err)
+		}
+	}
+	if err := stream.Flush(); err != nil {
+		return fmt.Errorf("error writing sheet %s: %w", sheet, err)
+	}
+	if err := file.SaveAs(fileName); err != nil {
+		return fmt.Errorf("error writing %s: %w", fileName, err)
+	}
+	return nil
+}
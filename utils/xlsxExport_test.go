@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExportExcelFile tests that an exported spreadsheet converts back to the same quotes, IDs
+// included
+func TestExportExcelFile(t *testing.T) {
+	data := QuotesData{Quotes: []Quote{
+		{ID: 7, Text: "Know thyself.", Tags: []string{"wisdom", "self"}, Author: "Socrates", Year: -400, Context: "Delphi", Language: "en-US"},
+		{ID: 3, Text: "Carpe diem.", Tags: []string{""}, Language: "la"},
+	}}
+	fileName := filepath.Join(t.TempDir(), "quotes.xlsx")
+	require.NoError(t, ExportExcelFile(data, fileName))
+
+	file, err := OpenExcelFile(fileName)
+	require.NoError(t, err)
+	defer file.Close()
+	rows, err := file.GetRows(file.GetSheetName(0))
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"Tags", "Quote", "Author", "Year", "Context", "Language", "ID"},
+		{"wisdom, self", "Know thyself.", "Socrates", "-400", "Delphi", "en-US", "7"},
+		{"", "Carpe diem.", "", "", "", "la", "3"},
+	}, rows)
+
+	opts := DefaultOptions()
+	opts.TagPolicy = tagPolicyPresets["clean"]
+	quotes, err := ReadExcelQuotes(file, opts)
+	require.NoError(t, err)
+	for i := range quotes {
+		quotes[i].WordCount, quotes[i].ReadingTimeSeconds = 0, 0
+	}
+	data.Quotes[1].Tags = []string{}
+	assert.Equal(t, data.Quotes, quotes)
+
+	assert.Error(t, ExportExcelFile(data, filepath.Join(t.TempDir(), "missing", "quotes.xlsx")))
+}
+
+// TestExportExcelFileUUIDs tests that UUIDs convert back with --id-strategy uuid only
+func TestExportExcelFileUUIDs(t *testing.T) {
+	data := QuotesData{Quotes: []Quote{{UUID: "0190a0e4-0000-7000-8000-000000000001", Text: "Know thyself.", Language: "en-US"}}}
+	fileName := filepath.Join(t.TempDir(), "quotes.xlsx")
+	require.NoError(t, ExportExcelFile(data, fileName))
+	file, err := OpenExcelFile(fileName)
+	require.NoError(t, err)
+	defer file.Close()
+
+	opts := DefaultOptions()
+	opts.IDStrategy = IDUUID
+	quotes, err := ReadExcelQuotes(file, opts)
+	require.NoError(t, err)
+	require.Len(t, quotes, 1)
+	assert.Equal(t, data.Quotes[0].UUID, quotes[0].UUID)
+
+	quotes, err = ReadExcelQuotes(file, DefaultOptions())
+	require.NoError(t, err)
+	require.Len(t, quotes, 1)
+	assert.Empty(t, quotes[0].UUID, "row IDs don't take UUIDs")
+	assert.Equal(t, int64(1), quotes[0].ID)
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/xml"
+)
+
+// The XML output has the following structure; optional elements are omitted when empty:
+//
+//	<?xml version="1.0" encoding="UTF-8"?>
+//	<quotes>
+//	  <quote id="1" lang="en-US">
+//	    <text>Quote text</text>
+//	    <author>Author</author>
+//	    <year>1950</year>
+//	    <context>Context</context>
+//	    <tags>
+//	      <tag>inspiration</tag>
+//	    </tags>
+//	  </quote>
+//	</quotes>
+
+// xmlQuote is the XML representation of a Quote
+type xmlQuote struct {
+	XMLName  xml.Name `xml:"quote"`
+	ID       string   `xml:"id,attr"`
+	Language string   `xml:"lang,attr"`
+	Text     string   `xml:"text"`
+	Author   string   `xml:"author,omitempty"`
+	Year     int      `xml:"year,omitempty"`
+	Context  string   `xml:"context,omitempty"`
+	Tags     []string `xml:"tags>tag"`
+}
+
+// toXMLQuote converts a Quote into its XML representation
+func toXMLQuote(quote Quote) xmlQuote {
+	return xmlQuote{
+		ID:       quote.key(),
+		Language: quote.Language,
+		Text:     quote.Text,
+		Author:   quote.Author,
+		Year:     quote.Year,
+		Context:  quote.Context,
+		Tags:     quote.Tags,
+	}
+}
+
+// xmlEncoder writes quotes as <quote> elements inside a <quotes> root
+type xmlEncoder struct{}
+
+func (xmlEncoder) writeQuote(w *bufio.Writer, quote Quote, index int) error {
+	data, err := xml.MarshalIndent(toXMLQuote(quote), "  ", "  ")
+	if err != nil {
+		return err
+	}
+	if index == 0 {
+		w.WriteString(xml.Header + "<quotes>\n")
+	}
+	w.Write(data)
+	return w.WriteByte('\n')
+}
+
+func (xmlEncoder) finish(w *bufio.Writer, count int) error {
+	if count == 0 {
+		w.WriteString(xml.Header + "<quotes>\n")
+	}
+	_, err := w.WriteString("</quotes>\n")
+	return err
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestXMLEncoder tests the XML layout and that it parses back into the same quotes
+func TestXMLEncoder(t *testing.T) {
+	quotes := []Quote{
+		{ID: 1, Text: "Less <is> more & then some", Tags: []string{"a", "b"}, Language: "en-US"},
+		{ID: 2, Text: "Second", Author: "Someone", Year: 1950, Tags: []string{"c"}, Language: "ta-IN"},
+	}
+
+	var buf bytes.Buffer
+	s := NewQuoteStreamWriter(&buf, StreamXML)
+	for _, q := range quotes {
+		require.NoError(t, s.WriteQuote(q))
+	}
+	require.NoError(t, s.Close())
+
+	assert.Contains(t, buf.String(), `<quote id="1" lang="en-US">`)
+	assert.Contains(t, buf.String(), `<text>Less &lt;is&gt; more &amp; then some</text>`)
+	assert.NotContains(t, buf.String(), `<author></author>`)
+
+	var doc struct {
+		Quotes []xmlQuote `xml:"quote"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	require.Len(t, doc.Quotes, 2)
+	for i, q := range doc.Quotes {
+		q.XMLName = xml.Name{}
+		assert.Equal(t, toXMLQuote(quotes[i]), q)
+	}
+}
+
+// TestXMLEncoderEmpty tests that an empty dataset is still a valid document
+func TestXMLEncoderEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewQuoteStreamWriter(&buf, StreamXML).Close())
+
+	var doc struct {
+		XMLName xml.Name
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "quotes", doc.XMLName.Local)
+}
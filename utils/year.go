@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// yearPattern matches textual years such as "1950", "c. 1950", "circa 500 BCE" or "AD 30"
+var yearPattern = regexp.MustCompile(`(?i)^(?:(?:c\.?|ca\.?|circa|~)\s*)?(?:(ad|ce)\s+)?(\d{1,4})(?:\s*(bce|bc|b\.c\.e?\.?|ce|ad|a\.d\.|c\.e\.))?$`)
+
+// dateLayouts are the textual date formats a year is taken from. Two-digit years are left out,
+// they can't tell 1950 from 2050.
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	"1/2/2006",
+	"2/1/2006",
+	"01/02/2006",
+	"2 January 2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"January 2006",
+	"Jan 2006",
+}
+
+// parseYear reads Quote.Year from a Year or Date cell. It accepts plain and textual years
+// (negative or BCE years are before the common era), Excel serial dates and common date formats.
+func parseYear(value string) (int, error) {
+	value = strings.TrimSpace(value)
+
+	if m := yearPattern.FindStringSubmatch(value); m != nil {
+		year, _ := strconv.Atoi(m[2])
+		if strings.HasPrefix(strings.ToLower(m[3]), "b") {
+			year = -year
+		}
+		return year, nil
+	}
+
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		if number == math.Trunc(number) && math.Abs(number) < 10000 {
+			return int(number), nil // a plain, possibly negative, year
+		}
+		// Anything larger is a date serial number, days since 1900
+		date, err := excelize.ExcelDateToTime(number, false)
+		if err != nil || number < 0 {
+			return 0, fmt.Errorf("invalid year %q", value)
+		}
+		return date.Year(), nil
+	}
+
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.Year(), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid year %q", value)
+}
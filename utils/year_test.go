@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+// TestParseYear tests the accepted year and date spellings
+func TestParseYear(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int
+	}{
+		{value: "1950", expected: 1950},
+		{value: " 1950 ", expected: 1950},
+		{value: "-500", expected: -500},
+		{value: "c. 1950", expected: 1950},
+		{value: "circa 1600", expected: 1600},
+		{value: "ca 30", expected: 30},
+		{value: "500 BCE", expected: -500},
+		{value: "c. 470 BC", expected: -470},
+		{value: "399 b.c.", expected: -399},
+		{value: "AD 30", expected: 30},
+		{value: "1066 CE", expected: 1066},
+		{value: "36526", expected: 2000},   // Excel serial for 2000-01-01
+		{value: "18264.5", expected: 1950}, // serial with a time of day
+		{value: "1950-06-01", expected: 1950},
+		{value: "January 2, 1921", expected: 1921},
+		{value: "March 1931", expected: 1931},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			year, err := parseYear(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, year)
+		})
+	}
+}
+
+// TestParseYearErrors tests values that are reported rather than zeroed
+func TestParseYearErrors(t *testing.T) {
+	for _, value := range []string{"unknown", "19th century", "1950ish", "-40000", "06-01-50"} {
+		_, err := parseYear(value)
+		assert.Error(t, err, value)
+	}
+}
+
+// TestOpenExcelFileDateYears tests that years are read from date cells with their century
+func TestOpenExcelFileDateYears(t *testing.T) {
+	f := excelize.NewFile()
+	defer f.Close()
+	require.NoError(t, f.SetSheetRow("Sheet1", "A1", &[]any{"Quote", "Year"}))
+	require.NoError(t, f.SetSheetRow("Sheet1", "A2", &[]any{"Old", time.Date(1950, 6, 1, 0, 0, 0, 0, time.UTC)}))
+	require.NoError(t, f.SetSheetRow("Sheet1", "A3", &[]any{"New", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}))
+	style, err := f.NewStyle(&excelize.Style{NumFmt: 14}) // the default short date format
+	require.NoError(t, err)
+	require.NoError(t, f.SetCellStyle("Sheet1", "B2", "B3", style))
+	file := filepath.Join(t.TempDir(), "dates.xlsx")
+	require.NoError(t, f.SaveAs(file))
+
+	opened, err := OpenExcelFile(file)
+	require.NoError(t, err)
+	defer opened.Close()
+	quotes, err := ReadExcelQuotes(opened, DefaultOptions())
+	require.NoError(t, err)
+	require.Len(t, quotes, 2)
+	assert.Equal(t, 1950, quotes[0].Year)
+	assert.Equal(t, 2024, quotes[1].Year)
+}